@@ -0,0 +1,115 @@
+// Herramienta de línea de comandos para administrar el anillo de claves JWT (JWT_KEYS_DIR).
+//
+// Uso:
+//
+//	keyring -dir ./keys init    [-alg ES256] [-kid id]
+//	keyring -dir ./keys list
+//	keyring -dir ./keys add     [-alg ES256] [-kid id]
+//	keyring -dir ./keys promote -kid id [-retire-after 168h]
+//	keyring -dir ./keys rotate  [-alg ES256] [-retire-after 168h]
+//	keyring -dir ./keys prune
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"auth-go-microservicio/pkg/jwt"
+)
+
+func main() {
+	dir := flag.String("dir", os.Getenv("JWT_KEYS_DIR"), "directorio del anillo de claves")
+	flag.Parse()
+
+	if *dir == "" || flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: keyring -dir <dir> <init|list|add|promote|rotate|prune> [flags]")
+		os.Exit(2)
+	}
+
+	command := flag.Arg(0)
+	cmdFlags := flag.NewFlagSet(command, flag.ExitOnError)
+	alg := cmdFlags.String("alg", "", "algoritmo de la clave (HS256, RS256, ES256, ES384, ES512, EdDSA)")
+	kid := cmdFlags.String("kid", "", "identificador de la clave")
+	retireAfter := cmdFlags.Duration("retire-after", 7*24*time.Hour, "tiempo que la clave anterior sigue verificando tokens")
+	cmdFlags.Parse(flag.Args()[1:])
+
+	if command == "init" {
+		algorithm := *alg
+		if algorithm == "" {
+			algorithm = "ES256"
+		}
+		key, err := jwt.GenerateSigningKey(*kid, algorithm)
+		if err != nil {
+			log.Fatal("Error generating key:", err)
+		}
+		if _, err := jwt.InitKeyRingDir(*dir, key); err != nil {
+			log.Fatal("Error initializing key ring:", err)
+		}
+		fmt.Printf("key ring initialized with active key %s (%s)\n", key.ID, key.Algorithm())
+		return
+	}
+
+	ring, err := jwt.LoadKeyRingDir(*dir)
+	if err != nil {
+		log.Fatal("Error loading key ring:", err)
+	}
+
+	switch command {
+	case "list":
+		active := ring.Active()
+		for _, key := range ring.Keys() {
+			status := "staged"
+			if key == active {
+				status = "active"
+			} else if !key.NotAfter.IsZero() {
+				status = "retiring until " + key.NotAfter.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\t%s\t%s\n", key.ID, key.Algorithm(), status)
+		}
+
+	case "add":
+		algorithm := *alg
+		if algorithm == "" {
+			algorithm = ring.Active().Algorithm()
+		}
+		key, err := jwt.GenerateSigningKey(*kid, algorithm)
+		if err != nil {
+			log.Fatal("Error generating key:", err)
+		}
+		if err := ring.Add(key); err != nil {
+			log.Fatal("Error adding key:", err)
+		}
+		fmt.Printf("key %s (%s) staged; promote it once consumers have refreshed the JWKS\n", key.ID, key.Algorithm())
+
+	case "promote":
+		if *kid == "" {
+			log.Fatal("-kid is required")
+		}
+		if err := ring.Promote(*kid, *retireAfter); err != nil {
+			log.Fatal("Error promoting key:", err)
+		}
+		fmt.Printf("key %s is now active\n", *kid)
+
+	case "rotate":
+		key, err := ring.Rotate(*alg, *retireAfter)
+		if err != nil {
+			log.Fatal("Error rotating key:", err)
+		}
+		fmt.Printf("key %s (%s) is now active\n", key.ID, key.Algorithm())
+
+	case "prune":
+		removed, err := ring.Prune()
+		if err != nil {
+			log.Fatal("Error pruning keys:", err)
+		}
+		for _, kid := range removed {
+			fmt.Printf("removed %s\n", kid)
+		}
+
+	default:
+		log.Fatalf("unknown command %q", command)
+	}
+}
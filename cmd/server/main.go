@@ -21,10 +21,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"auth-go-microservicio/configs"
@@ -32,10 +35,17 @@ import (
 	"auth-go-microservicio/internal/interface/http/handlers"
 	"auth-go-microservicio/internal/interface/http/routes"
 	"auth-go-microservicio/internal/usecase"
+	"auth-go-microservicio/migrations"
+	"auth-go-microservicio/pkg/encryption"
+	"auth-go-microservicio/pkg/events"
 	"auth-go-microservicio/pkg/jwt"
 	"auth-go-microservicio/pkg/keycloak"
+	"auth-go-microservicio/pkg/mailer"
 	"auth-go-microservicio/pkg/middleware"
+	"auth-go-microservicio/pkg/migrate"
 	"auth-go-microservicio/pkg/password"
+	"auth-go-microservicio/pkg/webauthn"
+	"auth-go-microservicio/pkg/webhook"
 
 	_ "github.com/lib/pq"
 
@@ -81,17 +91,135 @@ func main() {
 		log.Fatal("Error pinging database:", err)
 	}
 
+	// Migraciones del esquema: "migrate <comando>" las administra sin iniciar el servidor
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatal("Error loading migrations:", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(context.Background(), migrator, os.Args[2:]); err != nil {
+			log.Fatal("Error running migrations:", err)
+		}
+		return
+	}
+	if config.Database.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("Error running migrations:", err)
+		}
+	}
+
+	// Cargar anillo de claves de firma JWT
+	keyRing, err := loadKeyRing(&config.JWT)
+	if err != nil {
+		log.Fatal("Error loading JWT signing keys:", err)
+	}
+	if keyRing.IsPersistent() {
+		// Otras réplicas pueden rotar la clave; se relee el directorio periódicamente
+		go func() {
+			for range time.Tick(time.Minute) {
+				if err := keyRing.Reload(); err != nil {
+					log.Printf("Error reloading JWT key ring: %v", err)
+				}
+			}
+		}()
+	}
+	keyRetirement := time.Duration(config.JWT.RefreshExpiry) * 24 * time.Hour
+
 	// Inicializar servicios
 	jwtService := jwt.NewService(
-		config.JWT.SecretKey,
+		keyRing,
 		time.Duration(config.JWT.AccessExpiry)*time.Minute,
 		time.Duration(config.JWT.RefreshExpiry)*24*time.Hour,
 	)
-	passwordService := password.NewService(12) // bcrypt cost 12
+	passwordService := password.NewService(password.Config{
+		Algorithm:         config.Password.Algorithm,
+		BcryptCost:        config.Password.BcryptCost,
+		Argon2Memory:      uint32(config.Password.Argon2Memory),
+		Argon2Iterations:  uint32(config.Password.Argon2Iterations),
+		Argon2Parallelism: uint8(config.Password.Argon2Parallelism),
+		ScryptLogN:        config.Password.ScryptLogN,
+		ScryptR:           config.Password.ScryptR,
+		ScryptP:           config.Password.ScryptP,
+	})
+
+	// Inicializar la política de contraseñas y la base local de contraseñas filtradas
+	var breachChecker password.BreachChecker
+	if config.Password.BreachedDir != "" {
+		breachChecker, err = password.NewRangeDirChecker(config.Password.BreachedDir)
+		if err != nil {
+			log.Fatal("Error loading breached passwords:", err)
+		}
+	}
+	passwordPolicy, err := password.NewPolicy(password.PolicyConfig{
+		MinLength:            config.Password.MinLength,
+		MaxLength:            config.Password.MaxLength,
+		RequireUpper:         config.Password.RequireUpper,
+		RequireLower:         config.Password.RequireLower,
+		RequireDigit:         config.Password.RequireDigit,
+		RequireSymbol:        config.Password.RequireSymbol,
+		DisallowPersonalInfo: config.Password.DisallowPersonalInfo,
+		DictionaryFile:       config.Password.DictionaryFile,
+	}, breachChecker)
+	if err != nil {
+		log.Fatal("Error loading password policy:", err)
+	}
 
 	// Inicializar repositorios
 	userRepo := postgres.NewUserRepository(db)
 	tokenRepo := postgres.NewTokenRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	unitOfWork := postgres.NewUnitOfWork(db)
+	revocationRepo := postgres.NewRevocationRepository(db)
+	oneTimeTokenRepo := postgres.NewOneTimeTokenRepository(db)
+	mfaRepo := postgres.NewMFARepository(db)
+	webauthnRepo := postgres.NewWebAuthnRepository(db)
+	passwordHistoryRepo := postgres.NewPasswordHistoryRepository(db)
+	roleRepo := postgres.NewRoleRepository(db)
+	organizationRepo := postgres.NewOrganizationRepository(db)
+	invitationRepo := postgres.NewInvitationRepository(db)
+	auditRepo := postgres.NewAuditRepository(db)
+	outboxRepo := postgres.NewOutboxRepository(db)
+	webhookRepo := postgres.NewWebhookRepository(db)
+	oauthRepo := postgres.NewOAuthRepository(db)
+	serviceAccountRepo := postgres.NewServiceAccountRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+
+	// Inicializar el cifrado de secretos MFA y de webhooks. La clave es obligatoria: reutilizar la
+	// de firma JWT haría que filtrar una expusiera también los secretos TOTP.
+	mfaEncryptionKey := config.Auth.MFAEncryptionKey
+	if mfaEncryptionKey == "" {
+		if !config.Server.DevMode {
+			log.Fatal("MFA_ENCRYPTION_KEY is required; set SERVER_DEV_MODE=true to use an insecure development key")
+		}
+		log.Printf("⚠️  MFA_ENCRYPTION_KEY no configurada, se usa una clave de desarrollo insegura (SERVER_DEV_MODE=true)")
+		mfaEncryptionKey = devEncryptionKey
+	}
+	encryptionService, err := encryption.NewService(mfaEncryptionKey)
+	if err != nil {
+		log.Fatal("Error initializing encryption service:", err)
+	}
+
+	// Inicializar el relying party WebAuthn
+	relyingParty, err := webauthn.New(webauthn.Config{
+		RPID:    config.WebAuthn.RPID,
+		RPName:  config.WebAuthn.RPName,
+		Origins: config.WebAuthn.Origins,
+	})
+	if err != nil {
+		log.Fatal("Error initializing WebAuthn:", err)
+	}
+
+	// Inicializar el envío de emails
+	mailService, err := newMailer(&config.Mail)
+	if err != nil {
+		log.Fatal("Error initializing mailer:", err)
+	}
+
+	// Inicializar la publicación de eventos de dominio
+	eventPublisher, err := newEventPublisher(&config.Events, time.Duration(config.Events.PublishTimeout)*time.Second)
+	if err != nil {
+		log.Fatal("Error initializing event publisher:", err)
+	}
 
 	// Inicializar servicios de Keycloak (opcional)
 	var keycloakService keycloak.Service
@@ -118,11 +246,149 @@ func main() {
 	}
 
 	// Inicializar use cases (detecta automáticamente si usar Keycloak)
-	authUseCase := usecase.NewAuthUseCase(userRepo, tokenRepo, jwtService, passwordService, keycloakService, keycloakConfig)
-	userUseCase := usecase.NewUserUseCase(userRepo, passwordService)
+	auditLogger := usecase.NewAuditLogger(auditRepo)
+	auditUseCase := usecase.NewAuditUseCase(auditRepo)
+	revocationUseCase := usecase.NewRevocationUseCase(revocationRepo, time.Duration(config.JWT.RevocationCacheTTL)*time.Second)
+	roleUseCase := usecase.NewRoleUseCase(roleRepo, userRepo, revocationUseCase, auditLogger, time.Duration(config.Auth.PermissionCacheTTL)*time.Second)
+	organizationUseCase := usecase.NewOrganizationUseCase(organizationRepo, userRepo, roleUseCase, revocationUseCase, auditLogger, time.Duration(config.Auth.PermissionCacheTTL)*time.Second)
+	verificationUseCase := usecase.NewVerificationUseCase(
+		userRepo,
+		oneTimeTokenRepo,
+		jwtService,
+		mailService,
+		config.Auth.FrontendURL,
+		time.Duration(config.Auth.EmailVerificationExpiry)*time.Hour,
+	)
+	authPolicy := usecase.AuthPolicy{
+		RequireEmailVerification: config.Auth.RequireEmailVerification,
+		EmbedPermissions:         config.Auth.EmbedPermissions,
+		LockoutThreshold:         config.Auth.LockoutThreshold,
+		LockoutDuration:          time.Duration(config.Auth.LockoutDuration) * time.Second,
+		LockoutMaxDuration:       time.Duration(config.Auth.LockoutMaxDuration) * time.Second,
+		IPMaxFailures:            config.Auth.IPMaxFailures,
+		IPWindow:                 time.Duration(config.Auth.IPWindow) * time.Second,
+		AllowSelfRegistration:    config.Auth.AllowSelfRegistration,
+	}
+	passwordPolicyUseCase := usecase.NewPasswordPolicyUseCase(passwordPolicy, passwordHistoryRepo, passwordService, config.Password.HistorySize)
+	passwordResetUseCase := usecase.NewPasswordResetUseCase(
+		userRepo,
+		tokenRepo,
+		sessionRepo,
+		oneTimeTokenRepo,
+		revocationUseCase,
+		jwtService,
+		passwordService,
+		passwordPolicyUseCase,
+		mailService,
+		keycloakService,
+		auditLogger,
+		config.Auth.FrontendURL,
+		time.Duration(config.Auth.PasswordResetExpiry)*time.Minute,
+	)
+	webauthnUseCase := usecase.NewWebAuthnUseCase(webauthnRepo, userRepo, relyingParty, auditLogger, 5*time.Minute)
+	mfaUseCase := usecase.NewMFAUseCase(
+		mfaRepo,
+		webauthnRepo,
+		userRepo,
+		oneTimeTokenRepo,
+		jwtService,
+		encryptionService,
+		auditLogger,
+		config.Auth.MFAIssuer,
+		time.Duration(config.Auth.MFAChallengeExpiry)*time.Minute,
+	)
+	authUseCase := usecase.NewAuthUseCase(userRepo, tokenRepo, sessionRepo, unitOfWork, revocationUseCase, verificationUseCase, mfaUseCase, webauthnUseCase, roleUseCase, organizationUseCase, jwtService, passwordService, passwordPolicyUseCase, keycloakService, keycloakConfig, authPolicy, auditLogger)
+	userUseCase := usecase.NewUserUseCase(userRepo, revocationUseCase, passwordService, passwordPolicyUseCase, roleUseCase, auditLogger)
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, tokenRepo, revocationUseCase)
+	invitationUseCase := usecase.NewInvitationUseCase(
+		invitationRepo,
+		userRepo,
+		organizationRepo,
+		unitOfWork,
+		roleUseCase,
+		organizationUseCase,
+		passwordService,
+		passwordPolicyUseCase,
+		mailService,
+		config.Auth.FrontendURL,
+		time.Duration(config.Auth.InvitationExpiry)*time.Hour,
+		auditLogger,
+		config.Keycloak.Enabled,
+	)
+
+	// Invitar al primer administrador; reemplaza el alta pública de administradores
+	if config.Auth.BootstrapAdminEmail != "" && !config.Keycloak.Enabled {
+		if err := invitationUseCase.BootstrapAdmin(context.Background(), config.Auth.BootstrapAdminEmail); err != nil {
+			log.Printf("Error inviting bootstrap admin: %v", err)
+		}
+	}
+
+	serviceAccountUseCase := usecase.NewServiceAccountUseCase(serviceAccountRepo, roleUseCase, jwtService, auditLogger, usecase.ServiceAccountConfig{
+		Issuer:         config.OAuth.Issuer,
+		AccessTokenTTL: time.Duration(config.JWT.AccessExpiry) * time.Minute,
+	}, config.Keycloak.Enabled)
+
+	oauthUseCase := usecase.NewOAuthUseCase(oauthRepo, userRepo, tokenRepo, sessionRepo, unitOfWork, roleUseCase, organizationUseCase, serviceAccountUseCase, jwtService, auditLogger, usecase.OAuthConfig{
+		Issuer:           config.OAuth.Issuer,
+		ConsentURL:       config.OAuth.ConsentURL,
+		CodeExpiry:       time.Duration(config.OAuth.CodeExpiry) * time.Second,
+		AccessTokenTTL:   time.Duration(config.JWT.AccessExpiry) * time.Minute,
+		EmbedPermissions: config.Auth.EmbedPermissions,
+	}, config.Keycloak.Enabled)
+
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo, roleUseCase, auditLogger, config.Auth.APIKeyMaxPerUser, config.Keycloak.Enabled)
+
+	tokenIntrospectionUseCase := usecase.NewTokenIntrospectionUseCase(oauthUseCase, serviceAccountUseCase, tokenRepo, sessionRepo, revocationUseCase, jwtService, keycloakService, auditLogger, config.OAuth.Issuer, config.Keycloak.Enabled)
+
+	webhookTimeout := time.Duration(config.Webhooks.Timeout) * time.Second
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo, encryptionService, webhook.NewSender(webhookTimeout, config.Webhooks.AllowPrivateNetworks), auditLogger, usecase.WebhookConfig{
+		MaxAttempts:   config.Webhooks.MaxAttempts,
+		RetryBase:     time.Duration(config.Webhooks.RetryBaseDelay) * time.Second,
+		RetryMaxDelay: time.Duration(config.Webhooks.RetryMaxDelay) * time.Second,
+		BatchSize:     config.Webhooks.BatchSize,
+		Lease:         webhookTimeout*time.Duration(config.Webhooks.BatchSize) + time.Minute,
+		DisableAfter:  config.Webhooks.DisableAfter,
+	})
+	go webhookUseCase.Run(context.Background(), time.Duration(config.Webhooks.DeliveryInterval)*time.Second)
+
+	// Publicar en segundo plano los eventos de dominio guardados en el outbox; cada evento
+	// también genera las entregas de los webhooks suscriptos. La reserva de un lote cubre el
+	// peor caso de que todas sus publicaciones agoten el timeout.
+	publishTimeout := time.Duration(config.Events.PublishTimeout) * time.Second
+	outboxRelay := usecase.NewOutboxRelay(outboxRepo, events.NewFanoutPublisher(webhookUseCase, eventPublisher), usecase.OutboxRelayConfig{
+		BatchSize:     config.Events.RelayBatchSize,
+		Lease:         publishTimeout*time.Duration(config.Events.RelayBatchSize) + time.Minute,
+		RetryBase:     time.Duration(config.Events.RetryBaseDelay) * time.Second,
+		RetryMaxDelay: time.Duration(config.Events.RetryMaxDelay) * time.Second,
+	})
+	go outboxRelay.Run(context.Background(), time.Duration(config.Events.RelayInterval)*time.Second)
+
+	// Limpiar periódicamente los jti revocados, los tokens de un solo uso, los desafíos WebAuthn, los códigos OAuth y los client assertions que ya expiraron
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := revocationUseCase.Cleanup(context.Background()); err != nil {
+				log.Printf("Error cleaning up revoked access tokens: %v", err)
+			}
+			if err := oneTimeTokenRepo.DeleteExpired(context.Background()); err != nil {
+				log.Printf("Error cleaning up one-time tokens: %v", err)
+			}
+			if err := webauthnUseCase.CleanupChallenges(context.Background()); err != nil {
+				log.Printf("Error cleaning up WebAuthn challenges: %v", err)
+			}
+			if err := oauthUseCase.CleanupAuthorizationCodes(context.Background()); err != nil {
+				log.Printf("Error cleaning up OAuth authorization codes: %v", err)
+			}
+			if err := serviceAccountUseCase.CleanupAssertions(context.Background()); err != nil {
+				log.Printf("Error cleaning up service account assertions: %v", err)
+			}
+			if err := outboxRelay.Cleanup(context.Background()); err != nil {
+				log.Printf("Error cleaning up published outbox events: %v", err)
+			}
+		}
+	}()
 
 	// Inicializar middlewares
-	authMiddleware := middleware.NewAuthMiddleware(jwtService, keycloakService, config.Keycloak.Enabled)
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, revocationUseCase, roleUseCase, organizationUseCase, apiKeyUseCase, keycloakService, config.Keycloak.Enabled)
 
 	var keycloakMiddleware *middleware.KeycloakMiddleware
 	if config.Keycloak.Enabled {
@@ -132,6 +398,21 @@ func main() {
 	// Inicializar handlers
 	authHandler := handlers.NewAuthHandler(authUseCase)
 	userHandler := handlers.NewUserHandler(userUseCase)
+	sessionHandler := handlers.NewSessionHandler(sessionUseCase)
+	verificationHandler := handlers.NewVerificationHandler(verificationUseCase)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetUseCase)
+	mfaHandler := handlers.NewMFAHandler(mfaUseCase)
+	webauthnHandler := handlers.NewWebAuthnHandler(webauthnUseCase, authUseCase)
+	wellKnownHandler := handlers.NewWellKnownHandler(jwtService)
+	keyHandler := handlers.NewKeyHandler(keyRing, keyRetirement)
+	roleHandler := handlers.NewRoleHandler(roleUseCase)
+	organizationHandler := handlers.NewOrganizationHandler(organizationUseCase)
+	invitationHandler := handlers.NewInvitationHandler(invitationUseCase)
+	auditHandler := handlers.NewAuditHandler(auditUseCase)
+	webhookHandler := handlers.NewWebhookHandler(webhookUseCase)
+	oauthHandler := handlers.NewOAuthHandler(oauthUseCase, tokenIntrospectionUseCase)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountUseCase)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyUseCase)
 
 	var keycloakHandler *handlers.KeycloakHandler
 	if config.Keycloak.Enabled {
@@ -139,7 +420,7 @@ func main() {
 	}
 
 	// Configurar rutas
-	router := routes.SetupRoutes(authHandler, userHandler, keycloakHandler, authMiddleware, keycloakMiddleware, config)
+	router := routes.SetupRoutes(authHandler, userHandler, sessionHandler, verificationHandler, passwordResetHandler, mfaHandler, webauthnHandler, wellKnownHandler, keyHandler, roleHandler, organizationHandler, invitationHandler, auditHandler, webhookHandler, oauthHandler, serviceAccountHandler, apiKeyHandler, keycloakHandler, authMiddleware, keycloakMiddleware, config)
 
 	// Iniciar servidor
 	serverAddr := fmt.Sprintf("%s:%s", config.Server.Host, config.Server.Port)
@@ -156,3 +437,148 @@ func main() {
 		log.Fatal("Error starting server:", err)
 	}
 }
+
+// runMigrateCommand ejecuta un subcomando de migraciones: up, down [pasos], to <versión>,
+// baseline <versión> o status
+func runMigrateCommand(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|to <version>|baseline <version>|status")
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		log.Printf("Applied %d migrations", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+			steps = n
+		}
+		count, err := migrator.Down(ctx, steps)
+		log.Printf("Reverted %d migrations", count)
+		return err
+	case "to", "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("migrate %s requires a version", args[0])
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if args[0] == "baseline" {
+			count, err := migrator.Baseline(ctx, version)
+			log.Printf("Marked %d migrations as applied", count)
+			return err
+		}
+		count, err := migrator.To(ctx, version)
+		log.Printf("Applied or reverted %d migrations", count)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Modified {
+				state += " (modified)"
+			}
+			if status.Missing {
+				state += " (missing)"
+			}
+			fmt.Printf("%03d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// devEncryptionKey cifra los secretos TOTP y de webhooks en modo desarrollo cuando falta
+// MFA_ENCRYPTION_KEY; es pública, por lo que nunca debe usarse con datos reales
+const devEncryptionKey = "insecure-development-encryption-key"
+
+// newMailer crea el Mailer configurado por MAIL_DRIVER
+func newMailer(cfg *configs.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.FileDir, cfg.From)
+	case "log":
+		return mailer.NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", cfg.Driver)
+	}
+}
+
+// newEventPublisher crea el Publisher configurado por EVENTS_DRIVER
+func newEventPublisher(cfg *configs.EventsConfig, timeout time.Duration) (events.Publisher, error) {
+	switch cfg.Driver {
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("EVENTS_WEBHOOK_URL is required for the webhook driver")
+		}
+		return events.NewWebhookPublisher(cfg.WebhookURL, timeout), nil
+	case "nats":
+		return events.NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix, timeout)
+	case "kafka":
+		return events.NewKafkaRESTPublisher(cfg.KafkaRESTURL, cfg.KafkaTopic, timeout), nil
+	case "memory":
+		return events.NewMemoryPublisher(), nil
+	case "log":
+		return events.NewLogPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown EVENTS_DRIVER %q", cfg.Driver)
+	}
+}
+
+// loadKeyRing construye el anillo de claves JWT desde un directorio o desde variables de entorno
+func loadKeyRing(cfg *configs.JWTConfig) (*jwt.KeyRing, error) {
+	if cfg.KeysDir != "" {
+		return jwt.LoadKeyRingDir(cfg.KeysDir)
+	}
+
+	var active *jwt.SigningKey
+	var err error
+	if cfg.PrivateKeyPath != "" {
+		active, err = jwt.LoadPrivateKeyFile(cfg.KeyID, cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		active = jwt.NewHMACKey(cfg.KeyID, cfg.SecretKey)
+	}
+
+	if cfg.PreviousSecretKey == "" && cfg.PreviousPrivateKeyPath == "" {
+		return jwt.NewKeyRing(active)
+	}
+
+	var previous *jwt.SigningKey
+	if cfg.PreviousPrivateKeyPath != "" {
+		previous, err = jwt.LoadPrivateKeyFile(cfg.PreviousKeyID, cfg.PreviousPrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		previous = jwt.NewHMACKey(cfg.PreviousKeyID, cfg.PreviousSecretKey)
+	}
+
+	if cfg.PreviousKeyNotAfter == "" {
+		return nil, fmt.Errorf("JWT_PREVIOUS_KEY_NOT_AFTER is required when a previous key is configured")
+	}
+	previous.NotAfter, err = time.Parse(time.RFC3339, cfg.PreviousKeyNotAfter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_PREVIOUS_KEY_NOT_AFTER: %w", err)
+	}
+
+	return jwt.NewKeyRing(active, previous)
+}
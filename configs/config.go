@@ -3,6 +3,7 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -13,12 +14,22 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Keycloak KeycloakConfig
+	Auth     AuthConfig
+	Mail     MailConfig
+	WebAuthn WebAuthnConfig
+	Password PasswordConfig
+	Events   EventsConfig
+	Webhooks WebhooksConfig
+	OAuth    OAuthConfig
 }
 
 // ServerConfig configuración del servidor
 type ServerConfig struct {
 	Port string
 	Host string
+
+	// DevMode relaja las validaciones de arranque pensadas para producción; solo para desarrollo local
+	DevMode bool
 }
 
 // DatabaseConfig configuración de la base de datos
@@ -29,13 +40,26 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	AutoMigrate bool // aplica las migraciones pendientes al iniciar el servidor
 }
 
 // JWTConfig configuración de JWT
 type JWTConfig struct {
-	SecretKey     string
-	AccessExpiry  int // en minutos
-	RefreshExpiry int // en días
+	SecretKey      string
+	PrivateKeyPath string // clave PEM RSA, ECDSA o Ed25519; si está vacía se usa HS256 con SecretKey
+	KeyID          string
+	KeysDir        string // directorio con keyring.json; tiene prioridad sobre las claves individuales
+	AccessExpiry   int    // en minutos
+	RefreshExpiry  int    // en días
+
+	// Clave anterior que solo verifica tokens hasta PreviousKeyNotAfter (RFC 3339)
+	PreviousSecretKey      string
+	PreviousPrivateKeyPath string
+	PreviousKeyID          string
+	PreviousKeyNotAfter    string
+
+	RevocationCacheTTL int // en segundos; tiempo máximo que otra réplica tarda en ver una revocación
 }
 
 // KeycloakConfig configuración de Keycloak
@@ -47,6 +71,109 @@ type KeycloakConfig struct {
 	Enabled      bool
 }
 
+// AuthConfig configuración de los flujos de autenticación local
+type AuthConfig struct {
+	FrontendURL              string // base de los enlaces enviados por email
+	RequireEmailVerification bool
+	EmailVerificationExpiry  int // en horas
+	PasswordResetExpiry      int // en minutos
+	MFAIssuer                string
+	MFAEncryptionKey         string // cifra los secretos TOTP y de webhooks; obligatoria salvo en modo desarrollo
+	MFAChallengeExpiry       int    // en minutos
+
+	LockoutThreshold   int // intentos fallidos por cuenta antes del bloqueo; 0 lo desactiva
+	LockoutDuration    int // en segundos, se duplica con cada fallo posterior
+	LockoutMaxDuration int // en segundos
+	IPMaxFailures      int // intentos fallidos por IP en IPWindow; 0 lo desactiva
+	IPWindow           int // en segundos
+
+	EmbedPermissions   bool // incluye los permisos en el access token en lugar de resolverlos en cada petición
+	PermissionCacheTTL int  // en segundos; tiempo que una réplica cachea los permisos resueltos
+
+	AllowSelfRegistration bool   // si es false solo se crean cuentas aceptando una invitación
+	InvitationExpiry      int    // en horas
+	BootstrapAdminEmail   string // se invita como administrador al iniciar si todavía no hay ninguno
+
+	APIKeyMaxPerUser int // API keys personales por usuario; 0 no limita
+}
+
+// PasswordConfig configuración del hash de contraseñas. Los hashes existentes con otro
+// algoritmo o parámetros se regeneran en el siguiente login exitoso.
+type PasswordConfig struct {
+	Algorithm         string // argon2id, scrypt o bcrypt
+	BcryptCost        int
+	Argon2Memory      int // en KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	ScryptLogN        int // N = 2^ScryptLogN
+	ScryptR           int
+	ScryptP           int
+
+	MinLength            int
+	MaxLength            int
+	RequireUpper         bool
+	RequireLower         bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool   // rechaza contraseñas que contienen el email o el nombre
+	DictionaryFile       string // contraseñas prohibidas, una por línea
+	BreachedDir          string // rangos de Pwned Passwords (<PREFIJO>.txt con líneas SUFIJO:CANTIDAD)
+	HistorySize          int    // cantidad de contraseñas anteriores que no se pueden reutilizar; 0 lo desactiva
+}
+
+// MailConfig configuración del envío de emails
+type MailConfig struct {
+	Driver       string // smtp, file o log
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+}
+
+// EventsConfig configuración de la publicación de eventos de dominio desde el outbox
+type EventsConfig struct {
+	Driver            string // log, webhook, nats, kafka o memory
+	WebhookURL        string
+	NATSURL           string
+	NATSSubjectPrefix string
+	KafkaRESTURL      string // REST Proxy de Kafka (API v2)
+	KafkaTopic        string
+	PublishTimeout    int // en segundos
+	RelayInterval     int // en segundos
+	RelayBatchSize    int
+	RetryBaseDelay    int // en segundos, se duplica con cada intento fallido
+	RetryMaxDelay     int // en segundos
+}
+
+// WebhooksConfig configuración de la entrega de webhooks
+type WebhooksConfig struct {
+	MaxAttempts      int // intentos antes de marcar una entrega como fallida
+	Timeout          int // en segundos, por intento
+	RetryBaseDelay   int // en segundos, se duplica con cada intento fallido
+	RetryMaxDelay    int // en segundos
+	DeliveryInterval int // en segundos
+	BatchSize        int
+	DisableAfter     int // entregas fallidas consecutivas antes de desactivar la suscripción; 0 para nunca
+	// AllowPrivateNetworks permite entregar a direcciones de loopback, privadas o link-local; solo para desarrollo
+	AllowPrivateNetworks bool
+}
+
+// OAuthConfig configuración del servidor de autorización OAuth
+type OAuthConfig struct {
+	Issuer     string // URL pública del servicio; iss de los ID tokens y base del discovery de OpenID Connect
+	ConsentURL string // página del frontend que autentica al usuario y pide el consentimiento
+	CodeExpiry int    // en segundos
+}
+
+// WebAuthnConfig configuración del relying party WebAuthn
+type WebAuthnConfig struct {
+	RPID    string // dominio efectivo del frontend, sin esquema ni puerto
+	RPName  string
+	Origins []string // orígenes desde los que el navegador ejecuta las ceremonias
+}
+
 // Load carga la configuración desde variables de entorno
 func Load() (*Config, error) {
 	// Cargar archivo .env si existe
@@ -54,8 +181,9 @@ func Load() (*Config, error) {
 
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
+			Port:    getEnv("SERVER_PORT", "8080"),
+			Host:    getEnv("SERVER_HOST", "localhost"),
+			DevMode: getEnvAsBool("SERVER_DEV_MODE", false),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -64,11 +192,23 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "password"),
 			DBName:   getEnv("DB_NAME", "auth_service"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
 		},
 		JWT: JWTConfig{
-			SecretKey:     getEnv("JWT_SECRET_KEY", "your-secret-key"),
-			AccessExpiry:  getEnvAsInt("JWT_ACCESS_EXPIRY", 15),
-			RefreshExpiry: getEnvAsInt("JWT_REFRESH_EXPIRY", 7),
+			SecretKey:      getEnv("JWT_SECRET_KEY", "your-secret-key"),
+			PrivateKeyPath: getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			KeyID:          getEnv("JWT_KEY_ID", ""),
+			KeysDir:        getEnv("JWT_KEYS_DIR", ""),
+			AccessExpiry:   getEnvAsInt("JWT_ACCESS_EXPIRY", 15),
+			RefreshExpiry:  getEnvAsInt("JWT_REFRESH_EXPIRY", 7),
+
+			PreviousSecretKey:      getEnv("JWT_PREVIOUS_SECRET_KEY", ""),
+			PreviousPrivateKeyPath: getEnv("JWT_PREVIOUS_PRIVATE_KEY_PATH", ""),
+			PreviousKeyID:          getEnv("JWT_PREVIOUS_KEY_ID", ""),
+			PreviousKeyNotAfter:    getEnv("JWT_PREVIOUS_KEY_NOT_AFTER", ""),
+
+			RevocationCacheTTL: getEnvAsInt("JWT_REVOCATION_CACHE_TTL", 30),
 		},
 		Keycloak: KeycloakConfig{
 			BaseURL:      getEnv("KEYCLOAK_BASE_URL", "http://localhost:8080"),
@@ -77,8 +217,93 @@ func Load() (*Config, error) {
 			ClientSecret: getEnv("KEYCLOAK_CLIENT_SECRET", ""),
 			Enabled:      getEnvAsBool("KEYCLOAK_ENABLED", false),
 		},
+		Auth: AuthConfig{
+			FrontendURL:              getEnv("AUTH_FRONTEND_URL", "http://localhost:3000"),
+			RequireEmailVerification: getEnvAsBool("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationExpiry:  getEnvAsInt("AUTH_EMAIL_VERIFICATION_EXPIRY", 24),
+			PasswordResetExpiry:      getEnvAsInt("AUTH_PASSWORD_RESET_EXPIRY", 60),
+			MFAIssuer:                getEnv("MFA_ISSUER", "Auth Service"),
+			MFAEncryptionKey:         getEnv("MFA_ENCRYPTION_KEY", ""),
+			MFAChallengeExpiry:       getEnvAsInt("MFA_CHALLENGE_EXPIRY", 5),
+			LockoutThreshold:         getEnvAsInt("AUTH_LOCKOUT_THRESHOLD", 5),
+			LockoutDuration:          getEnvAsInt("AUTH_LOCKOUT_DURATION", 60),
+			LockoutMaxDuration:       getEnvAsInt("AUTH_LOCKOUT_MAX_DURATION", 3600),
+			IPMaxFailures:            getEnvAsInt("AUTH_IP_MAX_FAILURES", 20),
+			IPWindow:                 getEnvAsInt("AUTH_IP_WINDOW", 900),
+			EmbedPermissions:         getEnvAsBool("AUTH_EMBED_PERMISSIONS", true),
+			PermissionCacheTTL:       getEnvAsInt("AUTH_PERMISSION_CACHE_TTL", 30),
+			AllowSelfRegistration:    getEnvAsBool("AUTH_ALLOW_SELF_REGISTRATION", true),
+			InvitationExpiry:         getEnvAsInt("AUTH_INVITATION_EXPIRY", 72),
+			BootstrapAdminEmail:      getEnv("AUTH_BOOTSTRAP_ADMIN_EMAIL", ""),
+			APIKeyMaxPerUser:         getEnvAsInt("AUTH_API_KEY_MAX_PER_USER", 10),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:    getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:  getEnv("WEBAUTHN_RP_NAME", "Auth Service"),
+			Origins: getEnvAsSlice("WEBAUTHN_ORIGINS", []string{"http://localhost:3000"}),
+		},
+		Password: PasswordConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 12),
+			Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 19456),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 2),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 1),
+			ScryptLogN:        getEnvAsInt("PASSWORD_SCRYPT_LOG_N", 17),
+			ScryptR:           getEnvAsInt("PASSWORD_SCRYPT_R", 8),
+			ScryptP:           getEnvAsInt("PASSWORD_SCRYPT_P", 1),
+
+			MinLength:            getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:            getEnvAsInt("PASSWORD_MAX_LENGTH", 128),
+			RequireUpper:         getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireLower:         getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireDigit:         getEnvAsBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:        getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			DisallowPersonalInfo: getEnvAsBool("PASSWORD_DISALLOW_PERSONAL_INFO", true),
+			DictionaryFile:       getEnv("PASSWORD_DICTIONARY_FILE", ""),
+			BreachedDir:          getEnv("PASSWORD_BREACHED_DIR", ""),
+			HistorySize:          getEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FileDir:      getEnv("MAIL_FILE_DIR", "./tmp/mail"),
+		},
+		Events: EventsConfig{
+			Driver:            getEnv("EVENTS_DRIVER", "log"),
+			WebhookURL:        getEnv("EVENTS_WEBHOOK_URL", ""),
+			NATSURL:           getEnv("EVENTS_NATS_URL", "nats://localhost:4222"),
+			NATSSubjectPrefix: getEnv("EVENTS_NATS_SUBJECT_PREFIX", "auth"),
+			KafkaRESTURL:      getEnv("EVENTS_KAFKA_REST_URL", "http://localhost:8082"),
+			KafkaTopic:        getEnv("EVENTS_KAFKA_TOPIC", "auth.user-events"),
+			PublishTimeout:    getEnvAsInt("EVENTS_PUBLISH_TIMEOUT", 10),
+			RelayInterval:     getEnvAsInt("EVENTS_RELAY_INTERVAL", 5),
+			RelayBatchSize:    getEnvAsInt("EVENTS_RELAY_BATCH_SIZE", 100),
+			RetryBaseDelay:    getEnvAsInt("EVENTS_RETRY_BASE_DELAY", 5),
+			RetryMaxDelay:     getEnvAsInt("EVENTS_RETRY_MAX_DELAY", 3600),
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:          getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			Timeout:              getEnvAsInt("WEBHOOK_TIMEOUT", 10),
+			RetryBaseDelay:       getEnvAsInt("WEBHOOK_RETRY_BASE_DELAY", 30),
+			RetryMaxDelay:        getEnvAsInt("WEBHOOK_RETRY_MAX_DELAY", 21600),
+			DeliveryInterval:     getEnvAsInt("WEBHOOK_DELIVERY_INTERVAL", 5),
+			BatchSize:            getEnvAsInt("WEBHOOK_BATCH_SIZE", 50),
+			DisableAfter:         getEnvAsInt("WEBHOOK_DISABLE_AFTER_FAILURES", 5),
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		OAuth: OAuthConfig{
+			Issuer:     getEnv("OAUTH_ISSUER", "http://localhost:8080"),
+			CodeExpiry: getEnvAsInt("OAUTH_CODE_EXPIRY", 60),
+		},
 	}
 
+	// Por defecto la página de consentimiento vive en el frontend
+	config.OAuth.ConsentURL = getEnv("OAUTH_CONSENT_URL", strings.TrimRight(config.Auth.FrontendURL, "/")+"/oauth/consent")
+
 	return config, nil
 }
 
@@ -110,6 +335,22 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsSlice obtiene una variable de entorno separada por comas o retorna un valor por defecto
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
 // GetDSN retorna la cadena de conexión de la base de datos
 func (c *Config) GetDSN() string {
 	return "host=" + c.Database.Host +
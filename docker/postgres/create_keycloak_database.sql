@@ -0,0 +1,3 @@
+-- Crear la base de datos de Keycloak. No es parte del esquema del servicio: Postgres ejecuta este
+-- script al inicializar el volumen de docker-compose.
+CREATE DATABASE keycloak;
@@ -0,0 +1,138 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction identifica la operación registrada en el log de auditoría
+type AuditAction string
+
+const (
+	AuditActionRegistered            AuditAction = "registered"
+	AuditActionLoginSucceeded        AuditAction = "login_succeeded"
+	AuditActionLoginFailed           AuditAction = "login_failed"
+	AuditActionLogout                AuditAction = "logout"
+	AuditActionRefreshTokenReuse     AuditAction = "refresh_token_reuse"
+	AuditActionAccountLocked         AuditAction = "account_locked"
+	AuditActionAccountUnlocked       AuditAction = "account_unlocked"
+	AuditActionPasswordChanged       AuditAction = "password_changed"
+	AuditActionPasswordResetRequest  AuditAction = "password_reset_requested"
+	AuditActionPasswordReset         AuditAction = "password_reset"
+	AuditActionAccountDeleted        AuditAction = "account_deleted"
+	AuditActionUserUpdated           AuditAction = "user_updated"
+	AuditActionUserDeleted           AuditAction = "user_deleted"
+	AuditActionRoleCreated           AuditAction = "role_created"
+	AuditActionRolePermissions       AuditAction = "role_permissions_updated"
+	AuditActionRoleDeleted           AuditAction = "role_deleted"
+	AuditActionRoleAssigned          AuditAction = "role_assigned"
+	AuditActionRoleRemoved           AuditAction = "role_removed"
+	AuditActionOrganizationCreated   AuditAction = "organization_created"
+	AuditActionOrganizationDeleted   AuditAction = "organization_deleted"
+	AuditActionMemberAdded           AuditAction = "member_added"
+	AuditActionMemberRoleChanged     AuditAction = "member_role_changed"
+	AuditActionMemberRemoved         AuditAction = "member_removed"
+	AuditActionInvitationCreated     AuditAction = "invitation_created"
+	AuditActionInvitationRevoked     AuditAction = "invitation_revoked"
+	AuditActionInvitationAccepted    AuditAction = "invitation_accepted"
+	AuditActionMFAEnabled            AuditAction = "mfa_enabled"
+	AuditActionMFAReset              AuditAction = "mfa_reset"
+	AuditActionMFAFailed             AuditAction = "mfa_failed"
+	AuditActionRecoveryCodeUsed      AuditAction = "mfa_recovery_code_used"
+	AuditActionWebAuthnRegistered    AuditAction = "webauthn_credential_registered"
+	AuditActionWebAuthnDeleted       AuditAction = "webauthn_credential_deleted"
+	AuditActionWebAuthnSignCountDrop AuditAction = "webauthn_sign_count_regression"
+	AuditActionWebhookCreated        AuditAction = "webhook_created"
+	AuditActionWebhookUpdated        AuditAction = "webhook_updated"
+	AuditActionWebhookDeleted        AuditAction = "webhook_deleted"
+	AuditActionWebhookRedelivered    AuditAction = "webhook_redelivered"
+	AuditActionWebhookDisabled       AuditAction = "webhook_disabled"
+	AuditActionOAuthClientCreated    AuditAction = "oauth_client_created"
+	AuditActionOAuthClientUpdated    AuditAction = "oauth_client_updated"
+	AuditActionOAuthClientDeleted    AuditAction = "oauth_client_deleted"
+	AuditActionOAuthAuthorized       AuditAction = "oauth_authorized"
+	AuditActionOAuthCodeReuse        AuditAction = "oauth_code_reuse"
+	AuditActionOAuthTokenRevoked     AuditAction = "oauth_token_revoked"
+	AuditActionServiceAccountCreated AuditAction = "service_account_created"
+	AuditActionServiceAccountUpdated AuditAction = "service_account_updated"
+	AuditActionServiceAccountRotated AuditAction = "service_account_secret_rotated"
+	AuditActionServiceAccountDeleted AuditAction = "service_account_deleted"
+	AuditActionAPIKeyCreated         AuditAction = "api_key_created"
+	AuditActionAPIKeyRevoked         AuditAction = "api_key_revoked"
+)
+
+// AuditOutcome indica si la operación auditada se completó
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditEvent representa una entrada del log de auditoría. Las entradas forman una cadena:
+// Hash cubre los datos de la entrada y el Hash de la anterior, por lo que modificar, borrar
+// o reordenar entradas rompe la cadena a partir de ese punto.
+type AuditEvent struct {
+	Sequence   int64           `json:"sequence"`
+	ID         uuid.UUID       `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorID    string          `json:"actor_id,omitempty"`  // quién ejecutó la operación
+	TargetID   string          `json:"target_id,omitempty"` // usuario o recurso afectado
+	Action     AuditAction     `json:"action"`
+	Outcome    AuditOutcome    `json:"outcome"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// NewAuditEvent crea una nueva instancia de AuditEvent. El instante se trunca a microsegundos,
+// la precisión con la que lo guarda la base de datos, para que el hash se pueda recalcular.
+func NewAuditEvent(action AuditAction, outcome AuditOutcome, actorID, targetID string, metadata json.RawMessage) *AuditEvent {
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+
+	return &AuditEvent{
+		ID:         uuid.New(),
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		ActorID:    actorID,
+		TargetID:   targetID,
+		Action:     action,
+		Outcome:    outcome,
+		Metadata:   metadata,
+	}
+}
+
+// Chain enlaza la entrada a continuación de la anterior (prevHash vacío para la primera) y calcula su hash
+func (e *AuditEvent) Chain(sequence int64, prevHash string) {
+	e.Sequence = sequence
+	e.PrevHash = prevHash
+	e.Hash = e.ComputeHash()
+}
+
+// ComputeHash calcula el SHA-256 de la entrada, incluido el hash de la anterior
+func (e *AuditEvent) ComputeHash() string {
+	// Los campos se serializan en un orden fijo; Metadata se usa tal como se guardó
+	payload, _ := json.Marshal([]interface{}{
+		e.Sequence,
+		e.ID.String(),
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		e.ActorID,
+		e.TargetID,
+		e.Action,
+		e.Outcome,
+		e.IPAddress,
+		e.UserAgent,
+		e.Metadata,
+		e.PrevHash,
+	})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType representa el tipo de un evento de dominio publicado a otros servicios
+type EventType string
+
+// Eventos del ciclo de vida de los usuarios
+const (
+	EventUserRegistered  EventType = "user.registered"
+	EventUserLoggedIn    EventType = "user.logged_in"
+	EventPasswordChanged EventType = "user.password_changed"
+	EventUserDeactivated EventType = "user.deactivated"
+	EventUserDeleted     EventType = "user.deleted"
+	EventRoleChanged     EventType = "user.role_changed"
+)
+
+// EventTypes es el catálogo de eventos que publica el servicio
+var EventTypes = []EventType{
+	EventUserRegistered,
+	EventUserLoggedIn,
+	EventPasswordChanged,
+	EventUserDeactivated,
+	EventUserDeleted,
+	EventRoleChanged,
+}
+
+// IsValidEventType indica si el tipo de evento pertenece al catálogo
+func IsValidEventType(eventType EventType) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainEvent representa un hecho del dominio que se publica a otros servicios
+type DomainEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        EventType       `json:"type"`
+	AggregateID string          `json:"aggregate_id"` // entidad a la que se refiere el evento
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// NewDomainEvent crea un evento; el payload solo debe contener valores serializables a JSON
+func NewDomainEvent(eventType EventType, aggregateID string, payload map[string]interface{}) *DomainEvent {
+	data, err := json.Marshal(payload)
+	if err != nil || payload == nil {
+		data = []byte("{}")
+	}
+
+	return &DomainEvent{
+		ID:          uuid.New(),
+		Type:        eventType,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC().Truncate(time.Microsecond),
+		Payload:     data,
+	}
+}
+
+// OutboxEvent representa un evento guardado en el outbox junto con su estado de publicación
+type OutboxEvent struct {
+	DomainEvent
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+}
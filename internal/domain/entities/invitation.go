@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvitationStatus representa el estado de una invitación
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusRevoked  InvitationStatus = "revoked"
+	InvitationStatusExpired  InvitationStatus = "expired"
+)
+
+// Invitation representa la invitación de un administrador para crear una cuenta con un rol
+// o sumarse a una organización. Solo se almacena el hash del token; el valor viaja en el email.
+type Invitation struct {
+	ID               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	Role             Role       `json:"role"`                        // rol principal de la cuenta nueva
+	OrganizationID   *uuid.UUID `json:"organization_id,omitempty"`   // organización a la que se suma
+	OrganizationRole Role       `json:"organization_role,omitempty"` // rol dentro de la organización
+	TokenHash        string     `json:"-"`
+	InvitedBy        *uuid.UUID `json:"invited_by,omitempty"` // nil para la invitación inicial del administrador
+	ExpiresAt        time.Time  `json:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy       *uuid.UUID `json:"accepted_by,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy        *uuid.UUID `json:"revoked_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// NewInvitation crea una nueva instancia de Invitation
+func NewInvitation(email string, role Role, tokenHash string, invitedBy *uuid.UUID, expiresAt time.Time) *Invitation {
+	return &Invitation{
+		ID:        uuid.New(),
+		Email:     email,
+		Role:      role,
+		TokenHash: tokenHash,
+		InvitedBy: invitedBy,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Status calcula el estado de la invitación
+func (i *Invitation) Status(now time.Time) InvitationStatus {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case now.After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential representa el segundo factor TOTP de un usuario.
+// El secreto se almacena cifrado y nunca se serializa.
+type TOTPCredential struct {
+	UserID          uuid.UUID  `json:"user_id"`
+	EncryptedSecret string     `json:"-"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep    int64      `json:"-"` // último paso aceptado, para impedir la reutilización de códigos
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// NewTOTPCredential crea una credencial TOTP pendiente de confirmación
+func NewTOTPCredential(userID uuid.UUID, encryptedSecret string) *TOTPCredential {
+	return &TOTPCredential{
+		UserID:          userID,
+		EncryptedSecret: encryptedSecret,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// IsConfirmed indica si el usuario ya verificó la credencial con un código válido
+func (c *TOTPCredential) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+// RecoveryCode representa un código de recuperación de un solo uso
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewRecoveryCode crea una nueva instancia de RecoveryCode
+func NewRecoveryCode(userID uuid.UUID, codeHash string) *RecoveryCode {
+	return &RecoveryCode{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  codeHash,
+		CreatedAt: time.Now(),
+	}
+}
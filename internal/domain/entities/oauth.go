@@ -0,0 +1,139 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClientType representa el tipo de cliente OAuth según RFC 6749
+type OAuthClientType string
+
+const (
+	OAuthClientPublic       OAuthClientType = "public"       // SPAs y apps móviles; no guardan un secreto y usan PKCE
+	OAuthClientConfidential OAuthClientType = "confidential" // backends que se autentican con su secreto
+)
+
+// OAuthClient representa una aplicación registrada que obtiene tokens en nombre de los usuarios.
+// Solo se almacena el hash del secreto; el valor se muestra al crear el cliente o rotarlo.
+type OAuthClient struct {
+	ID            uuid.UUID       `json:"id"`
+	ClientID      string          `json:"client_id"`
+	SecretHash    string          `json:"-"`
+	Name          string          `json:"name"`
+	Type          OAuthClientType `json:"type"`
+	RedirectURIs  []string        `json:"redirect_uris"`
+	AllowedScopes []string        `json:"allowed_scopes"`
+	SkipConsent   bool            `json:"skip_consent"` // aplicaciones propias que no piden consentimiento
+	CreatedBy     *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// NewOAuthClient crea una nueva instancia de OAuthClient
+func NewOAuthClient(clientID, name string, clientType OAuthClientType, redirectURIs, allowedScopes []string, createdBy *uuid.UUID) *OAuthClient {
+	now := time.Now()
+	return &OAuthClient{
+		ID:            uuid.New(),
+		ClientID:      clientID,
+		Name:          name,
+		Type:          clientType,
+		RedirectURIs:  redirectURIs,
+		AllowedScopes: allowedScopes,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// IsPublic indica si el cliente es público
+func (c *OAuthClient) IsPublic() bool {
+	return c.Type == OAuthClientPublic
+}
+
+// AllowsRedirectURI indica si la URI está registrada; la comparación es exacta
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	for _, uri := range c.RedirectURIs {
+		if uri == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScopes indica si todos los scopes están permitidos para el cliente
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	return containsAll(c.AllowedScopes, scopes)
+}
+
+// AuthorizationCode representa un código de autorización de un solo uso. Solo se almacena su
+// hash; FamilyID identifica los refresh tokens emitidos al canjearlo para revocarlos si el
+// código se vuelve a presentar.
+type AuthorizationCode struct {
+	CodeHash            string     `json:"-"`
+	ClientID            string     `json:"client_id"`
+	UserID              uuid.UUID  `json:"user_id"`
+	RedirectURI         string     `json:"redirect_uri"`
+	RedirectURIProvided bool       `json:"redirect_uri_provided"` // la solicitud incluyó redirect_uri; el canje debe repetirlo
+	Scope               string     `json:"scope"`
+	CodeChallenge       string     `json:"-"`
+	CodeChallengeMethod string     `json:"-"`
+	Nonce               string     `json:"-"`
+	AuthTime            *time.Time `json:"auth_time,omitempty"`    // login del usuario que concedió la autorización
+	AuthMethods         []string   `json:"auth_methods,omitempty"` // valores amr de ese login
+	ExpiresAt           time.Time  `json:"expires_at"`
+	UsedAt              *time.Time `json:"used_at,omitempty"`
+	FamilyID            *uuid.UUID `json:"family_id,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// NewAuthorizationCode crea una nueva instancia de AuthorizationCode
+func NewAuthorizationCode(codeHash, clientID string, userID uuid.UUID, redirectURI, scope, codeChallenge, codeChallengeMethod string, expiresAt time.Time) *AuthorizationCode {
+	return &AuthorizationCode{
+		CodeHash:            codeHash,
+		ClientID:            clientID,
+		UserID:              userID,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		ExpiresAt:           expiresAt,
+		CreatedAt:           time.Now(),
+	}
+}
+
+// IsExpired verifica si el código ha expirado
+func (c *AuthorizationCode) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// OAuthConsent representa los scopes que un usuario concedió a un cliente
+type OAuthConsent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Covers indica si el consentimiento incluye todos los scopes
+func (c *OAuthConsent) Covers(scopes []string) bool {
+	return containsAll(c.Scopes, scopes)
+}
+
+// containsAll indica si values contiene todos los elementos de required
+func containsAll(values, required []string) bool {
+	for _, r := range required {
+		found := false
+		for _, v := range values {
+			if v == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
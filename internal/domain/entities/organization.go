@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization representa una empresa cliente (tenant) de la plataforma
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewOrganization crea una nueva instancia de Organization
+func NewOrganization(name, slug string) *Organization {
+	now := time.Now()
+	return &Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// OrganizationMember representa la pertenencia de un usuario a una organización.
+// El rol es propio de la organización y no se suma a los roles globales del usuario.
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Role           Role      `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Datos relacionados que se completan según la consulta
+	Organization *Organization `json:"organization,omitempty"`
+	User         *User         `json:"user,omitempty"`
+}
+
+// NewOrganizationMember crea una nueva membresía
+func NewOrganizationMember(organizationID, userID uuid.UUID, role Role) *OrganizationMember {
+	now := time.Now()
+	return &OrganizationMember{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
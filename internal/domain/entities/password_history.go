@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordHistory representa un hash de contraseña usado anteriormente por un usuario
+type PasswordHistory struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewPasswordHistory crea una entrada del historial de contraseñas
+func NewPasswordHistory(userID uuid.UUID, passwordHash string) *PasswordHistory {
+	return &PasswordHistory{
+		ID:           uuid.New(),
+		UserID:       userID,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+}
@@ -0,0 +1,115 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role es el nombre de un rol definido en la base de datos
+type Role string
+
+// Roles de sistema creados por las migraciones; no se pueden eliminar
+const (
+	RoleUser      Role = "user"
+	RoleAdmin     Role = "admin"
+	RoleModerator Role = "moderator"
+
+	// Roles de sistema para las membresías de organización
+	RoleOrgAdmin  Role = "org_admin"
+	RoleOrgMember Role = "org_member"
+)
+
+// Permission es un permiso con nombre <recurso>:<acción>
+type Permission string
+
+const (
+	PermissionUsersRead            Permission = "users:read"
+	PermissionUsersWrite           Permission = "users:write"
+	PermissionUsersDelete          Permission = "users:delete"
+	PermissionSessionsRead         Permission = "sessions:read"
+	PermissionSessionsRevoke       Permission = "sessions:revoke"
+	PermissionMFAReset             Permission = "mfa:reset"
+	PermissionKeysRead             Permission = "keys:read"
+	PermissionKeysWrite            Permission = "keys:write"
+	PermissionRolesRead            Permission = "roles:read"
+	PermissionRolesWrite           Permission = "roles:write"
+	PermissionRolesAssign          Permission = "roles:assign"
+	PermissionOrgsRead             Permission = "orgs:read"
+	PermissionOrgsWrite            Permission = "orgs:write"
+	PermissionInvitesRead          Permission = "invitations:read"
+	PermissionInvitesWrite         Permission = "invitations:write"
+	PermissionAuditRead            Permission = "audit:read"
+	PermissionWebhooksRead         Permission = "webhooks:read"
+	PermissionWebhooksWrite        Permission = "webhooks:write"
+	PermissionClientsRead          Permission = "clients:read"
+	PermissionClientsWrite         Permission = "clients:write"
+	PermissionServiceAccountsRead  Permission = "service_accounts:read"
+	PermissionServiceAccountsWrite Permission = "service_accounts:write"
+
+	// Permisos que se evalúan dentro de una organización con el rol de la membresía
+	PermissionMembersRead  Permission = "members:read"
+	PermissionMembersWrite Permission = "members:write"
+)
+
+// Permissions es el catálogo de permisos que verifica el servicio
+var Permissions = []Permission{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersDelete,
+	PermissionSessionsRead,
+	PermissionSessionsRevoke,
+	PermissionMFAReset,
+	PermissionKeysRead,
+	PermissionKeysWrite,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionRolesAssign,
+	PermissionOrgsRead,
+	PermissionOrgsWrite,
+	PermissionInvitesRead,
+	PermissionInvitesWrite,
+	PermissionAuditRead,
+	PermissionWebhooksRead,
+	PermissionWebhooksWrite,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionServiceAccountsRead,
+	PermissionServiceAccountsWrite,
+	PermissionMembersRead,
+	PermissionMembersWrite,
+}
+
+// IsValidPermission indica si el permiso pertenece al catálogo
+func IsValidPermission(permission Permission) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleDefinition representa un rol que agrupa permisos
+type RoleDefinition struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        Role         `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	IsSystem    bool         `json:"is_system"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// NewRoleDefinition crea un rol definido por un administrador
+func NewRoleDefinition(name Role, description string, permissions []Permission) *RoleDefinition {
+	now := time.Now()
+	return &RoleDefinition{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccountAuthMethod indica cómo se autentica una cuenta de servicio en /oauth/token
+type ServiceAccountAuthMethod string
+
+const (
+	ServiceAccountAuthSecret        ServiceAccountAuthMethod = "client_secret"   // secreto compartido (Basic o en el cuerpo)
+	ServiceAccountAuthPrivateKeyJWT ServiceAccountAuthMethod = "private_key_jwt" // JWT firmado con su clave privada (RFC 7523)
+)
+
+// ServiceAccount representa un principal de máquina que obtiene access tokens con el grant
+// client_credentials, sin usuario. Solo se almacena el hash del secreto; el valor se muestra al
+// crear la cuenta o rotarlo. Con private_key_jwt se guarda la clave pública en PEM.
+type ServiceAccount struct {
+	ID              uuid.UUID                `json:"id"`
+	ClientID        string                   `json:"client_id"`
+	Name            string                   `json:"name"`
+	Description     string                   `json:"description,omitempty"`
+	AuthMethod      ServiceAccountAuthMethod `json:"auth_method"`
+	SecretHash      string                   `json:"-"`
+	PublicKey       string                   `json:"public_key,omitempty"`
+	AllowedScopes   []string                 `json:"allowed_scopes"`
+	IsActive        bool                     `json:"is_active"`
+	CreatedBy       *uuid.UUID               `json:"created_by,omitempty"`
+	LastUsedAt      *time.Time               `json:"last_used_at,omitempty"`
+	SecretRotatedAt *time.Time               `json:"secret_rotated_at,omitempty"`
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+}
+
+// NewServiceAccount crea una nueva instancia de ServiceAccount activa
+func NewServiceAccount(clientID, name, description string, authMethod ServiceAccountAuthMethod, allowedScopes []string, createdBy *uuid.UUID) *ServiceAccount {
+	now := time.Now()
+	return &ServiceAccount{
+		ID:            uuid.New(),
+		ClientID:      clientID,
+		Name:          name,
+		Description:   description,
+		AuthMethod:    authMethod,
+		AllowedScopes: allowedScopes,
+		IsActive:      true,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// UsesPrivateKeyJWT indica si la cuenta se autentica con un JWT firmado
+func (a *ServiceAccount) UsesPrivateKeyJWT() bool {
+	return a.AuthMethod == ServiceAccountAuthPrivateKeyJWT
+}
+
+// AllowsScopes indica si todos los scopes están permitidos para la cuenta
+func (a *ServiceAccount) AllowsScopes(scopes []string) bool {
+	return containsAll(a.AllowedScopes, scopes)
+}
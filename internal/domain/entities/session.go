@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session representa un inicio de sesión en un dispositivo, ligado a una familia de refresh tokens
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"-"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	IsRevoked  bool       `json:"is_revoked"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewSession crea una nueva instancia de Session
+func NewSession(userID, familyID uuid.UUID, deviceName, userAgent, ipAddress string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		FamilyID:   familyID,
+		DeviceName: deviceName,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+}
+
+// Touch actualiza la última actividad de la sesión
+func (s *Session) Touch(userAgent, ipAddress string) {
+	s.LastUsedAt = time.Now()
+	if userAgent != "" {
+		s.UserAgent = userAgent
+	}
+	if ipAddress != "" {
+		s.IPAddress = ipAddress
+	}
+}
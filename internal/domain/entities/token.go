@@ -8,13 +8,17 @@ import (
 
 // Token representa un token JWT
 type Token struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Token     string    `json:"token"`
-	TokenType TokenType `json:"token_type"`
-	IsRevoked bool      `json:"is_revoked"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	Token      string     `json:"token"`
+	TokenType  TokenType  `json:"token_type"`
+	ClientID   string     `json:"client_id,omitempty"` // cliente OAuth; vacío para los tokens del login propio
+	Scope      string     `json:"scope,omitempty"`
+	IsRevoked  bool       `json:"is_revoked"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty"` // token que lo reemplazó al rotarlo; nil si se revocó por otro motivo
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 // TokenType representa el tipo de token
@@ -25,11 +29,13 @@ const (
 	TokenTypeRefresh TokenType = "refresh"
 )
 
-// NewToken crea una nueva instancia de Token
+// NewToken crea una nueva instancia de Token que inicia su propia familia
 func NewToken(userID uuid.UUID, token string, tokenType TokenType, expiresAt time.Time) *Token {
+	id := uuid.New()
 	return &Token{
-		ID:        uuid.New(),
+		ID:        id,
 		UserID:    userID,
+		FamilyID:  id,
 		Token:     token,
 		TokenType: tokenType,
 		IsRevoked: false,
@@ -38,6 +44,13 @@ func NewToken(userID uuid.UUID, token string, tokenType TokenType, expiresAt tim
 	}
 }
 
+// NewTokenInFamily crea un token que reemplaza a otro dentro de la misma familia
+func NewTokenInFamily(userID, familyID uuid.UUID, token string, tokenType TokenType, expiresAt time.Time) *Token {
+	t := NewToken(userID, token, tokenType, expiresAt)
+	t.FamilyID = familyID
+	return t
+}
+
 // IsExpired verifica si el token ha expirado
 func (t *Token) IsExpired() bool {
 	return time.Now().After(t.ExpiresAt)
@@ -48,6 +61,12 @@ func (t *Token) Revoke() {
 	t.IsRevoked = true
 }
 
+// IsRotated indica si el token se revocó al rotarlo, de modo que volver a presentarlo es una
+// reutilización
+func (t *Token) IsRotated() bool {
+	return t.IsRevoked && t.ReplacedBy != nil
+}
+
 // IsValid verifica si el token es válido (no expirado y no revocado)
 func (t *Token) IsValid() bool {
 	return !t.IsExpired() && !t.IsRevoked
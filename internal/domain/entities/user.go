@@ -13,21 +13,21 @@ type User struct {
 	Password    string     `json:"-"` // No se serializa en JSON
 	FirstName   string     `json:"first_name"`
 	LastName    string     `json:"last_name"`
-	Role        Role       `json:"role"`
+	Role        Role       `json:"role"` // rol principal; se le suman los asignados en user_roles
 	IsActive    bool       `json:"is_active"`
 	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
-}
 
-// Role representa los roles de usuario
-type Role string
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 
-const (
-	RoleUser      Role = "user"
-	RoleAdmin     Role = "admin"
-	RoleModerator Role = "moderator"
-)
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	// Eventos pendientes; el repositorio los guarda en el outbox en la misma transacción que el usuario
+	events []*DomainEvent
+}
 
 // NewUser crea una nueva instancia de User
 func NewUser(email, password, firstName, lastName string) *User {
@@ -78,6 +78,19 @@ func (u *User) UpdateLastLogin() {
 	u.UpdatedAt = now
 }
 
+// MarkEmailVerified marca el email del usuario como verificado
+func (u *User) MarkEmailVerified() {
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+	u.UpdatedAt = now
+}
+
+// IsLocked indica si la cuenta está bloqueada temporalmente por intentos fallidos
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
 // Deactivate desactiva el usuario
 func (u *User) Deactivate() {
 	u.IsActive = false
@@ -89,3 +102,34 @@ func (u *User) Activate() {
 	u.IsActive = true
 	u.UpdatedAt = time.Now()
 }
+
+// RecordEvent registra un evento de dominio que se guarda con el siguiente Create, Update o Delete
+func (u *User) RecordEvent(eventType EventType, payload map[string]interface{}) {
+	u.events = append(u.events, NewDomainEvent(eventType, u.ID.String(), payload))
+}
+
+// PendingEvents retorna los eventos registrados que todavía no se guardaron
+func (u *User) PendingEvents() []*DomainEvent {
+	return u.events
+}
+
+// ClearEvents descarta los eventos ya guardados; los registrados después siguen pendientes
+func (u *User) ClearEvents(saved []*DomainEvent) {
+	pending := u.events[:0:0]
+	for _, event := range u.events {
+		if !containsEvent(saved, event) {
+			pending = append(pending, event)
+		}
+	}
+	u.events = pending
+}
+
+// containsEvent indica si el evento está en la lista
+func containsEvent(events []*DomainEvent, event *DomainEvent) bool {
+	for _, e := range events {
+		if e.ID == event.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential representa una credencial de clave pública (passkey o llave de seguridad)
+type WebAuthnCredential struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	CredentialID   string     `json:"credential_id"` // base64url sin relleno
+	PublicKey      []byte     `json:"-"`             // clave COSE en CBOR
+	SignCount      uint32     `json:"-"`
+	AAGUID         uuid.UUID  `json:"aaguid"`
+	Transports     []string   `json:"transports,omitempty"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// NewWebAuthnCredential crea una nueva instancia de WebAuthnCredential
+func NewWebAuthnCredential(userID uuid.UUID, credentialID string, publicKey []byte, signCount uint32, name string) *WebAuthnCredential {
+	return &WebAuthnCredential{
+		ID:           uuid.New(),
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    publicKey,
+		SignCount:    signCount,
+		Name:         name,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// MarkUsed registra el uso de la credencial con el nuevo contador de firmas
+func (c *WebAuthnCredential) MarkUsed(signCount uint32, backupState bool) {
+	now := time.Now()
+	c.SignCount = signCount
+	c.BackupState = backupState
+	c.LastUsedAt = &now
+}
+
+// WebAuthnCeremony identifica el tipo de ceremonia de un desafío pendiente
+type WebAuthnCeremony string
+
+const (
+	WebAuthnCeremonyRegistration WebAuthnCeremony = "registration"
+	WebAuthnCeremonyLogin        WebAuthnCeremony = "login" // credencial primaria, requiere verificación del usuario
+	WebAuthnCeremonyMFA          WebAuthnCeremony = "mfa"   // segundo factor tras la contraseña
+)
+
+// WebAuthnChallenge representa un desafío emitido y pendiente de respuesta
+type WebAuthnChallenge struct {
+	Challenge string           `json:"-"` // base64url sin relleno
+	UserID    *uuid.UUID       `json:"user_id,omitempty"`
+	Ceremony  WebAuthnCeremony `json:"ceremony"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// NewWebAuthnChallenge crea una nueva instancia de WebAuthnChallenge
+func NewWebAuthnChallenge(challenge string, userID *uuid.UUID, ceremony WebAuthnCeremony, expiresAt time.Time) *WebAuthnChallenge {
+	return &WebAuthnChallenge{
+		Challenge: challenge,
+		UserID:    userID,
+		Ceremony:  ceremony,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription representa un endpoint HTTP suscripto a eventos de dominio. El secreto
+// firma las entregas y se guarda cifrado; solo se muestra al crear la suscripción.
+type WebhookSubscription struct {
+	ID              uuid.UUID   `json:"id"`
+	URL             string      `json:"url"`
+	Description     string      `json:"description,omitempty"`
+	EncryptedSecret string      `json:"-"`
+	Events          []EventType `json:"events"` // vacío para recibir todos los eventos
+	IsActive        bool        `json:"is_active"`
+	// ConsecutiveFailures cuenta las entregas fallidas desde la última exitosa; al llegar al
+	// límite configurado la suscripción se desactiva y DisabledAt registra cuándo
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedBy           *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// NewWebhookSubscription crea una nueva instancia de WebhookSubscription activa
+func NewWebhookSubscription(url, description, encryptedSecret string, events []EventType, createdBy *uuid.UUID) *WebhookSubscription {
+	now := time.Now()
+	return &WebhookSubscription{
+		ID:              uuid.New(),
+		URL:             url,
+		Description:     description,
+		EncryptedSecret: encryptedSecret,
+		Events:          events,
+		IsActive:        true,
+		CreatedBy:       createdBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// Accepts indica si la suscripción recibe el tipo de evento
+func (s *WebhookSubscription) Accepts(eventType EventType) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus representa el estado de una entrega
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // esperando el primer intento o un reintento
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // el endpoint respondió 2xx
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // se agotaron los intentos
+)
+
+// WebhookDelivery representa la entrega de un evento a una suscripción
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      EventType             `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"` // cuerpo que se envía, igual en cada intento
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// NewWebhookDelivery crea una entrega pendiente
+func NewWebhookDelivery(subscriptionID, eventID uuid.UUID, eventType EventType, payload json.RawMessage) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+	}
+}
+
+// WebhookAttempt representa un intento de entrega con la respuesta del endpoint
+type WebhookAttempt struct {
+	ID           uuid.UUID `json:"id"`
+	DeliveryID   uuid.UUID `json:"delivery_id"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+	StatusCode   int       `json:"status_code,omitempty"` // 0 si no hubo respuesta
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// APIKeyRepository define las operaciones que debe implementar el repositorio de API keys
+type APIKeyRepository interface {
+	// Create guarda una nueva API key
+	Create(ctx context.Context, key *entities.APIKey) error
+
+	// GetByPrefix obtiene una API key por su prefijo público
+	GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+
+	// ListByUserID obtiene las API keys de un usuario, de la más reciente a la más antigua
+	ListByUserID(ctx context.Context, userID string) ([]*entities.APIKey, error)
+
+	// CountByUserID cuenta las API keys de un usuario
+	CountByUserID(ctx context.Context, userID string) (int, error)
+
+	// Delete elimina una API key del usuario
+	Delete(ctx context.Context, userID, id string) error
+
+	// UpdateLastUsed registra el último uso de la API key
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
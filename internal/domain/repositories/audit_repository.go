@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// AuditFilter representa los criterios para consultar el log de auditoría
+type AuditFilter struct {
+	UserID string     // vacío para no filtrar; coincide con el actor o el destinatario
+	Action string     // vacío para no filtrar por acción
+	From   *time.Time // inclusive
+	To     *time.Time // exclusive
+	Before int64      // cursor: solo entradas con secuencia menor; 0 desde la más reciente
+	Limit  int
+}
+
+// AuditRepository define las operaciones del log de auditoría. Es de solo agregado:
+// no hay operaciones para modificar ni borrar entradas.
+type AuditRepository interface {
+	// Append encadena la entrada a continuación de la última y la guarda
+	Append(ctx context.Context, event *entities.AuditEvent) error
+
+	// List obtiene las entradas que cumplen el filtro, de la más reciente a la más antigua
+	List(ctx context.Context, filter *AuditFilter) ([]*entities.AuditEvent, error)
+
+	// Walk recorre en orden de secuencia las entradas que cumplen el filtro (sin cursor ni límite)
+	Walk(ctx context.Context, filter *AuditFilter, fn func(*entities.AuditEvent) error) error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// ErrInvitationNotPending indica que la invitación ya fue aceptada, revocada o venció
+var ErrInvitationNotPending = errors.New("invalid or expired invitation")
+
+// InvitationFilter representa los criterios para listar y contar invitaciones
+type InvitationFilter struct {
+	OrganizationID string // vacío para no filtrar por organización
+	Email          string // vacío para no filtrar por email
+	PendingOnly    bool   // solo las no aceptadas, no revocadas y vigentes
+	Offset         int
+	Limit          int
+}
+
+// InvitationRepository define las operaciones que debe implementar el repositorio de invitaciones
+type InvitationRepository interface {
+	// Create guarda una nueva invitación
+	Create(ctx context.Context, invitation *entities.Invitation) error
+
+	// GetByID obtiene una invitación por su ID
+	GetByID(ctx context.Context, id string) (*entities.Invitation, error)
+
+	// GetByTokenHash obtiene una invitación por el hash de su token
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error)
+
+	// List obtiene las invitaciones que cumplen el filtro, de la más reciente a la más antigua
+	List(ctx context.Context, filter *InvitationFilter) ([]*entities.Invitation, error)
+
+	// Count cuenta las invitaciones que cumplen el filtro
+	Count(ctx context.Context, filter *InvitationFilter) (int64, error)
+
+	// Claim marca como aceptada una invitación pendiente; si ya no lo está retorna
+	// ErrInvitationNotPending. De dos aceptaciones concurrentes solo una la reclama.
+	Claim(ctx context.Context, id string) error
+
+	// SetAcceptedBy registra la cuenta que aceptó una invitación ya reclamada
+	SetAcceptedBy(ctx context.Context, id, userID string) error
+
+	// Revoke revoca una invitación pendiente
+	Revoke(ctx context.Context, id, revokedBy string) error
+}
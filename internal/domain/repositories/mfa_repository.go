@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// MFARepository define las operaciones que debe implementar el repositorio de segundos factores
+type MFARepository interface {
+	// SaveTOTP crea o reemplaza la credencial TOTP del usuario
+	SaveTOTP(ctx context.Context, credential *entities.TOTPCredential) error
+
+	// GetTOTP obtiene la credencial TOTP del usuario
+	GetTOTP(ctx context.Context, userID string) (*entities.TOTPCredential, error)
+
+	// ConfirmTOTP marca la credencial TOTP como confirmada
+	ConfirmTOTP(ctx context.Context, userID string, confirmedAt time.Time) error
+
+	// UseTOTPStep registra el paso usado; falla si ya se usó ese paso o uno posterior
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+
+	// ReplaceRecoveryCodes reemplaza los códigos de recuperación del usuario
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.RecoveryCode) error
+
+	// ConsumeRecoveryCode marca como usado un código de recuperación; falla si no existe o ya se usó
+	ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) error
+
+	// CountRecoveryCodes cuenta los códigos de recuperación sin usar
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+
+	// DeleteByUserID elimina la credencial TOTP y los códigos de recuperación del usuario
+	DeleteByUserID(ctx context.Context, userID string) error
+}
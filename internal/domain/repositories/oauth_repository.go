@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// ErrAuthorizationCodeUsed indica que el código a canjear ya estaba canjeado
+var ErrAuthorizationCodeUsed = errors.New("authorization code already used")
+
+// OAuthRepository define las operaciones que debe implementar el repositorio del servidor de
+// autorización OAuth: clientes, códigos de autorización y consentimientos
+type OAuthRepository interface {
+	// CreateClient guarda un nuevo cliente
+	CreateClient(ctx context.Context, client *entities.OAuthClient) error
+
+	// GetClient obtiene un cliente por su ID interno
+	GetClient(ctx context.Context, id string) (*entities.OAuthClient, error)
+
+	// GetClientByClientID obtiene un cliente por su client_id
+	GetClientByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error)
+
+	// ListClients obtiene todos los clientes, del más reciente al más antiguo
+	ListClients(ctx context.Context) ([]*entities.OAuthClient, error)
+
+	// UpdateClient actualiza un cliente existente
+	UpdateClient(ctx context.Context, client *entities.OAuthClient) error
+
+	// DeleteClient elimina un cliente junto con sus códigos, consentimientos y refresh tokens,
+	// y revoca las sesiones de esos tokens
+	DeleteClient(ctx context.Context, id string) error
+
+	// CreateAuthorizationCode guarda un nuevo código de autorización
+	CreateAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error
+
+	// GetAuthorizationCode obtiene un código por su hash
+	GetAuthorizationCode(ctx context.Context, codeHash string) (*entities.AuthorizationCode, error)
+
+	// MarkAuthorizationCodeUsed marca el código como canjeado con la familia de refresh tokens
+	// emitida; si ya estaba canjeado retorna ErrAuthorizationCodeUsed
+	MarkAuthorizationCodeUsed(ctx context.Context, codeHash, familyID string) error
+
+	// DeleteExpiredAuthorizationCodes elimina los códigos que expiraron antes de before
+	DeleteExpiredAuthorizationCodes(ctx context.Context, before time.Time) error
+
+	// GetConsent obtiene el consentimiento de un usuario para un cliente
+	GetConsent(ctx context.Context, userID, clientID string) (*entities.OAuthConsent, error)
+
+	// SaveConsent crea o reemplaza el consentimiento de un usuario para un cliente
+	SaveConsent(ctx context.Context, consent *entities.OAuthConsent) error
+}
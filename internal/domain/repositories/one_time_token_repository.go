@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// OneTimeTokenRepository define las operaciones que debe implementar el repositorio de tokens de un solo uso
+type OneTimeTokenRepository interface {
+	// Create crea un nuevo token de un solo uso
+	Create(ctx context.Context, token *entities.OneTimeToken) error
+
+	// Consume marca como usado un token vigente y lo retorna; falla si no existe, expiró o ya se usó
+	Consume(ctx context.Context, tokenHash string, purpose entities.TokenPurpose) (*entities.OneTimeToken, error)
+
+	// InvalidateByUserID marca como usados los tokens pendientes de un usuario para un propósito
+	InvalidateByUserID(ctx context.Context, userID string, purpose entities.TokenPurpose) error
+
+	// DeleteExpired elimina tokens expirados
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// OrganizationRepository define las operaciones que debe implementar el repositorio de organizaciones y membresías
+type OrganizationRepository interface {
+	// Create crea una organización y, si se indica, su primera membresía
+	Create(ctx context.Context, organization *entities.Organization, owner *entities.OrganizationMember) error
+
+	// GetByID obtiene una organización por su ID
+	GetByID(ctx context.Context, id string) (*entities.Organization, error)
+
+	// List obtiene una lista de organizaciones con paginación
+	List(ctx context.Context, offset, limit int) ([]*entities.Organization, error)
+
+	// Count cuenta el total de organizaciones
+	Count(ctx context.Context) (int64, error)
+
+	// Delete elimina una organización y sus membresías
+	Delete(ctx context.Context, id string) error
+
+	// AddMember agrega un usuario a una organización
+	AddMember(ctx context.Context, member *entities.OrganizationMember) error
+
+	// FindMember obtiene la membresía del usuario; retorna nil si no es miembro
+	FindMember(ctx context.Context, organizationID, userID string) (*entities.OrganizationMember, error)
+
+	// UpdateMemberRole cambia el rol del usuario dentro de la organización
+	UpdateMemberRole(ctx context.Context, organizationID, userID string, role entities.Role) error
+
+	// RemoveMember quita un usuario de una organización
+	RemoveMember(ctx context.Context, organizationID, userID string) error
+
+	// ListMembers obtiene todas las membresías de una organización
+	ListMembers(ctx context.Context, organizationID string) ([]*entities.OrganizationMember, error)
+
+	// ListByUserID obtiene las membresías del usuario con los datos de cada organización
+	ListByUserID(ctx context.Context, userID string) ([]*entities.OrganizationMember, error)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// OutboxRepository define las operaciones del outbox de eventos de dominio. Los eventos se
+// agregan en la transacción del repositorio que los produce; aquí solo se publican.
+type OutboxRepository interface {
+	// Claim reserva hasta limit eventos pendientes cuyo próximo intento ya venció, incrementa sus
+	// intentos y los oculta a otras réplicas hasta leaseUntil
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.OutboxEvent, error)
+
+	// MarkPublished marca el evento como publicado
+	MarkPublished(ctx context.Context, id string) error
+
+	// MarkFailed guarda el error del intento y programa el siguiente
+	MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+
+	// DeletePublished elimina los eventos publicados antes de la fecha indicada
+	DeletePublished(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// PasswordHistoryRepository define las operaciones del historial de contraseñas
+type PasswordHistoryRepository interface {
+	// Add agrega una entrada y conserva solo las keep más recientes del usuario
+	Add(ctx context.Context, entry *entities.PasswordHistory, keep int) error
+
+	// ListRecent obtiene las limit entradas más recientes del usuario
+	ListRecent(ctx context.Context, userID string, limit int) ([]*entities.PasswordHistory, error)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationRepository define las operaciones de la lista de access tokens revocados
+type RevocationRepository interface {
+	// RevokeAccessToken agrega un jti a la lista de revocados hasta su expiración; userID es vacío
+	// en los tokens de cuentas de servicio
+	RevokeAccessToken(ctx context.Context, jti, userID string, expiresAt time.Time) error
+
+	// IsAccessTokenRevoked verifica si un jti está revocado
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+
+	// SetUserWatermark invalida los access tokens del usuario emitidos antes de notBefore
+	SetUserWatermark(ctx context.Context, userID string, notBefore time.Time) error
+
+	// GetUserWatermark obtiene la marca de agua del usuario (cero si no tiene)
+	GetUserWatermark(ctx context.Context, userID string) (time.Time, error)
+
+	// DeleteExpired elimina los jti revocados que ya expiraron
+	DeleteExpired(ctx context.Context) error
+}
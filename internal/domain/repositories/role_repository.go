@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// RoleRepository define las operaciones que debe implementar el repositorio de roles y permisos
+type RoleRepository interface {
+	// Create crea un rol con sus permisos
+	Create(ctx context.Context, role *entities.RoleDefinition) error
+
+	// GetByID obtiene un rol por su ID
+	GetByID(ctx context.Context, id string) (*entities.RoleDefinition, error)
+
+	// GetByName obtiene un rol por su nombre
+	GetByName(ctx context.Context, name string) (*entities.RoleDefinition, error)
+
+	// List obtiene todos los roles
+	List(ctx context.Context) ([]*entities.RoleDefinition, error)
+
+	// SetPermissions reemplaza los permisos de un rol
+	SetPermissions(ctx context.Context, roleID string, permissions []entities.Permission) error
+
+	// Delete elimina un rol que no es de sistema ni es el rol principal de ningún usuario
+	Delete(ctx context.Context, id string) error
+
+	// AssignToUser asigna un rol adicional a un usuario
+	AssignToUser(ctx context.Context, userID, roleID string) error
+
+	// RemoveFromUser quita un rol adicional de un usuario
+	RemoveFromUser(ctx context.Context, userID, roleID string) error
+
+	// ListByUserID obtiene los roles efectivos del usuario: el principal y los asignados
+	ListByUserID(ctx context.Context, userID string) ([]*entities.RoleDefinition, error)
+
+	// ListUserIDs obtiene los IDs de los usuarios que tienen el rol como principal o asignado
+	ListUserIDs(ctx context.Context, roleID string) ([]string, error)
+
+	// ListByNames obtiene los roles con los nombres indicados; los inexistentes se ignoran
+	ListByNames(ctx context.Context, names []string) ([]*entities.RoleDefinition, error)
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// ServiceAccountRepository define las operaciones que debe implementar el repositorio de cuentas de servicio
+type ServiceAccountRepository interface {
+	// Create guarda una nueva cuenta de servicio
+	Create(ctx context.Context, account *entities.ServiceAccount) error
+
+	// GetByID obtiene una cuenta por su ID interno
+	GetByID(ctx context.Context, id string) (*entities.ServiceAccount, error)
+
+	// GetByClientID obtiene una cuenta por su client_id
+	GetByClientID(ctx context.Context, clientID string) (*entities.ServiceAccount, error)
+
+	// List obtiene todas las cuentas, de la más reciente a la más antigua
+	List(ctx context.Context) ([]*entities.ServiceAccount, error)
+
+	// Update actualiza una cuenta existente
+	Update(ctx context.Context, account *entities.ServiceAccount) error
+
+	// Delete elimina una cuenta
+	Delete(ctx context.Context, id string) error
+
+	// UpdateLastUsed registra el último token emitido a la cuenta
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+
+	// UseAssertion registra el jti de un client assertion hasta su expiración; falla si ya se usó
+	UseAssertion(ctx context.Context, clientID, jti string, expiresAt time.Time) error
+
+	// DeleteExpiredAssertions elimina los jti de assertions que ya expiraron
+	DeleteExpiredAssertions(ctx context.Context) error
+}
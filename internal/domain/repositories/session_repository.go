@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// SessionRepository define las operaciones que debe implementar el repositorio de sesiones
+type SessionRepository interface {
+	// Create crea una nueva sesión
+	Create(ctx context.Context, session *entities.Session) error
+
+	// GetByID obtiene una sesión por su ID
+	GetByID(ctx context.Context, id string) (*entities.Session, error)
+
+	// GetByFamilyID obtiene la sesión ligada a una familia de refresh tokens
+	GetByFamilyID(ctx context.Context, familyID string) (*entities.Session, error)
+
+	// ListActiveByUserID obtiene las sesiones no revocadas de un usuario
+	ListActiveByUserID(ctx context.Context, userID string) ([]*entities.Session, error)
+
+	// Touch actualiza la última actividad, el user agent y la IP de una sesión
+	Touch(ctx context.Context, session *entities.Session) error
+
+	// Revoke marca una sesión como revocada
+	Revoke(ctx context.Context, id string) error
+
+	// RevokeByFamilyID revoca la sesión ligada a una familia de refresh tokens
+	RevokeByFamilyID(ctx context.Context, familyID string) error
+
+	// RevokeByUserID revoca todas las sesiones de un usuario
+	RevokeByUserID(ctx context.Context, userID string) error
+}
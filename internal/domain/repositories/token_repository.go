@@ -2,10 +2,14 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"auth-go-microservicio/internal/domain/entities"
 )
 
+// ErrTokenNotRevocable indica que el token a revocar no existe o ya estaba revocado
+var ErrTokenNotRevocable = errors.New("token not found or already revoked")
+
 // TokenRepository define las operaciones que debe implementar el repositorio de tokens
 type TokenRepository interface {
 	// Create crea un nuevo token
@@ -20,9 +24,17 @@ type TokenRepository interface {
 	// RevokeByUserID revoca todos los tokens de un usuario
 	RevokeByUserID(ctx context.Context, userID string) error
 
-	// RevokeToken revoca un token específico
+	// RevokeToken revoca un token específico que todavía no esté revocado; si no existe o ya
+	// estaba revocado retorna ErrTokenNotRevocable
 	RevokeToken(ctx context.Context, token string) error
 
+	// RotateToken revoca un token que todavía no esté revocado y registra el token que lo
+	// reemplaza; si no existe o ya estaba revocado retorna ErrTokenNotRevocable
+	RotateToken(ctx context.Context, token, replacedByID string) error
+
+	// RevokeFamily revoca todos los tokens de una familia
+	RevokeFamily(ctx context.Context, familyID string) error
+
 	// DeleteExpired elimina tokens expirados
 	DeleteExpired(ctx context.Context) error
 
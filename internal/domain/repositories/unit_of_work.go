@@ -0,0 +1,21 @@
+package repositories
+
+import "context"
+
+// TxRepositories agrupa los repositorios ligados a una misma transacción
+type TxRepositories struct {
+	Users         UserRepository
+	Tokens        TokenRepository
+	Sessions      SessionRepository
+	OAuth         OAuthRepository
+	Invitations   InvitationRepository
+	Roles         RoleRepository
+	Organizations OrganizationRepository
+}
+
+// UnitOfWork ejecuta operaciones de varios repositorios de forma atómica
+type UnitOfWork interface {
+	// Do ejecuta fn dentro de una transacción con repositorios ligados a ella. Confirma si fn
+	// retorna nil y revierte todos los cambios si retorna un error o entra en pánico.
+	Do(ctx context.Context, fn func(repos *TxRepositories) error) error
+}
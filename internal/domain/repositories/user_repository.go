@@ -2,11 +2,22 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"auth-go-microservicio/internal/domain/entities"
 )
 
-// UserRepository define las operaciones que debe implementar el repositorio de usuarios
+// UserFilter representa los criterios para listar y contar usuarios
+type UserFilter struct {
+	OrganizationID string // vacío para no filtrar por organización
+	Role           string // vacío para no filtrar por rol principal
+	Offset         int
+	Limit          int
+}
+
+// UserRepository define las operaciones que debe implementar el repositorio de usuarios.
+// Create, Update y Delete guardan en el outbox los eventos pendientes del usuario en la
+// misma transacción que el cambio.
 type UserRepository interface {
 	// Create crea un nuevo usuario
 	Create(ctx context.Context, user *entities.User) error
@@ -20,15 +31,24 @@ type UserRepository interface {
 	// Update actualiza un usuario existente
 	Update(ctx context.Context, user *entities.User) error
 
-	// Delete elimina un usuario por su ID
-	Delete(ctx context.Context, id string) error
+	// Delete elimina el usuario
+	Delete(ctx context.Context, user *entities.User) error
 
-	// List obtiene una lista de usuarios con paginación
-	List(ctx context.Context, offset, limit int) ([]*entities.User, error)
+	// List obtiene una lista de usuarios con paginación, opcionalmente de una organización
+	List(ctx context.Context, filter *UserFilter) ([]*entities.User, error)
 
-	// Count cuenta el total de usuarios
-	Count(ctx context.Context) (int64, error)
+	// Count cuenta el total de usuarios que cumplen el filtro
+	Count(ctx context.Context, filter *UserFilter) (int64, error)
 
 	// ExistsByEmail verifica si existe un usuario con el email dado
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+
+	// RecordFailedLogin incrementa atómicamente los intentos fallidos y retorna el total
+	RecordFailedLogin(ctx context.Context, id string) (int, error)
+
+	// LockUntil bloquea la cuenta hasta la fecha indicada
+	LockUntil(ctx context.Context, id string, until time.Time) error
+
+	// ResetFailedLogins reinicia los intentos fallidos y quita el bloqueo
+	ResetFailedLogins(ctx context.Context, id string) error
 }
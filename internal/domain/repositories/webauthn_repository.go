@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// WebAuthnRepository define las operaciones que debe implementar el repositorio de credenciales WebAuthn
+type WebAuthnRepository interface {
+	// CreateCredential registra una nueva credencial
+	CreateCredential(ctx context.Context, credential *entities.WebAuthnCredential) error
+
+	// GetCredential obtiene una credencial por su credential id (base64url)
+	GetCredential(ctx context.Context, credentialID string) (*entities.WebAuthnCredential, error)
+
+	// ListCredentialsByUserID obtiene las credenciales de un usuario
+	ListCredentialsByUserID(ctx context.Context, userID string) ([]*entities.WebAuthnCredential, error)
+
+	// UpdateCredentialUsage guarda el contador de firmas y la fecha de último uso
+	UpdateCredentialUsage(ctx context.Context, credential *entities.WebAuthnCredential) error
+
+	// DeleteCredential elimina una credencial de un usuario
+	DeleteCredential(ctx context.Context, userID, id string) error
+
+	// DeleteCredentialsByUserID elimina todas las credenciales de un usuario
+	DeleteCredentialsByUserID(ctx context.Context, userID string) error
+
+	// CreateChallenge guarda un desafío pendiente
+	CreateChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error
+
+	// ConsumeChallenge elimina y retorna un desafío vigente; falla si no existe o expiró
+	ConsumeChallenge(ctx context.Context, challenge string, ceremony entities.WebAuthnCeremony) (*entities.WebAuthnChallenge, error)
+
+	// DeleteExpiredChallenges elimina desafíos expirados
+	DeleteExpiredChallenges(ctx context.Context) error
+}
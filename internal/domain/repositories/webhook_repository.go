@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// WebhookDeliveryFilter representa los criterios para listar y contar entregas de una suscripción
+type WebhookDeliveryFilter struct {
+	SubscriptionID string
+	Status         string // vacío para no filtrar por estado
+	Offset         int
+	Limit          int
+}
+
+// WebhookRepository define las operaciones que debe implementar el repositorio de webhooks
+type WebhookRepository interface {
+	// CreateSubscription guarda una nueva suscripción
+	CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error
+
+	// GetSubscription obtiene una suscripción por su ID
+	GetSubscription(ctx context.Context, id string) (*entities.WebhookSubscription, error)
+
+	// ListSubscriptions obtiene todas las suscripciones, de la más reciente a la más antigua
+	ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error)
+
+	// ListActiveSubscriptions obtiene las suscripciones activas que reciben el tipo de evento
+	ListActiveSubscriptions(ctx context.Context, eventType entities.EventType) ([]*entities.WebhookSubscription, error)
+
+	// UpdateSubscription actualiza una suscripción existente
+	UpdateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error
+
+	// RecordDeliveryFailure suma una entrega fallida consecutiva a la suscripción y la desactiva al
+	// llegar a disableAfter (0 para no desactivarla nunca). Retorna true si esta llamada la desactivó.
+	RecordDeliveryFailure(ctx context.Context, id string, disableAfter int) (bool, error)
+
+	// ResetDeliveryFailures reinicia el contador de entregas fallidas consecutivas
+	ResetDeliveryFailures(ctx context.Context, id string) error
+
+	// DeleteSubscription elimina una suscripción y su historial de entregas
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// CreateDeliveries guarda las entregas pendientes; ignora las que ya existen para el mismo
+	// evento y suscripción, porque los eventos pueden llegar más de una vez
+	CreateDeliveries(ctx context.Context, deliveries []*entities.WebhookDelivery) error
+
+	// ClaimDeliveries reserva hasta limit entregas pendientes cuyo próximo intento ya venció y
+	// las oculta a otras réplicas hasta leaseUntil
+	ClaimDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.WebhookDelivery, error)
+
+	// RecordAttempt guarda el intento y el nuevo estado de la entrega en una transacción
+	RecordAttempt(ctx context.Context, delivery *entities.WebhookDelivery, attempt *entities.WebhookAttempt) error
+
+	// GetDelivery obtiene una entrega por su ID
+	GetDelivery(ctx context.Context, id string) (*entities.WebhookDelivery, error)
+
+	// ListDeliveries obtiene las entregas que cumplen el filtro, de la más reciente a la más antigua
+	ListDeliveries(ctx context.Context, filter *WebhookDeliveryFilter) ([]*entities.WebhookDelivery, error)
+
+	// CountDeliveries cuenta las entregas que cumplen el filtro
+	CountDeliveries(ctx context.Context, filter *WebhookDeliveryFilter) (int64, error)
+
+	// ListAttempts obtiene los intentos de una entrega en orden cronológico
+	ListAttempts(ctx context.Context, deliveryID string) ([]*entities.WebhookAttempt, error)
+
+	// Redeliver vuelve a poner la entrega como pendiente para enviarla de inmediato
+	Redeliver(ctx context.Context, id string) error
+}
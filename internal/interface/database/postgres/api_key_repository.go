@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns lista las columnas en el orden que espera scanAPIKey
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at`
+
+// APIKeyRepository implementa el repositorio de API keys para PostgreSQL
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository crea una nueva instancia de APIKeyRepository
+func NewAPIKeyRepository(db *sql.DB) repositories.APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create guarda una nueva API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *entities.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		nullTime(key.ExpiresAt),
+		key.CreatedAt,
+	)
+
+	return err
+}
+
+// GetByPrefix obtiene una API key por su prefijo público
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, prefix))
+}
+
+// ListByUserID obtiene las API keys de un usuario, de la más reciente a la más antigua
+func (r *APIKeyRepository) ListByUserID(ctx context.Context, userID string) ([]*entities.APIKey, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*entities.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// CountByUserID cuenta las API keys de un usuario
+func (r *APIKeyRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, errors.New("invalid user id")
+	}
+
+	var count int
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM api_keys WHERE user_id = $1`, parsedUserID).Scan(&count)
+	return count, err
+}
+
+// Delete elimina una API key del usuario
+func (r *APIKeyRepository) Delete(ctx context.Context, userID, id string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid api key id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, parsedID, parsedUserID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("api key not found")
+	}
+
+	return nil
+}
+
+// UpdateLastUsed registra el último uso de la API key
+func (r *APIKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid api key id")
+	}
+
+	_, err = r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, parsedID, usedAt)
+	return err
+}
+
+// scanAPIKey lee una API key desde una fila
+func scanAPIKey(row scanner) (*entities.APIKey, error) {
+	var key entities.APIKey
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		pq.Array(&key.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("api key not found")
+		}
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	_ "github.com/lib/pq"
+)
+
+// auditColumns lista las columnas en el orden que espera scanAuditEvent
+const auditColumns = `seq, id, occurred_at, COALESCE(actor_id, ''), COALESCE(target_id, ''), action, outcome,
+	COALESCE(ip_address, ''), COALESCE(user_agent, ''), metadata, prev_hash, hash`
+
+// AuditRepository implementa el log de auditoría para PostgreSQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository crea una nueva instancia de AuditRepository
+func NewAuditRepository(db *sql.DB) repositories.AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Append encadena la entrada a continuación de la última y la guarda. El bloqueo de la tabla
+// serializa los agregados para que dos réplicas no encadenen sobre la misma entrada.
+func (r *AuditRepository) Append(ctx context.Context, event *entities.AuditEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_events IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	var lastSequence int64
+	var lastHash string
+	err = tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_events ORDER BY seq DESC LIMIT 1`).Scan(&lastSequence, &lastHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	event.Chain(lastSequence+1, lastHash)
+
+	query := `
+		INSERT INTO audit_events (seq, id, occurred_at, actor_id, target_id, action, outcome, ip_address, user_agent, metadata, prev_hash, hash)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		event.Sequence,
+		event.ID,
+		event.OccurredAt,
+		event.ActorID,
+		event.TargetID,
+		event.Action,
+		event.Outcome,
+		event.IPAddress,
+		event.UserAgent,
+		string(event.Metadata),
+		event.PrevHash,
+		event.Hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// List obtiene las entradas que cumplen el filtro, de la más reciente a la más antigua
+func (r *AuditRepository) List(ctx context.Context, filter *repositories.AuditFilter) ([]*entities.AuditEvent, error) {
+	where, args := auditFilterClause(filter, true)
+	args = append(args, filter.Limit)
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_events ` + where + `
+		ORDER BY seq DESC
+		LIMIT $` + strconv.Itoa(len(args)) + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*entities.AuditEvent
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Walk recorre en orden de secuencia las entradas que cumplen el filtro (sin cursor ni límite)
+func (r *AuditRepository) Walk(ctx context.Context, filter *repositories.AuditFilter, fn func(*entities.AuditEvent) error) error {
+	where, args := auditFilterClause(filter, false)
+
+	query := `SELECT ` + auditColumns + ` FROM audit_events ` + where + ` ORDER BY seq ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// auditFilterClause arma la condición WHERE y sus argumentos a partir del filtro;
+// withCursor incluye la condición del cursor
+func auditFilterClause(filter *repositories.AuditFilter, withCursor bool) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		n := strconv.Itoa(len(args))
+		conditions = append(conditions, "(actor_id = $"+n+" OR target_id = $"+n+")")
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, "action = $"+strconv.Itoa(len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, filter.From.UTC())
+		conditions = append(conditions, "occurred_at >= $"+strconv.Itoa(len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, filter.To.UTC())
+		conditions = append(conditions, "occurred_at < $"+strconv.Itoa(len(args)))
+	}
+	if withCursor && filter.Before > 0 {
+		args = append(args, filter.Before)
+		conditions = append(conditions, "seq < $"+strconv.Itoa(len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanAuditEvent lee una entrada del log de auditoría desde una fila
+func scanAuditEvent(row scanner) (*entities.AuditEvent, error) {
+	var event entities.AuditEvent
+	var metadata []byte
+
+	err := row.Scan(
+		&event.Sequence,
+		&event.ID,
+		&event.OccurredAt,
+		&event.ActorID,
+		&event.TargetID,
+		&event.Action,
+		&event.Outcome,
+		&event.IPAddress,
+		&event.UserAgent,
+		&metadata,
+		&event.PrevHash,
+		&event.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	event.OccurredAt = event.OccurredAt.UTC()
+	event.Metadata = metadata
+
+	return &event, nil
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// invitationColumns lista las columnas en el orden que espera scanInvitation
+const invitationColumns = `id, email, role, organization_id, COALESCE(organization_role, ''), token_hash, invited_by,
+	expires_at, accepted_at, accepted_by, revoked_at, revoked_by, created_at`
+
+// InvitationRepository implementa el repositorio de invitaciones para PostgreSQL
+type InvitationRepository struct {
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+}
+
+// NewInvitationRepository crea una nueva instancia de InvitationRepository
+func NewInvitationRepository(db *sql.DB) repositories.InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// Create guarda una nueva invitación
+func (r *InvitationRepository) Create(ctx context.Context, invitation *entities.Invitation) error {
+	query := `
+		INSERT INTO invitations (id, email, role, organization_id, organization_role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		invitation.ID,
+		invitation.Email,
+		invitation.Role,
+		nullUUID(invitation.OrganizationID),
+		invitation.OrganizationRole,
+		invitation.TokenHash,
+		nullUUID(invitation.InvitedBy),
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	)
+
+	return err
+}
+
+// GetByID obtiene una invitación por su ID
+func (r *InvitationRepository) GetByID(ctx context.Context, id string) (*entities.Invitation, error) {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid invitation id")
+	}
+
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE id = $1`
+
+	return scanInvitation(r.db.QueryRowContext(ctx, query, invitationID))
+}
+
+// GetByTokenHash obtiene una invitación por el hash de su token
+func (r *InvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE token_hash = $1`
+
+	return scanInvitation(r.db.QueryRowContext(ctx, query, tokenHash))
+}
+
+// List obtiene las invitaciones que cumplen el filtro, de la más reciente a la más antigua
+func (r *InvitationRepository) List(ctx context.Context, filter *repositories.InvitationFilter) ([]*entities.Invitation, error) {
+	where, args := invitationFilterClause(filter)
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitations ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*entities.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+// Count cuenta las invitaciones que cumplen el filtro
+func (r *InvitationRepository) Count(ctx context.Context, filter *repositories.InvitationFilter) (int64, error) {
+	where, args := invitationFilterClause(filter)
+	query := `SELECT COUNT(*) FROM invitations ` + where
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+
+	return count, err
+}
+
+// Claim marca como aceptada una invitación pendiente. La actualización es condicional, por lo
+// que falla con ErrInvitationNotPending si otra aceptación la reclamó primero.
+func (r *InvitationRepository) Claim(ctx context.Context, id string) error {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid invitation id")
+	}
+
+	query := `
+		UPDATE invitations
+		SET accepted_at = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, invitationID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repositories.ErrInvitationNotPending
+	}
+
+	return nil
+}
+
+// SetAcceptedBy registra la cuenta que aceptó una invitación ya reclamada. Se separa de Claim
+// porque al crear la cuenta la invitación se reclama antes de que exista el usuario.
+func (r *InvitationRepository) SetAcceptedBy(ctx context.Context, id, userID string) error {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid invitation id")
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE invitations SET accepted_by = $2 WHERE id = $1 AND accepted_at IS NOT NULL`
+
+	_, err = r.db.ExecContext(ctx, query, invitationID, parsedUserID)
+	return err
+}
+
+// Revoke revoca una invitación pendiente
+func (r *InvitationRepository) Revoke(ctx context.Context, id, revokedBy string) error {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid invitation id")
+	}
+	revokedByID, err := uuid.Parse(revokedBy)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `
+		UPDATE invitations
+		SET revoked_at = $3, revoked_by = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, invitationID, revokedByID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("invitation not found or no longer pending")
+	}
+
+	return nil
+}
+
+// invitationFilterClause arma la condición WHERE y sus argumentos a partir del filtro
+func invitationFilterClause(filter *repositories.InvitationFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.OrganizationID != "" {
+		args = append(args, filter.OrganizationID)
+		conditions = append(conditions, "organization_id = $"+strconv.Itoa(len(args)))
+	}
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		conditions = append(conditions, "email = $"+strconv.Itoa(len(args)))
+	}
+	if filter.PendingOnly {
+		conditions = append(conditions, "accepted_at IS NULL AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP")
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanInvitation lee una invitación desde una fila
+func scanInvitation(row scanner) (*entities.Invitation, error) {
+	var invitation entities.Invitation
+	var organizationID, invitedBy, acceptedBy, revokedBy uuid.NullUUID
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.Email,
+		&invitation.Role,
+		&organizationID,
+		&invitation.OrganizationRole,
+		&invitation.TokenHash,
+		&invitedBy,
+		&invitation.ExpiresAt,
+		&acceptedAt,
+		&acceptedBy,
+		&revokedAt,
+		&revokedBy,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, err
+	}
+
+	invitation.OrganizationID = uuidPtr(organizationID)
+	invitation.InvitedBy = uuidPtr(invitedBy)
+	invitation.AcceptedBy = uuidPtr(acceptedBy)
+	invitation.RevokedBy = uuidPtr(revokedBy)
+	if acceptedAt.Valid {
+		invitation.AcceptedAt = &acceptedAt.Time
+	}
+	if revokedAt.Valid {
+		invitation.RevokedAt = &revokedAt.Time
+	}
+
+	return &invitation, nil
+}
+
+// nullUUID convierte un UUID opcional en un valor nulo de SQL
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+// uuidPtr convierte un UUID nulo de SQL en un UUID opcional
+func uuidPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// MFARepository implementa el repositorio de segundos factores para PostgreSQL
+type MFARepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository crea una nueva instancia de MFARepository
+func NewMFARepository(db *sql.DB) repositories.MFARepository {
+	return &MFARepository{db: db}
+}
+
+// SaveTOTP crea o reemplaza la credencial TOTP del usuario
+func (r *MFARepository) SaveTOTP(ctx context.Context, credential *entities.TOTPCredential) error {
+	query := `
+		INSERT INTO user_totp_credentials (user_id, encrypted_secret, confirmed_at, last_used_step, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			encrypted_secret = EXCLUDED.encrypted_secret,
+			confirmed_at = EXCLUDED.confirmed_at,
+			last_used_step = EXCLUDED.last_used_step,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		credential.UserID,
+		credential.EncryptedSecret,
+		nullTime(credential.ConfirmedAt),
+		credential.LastUsedStep,
+		credential.CreatedAt,
+	)
+
+	return err
+}
+
+// GetTOTP obtiene la credencial TOTP del usuario
+func (r *MFARepository) GetTOTP(ctx context.Context, userID string) (*entities.TOTPCredential, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT user_id, encrypted_secret, confirmed_at, last_used_step, created_at
+		FROM user_totp_credentials WHERE user_id = $1
+	`
+
+	var credential entities.TOTPCredential
+	var confirmedAt sql.NullTime
+
+	err = r.db.QueryRowContext(ctx, query, parsedUserID).Scan(
+		&credential.UserID,
+		&credential.EncryptedSecret,
+		&confirmedAt,
+		&credential.LastUsedStep,
+		&credential.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("totp credential not found")
+		}
+		return nil, err
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return &credential, nil
+}
+
+// ConfirmTOTP marca la credencial TOTP como confirmada
+func (r *MFARepository) ConfirmTOTP(ctx context.Context, userID string, confirmedAt time.Time) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE user_totp_credentials SET confirmed_at = $2 WHERE user_id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID, confirmedAt)
+	return err
+}
+
+// UseTOTPStep registra el paso usado; falla si ya se usó ese paso o uno posterior
+func (r *MFARepository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	// El UPDATE condicional impide que dos peticiones concurrentes usen el mismo código
+	query := `UPDATE user_totp_credentials SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	result, err := r.db.ExecContext(ctx, query, parsedUserID, step)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("totp code already used")
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes reemplaza los códigos de recuperación del usuario
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.RecoveryCode) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, parsedUserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, query, code.ID, code.UserID, code.CodeHash, code.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marca como usado un código de recuperación; falla si no existe o ya se usó
+func (r *MFARepository) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE mfa_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, parsedUserID, codeHash, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("recovery code not found")
+	}
+
+	return nil
+}
+
+// CountRecoveryCodes cuenta los códigos de recuperación sin usar
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, errors.New("invalid user id")
+	}
+
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	err = r.db.QueryRowContext(ctx, query, parsedUserID).Scan(&count)
+	return count, err
+}
+
+// DeleteByUserID elimina la credencial TOTP y los códigos de recuperación del usuario
+func (r *MFARepository) DeleteByUserID(ctx context.Context, userID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp_credentials WHERE user_id = $1`, parsedUserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, parsedUserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
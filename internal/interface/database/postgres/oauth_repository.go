@@ -0,0 +1,344 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// oauthClientColumns lista las columnas en el orden que espera scanOAuthClient
+const oauthClientColumns = `id, client_id, COALESCE(secret_hash, ''), name, client_type, redirect_uris, allowed_scopes,
+	skip_consent, created_by, created_at, updated_at`
+
+// authorizationCodeColumns lista las columnas en el orden que espera scanAuthorizationCode
+const authorizationCodeColumns = `code_hash, client_id, user_id, redirect_uri, redirect_uri_provided, scope, COALESCE(code_challenge, ''),
+	COALESCE(code_challenge_method, ''), COALESCE(nonce, ''), auth_time, auth_methods, expires_at, used_at, family_id, created_at`
+
+// OAuthRepository implementa el repositorio del servidor de autorización OAuth para PostgreSQL
+type OAuthRepository struct {
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+}
+
+// NewOAuthRepository crea una nueva instancia de OAuthRepository
+func NewOAuthRepository(db *sql.DB) repositories.OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient guarda un nuevo cliente
+func (r *OAuthRepository) CreateClient(ctx context.Context, client *entities.OAuthClient) error {
+	query := `
+		INSERT INTO oauth_clients (id, client_id, secret_hash, name, client_type, redirect_uris, allowed_scopes,
+			skip_consent, created_by, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		client.ID,
+		client.ClientID,
+		client.SecretHash,
+		client.Name,
+		client.Type,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.AllowedScopes),
+		client.SkipConsent,
+		nullUUID(client.CreatedBy),
+		client.CreatedAt,
+		client.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetClient obtiene un cliente por su ID interno
+func (r *OAuthRepository) GetClient(ctx context.Context, id string) (*entities.OAuthClient, error) {
+	clientID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid client id")
+	}
+
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE id = $1`
+
+	return scanOAuthClient(r.db.QueryRowContext(ctx, query, clientID))
+}
+
+// GetClientByClientID obtiene un cliente por su client_id
+func (r *OAuthRepository) GetClientByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = $1`
+
+	return scanOAuthClient(r.db.QueryRowContext(ctx, query, clientID))
+}
+
+// ListClients obtiene todos los clientes, del más reciente al más antiguo
+func (r *OAuthRepository) ListClients(ctx context.Context) ([]*entities.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*entities.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+// UpdateClient actualiza un cliente existente
+func (r *OAuthRepository) UpdateClient(ctx context.Context, client *entities.OAuthClient) error {
+	query := `
+		UPDATE oauth_clients
+		SET secret_hash = NULLIF($2, ''), name = $3, redirect_uris = $4, allowed_scopes = $5, skip_consent = $6, updated_at = $7
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		client.ID,
+		client.SecretHash,
+		client.Name,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.AllowedScopes),
+		client.SkipConsent,
+		client.UpdatedAt,
+	)
+
+	return err
+}
+
+// DeleteClient elimina un cliente; sus códigos, consentimientos y refresh tokens se eliminan en
+// cascada y las sesiones de esos tokens se revocan en la misma transacción
+func (r *OAuthRepository) DeleteClient(ctx context.Context, id string) error {
+	clientID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid client id")
+	}
+
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE sessions SET is_revoked = true, revoked_at = $2
+			WHERE is_revoked = false AND family_id IN (
+				SELECT t.family_id FROM tokens t
+				JOIN oauth_clients c ON c.client_id = t.client_id
+				WHERE c.id = $1
+			)
+		`, clientID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM oauth_clients WHERE id = $1`, clientID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New("client not found")
+		}
+
+		return nil
+	})
+}
+
+// CreateAuthorizationCode guarda un nuevo código de autorización
+func (r *OAuthRepository) CreateAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error {
+	query := `
+		INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, redirect_uri_provided, scope,
+			code_challenge, code_challenge_method, nonce, auth_time, auth_methods, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		code.RedirectURIProvided,
+		code.Scope,
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.Nonce,
+		nullTime(code.AuthTime),
+		pq.Array(code.AuthMethods),
+		code.ExpiresAt,
+		code.CreatedAt,
+	)
+
+	return err
+}
+
+// GetAuthorizationCode obtiene un código por su hash
+func (r *OAuthRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*entities.AuthorizationCode, error) {
+	query := `SELECT ` + authorizationCodeColumns + ` FROM oauth_authorization_codes WHERE code_hash = $1`
+
+	return scanAuthorizationCode(r.db.QueryRowContext(ctx, query, codeHash))
+}
+
+// MarkAuthorizationCodeUsed marca el código como canjeado. Retorna ErrAuthorizationCodeUsed si
+// ya estaba canjeado, lo que permite detectar dos canjes concurrentes del mismo código.
+func (r *OAuthRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash, familyID string) error {
+	parsedFamilyID, err := uuid.Parse(familyID)
+	if err != nil {
+		return errors.New("invalid family id")
+	}
+
+	query := `
+		UPDATE oauth_authorization_codes SET used_at = $2, family_id = $3
+		WHERE code_hash = $1 AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, codeHash, time.Now(), parsedFamilyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repositories.ErrAuthorizationCodeUsed
+	}
+
+	return nil
+}
+
+// DeleteExpiredAuthorizationCodes elimina los códigos que expiraron antes de before
+func (r *OAuthRepository) DeleteExpiredAuthorizationCodes(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oauth_authorization_codes WHERE expires_at < $1`, before)
+	return err
+}
+
+// GetConsent obtiene el consentimiento de un usuario para un cliente
+func (r *OAuthRepository) GetConsent(ctx context.Context, userID, clientID string) (*entities.OAuthConsent, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT user_id, client_id, scopes, created_at, updated_at
+		FROM oauth_consents WHERE user_id = $1 AND client_id = $2
+	`
+
+	var consent entities.OAuthConsent
+	err = r.db.QueryRowContext(ctx, query, parsedUserID, clientID).Scan(
+		&consent.UserID,
+		&consent.ClientID,
+		pq.Array(&consent.Scopes),
+		&consent.CreatedAt,
+		&consent.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("consent not found")
+		}
+		return nil, err
+	}
+
+	return &consent, nil
+}
+
+// SaveConsent crea o reemplaza el consentimiento de un usuario para un cliente
+func (r *OAuthRepository) SaveConsent(ctx context.Context, consent *entities.OAuthConsent) error {
+	query := `
+		INSERT INTO oauth_consents (user_id, client_id, scopes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		consent.UserID,
+		consent.ClientID,
+		pq.Array(consent.Scopes),
+		consent.CreatedAt,
+		consent.UpdatedAt,
+	)
+
+	return err
+}
+
+// scanOAuthClient lee un cliente desde una fila
+func scanOAuthClient(row scanner) (*entities.OAuthClient, error) {
+	var client entities.OAuthClient
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.SecretHash,
+		&client.Name,
+		&client.Type,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.AllowedScopes),
+		&client.SkipConsent,
+		&createdBy,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("client not found")
+		}
+		return nil, err
+	}
+
+	client.CreatedBy = uuidPtr(createdBy)
+	return &client, nil
+}
+
+// scanAuthorizationCode lee un código de autorización desde una fila
+func scanAuthorizationCode(row scanner) (*entities.AuthorizationCode, error) {
+	var code entities.AuthorizationCode
+	var authTime, usedAt sql.NullTime
+	var familyID uuid.NullUUID
+
+	err := row.Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.RedirectURIProvided,
+		&code.Scope,
+		&code.CodeChallenge,
+		&code.CodeChallengeMethod,
+		&code.Nonce,
+		&authTime,
+		pq.Array(&code.AuthMethods),
+		&code.ExpiresAt,
+		&usedAt,
+		&familyID,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("authorization code not found")
+		}
+		return nil, err
+	}
+
+	if authTime.Valid {
+		code.AuthTime = &authTime.Time
+	}
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+	code.FamilyID = uuidPtr(familyID)
+	return &code, nil
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// OneTimeTokenRepository implementa el repositorio de tokens de un solo uso para PostgreSQL
+type OneTimeTokenRepository struct {
+	db *sql.DB
+}
+
+// NewOneTimeTokenRepository crea una nueva instancia de OneTimeTokenRepository
+func NewOneTimeTokenRepository(db *sql.DB) repositories.OneTimeTokenRepository {
+	return &OneTimeTokenRepository{db: db}
+}
+
+// Create crea un nuevo token de un solo uso
+func (r *OneTimeTokenRepository) Create(ctx context.Context, token *entities.OneTimeToken) error {
+	query := `
+		INSERT INTO one_time_tokens (id, user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	return err
+}
+
+// Consume marca como usado un token vigente y lo retorna; falla si no existe, expiró o ya se usó
+func (r *OneTimeTokenRepository) Consume(ctx context.Context, tokenHash string, purpose entities.TokenPurpose) (*entities.OneTimeToken, error) {
+	// El UPDATE condicional garantiza un único uso aunque lleguen peticiones concurrentes
+	query := `
+		UPDATE one_time_tokens SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+	`
+
+	var token entities.OneTimeToken
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash, purpose, time.Now()).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return &token, nil
+}
+
+// InvalidateByUserID marca como usados los tokens pendientes de un usuario para un propósito
+func (r *OneTimeTokenRepository) InvalidateByUserID(ctx context.Context, userID string, purpose entities.TokenPurpose) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE one_time_tokens SET used_at = $3 WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID, purpose, time.Now())
+	return err
+}
+
+// DeleteExpired elimina tokens expirados
+func (r *OneTimeTokenRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM one_time_tokens WHERE expires_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now())
+	return err
+}
@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// OrganizationRepository implementa el repositorio de organizaciones y membresías para PostgreSQL
+type OrganizationRepository struct {
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+}
+
+// NewOrganizationRepository crea una nueva instancia de OrganizationRepository
+func NewOrganizationRepository(db *sql.DB) repositories.OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// Create crea una organización y, si se indica, su primera membresía
+func (r *OrganizationRepository) Create(ctx context.Context, organization *entities.Organization, owner *entities.OrganizationMember) error {
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		query := `
+			INSERT INTO organizations (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (slug) DO NOTHING
+		`
+
+		result, err := tx.ExecContext(ctx, query,
+			organization.ID,
+			organization.Name,
+			organization.Slug,
+			organization.CreatedAt,
+			organization.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New("organization slug already exists")
+		}
+
+		if owner == nil {
+			return nil
+		}
+		return insertMember(ctx, tx, owner)
+	})
+}
+
+// GetByID obtiene una organización por su ID
+func (r *OrganizationRepository) GetByID(ctx context.Context, id string) (*entities.Organization, error) {
+	organizationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid organization id")
+	}
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM organizations WHERE id = $1`
+
+	var organization entities.Organization
+	err = r.db.QueryRowContext(ctx, query, organizationID).Scan(
+		&organization.ID,
+		&organization.Name,
+		&organization.Slug,
+		&organization.CreatedAt,
+		&organization.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("organization not found")
+		}
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// List obtiene una lista de organizaciones con paginación
+func (r *OrganizationRepository) List(ctx context.Context, offset, limit int) ([]*entities.Organization, error) {
+	query := `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations
+		ORDER BY name
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var organizations []*entities.Organization
+	for rows.Next() {
+		var organization entities.Organization
+		if err := rows.Scan(
+			&organization.ID,
+			&organization.Name,
+			&organization.Slug,
+			&organization.CreatedAt,
+			&organization.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, &organization)
+	}
+
+	return organizations, rows.Err()
+}
+
+// Count cuenta el total de organizaciones
+func (r *OrganizationRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM organizations`).Scan(&count)
+	return count, err
+}
+
+// Delete elimina una organización y sus membresías
+func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
+	organizationID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid organization id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, organizationID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("organization not found")
+	}
+
+	return nil
+}
+
+// AddMember agrega un usuario a una organización
+func (r *OrganizationRepository) AddMember(ctx context.Context, member *entities.OrganizationMember) error {
+	return insertMember(ctx, r.db, member)
+}
+
+// FindMember obtiene la membresía del usuario; retorna nil si no es miembro
+func (r *OrganizationRepository) FindMember(ctx context.Context, organizationID, userID string) (*entities.OrganizationMember, error) {
+	parsedOrganizationID, err := uuid.Parse(organizationID)
+	if err != nil {
+		return nil, errors.New("invalid organization id")
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT organization_id, user_id, role, created_at, updated_at
+		FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`
+
+	member, err := scanMember(r.db.QueryRowContext(ctx, query, parsedOrganizationID, parsedUserID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return member, err
+}
+
+// UpdateMemberRole cambia el rol del usuario dentro de la organización
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, organizationID, userID string, role entities.Role) error {
+	parsedOrganizationID, err := uuid.Parse(organizationID)
+	if err != nil {
+		return errors.New("invalid organization id")
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `
+		UPDATE organization_members
+		SET role = $3, updated_at = $4
+		WHERE organization_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, parsedOrganizationID, parsedUserID, role, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("membership not found")
+	}
+
+	return nil
+}
+
+// RemoveMember quita un usuario de una organización
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, organizationID, userID string) error {
+	parsedOrganizationID, err := uuid.Parse(organizationID)
+	if err != nil {
+		return errors.New("invalid organization id")
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, parsedOrganizationID, parsedUserID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("membership not found")
+	}
+
+	return nil
+}
+
+// ListMembers obtiene todas las membresías de una organización
+func (r *OrganizationRepository) ListMembers(ctx context.Context, organizationID string) ([]*entities.OrganizationMember, error) {
+	parsedOrganizationID, err := uuid.Parse(organizationID)
+	if err != nil {
+		return nil, errors.New("invalid organization id")
+	}
+
+	query := `
+		SELECT organization_id, user_id, role, created_at, updated_at
+		FROM organization_members
+		WHERE organization_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedOrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*entities.OrganizationMember
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// ListByUserID obtiene las membresías del usuario con los datos de cada organización
+func (r *OrganizationRepository) ListByUserID(ctx context.Context, userID string) ([]*entities.OrganizationMember, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, m.updated_at,
+			o.name, o.slug, o.created_at, o.updated_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1
+		ORDER BY o.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*entities.OrganizationMember
+	for rows.Next() {
+		var member entities.OrganizationMember
+		var organization entities.Organization
+		if err := rows.Scan(
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Role,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+			&organization.Name,
+			&organization.Slug,
+			&organization.CreatedAt,
+			&organization.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		organization.ID = member.OrganizationID
+		member.Organization = &organization
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+// execer ejecuta sentencias sobre la conexión o dentro de una transacción
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertMember inserta una membresía
+func insertMember(ctx context.Context, db execer, member *entities.OrganizationMember) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+	`
+
+	result, err := db.ExecContext(ctx, query,
+		member.OrganizationID,
+		member.UserID,
+		member.Role,
+		member.CreatedAt,
+		member.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("user is already a member of the organization")
+	}
+
+	return nil
+}
+
+// scanMember lee una membresía desde una fila
+func scanMember(row scanner) (*entities.OrganizationMember, error) {
+	var member entities.OrganizationMember
+
+	err := row.Scan(
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// outboxColumns lista las columnas en el orden que espera scanOutboxEvent
+const outboxColumns = `id, event_type, aggregate_id, occurred_at, payload, attempts, next_attempt_at, COALESCE(last_error, ''), published_at`
+
+// OutboxRepository implementa el outbox de eventos de dominio para PostgreSQL
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository crea una nueva instancia de OutboxRepository
+func NewOutboxRepository(db *sql.DB) repositories.OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// insertOutboxEvents agrega los eventos al outbox; se llama dentro de la transacción del cambio que los produce
+func insertOutboxEvents(ctx context.Context, db execer, events []*entities.DomainEvent) error {
+	query := `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, occurred_at, payload, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $4)
+	`
+
+	for _, event := range events {
+		_, err := db.ExecContext(ctx, query,
+			event.ID,
+			event.Type,
+			event.AggregateID,
+			event.OccurredAt,
+			string(event.Payload),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Claim reserva eventos pendientes. SKIP LOCKED evita que dos réplicas reserven el mismo evento
+// y el nuevo next_attempt_at lo oculta hasta leaseUntil; si la réplica cae antes de marcarlo,
+// otra lo vuelve a publicar al vencer la reserva.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.OutboxEvent, error) {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= $2
+			ORDER BY occurred_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	rows, err := r.db.QueryContext(ctx, query, leaseUntil.UTC(), time.Now().UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*entities.OutboxEvent
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING no garantiza orden
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	return events, nil
+}
+
+// MarkPublished marca el evento como publicado
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id string) error {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid event id")
+	}
+
+	query := `UPDATE outbox_events SET published_at = $2, last_error = NULL WHERE id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, eventID, time.Now().UTC())
+	return err
+}
+
+// MarkFailed guarda el error del intento y programa el siguiente
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid event id")
+	}
+
+	query := `UPDATE outbox_events SET last_error = $2, next_attempt_at = $3 WHERE id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, eventID, lastError, nextAttemptAt.UTC())
+	return err
+}
+
+// DeletePublished elimina los eventos publicados antes de la fecha indicada
+func (r *OutboxRepository) DeletePublished(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, before.UTC())
+	return err
+}
+
+// scanOutboxEvent lee un evento del outbox desde una fila
+func scanOutboxEvent(row scanner) (*entities.OutboxEvent, error) {
+	var event entities.OutboxEvent
+	var payload []byte
+	var publishedAt sql.NullTime
+
+	err := row.Scan(
+		&event.ID,
+		&event.Type,
+		&event.AggregateID,
+		&event.OccurredAt,
+		&payload,
+		&event.Attempts,
+		&event.NextAttemptAt,
+		&event.LastError,
+		&publishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	event.OccurredAt = event.OccurredAt.UTC()
+	event.Payload = payload
+	if publishedAt.Valid {
+		event.PublishedAt = &publishedAt.Time
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// PasswordHistoryRepository implementa el repositorio del historial de contraseñas para PostgreSQL
+type PasswordHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewPasswordHistoryRepository crea una nueva instancia de PasswordHistoryRepository
+func NewPasswordHistoryRepository(db *sql.DB) repositories.PasswordHistoryRepository {
+	return &PasswordHistoryRepository{db: db}
+}
+
+// Add agrega una entrada y conserva solo las keep más recientes del usuario
+func (r *PasswordHistoryRepository) Add(ctx context.Context, entry *entities.PasswordHistory, keep int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO password_history (id, user_id, password_hash, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, entry.ID, entry.UserID, entry.PasswordHash, entry.CreatedAt); err != nil {
+		return err
+	}
+
+	pruneQuery := `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1
+			ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	if _, err := tx.ExecContext(ctx, pruneQuery, entry.UserID, keep); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListRecent obtiene las limit entradas más recientes del usuario
+func (r *PasswordHistoryRepository) ListRecent(ctx context.Context, userID string, limit int) ([]*entities.PasswordHistory, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT id, user_id, password_hash, created_at
+		FROM password_history WHERE user_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*entities.PasswordHistory
+	for rows.Next() {
+		var entry entities.PasswordHistory
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.PasswordHash, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// RevocationRepository implementa la lista de access tokens revocados para PostgreSQL
+type RevocationRepository struct {
+	db *sql.DB
+}
+
+// NewRevocationRepository crea una nueva instancia de RevocationRepository
+func NewRevocationRepository(db *sql.DB) repositories.RevocationRepository {
+	return &RevocationRepository{db: db}
+}
+
+// RevokeAccessToken agrega un jti a la lista de revocados hasta su expiración; userID es vacío
+// en los tokens de cuentas de servicio
+func (r *RevocationRepository) RevokeAccessToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	var parsedUserID *uuid.UUID
+	if userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return errors.New("invalid user id")
+		}
+		parsedUserID = &id
+	}
+
+	query := `
+		INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, jti, nullUUID(parsedUserID), expiresAt, time.Now())
+	return err
+}
+
+// IsAccessTokenRevoked verifica si un jti está revocado
+func (r *RevocationRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&exists)
+
+	return exists, err
+}
+
+// SetUserWatermark invalida los access tokens del usuario emitidos antes de notBefore
+func (r *RevocationRepository) SetUserWatermark(ctx context.Context, userID string, notBefore time.Time) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `
+		INSERT INTO token_watermarks (user_id, not_before, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET not_before = GREATEST(token_watermarks.not_before, EXCLUDED.not_before),
+		    updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID, notBefore, time.Now())
+	return err
+}
+
+// GetUserWatermark obtiene la marca de agua del usuario (cero si no tiene)
+func (r *RevocationRepository) GetUserWatermark(ctx context.Context, userID string) (time.Time, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return time.Time{}, errors.New("invalid user id")
+	}
+
+	query := `SELECT not_before FROM token_watermarks WHERE user_id = $1`
+
+	var notBefore time.Time
+	err = r.db.QueryRowContext(ctx, query, parsedUserID).Scan(&notBefore)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+
+	return notBefore, err
+}
+
+// DeleteExpired elimina los jti revocados que ya expiraron
+func (r *RevocationRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM revoked_access_tokens WHERE expires_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now())
+	return err
+}
@@ -0,0 +1,331 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// RoleRepository implementa el repositorio de roles y permisos para PostgreSQL
+type RoleRepository struct {
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+}
+
+// NewRoleRepository crea una nueva instancia de RoleRepository
+func NewRoleRepository(db *sql.DB) repositories.RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// roleSelect selecciona los roles con sus permisos separados por comas
+const roleSelect = `
+	SELECT r.id, r.name, r.description, r.is_system, r.created_at, r.updated_at,
+		COALESCE(string_agg(rp.permission, ',' ORDER BY rp.permission), '')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+`
+
+// Create crea un rol con sus permisos
+func (r *RoleRepository) Create(ctx context.Context, role *entities.RoleDefinition) error {
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		query := `
+			INSERT INTO roles (id, name, description, is_system, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (name) DO NOTHING
+		`
+
+		result, err := tx.ExecContext(ctx, query,
+			role.ID,
+			role.Name,
+			role.Description,
+			role.IsSystem,
+			role.CreatedAt,
+			role.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New("role already exists")
+		}
+
+		return insertPermissions(ctx, tx, role.ID, role.Permissions)
+	})
+}
+
+// GetByID obtiene un rol por su ID
+func (r *RoleRepository) GetByID(ctx context.Context, id string) (*entities.RoleDefinition, error) {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid role id")
+	}
+
+	return r.getOne(ctx, roleSelect+` WHERE r.id = $1 GROUP BY r.id`, roleID)
+}
+
+// GetByName obtiene un rol por su nombre
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*entities.RoleDefinition, error) {
+	return r.getOne(ctx, roleSelect+` WHERE r.name = $1 GROUP BY r.id`, name)
+}
+
+// List obtiene todos los roles
+func (r *RoleRepository) List(ctx context.Context) ([]*entities.RoleDefinition, error) {
+	return r.query(ctx, roleSelect+` GROUP BY r.id ORDER BY r.name`)
+}
+
+// SetPermissions reemplaza los permisos de un rol
+func (r *RoleRepository) SetPermissions(ctx context.Context, roleID string, permissions []entities.Permission) error {
+	parsedRoleID, err := uuid.Parse(roleID)
+	if err != nil {
+		return errors.New("invalid role id")
+	}
+
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE roles SET updated_at = $2 WHERE id = $1`, parsedRoleID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New("role not found")
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, parsedRoleID); err != nil {
+			return err
+		}
+
+		return insertPermissions(ctx, tx, parsedRoleID, permissions)
+	})
+}
+
+// Delete elimina un rol que no es de sistema ni es el rol principal de ningún usuario
+func (r *RoleRepository) Delete(ctx context.Context, id string) error {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid role id")
+	}
+
+	query := `
+		DELETE FROM roles
+		WHERE id = $1 AND is_system = false
+			AND NOT EXISTS (SELECT 1 FROM users WHERE users.role = roles.name)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, roleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("role not found, is a system role or is the primary role of a user")
+	}
+
+	return nil
+}
+
+// AssignToUser asigna un rol adicional a un usuario
+func (r *RoleRepository) AssignToUser(ctx context.Context, userID, roleID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+	parsedRoleID, err := uuid.Parse(roleID)
+	if err != nil {
+		return errors.New("invalid role id")
+	}
+
+	query := `
+		INSERT INTO user_roles (user_id, role_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID, parsedRoleID, time.Now())
+	return err
+}
+
+// RemoveFromUser quita un rol adicional de un usuario
+func (r *RoleRepository) RemoveFromUser(ctx context.Context, userID, roleID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+	parsedRoleID, err := uuid.Parse(roleID)
+	if err != nil {
+		return errors.New("invalid role id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, parsedUserID, parsedRoleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("role not assigned to user")
+	}
+
+	return nil
+}
+
+// ListByUserID obtiene los roles efectivos del usuario: el principal y los asignados
+func (r *RoleRepository) ListByUserID(ctx context.Context, userID string) ([]*entities.RoleDefinition, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := roleSelect + `
+		WHERE r.name = (SELECT role FROM users WHERE id = $1)
+			OR r.id IN (SELECT role_id FROM user_roles WHERE user_id = $1)
+		GROUP BY r.id ORDER BY r.name
+	`
+
+	return r.query(ctx, query, parsedUserID)
+}
+
+// ListUserIDs obtiene los IDs de los usuarios que tienen el rol como principal o asignado
+func (r *RoleRepository) ListUserIDs(ctx context.Context, roleID string) ([]string, error) {
+	parsedRoleID, err := uuid.Parse(roleID)
+	if err != nil {
+		return nil, errors.New("invalid role id")
+	}
+
+	query := `
+		SELECT u.id FROM users u JOIN roles r ON r.name = u.role WHERE r.id = $1
+		UNION
+		SELECT user_id FROM user_roles WHERE role_id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedRoleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID.String())
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ListByNames obtiene los roles con los nombres indicados; los inexistentes se ignoran
+func (r *RoleRepository) ListByNames(ctx context.Context, names []string) ([]*entities.RoleDefinition, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = name
+	}
+
+	query := roleSelect + ` WHERE r.name IN (` + strings.Join(placeholders, ", ") + `) GROUP BY r.id ORDER BY r.name`
+
+	return r.query(ctx, query, args...)
+}
+
+// getOne obtiene un único rol
+func (r *RoleRepository) getOne(ctx context.Context, query string, args ...interface{}) (*entities.RoleDefinition, error) {
+	role, err := scanRole(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// query obtiene una lista de roles
+func (r *RoleRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.RoleDefinition, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*entities.RoleDefinition
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// insertPermissions inserta los permisos de un rol dentro de una transacción
+func insertPermissions(ctx context.Context, tx execer, roleID uuid.UUID, permissions []entities.Permission) error {
+	query := `INSERT INTO role_permissions (role_id, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for _, permission := range permissions {
+		if _, err := tx.ExecContext(ctx, query, roleID, permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanRole lee un rol desde una fila
+func scanRole(row scanner) (*entities.RoleDefinition, error) {
+	var role entities.RoleDefinition
+	var permissions string
+
+	err := row.Scan(
+		&role.ID,
+		&role.Name,
+		&role.Description,
+		&role.IsSystem,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+		&permissions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions = []entities.Permission{}
+	if permissions != "" {
+		for _, permission := range strings.Split(permissions, ",") {
+			role.Permissions = append(role.Permissions, entities.Permission(permission))
+		}
+	}
+
+	return &role, nil
+}
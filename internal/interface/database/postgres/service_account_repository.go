@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// serviceAccountColumns lista las columnas en el orden que espera scanServiceAccount
+const serviceAccountColumns = `id, client_id, name, COALESCE(description, ''), auth_method, COALESCE(secret_hash, ''),
+	COALESCE(public_key, ''), allowed_scopes, is_active, created_by, last_used_at, secret_rotated_at, created_at, updated_at`
+
+// ServiceAccountRepository implementa el repositorio de cuentas de servicio para PostgreSQL
+type ServiceAccountRepository struct {
+	db *sql.DB
+}
+
+// NewServiceAccountRepository crea una nueva instancia de ServiceAccountRepository
+func NewServiceAccountRepository(db *sql.DB) repositories.ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// Create guarda una nueva cuenta de servicio
+func (r *ServiceAccountRepository) Create(ctx context.Context, account *entities.ServiceAccount) error {
+	query := `
+		INSERT INTO service_accounts (id, client_id, name, description, auth_method, secret_hash, public_key,
+			allowed_scopes, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		account.ID,
+		account.ClientID,
+		account.Name,
+		account.Description,
+		account.AuthMethod,
+		account.SecretHash,
+		account.PublicKey,
+		pq.Array(account.AllowedScopes),
+		account.IsActive,
+		nullUUID(account.CreatedBy),
+		account.CreatedAt,
+		account.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID obtiene una cuenta por su ID interno
+func (r *ServiceAccountRepository) GetByID(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid service account id")
+	}
+
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE id = $1`
+
+	return scanServiceAccount(r.db.QueryRowContext(ctx, query, accountID))
+}
+
+// GetByClientID obtiene una cuenta por su client_id
+func (r *ServiceAccountRepository) GetByClientID(ctx context.Context, clientID string) (*entities.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE client_id = $1`
+
+	return scanServiceAccount(r.db.QueryRowContext(ctx, query, clientID))
+}
+
+// List obtiene todas las cuentas, de la más reciente a la más antigua
+func (r *ServiceAccountRepository) List(ctx context.Context) ([]*entities.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*entities.ServiceAccount
+	for rows.Next() {
+		account, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// Update actualiza una cuenta existente
+func (r *ServiceAccountRepository) Update(ctx context.Context, account *entities.ServiceAccount) error {
+	query := `
+		UPDATE service_accounts
+		SET name = $2, description = NULLIF($3, ''), secret_hash = NULLIF($4, ''), public_key = NULLIF($5, ''),
+			allowed_scopes = $6, is_active = $7, secret_rotated_at = $8, updated_at = $9
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		account.ID,
+		account.Name,
+		account.Description,
+		account.SecretHash,
+		account.PublicKey,
+		pq.Array(account.AllowedScopes),
+		account.IsActive,
+		nullTime(account.SecretRotatedAt),
+		account.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("service account not found")
+	}
+
+	return nil
+}
+
+// Delete elimina una cuenta
+func (r *ServiceAccountRepository) Delete(ctx context.Context, id string) error {
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid service account id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM service_accounts WHERE id = $1`, accountID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("service account not found")
+	}
+
+	return nil
+}
+
+// UpdateLastUsed registra el último token emitido a la cuenta
+func (r *ServiceAccountRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid service account id")
+	}
+
+	_, err = r.db.ExecContext(ctx, `UPDATE service_accounts SET last_used_at = $2 WHERE id = $1`, accountID, usedAt)
+	return err
+}
+
+// UseAssertion registra el jti de un client assertion hasta su expiración; la clave primaria
+// hace que dos usos concurrentes del mismo jti fallen en uno de ellos
+func (r *ServiceAccountRepository) UseAssertion(ctx context.Context, clientID, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO service_account_assertions (client_id, jti, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, clientID, jti, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("client assertion already used")
+	}
+
+	return nil
+}
+
+// DeleteExpiredAssertions elimina los jti de assertions que ya expiraron
+func (r *ServiceAccountRepository) DeleteExpiredAssertions(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM service_account_assertions WHERE expires_at < $1`, time.Now())
+	return err
+}
+
+// scanServiceAccount lee una cuenta de servicio desde una fila
+func scanServiceAccount(row scanner) (*entities.ServiceAccount, error) {
+	var account entities.ServiceAccount
+	var createdBy uuid.NullUUID
+	var lastUsedAt, secretRotatedAt sql.NullTime
+
+	err := row.Scan(
+		&account.ID,
+		&account.ClientID,
+		&account.Name,
+		&account.Description,
+		&account.AuthMethod,
+		&account.SecretHash,
+		&account.PublicKey,
+		pq.Array(&account.AllowedScopes),
+		&account.IsActive,
+		&createdBy,
+		&lastUsedAt,
+		&secretRotatedAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("service account not found")
+		}
+		return nil, err
+	}
+
+	account.CreatedBy = uuidPtr(createdBy)
+	if lastUsedAt.Valid {
+		account.LastUsedAt = &lastUsedAt.Time
+	}
+	if secretRotatedAt.Valid {
+		account.SecretRotatedAt = &secretRotatedAt.Time
+	}
+	return &account, nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// SessionRepository implementa el repositorio de sesiones para PostgreSQL
+type SessionRepository struct {
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+}
+
+// NewSessionRepository crea una nueva instancia de SessionRepository
+func NewSessionRepository(db *sql.DB) repositories.SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// sessionColumns lista las columnas en el orden que espera scanSession
+const sessionColumns = `id, user_id, family_id, device_name, user_agent, ip_address, is_revoked, created_at, last_used_at, revoked_at`
+
+// Create crea una nueva sesión
+func (r *SessionRepository) Create(ctx context.Context, session *entities.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, family_id, device_name, user_agent, ip_address, is_revoked, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.FamilyID,
+		session.DeviceName,
+		session.UserAgent,
+		session.IPAddress,
+		session.IsRevoked,
+		session.CreatedAt,
+		session.LastUsedAt,
+	)
+
+	return err
+}
+
+// GetByID obtiene una sesión por su ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*entities.Session, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid session id")
+	}
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	return scanSession(r.db.QueryRowContext(ctx, query, sessionID))
+}
+
+// GetByFamilyID obtiene la sesión ligada a una familia de refresh tokens
+func (r *SessionRepository) GetByFamilyID(ctx context.Context, familyID string) (*entities.Session, error) {
+	parsedFamilyID, err := uuid.Parse(familyID)
+	if err != nil {
+		return nil, errors.New("invalid family id")
+	}
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE family_id = $1`
+
+	return scanSession(r.db.QueryRowContext(ctx, query, parsedFamilyID))
+}
+
+// ListActiveByUserID obtiene las sesiones no revocadas de un usuario
+func (r *SessionRepository) ListActiveByUserID(ctx context.Context, userID string) ([]*entities.Session, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = $1 AND is_revoked = false
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*entities.Session{}
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch actualiza la última actividad, el user agent y la IP de una sesión
+func (r *SessionRepository) Touch(ctx context.Context, session *entities.Session) error {
+	query := `UPDATE sessions SET user_agent = $2, ip_address = $3, last_used_at = $4 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserAgent,
+		session.IPAddress,
+		session.LastUsedAt,
+	)
+
+	return err
+}
+
+// Revoke marca una sesión como revocada
+func (r *SessionRepository) Revoke(ctx context.Context, id string) error {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid session id")
+	}
+
+	query := `UPDATE sessions SET is_revoked = true, revoked_at = $2 WHERE id = $1 AND is_revoked = false`
+
+	result, err := r.db.ExecContext(ctx, query, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("session not found")
+	}
+
+	return nil
+}
+
+// RevokeByFamilyID revoca la sesión ligada a una familia de refresh tokens
+func (r *SessionRepository) RevokeByFamilyID(ctx context.Context, familyID string) error {
+	parsedFamilyID, err := uuid.Parse(familyID)
+	if err != nil {
+		return errors.New("invalid family id")
+	}
+
+	query := `UPDATE sessions SET is_revoked = true, revoked_at = $2 WHERE family_id = $1 AND is_revoked = false`
+
+	_, err = r.db.ExecContext(ctx, query, parsedFamilyID, time.Now())
+	return err
+}
+
+// RevokeByUserID revoca todas las sesiones de un usuario
+func (r *SessionRepository) RevokeByUserID(ctx context.Context, userID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE sessions SET is_revoked = true, revoked_at = $2 WHERE user_id = $1 AND is_revoked = false`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID, time.Now())
+	return err
+}
+
+// scanner abstrae *sql.Row y *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSession lee una sesión desde una fila
+func scanSession(row scanner) (*entities.Session, error) {
+	var session entities.Session
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.FamilyID,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.IsRevoked,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&revokedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return &session, nil
+}
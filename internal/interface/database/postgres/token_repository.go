@@ -15,7 +15,7 @@ import (
 
 // TokenRepository implementa el repositorio de tokens para PostgreSQL
 type TokenRepository struct {
-	db *sql.DB
+	db dbtx // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
 }
 
 // NewTokenRepository crea una nueva instancia de TokenRepository
@@ -26,15 +26,18 @@ func NewTokenRepository(db *sql.DB) repositories.TokenRepository {
 // Create crea un nuevo token
 func (r *TokenRepository) Create(ctx context.Context, token *entities.Token) error {
 	query := `
-		INSERT INTO tokens (id, user_id, token, token_type, is_revoked, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO tokens (id, user_id, family_id, token, token_type, client_id, scope, is_revoked, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		token.ID,
 		token.UserID,
+		token.FamilyID,
 		token.Token,
 		token.TokenType,
+		token.ClientID,
+		token.Scope,
 		token.IsRevoked,
 		token.ExpiresAt,
 		token.CreatedAt,
@@ -46,7 +49,7 @@ func (r *TokenRepository) Create(ctx context.Context, token *entities.Token) err
 // GetByToken obtiene un token por su valor
 func (r *TokenRepository) GetByToken(ctx context.Context, token string) (*entities.Token, error) {
 	query := `
-		SELECT id, user_id, token, token_type, is_revoked, expires_at, created_at
+		SELECT id, user_id, family_id, token, token_type, COALESCE(client_id, ''), COALESCE(scope, ''), is_revoked, replaced_by, expires_at, created_at
 		FROM tokens WHERE token = $1
 	`
 
@@ -55,9 +58,13 @@ func (r *TokenRepository) GetByToken(ctx context.Context, token string) (*entiti
 	err := r.db.QueryRowContext(ctx, query, token).Scan(
 		&tokenEntity.ID,
 		&tokenEntity.UserID,
+		&tokenEntity.FamilyID,
 		&tokenEntity.Token,
 		&tokenEntity.TokenType,
+		&tokenEntity.ClientID,
+		&tokenEntity.Scope,
 		&tokenEntity.IsRevoked,
+		&tokenEntity.ReplacedBy,
 		&tokenEntity.ExpiresAt,
 		&tokenEntity.CreatedAt,
 	)
@@ -80,7 +87,7 @@ func (r *TokenRepository) GetByUserID(ctx context.Context, userID string) ([]*en
 	}
 
 	query := `
-		SELECT id, user_id, token, token_type, is_revoked, expires_at, created_at
+		SELECT id, user_id, family_id, token, token_type, COALESCE(client_id, ''), COALESCE(scope, ''), is_revoked, replaced_by, expires_at, created_at
 		FROM tokens WHERE user_id = $1
 	`
 
@@ -98,9 +105,13 @@ func (r *TokenRepository) GetByUserID(ctx context.Context, userID string) ([]*en
 		err := rows.Scan(
 			&token.ID,
 			&token.UserID,
+			&token.FamilyID,
 			&token.Token,
 			&token.TokenType,
+			&token.ClientID,
+			&token.Scope,
 			&token.IsRevoked,
+			&token.ReplacedBy,
 			&token.ExpiresAt,
 			&token.CreatedAt,
 		)
@@ -132,9 +143,10 @@ func (r *TokenRepository) RevokeByUserID(ctx context.Context, userID string) err
 	return err
 }
 
-// RevokeToken revoca un token específico
+// RevokeToken revoca un token específico. Falla si el token ya estaba revocado,
+// lo que permite detectar dos rotaciones concurrentes del mismo refresh token.
 func (r *TokenRepository) RevokeToken(ctx context.Context, token string) error {
-	query := `UPDATE tokens SET is_revoked = true WHERE token = $1`
+	query := `UPDATE tokens SET is_revoked = true WHERE token = $1 AND is_revoked = false`
 
 	result, err := r.db.ExecContext(ctx, query, token)
 	if err != nil {
@@ -147,12 +159,52 @@ func (r *TokenRepository) RevokeToken(ctx context.Context, token string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("token not found")
+		return repositories.ErrTokenNotRevocable
 	}
 
 	return nil
 }
 
+// RotateToken revoca un token que todavía no esté revocado y registra el token que lo reemplaza.
+// Falla igual que RevokeToken si otra petición lo revocó primero.
+func (r *TokenRepository) RotateToken(ctx context.Context, token, replacedByID string) error {
+	parsedReplacedByID, err := uuid.Parse(replacedByID)
+	if err != nil {
+		return errors.New("invalid token id")
+	}
+
+	query := `UPDATE tokens SET is_revoked = true, replaced_by = $2 WHERE token = $1 AND is_revoked = false`
+
+	result, err := r.db.ExecContext(ctx, query, token, parsedReplacedByID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repositories.ErrTokenNotRevocable
+	}
+
+	return nil
+}
+
+// RevokeFamily revoca todos los tokens de una familia
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	parsedFamilyID, err := uuid.Parse(familyID)
+	if err != nil {
+		return errors.New("invalid family id")
+	}
+
+	query := `UPDATE tokens SET is_revoked = true WHERE family_id = $1 AND is_revoked = false`
+
+	_, err = r.db.ExecContext(ctx, query, parsedFamilyID)
+	return err
+}
+
 // DeleteExpired elimina tokens expirados
 func (r *TokenRepository) DeleteExpired(ctx context.Context) error {
 	query := `DELETE FROM tokens WHERE expires_at < $1`
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// dbtx ejecuta sentencias sobre la conexión o dentro de una transacción; los repositorios que
+// participan de una unidad de trabajo lo usan en lugar de *sql.DB
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork implementa la unidad de trabajo sobre una transacción de PostgreSQL
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork crea una nueva instancia de UnitOfWork
+func NewUnitOfWork(db *sql.DB) repositories.UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do ejecuta fn en una transacción con los repositorios ligados a ella
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos *repositories.TxRepositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Revierte si fn falla o entra en pánico; después de Commit no tiene efecto
+	defer tx.Rollback()
+
+	hooks := &commitHooks{}
+	repos := &repositories.TxRepositories{
+		Users:         &UserRepository{db: tx, hooks: hooks},
+		Tokens:        &TokenRepository{db: tx},
+		Sessions:      &SessionRepository{db: tx},
+		OAuth:         &OAuthRepository{db: tx},
+		Invitations:   &InvitationRepository{db: tx},
+		Roles:         &RoleRepository{db: tx},
+		Organizations: &OrganizationRepository{db: tx},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	hooks.run()
+	return nil
+}
+
+// commitHooks registra los eventos de dominio guardados en la transacción de una unidad de
+// trabajo y las acciones que se ejecutan solo si esa transacción se confirma
+type commitHooks struct {
+	events  map[uuid.UUID]bool
+	actions []func()
+}
+
+// unsaved filtra los eventos que todavía no se guardaron en la transacción; sin unidad de
+// trabajo retorna todos
+func (h *commitHooks) unsaved(events []*entities.DomainEvent) []*entities.DomainEvent {
+	if h == nil {
+		return events
+	}
+
+	pending := make([]*entities.DomainEvent, 0, len(events))
+	for _, event := range events {
+		if !h.events[event.ID] {
+			pending = append(pending, event)
+		}
+	}
+	return pending
+}
+
+// saved registra los eventos guardados en la transacción y la acción a ejecutar al confirmarla
+func (h *commitHooks) saved(events []*entities.DomainEvent, onCommit func()) {
+	if h.events == nil {
+		h.events = map[uuid.UUID]bool{}
+	}
+	for _, event := range events {
+		h.events[event.ID] = true
+	}
+	h.actions = append(h.actions, onCommit)
+}
+
+// run ejecuta las acciones registradas después de confirmar la transacción
+func (h *commitHooks) run() {
+	for _, action := range h.actions {
+		action()
+	}
+}
+
+// inTx ejecuta fn en una transacción nueva o, si el repositorio ya está ligado a una, dentro de esa
+func inTx(ctx context.Context, db dbtx, fn func(tx dbtx) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"auth-go-microservicio/internal/domain/entities"
 	"auth-go-microservicio/internal/domain/repositories"
@@ -12,9 +15,13 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// userColumns lista las columnas en el orden que espera scanUser
+const userColumns = `id, email, password, first_name, last_name, role, is_active, last_login_at, created_at, updated_at, email_verified_at, failed_login_attempts, locked_until`
+
 // UserRepository implementa el repositorio de usuarios para PostgreSQL
 type UserRepository struct {
-	db *sql.DB
+	db    dbtx         // *sql.DB o, dentro de una unidad de trabajo, *sql.Tx
+	hooks *commitHooks // acciones posteriores a la confirmación de la unidad de trabajo; nil fuera de ella
 }
 
 // NewUserRepository crea una nueva instancia de UserRepository
@@ -25,23 +32,25 @@ func NewUserRepository(db *sql.DB) repositories.UserRepository {
 // Create crea un nuevo usuario
 func (r *UserRepository) Create(ctx context.Context, user *entities.User) error {
 	query := `
-		INSERT INTO users (id, email, password, first_name, last_name, role, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO users (id, email, password, first_name, last_name, role, is_active, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		user.ID,
-		user.Email,
-		user.Password,
-		user.FirstName,
-		user.LastName,
-		user.Role,
-		user.IsActive,
-		user.CreatedAt,
-		user.UpdatedAt,
-	)
-
-	return err
+	return r.saveWithEvents(ctx, user, func(db execer) error {
+		_, err := db.ExecContext(ctx, query,
+			user.ID,
+			user.Email,
+			user.Password,
+			user.FirstName,
+			user.LastName,
+			user.Role,
+			user.IsActive,
+			nullTime(user.EmailVerifiedAt),
+			user.CreatedAt,
+			user.UpdatedAt,
+		)
+		return err
+	})
 }
 
 // GetByID obtiene un usuario por su ID
@@ -51,144 +60,110 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*entities.User
 		return nil, errors.New("invalid user id")
 	}
 
-	query := `
-		SELECT id, email, password, first_name, last_name, role, is_active, last_login_at, created_at, updated_at
-		FROM users WHERE id = $1
-	`
-
-	var user entities.User
-	var lastLoginAt sql.NullTime
-
-	err = r.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FirstName,
-		&user.LastName,
-		&user.Role,
-		&user.IsActive,
-		&lastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	if lastLoginAt.Valid {
-		user.LastLoginAt = &lastLoginAt.Time
-	}
-
-	return &user, nil
+	return scanUser(r.db.QueryRowContext(ctx, query, userID))
 }
 
 // GetByEmail obtiene un usuario por su email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
-	query := `
-		SELECT id, email, password, first_name, last_name, role, is_active, last_login_at, created_at, updated_at
-		FROM users WHERE email = $1
-	`
-
-	var user entities.User
-	var lastLoginAt sql.NullTime
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FirstName,
-		&user.LastName,
-		&user.Role,
-		&user.IsActive,
-		&lastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	if lastLoginAt.Valid {
-		user.LastLoginAt = &lastLoginAt.Time
-	}
-
-	return &user, nil
+	return scanUser(r.db.QueryRowContext(ctx, query, email))
 }
 
-// Update actualiza un usuario existente
+// Update actualiza un usuario existente. Los intentos fallidos y el bloqueo solo se
+// modifican con RecordFailedLogin, LockUntil y ResetFailedLogins para no pisar
+// incrementos concurrentes.
 func (r *UserRepository) Update(ctx context.Context, user *entities.User) error {
 	query := `
 		UPDATE users 
 		SET email = $2, password = $3, first_name = $4, last_name = $5, role = $6, 
-		    is_active = $7, last_login_at = $8, updated_at = $9
+		    is_active = $7, last_login_at = $8, updated_at = $9, email_verified_at = $10
 		WHERE id = $1
 	`
 
-	var lastLoginAt sql.NullTime
-	if user.LastLoginAt != nil {
-		lastLoginAt.Time = *user.LastLoginAt
-		lastLoginAt.Valid = true
-	}
-
-	_, err := r.db.ExecContext(ctx, query,
-		user.ID,
-		user.Email,
-		user.Password,
-		user.FirstName,
-		user.LastName,
-		user.Role,
-		user.IsActive,
-		lastLoginAt,
-		user.UpdatedAt,
-	)
-
-	return err
+	return r.saveWithEvents(ctx, user, func(db execer) error {
+		_, err := db.ExecContext(ctx, query,
+			user.ID,
+			user.Email,
+			user.Password,
+			user.FirstName,
+			user.LastName,
+			user.Role,
+			user.IsActive,
+			nullTime(user.LastLoginAt),
+			user.UpdatedAt,
+			nullTime(user.EmailVerifiedAt),
+		)
+		return err
+	})
 }
 
-// Delete elimina un usuario por su ID
-func (r *UserRepository) Delete(ctx context.Context, id string) error {
-	userID, err := uuid.Parse(id)
-	if err != nil {
-		return errors.New("invalid user id")
-	}
-
+// Delete elimina el usuario
+func (r *UserRepository) Delete(ctx context.Context, user *entities.User) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, userID)
-	if err != nil {
-		return err
+	return r.saveWithEvents(ctx, user, func(db execer) error {
+		result, err := db.ExecContext(ctx, query, user.ID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		return nil
+	})
+}
+
+// saveWithEvents ejecuta la escritura del usuario y guarda sus eventos pendientes en el outbox
+// en la misma transacción (la de la unidad de trabajo si el repositorio está ligado a una); los
+// eventos se descartan del usuario solo cuando esa transacción se confirma
+func (r *UserRepository) saveWithEvents(ctx context.Context, user *entities.User, write func(db execer) error) error {
+	events := r.hooks.unsaved(user.PendingEvents())
+	if len(events) == 0 {
+		return write(r.db)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	err := inTx(ctx, r.db, func(tx dbtx) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, tx, events)
+	})
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("user not found")
+	// Si la unidad de trabajo se revierte los eventos siguen pendientes y se guardan con la próxima escritura
+	if r.hooks != nil {
+		r.hooks.saved(events, func() { user.ClearEvents(events) })
+		return nil
 	}
-
+	user.ClearEvents(events)
 	return nil
 }
 
-// List obtiene una lista de usuarios con paginación
-func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, error) {
+// List obtiene una lista de usuarios con paginación, opcionalmente de una organización
+func (r *UserRepository) List(ctx context.Context, filter *repositories.UserFilter) ([]*entities.User, error) {
+	where, args := userFilterClause(filter)
+	args = append(args, filter.Limit, filter.Offset)
+
 	query := `
-		SELECT id, email, password, first_name, last_name, role, is_active, last_login_at, created_at, updated_at
-		FROM users 
+		SELECT ` + userColumns + `
+		FROM users ` + where + `
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -197,31 +172,12 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entiti
 	var users []*entities.User
 
 	for rows.Next() {
-		var user entities.User
-		var lastLoginAt sql.NullTime
-
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Password,
-			&user.FirstName,
-			&user.LastName,
-			&user.Role,
-			&user.IsActive,
-			&lastLoginAt,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if lastLoginAt.Valid {
-			user.LastLoginAt = &lastLoginAt.Time
-		}
-
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -231,16 +187,37 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entiti
 	return users, nil
 }
 
-// Count cuenta el total de usuarios
-func (r *UserRepository) Count(ctx context.Context) (int64, error) {
-	query := `SELECT COUNT(*) FROM users`
+// Count cuenta el total de usuarios que cumplen el filtro
+func (r *UserRepository) Count(ctx context.Context, filter *repositories.UserFilter) (int64, error) {
+	where, args := userFilterClause(filter)
+	query := `SELECT COUNT(*) FROM users ` + where
 
 	var count int64
-	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 
 	return count, err
 }
 
+// userFilterClause arma la condición WHERE y sus argumentos a partir del filtro
+func userFilterClause(filter *repositories.UserFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.OrganizationID != "" {
+		args = append(args, filter.OrganizationID)
+		conditions = append(conditions, "id IN (SELECT user_id FROM organization_members WHERE organization_id = $"+strconv.Itoa(len(args))+")")
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, "role = $"+strconv.Itoa(len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // ExistsByEmail verifica si existe un usuario con el email dado
 func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
@@ -250,3 +227,119 @@ func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 
 	return exists, err
 }
+
+// RecordFailedLogin incrementa atómicamente los intentos fallidos y retorna el total
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, id string) (int, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return 0, errors.New("invalid user id")
+	}
+
+	query := `
+		UPDATE users SET failed_login_attempts = failed_login_attempts + 1
+		WHERE id = $1
+		RETURNING failed_login_attempts
+	`
+
+	var attempts int
+	err = r.db.QueryRowContext(ctx, query, userID).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("user not found")
+		}
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+// LockUntil bloquea la cuenta hasta la fecha indicada
+func (r *UserRepository) LockUntil(ctx context.Context, id string, until time.Time) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE users SET locked_until = $2 WHERE id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, userID, until)
+	return err
+}
+
+// ResetFailedLogins reinicia los intentos fallidos y quita el bloqueo
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, id string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// scanUser lee un usuario desde una fila
+func scanUser(row scanner) (*entities.User, error) {
+	var user entities.User
+	var lastLoginAt sql.NullTime
+	var emailVerifiedAt sql.NullTime
+	var lockedUntil sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.FirstName,
+		&user.LastName,
+		&user.Role,
+		&user.IsActive,
+		&lastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&emailVerifiedAt,
+		&user.FailedLoginAttempts,
+		&lockedUntil,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+
+	return &user, nil
+}
+
+// nullTime convierte un puntero a time.Time en un valor nulo de SQL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
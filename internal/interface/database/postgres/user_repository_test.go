@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+)
+
+// recordingDriver es un driver de database/sql que registra las sentencias ejecutadas y el
+// resultado de cada transacción, sin base de datos
+type recordingDriver struct {
+	mu        sync.Mutex
+	execs     []string
+	commits   int
+	rollbacks int
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+func (d *recordingDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+func (d *recordingDriver) Driver() driver.Driver { return d }
+
+// count retorna cuántas sentencias ejecutadas contienen el fragmento
+func (d *recordingDriver) count(fragment string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, query := range d.execs {
+		if strings.Contains(query, fragment) {
+			n++
+		}
+	}
+	return n
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+func (c *recordingConn) Close() error                             { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                { return &recordingTx{driver: c.driver}, nil }
+func (c *recordingConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.execs = append(c.driver.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+type recordingTx struct {
+	driver *recordingDriver
+}
+
+func (t *recordingTx) Commit() error {
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.driver.commits++
+	return nil
+}
+
+func (t *recordingTx) Rollback() error {
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.driver.rollbacks++
+	return nil
+}
+
+// newRecordingDB abre una conexión sobre el driver de prueba
+func newRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	d := &recordingDriver{}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+// newUserWithEvent crea un usuario con un evento pendiente
+func newUserWithEvent() *entities.User {
+	user := entities.NewUserWithRole("ana@example.com", "hash", "Ana", "Pérez", entities.RoleUser)
+	user.RecordEvent(entities.EventUserLoggedIn, map[string]interface{}{"method": "password"})
+	return user
+}
+
+func TestSaveWithEventsKeepsEventsWhenUnitOfWorkRollsBack(t *testing.T) {
+	db, d := newRecordingDB(t)
+	user := newUserWithEvent()
+
+	err := NewUnitOfWork(db).Do(context.Background(), func(repos *repositories.TxRepositories) error {
+		if err := repos.Users.Update(context.Background(), user); err != nil {
+			return err
+		}
+		return errors.New("session create failed")
+	})
+	if err == nil {
+		t.Fatal("expected the unit of work error")
+	}
+
+	if d.commits != 0 || d.rollbacks != 1 {
+		t.Errorf("expected a rollback, got commits=%d rollbacks=%d", d.commits, d.rollbacks)
+	}
+	if len(user.PendingEvents()) != 1 {
+		t.Fatalf("events were cleared after rollback: %d pending", len(user.PendingEvents()))
+	}
+
+	// La siguiente escritura guarda el evento que no llegó a confirmarse
+	if err := NewUserRepository(db).Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	if n := d.count("INSERT INTO outbox_events"); n != 2 {
+		t.Errorf("expected the event to be written again, got %d outbox inserts", n)
+	}
+	if len(user.PendingEvents()) != 0 {
+		t.Errorf("events were not cleared after commit: %d pending", len(user.PendingEvents()))
+	}
+}
+
+func TestSaveWithEventsClearsEventsAfterUnitOfWorkCommits(t *testing.T) {
+	db, d := newRecordingDB(t)
+	user := newUserWithEvent()
+
+	err := NewUnitOfWork(db).Do(context.Background(), func(repos *repositories.TxRepositories) error {
+		if err := repos.Users.Update(context.Background(), user); err != nil {
+			return err
+		}
+		if len(user.PendingEvents()) != 1 {
+			t.Errorf("events were cleared before commit: %d pending", len(user.PendingEvents()))
+		}
+		// Una segunda escritura en la misma transacción no repite el evento
+		return repos.Users.Update(context.Background(), user)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.commits != 1 {
+		t.Errorf("expected a commit, got %d", d.commits)
+	}
+	if n := d.count("INSERT INTO outbox_events"); n != 1 {
+		t.Errorf("expected one outbox insert, got %d", n)
+	}
+	if len(user.PendingEvents()) != 0 {
+		t.Errorf("events were not cleared after commit: %d pending", len(user.PendingEvents()))
+	}
+}
@@ -0,0 +1,263 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// WebAuthnRepository implementa el repositorio de credenciales WebAuthn para PostgreSQL
+type WebAuthnRepository struct {
+	db *sql.DB
+}
+
+// NewWebAuthnRepository crea una nueva instancia de WebAuthnRepository
+func NewWebAuthnRepository(db *sql.DB) repositories.WebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+// webAuthnCredentialColumns lista las columnas en el orden que espera scanWebAuthnCredential
+const webAuthnCredentialColumns = `id, user_id, credential_id, public_key, sign_count, aaguid, transports, name, backup_eligible, backup_state, created_at, last_used_at`
+
+// CreateCredential registra una nueva credencial
+func (r *WebAuthnRepository) CreateCredential(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (id, user_id, credential_id, public_key, sign_count, aaguid, transports, name, backup_eligible, backup_state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		credential.ID,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		int64(credential.SignCount),
+		credential.AAGUID,
+		strings.Join(credential.Transports, ","),
+		credential.Name,
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.CreatedAt,
+	)
+
+	return err
+}
+
+// GetCredential obtiene una credencial por su credential id (base64url)
+func (r *WebAuthnRepository) GetCredential(ctx context.Context, credentialID string) (*entities.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM webauthn_credentials WHERE credential_id = $1`
+
+	return scanWebAuthnCredential(r.db.QueryRowContext(ctx, query, credentialID))
+}
+
+// ListCredentialsByUserID obtiene las credenciales de un usuario
+func (r *WebAuthnRepository) ListCredentialsByUserID(ctx context.Context, userID string) ([]*entities.WebAuthnCredential, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	query := `
+		SELECT ` + webAuthnCredentialColumns + `
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*entities.WebAuthnCredential{}
+
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateCredentialUsage guarda el contador de firmas y la fecha de último uso
+func (r *WebAuthnRepository) UpdateCredentialUsage(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	query := `
+		UPDATE webauthn_credentials
+		SET sign_count = $2, backup_state = $3, last_used_at = $4
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		credential.ID,
+		int64(credential.SignCount),
+		credential.BackupState,
+		nullTime(credential.LastUsedAt),
+	)
+
+	return err
+}
+
+// DeleteCredential elimina una credencial de un usuario
+func (r *WebAuthnRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid credential id")
+	}
+
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, parsedID, parsedUserID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("credential not found")
+	}
+
+	return nil
+}
+
+// DeleteCredentialsByUserID elimina todas las credenciales de un usuario
+func (r *WebAuthnRepository) DeleteCredentialsByUserID(ctx context.Context, userID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	query := `DELETE FROM webauthn_credentials WHERE user_id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, parsedUserID)
+	return err
+}
+
+// CreateChallenge guarda un desafío pendiente
+func (r *WebAuthnRepository) CreateChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error {
+	query := `
+		INSERT INTO webauthn_challenges (challenge, user_id, ceremony, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	var userID interface{}
+	if challenge.UserID != nil {
+		userID = *challenge.UserID
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		challenge.Challenge,
+		userID,
+		challenge.Ceremony,
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+	)
+
+	return err
+}
+
+// ConsumeChallenge elimina y retorna un desafío vigente; falla si no existe o expiró
+func (r *WebAuthnRepository) ConsumeChallenge(ctx context.Context, challenge string, ceremony entities.WebAuthnCeremony) (*entities.WebAuthnChallenge, error) {
+	// El DELETE garantiza un único uso aunque lleguen respuestas concurrentes
+	query := `
+		DELETE FROM webauthn_challenges
+		WHERE challenge = $1 AND ceremony = $2 AND expires_at > $3
+		RETURNING challenge, user_id, ceremony, expires_at, created_at
+	`
+
+	var result entities.WebAuthnChallenge
+	var userID uuid.NullUUID
+
+	err := r.db.QueryRowContext(ctx, query, challenge, ceremony, time.Now()).Scan(
+		&result.Challenge,
+		&userID,
+		&result.Ceremony,
+		&result.ExpiresAt,
+		&result.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("challenge not found")
+		}
+		return nil, err
+	}
+
+	if userID.Valid {
+		result.UserID = &userID.UUID
+	}
+
+	return &result, nil
+}
+
+// DeleteExpiredChallenges elimina desafíos expirados
+func (r *WebAuthnRepository) DeleteExpiredChallenges(ctx context.Context) error {
+	query := `DELETE FROM webauthn_challenges WHERE expires_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now())
+	return err
+}
+
+// scanWebAuthnCredential lee una credencial desde una fila
+func scanWebAuthnCredential(row scanner) (*entities.WebAuthnCredential, error) {
+	var credential entities.WebAuthnCredential
+	var signCount int64
+	var transports string
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&credential.ID,
+		&credential.UserID,
+		&credential.CredentialID,
+		&credential.PublicKey,
+		&signCount,
+		&credential.AAGUID,
+		&transports,
+		&credential.Name,
+		&credential.BackupEligible,
+		&credential.BackupState,
+		&credential.CreatedAt,
+		&lastUsedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("credential not found")
+		}
+		return nil, err
+	}
+
+	credential.SignCount = uint32(signCount)
+	if transports != "" {
+		credential.Transports = strings.Split(transports, ",")
+	}
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &credential, nil
+}
@@ -0,0 +1,522 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// webhookSubscriptionColumns lista las columnas en el orden que espera scanWebhookSubscription
+const webhookSubscriptionColumns = `id, url, COALESCE(description, ''), encrypted_secret, events, is_active,
+	consecutive_failures, disabled_at, created_by, created_at, updated_at`
+
+// webhookDeliveryColumns lista las columnas en el orden que espera scanWebhookDelivery
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	COALESCE(last_error, ''), delivered_at, created_at`
+
+// WebhookRepository implementa el repositorio de webhooks para PostgreSQL
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository crea una nueva instancia de WebhookRepository
+func NewWebhookRepository(db *sql.DB) repositories.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription guarda una nueva suscripción
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, description, encrypted_secret, events, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		subscription.ID,
+		subscription.URL,
+		subscription.Description,
+		subscription.EncryptedSecret,
+		eventTypesArray(subscription.Events),
+		subscription.IsActive,
+		nullUUID(subscription.CreatedBy),
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetSubscription obtiene una suscripción por su ID
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*entities.WebhookSubscription, error) {
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook id")
+	}
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	return scanWebhookSubscription(r.db.QueryRowContext(ctx, query, subscriptionID))
+}
+
+// ListSubscriptions obtiene todas las suscripciones, de la más reciente a la más antigua
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at DESC`
+
+	return r.querySubscriptions(ctx, query)
+}
+
+// ListActiveSubscriptions obtiene las suscripciones activas que reciben el tipo de evento
+func (r *WebhookRepository) ListActiveSubscriptions(ctx context.Context, eventType entities.EventType) ([]*entities.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE is_active = TRUE AND (cardinality(events) = 0 OR $1 = ANY(events))
+	`
+
+	return r.querySubscriptions(ctx, query, string(eventType))
+}
+
+// querySubscriptions ejecuta una consulta de suscripciones
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*entities.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*entities.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// UpdateSubscription actualiza una suscripción existente
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, description = NULLIF($3, ''), encrypted_secret = $4, events = $5, is_active = $6,
+			consecutive_failures = $7, disabled_at = $8, updated_at = $9
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		subscription.ID,
+		subscription.URL,
+		subscription.Description,
+		subscription.EncryptedSecret,
+		eventTypesArray(subscription.Events),
+		subscription.IsActive,
+		subscription.ConsecutiveFailures,
+		nullTime(subscription.DisabledAt),
+		subscription.UpdatedAt,
+	)
+
+	return err
+}
+
+// RecordDeliveryFailure incrementa el contador en una sola sentencia para que las réplicas que
+// entregan en paralelo no pierdan fallos; previous conserva el estado anterior a la actualización
+func (r *WebhookRepository) RecordDeliveryFailure(ctx context.Context, id string, disableAfter int) (bool, error) {
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return false, errors.New("invalid webhook id")
+	}
+
+	query := `
+		WITH previous AS (
+			SELECT id, is_active FROM webhook_subscriptions WHERE id = $1 FOR UPDATE
+		)
+		UPDATE webhook_subscriptions s
+		SET consecutive_failures = s.consecutive_failures + 1,
+			is_active = s.is_active AND NOT ($2 > 0 AND s.consecutive_failures + 1 >= $2),
+			disabled_at = CASE WHEN s.is_active AND $2 > 0 AND s.consecutive_failures + 1 >= $2
+				THEN $3 ELSE s.disabled_at END
+		FROM previous p
+		WHERE s.id = p.id
+		RETURNING p.is_active AND NOT s.is_active
+	`
+
+	var disabled bool
+	err = r.db.QueryRowContext(ctx, query, subscriptionID, disableAfter, time.Now()).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, errors.New("webhook not found")
+	}
+
+	return disabled, err
+}
+
+// ResetDeliveryFailures reinicia el contador; no escribe si ya estaba en cero
+func (r *WebhookRepository) ResetDeliveryFailures(ctx context.Context, id string) error {
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid webhook id")
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions SET consecutive_failures = 0
+		WHERE id = $1 AND consecutive_failures > 0
+	`, subscriptionID)
+
+	return err
+}
+
+// DeleteSubscription elimina una suscripción; sus entregas e intentos se eliminan en cascada
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid webhook id")
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
+
+// CreateDeliveries guarda las entregas pendientes ignorando las repetidas
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*entities.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	for _, delivery := range deliveries {
+		_, err := r.db.ExecContext(ctx, query,
+			delivery.ID,
+			delivery.SubscriptionID,
+			delivery.EventID,
+			delivery.EventType,
+			string(delivery.Payload),
+			delivery.Status,
+			delivery.Attempts,
+			nullTime(delivery.NextAttemptAt),
+			delivery.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimDeliveries reserva entregas pendientes. Igual que en el outbox, SKIP LOCKED evita que dos
+// réplicas reserven la misma entrega y el nuevo next_attempt_at la oculta hasta leaseUntil.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $2 AND s.is_active = TRUE
+			ORDER BY d.next_attempt_at
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	return r.queryDeliveries(ctx, query, leaseUntil.UTC(), time.Now().UTC(), limit)
+}
+
+// RecordAttempt guarda el intento y el nuevo estado de la entrega en una transacción
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *entities.WebhookDelivery, attempt *entities.WebhookAttempt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (id, delivery_id, attempted_at, status_code, response_body, error, duration_ms)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''), $7)
+	`,
+		attempt.ID,
+		attempt.DeliveryID,
+		attempt.AttemptedAt,
+		attempt.StatusCode,
+		attempt.ResponseBody,
+		attempt.Error,
+		attempt.DurationMs,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = NULLIF($5, ''), delivered_at = $6
+		WHERE id = $1
+	`,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		nullTime(delivery.NextAttemptAt),
+		delivery.LastError,
+		nullTime(delivery.DeliveredAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDelivery obtiene una entrega por su ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*entities.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid delivery id")
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	return scanWebhookDelivery(r.db.QueryRowContext(ctx, query, deliveryID))
+}
+
+// ListDeliveries obtiene las entregas que cumplen el filtro, de la más reciente a la más antigua
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter *repositories.WebhookDeliveryFilter) ([]*entities.WebhookDelivery, error) {
+	where, args := webhookDeliveryFilterClause(filter)
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
+	`
+
+	return r.queryDeliveries(ctx, query, args...)
+}
+
+// CountDeliveries cuenta las entregas que cumplen el filtro
+func (r *WebhookRepository) CountDeliveries(ctx context.Context, filter *repositories.WebhookDeliveryFilter) (int64, error) {
+	where, args := webhookDeliveryFilterClause(filter)
+	query := `SELECT COUNT(*) FROM webhook_deliveries ` + where
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+
+	return count, err
+}
+
+// queryDeliveries ejecuta una consulta de entregas
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*entities.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*entities.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ListAttempts obtiene los intentos de una entrega en orden cronológico
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID string) ([]*entities.WebhookAttempt, error) {
+	parsedID, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, errors.New("invalid delivery id")
+	}
+
+	query := `
+		SELECT id, delivery_id, attempted_at, COALESCE(status_code, 0), COALESCE(response_body, ''), COALESCE(error, ''), duration_ms
+		FROM webhook_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempted_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parsedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*entities.WebhookAttempt
+	for rows.Next() {
+		var attempt entities.WebhookAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.AttemptedAt,
+			&attempt.StatusCode,
+			&attempt.ResponseBody,
+			&attempt.Error,
+			&attempt.DurationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// Redeliver vuelve a poner la entrega como pendiente y reinicia sus intentos; el historial se conserva
+func (r *WebhookRepository) Redeliver(ctx context.Context, id string) error {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid delivery id")
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, delivered_at = NULL
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, deliveryID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("delivery not found")
+	}
+
+	return nil
+}
+
+// webhookDeliveryFilterClause arma la condición WHERE y sus argumentos a partir del filtro
+func webhookDeliveryFilterClause(filter *repositories.WebhookDeliveryFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != "" {
+		args = append(args, filter.SubscriptionID)
+		conditions = append(conditions, "subscription_id = $"+strconv.Itoa(len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, "status = $"+strconv.Itoa(len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanWebhookSubscription lee una suscripción desde una fila
+func scanWebhookSubscription(row scanner) (*entities.WebhookSubscription, error) {
+	var subscription entities.WebhookSubscription
+	var events []string
+	var disabledAt sql.NullTime
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Description,
+		&subscription.EncryptedSecret,
+		pq.Array(&events),
+		&subscription.IsActive,
+		&subscription.ConsecutiveFailures,
+		&disabledAt,
+		&createdBy,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+
+	if disabledAt.Valid {
+		subscription.DisabledAt = &disabledAt.Time
+	}
+	subscription.CreatedBy = uuidPtr(createdBy)
+	subscription.Events = make([]entities.EventType, 0, len(events))
+	for _, event := range events {
+		subscription.Events = append(subscription.Events, entities.EventType(event))
+	}
+
+	return &subscription, nil
+}
+
+// scanWebhookDelivery lee una entrega desde una fila
+func scanWebhookDelivery(row scanner) (*entities.WebhookDelivery, error) {
+	var delivery entities.WebhookDelivery
+	var payload []byte
+	var nextAttemptAt, deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&delivery.LastError,
+		&deliveredAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("delivery not found")
+		}
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+
+	return &delivery, nil
+}
+
+// eventTypesArray convierte el filtro de eventos en un arreglo de PostgreSQL
+func eventTypesArray(events []entities.EventType) interface{} {
+	values := make([]string, 0, len(events))
+	for _, event := range events {
+		values = append(values, string(event))
+	}
+	return pq.Array(values)
+}
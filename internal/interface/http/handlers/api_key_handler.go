@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler maneja las peticiones HTTP de las API keys personales
+type APIKeyHandler struct {
+	apiKeyUseCase *usecase.APIKeyUseCase
+}
+
+// NewAPIKeyHandler crea una nueva instancia de APIKeyHandler
+func NewAPIKeyHandler(apiKeyUseCase *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase: apiKeyUseCase,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary      Crear API key
+// @Description  Genera una API key personal para el usuario autenticado, con scopes y expiración opcionales. La clave solo se retorna en esta respuesta. No se pueden crear claves autenticándose con otra API key ni con un token emitido a un cliente OAuth o a una cuenta de servicio
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateAPIKeyRequest true "Nombre, scopes y expiración"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /users/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID.(string)
+
+	response, err := h.apiKeyUseCase.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "api key created successfully",
+		"data":    response,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary      Listar API keys
+// @Description  Lista las API keys del usuario autenticado, sin el valor de las claves
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	keys, err := h.apiKeyUseCase.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "api keys retrieved successfully",
+		"data":    keys,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revocar API key
+// @Description  Elimina una API key del usuario autenticado; deja de ser válida de inmediato
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la API key"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /users/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.apiKeyUseCase.Revoke(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "api key revoked successfully",
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler maneja las peticiones HTTP de consulta del log de auditoría
+type AuditHandler struct {
+	auditUseCase *usecase.AuditUseCase
+}
+
+// NewAuditHandler crea una nueva instancia de AuditHandler
+func NewAuditHandler(auditUseCase *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+// ListEvents godoc
+// @Summary      Consultar log de auditoría
+// @Description  Lista los eventos de auditoría del más reciente al más antiguo con paginación por cursor
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id query string false "Actor o destinatario del evento"
+// @Param        action  query string false "Acción (por ejemplo login_failed)"
+// @Param        from    query string false "Desde (RFC 3339, inclusive)"
+// @Param        to      query string false "Hasta (RFC 3339, exclusive)"
+// @Param        cursor  query string false "next_cursor de la página anterior"
+// @Param        limit   query int    false "Límite de resultados" default(50)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/audit/events [get]
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	req, err := auditEventsRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Cursor = c.Query("cursor")
+	req.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	response, err := h.auditUseCase.ListEvents(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "audit events retrieved successfully",
+		"data":    response,
+	})
+}
+
+// ExportEvents godoc
+// @Summary      Exportar log de auditoría
+// @Description  Exporta en JSON Lines, en orden de secuencia, los eventos que cumplen los filtros, con sus hashes
+// @Tags         admin
+// @Produce      application/x-ndjson
+// @Security     BearerAuth
+// @Param        user_id query string false "Actor o destinatario del evento"
+// @Param        action  query string false "Acción"
+// @Param        from    query string false "Desde (RFC 3339, inclusive)"
+// @Param        to      query string false "Hasta (RFC 3339, exclusive)"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/audit/events/export [get]
+func (h *AuditHandler) ExportEvents(c *gin.Context) {
+	req, err := auditEventsRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// Con la respuesta ya iniciada un error solo puede informarse en el log
+	if err := h.auditUseCase.ExportEvents(c.Request.Context(), req, c.Writer); err != nil {
+		log.Printf("Error exporting audit events: %v", err)
+	}
+}
+
+// VerifyChain godoc
+// @Summary      Verificar log de auditoría
+// @Description  Recalcula la cadena de hashes y reporta la primera entrada modificada, borrada o reordenada
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/audit/verify [get]
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	response, err := h.auditUseCase.VerifyChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "audit chain verified",
+		"data":    response,
+	})
+}
+
+// auditEventsRequest lee los filtros comunes a la consulta y la exportación
+func auditEventsRequest(c *gin.Context) (*usecase.ListAuditEventsRequest, error) {
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	return &usecase.ListAuditEventsRequest{
+		UserID: c.Query("user_id"),
+		Action: c.Query("action"),
+		From:   from,
+		To:     to,
+	}, nil
+}
+
+// timeQuery lee un parámetro opcional en formato RFC 3339
+func timeQuery(c *gin.Context, param string) (*time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("invalid " + param + ", expected RFC 3339")
+	}
+	return &parsed, nil
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"auth-go-microservicio/internal/usecase"
 
@@ -22,13 +24,14 @@ func NewAuthHandler(authUseCase *usecase.AuthUseCase) *AuthHandler {
 
 // Register godoc
 // @Summary      Registro de usuario
-// @Description  Registra un nuevo usuario en el sistema
+// @Description  Registra un nuevo usuario con el rol user; deshabilitado si AUTH_ALLOW_SELF_REGISTRATION=false
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request body usecase.RegisterRequest true "Datos de registro"
 // @Success      201  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req usecase.RegisterRequest
@@ -39,7 +42,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	response, err := h.authUseCase.Register(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrRegistrationDisabled) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, errorBody(err))
 		return
 	}
 
@@ -49,58 +56,63 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	})
 }
 
-// RegisterAdmin godoc
-// @Summary      Registro de usuario administrador
-// @Description  Registra un nuevo usuario administrador en el sistema
+// Login godoc
+// @Summary      Login de usuario
+// @Description  Autentica un usuario y retorna tokens de acceso
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        request body usecase.RegisterRequest true "Datos de registro de administrador"
-// @Success      201  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Router       /auth/register-admin [post]
-func (h *AuthHandler) RegisterAdmin(c *gin.Context) {
-	var req usecase.RegisterRequest
+// @Param        request body usecase.LoginRequest true "Credenciales de login"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      429  {object}  map[string]interface{}
+// @Router       /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req usecase.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Forzar el rol de administrador
-	req.Role = "admin"
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
 
-	response, err := h.authUseCase.Register(c.Request.Context(), &req)
+	response, err := h.authUseCase.Login(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(loginErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "admin user registered successfully",
+	c.JSON(http.StatusOK, gin.H{
+		"message": "login successful",
 		"data":    response,
 	})
 }
 
-// Login godoc
-// @Summary      Login de usuario
-// @Description  Autentica un usuario y retorna tokens de acceso
+// VerifyMFA godoc
+// @Summary      Verificar segundo factor
+// @Description  Intercambia el mfa_token del login y un código TOTP o de recuperación por los tokens de acceso
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        request body usecase.LoginRequest true "Credenciales de login"
+// @Param        request body usecase.VerifyMFARequest true "Token de desafío y código"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
-// @Router       /auth/login [post]
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req usecase.LoginRequest
+// @Failure      429  {object}  map[string]interface{}
+// @Router       /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req usecase.VerifyMFARequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	response, err := h.authUseCase.Login(c.Request.Context(), &req)
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	response, err := h.authUseCase.VerifyMFA(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.JSON(loginErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -112,7 +124,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Logout godoc
 // @Summary      Logout de usuario
-// @Description  Cierra la sesión del usuario revocando el refresh token
+// @Description  Cierra la sesión del usuario revocando el refresh token y, si se envía el header Authorization, el access token
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -127,6 +139,8 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
+	req.AccessToken = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
 	err := h.authUseCase.Logout(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -155,6 +169,9 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
 	response, err := h.authUseCase.Refresh(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -166,3 +183,49 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		"data":    response,
 	})
 }
+
+// SwitchOrganization godoc
+// @Summary      Cambiar organización activa
+// @Description  Rota el refresh token y emite tokens con el claim org_id de otra organización del usuario
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.SwitchOrganizationRequest true "Refresh token y organización"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /auth/switch-organization [post]
+func (h *AuthHandler) SwitchOrganization(c *gin.Context) {
+	var req usecase.SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	response, err := h.authUseCase.SwitchOrganization(c.Request.Context(), &req)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, usecase.ErrNotOrganizationMember) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "organization switched successfully",
+		"data":    response,
+	})
+}
+
+// loginErrorStatus retorna 429 para los bloqueos por intentos fallidos y 401 para el resto
+func loginErrorStatus(err error) int {
+	if errors.Is(err, usecase.ErrAccountLocked) || errors.Is(err, usecase.ErrTooManyAttempts) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusUnauthorized
+}
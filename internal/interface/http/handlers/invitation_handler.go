@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationHandler maneja las peticiones HTTP de invitaciones. Las rutas bajo /orgs/{org_id}
+// quedan limitadas a esa organización y no pueden otorgar roles de la plataforma.
+type InvitationHandler struct {
+	invitationUseCase *usecase.InvitationUseCase
+}
+
+// NewInvitationHandler crea una nueva instancia de InvitationHandler
+func NewInvitationHandler(invitationUseCase *usecase.InvitationUseCase) *InvitationHandler {
+	return &InvitationHandler{
+		invitationUseCase: invitationUseCase,
+	}
+}
+
+// CreateInvitation godoc
+// @Summary      Crear invitación
+// @Description  Invita un email con un rol y, opcionalmente, una organización; envía el enlace por email
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateInvitationRequest true "Email, rol, organización y vencimiento"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/invitations [post]
+// @Router       /orgs/{org_id}/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var req usecase.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.InvitedBy = c.GetString("user_id")
+	if organizationID := c.Param("org_id"); organizationID != "" {
+		req.OrganizationID = organizationID
+		req.Role = ""
+	}
+
+	invitation, err := h.invitationUseCase.CreateInvitation(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "invitation sent successfully",
+		"data":    invitation,
+	})
+}
+
+// ListInvitations godoc
+// @Summary      Listar invitaciones
+// @Description  Lista las invitaciones con su estado y quién las emitió, aceptó o revocó
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        offset  query int    false "Offset para paginación" default(0)
+// @Param        limit   query int    false "Límite de resultados" default(10)
+// @Param        email   query string false "Email invitado"
+// @Param        pending query bool   false "Solo las pendientes"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/invitations [get]
+// @Router       /orgs/{org_id}/invitations [get]
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	pending, _ := strconv.ParseBool(c.DefaultQuery("pending", "false"))
+
+	req := &usecase.ListInvitationsRequest{
+		OrganizationID: c.Query("org_id"),
+		Email:          c.Query("email"),
+		PendingOnly:    pending,
+		Offset:         offset,
+		Limit:          limit,
+	}
+	if organizationID := c.Param("org_id"); organizationID != "" {
+		req.OrganizationID = organizationID
+	}
+
+	response, err := h.invitationUseCase.ListInvitations(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "invitations retrieved successfully",
+		"data":    response,
+	})
+}
+
+// RevokeInvitation godoc
+// @Summary      Revocar invitación
+// @Description  Revoca una invitación pendiente
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la invitación"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/invitations/{id} [delete]
+// @Router       /orgs/{org_id}/invitations/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	req := &usecase.RevokeInvitationRequest{
+		InvitationID:   c.Param("id"),
+		OrganizationID: c.Param("org_id"),
+		RevokedBy:      c.GetString("user_id"),
+	}
+
+	if err := h.invitationUseCase.RevokeInvitation(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "invitation revoked successfully",
+	})
+}
+
+// AcceptInvitation godoc
+// @Summary      Aceptar invitación
+// @Description  Crea la cuenta con el rol invitado o, si el email ya tiene cuenta, la vincula
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.AcceptInvitationRequest true "Token de la invitación y datos de la cuenta"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/accept-invite [post]
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var req usecase.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.invitationUseCase.AcceptInvitation(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "invitation accepted successfully",
+		"data":    response,
+	})
+}
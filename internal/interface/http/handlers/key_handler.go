@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyHandler maneja la administración del anillo de claves de firma JWT
+type KeyHandler struct {
+	keyRing       *jwt.KeyRing
+	keyRetirement time.Duration
+}
+
+// NewKeyHandler crea una nueva instancia de KeyHandler
+func NewKeyHandler(keyRing *jwt.KeyRing, keyRetirement time.Duration) *KeyHandler {
+	return &KeyHandler{
+		keyRing:       keyRing,
+		keyRetirement: keyRetirement,
+	}
+}
+
+// KeyInfo representa una clave del anillo sin su material privado
+type KeyInfo struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"algorithm"`
+	Status    string     `json:"status"` // active, retiring o staged
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+}
+
+// RotateKeyRequest representa la solicitud de rotación de clave
+type RotateKeyRequest struct {
+	Algorithm string `json:"algorithm" binding:"omitempty,oneof=HS256 RS256 ES256 ES384 ES512 EdDSA"`
+}
+
+// ListKeys godoc
+// @Summary      Listar claves de firma
+// @Description  Lista las claves vigentes del anillo JWT (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/keys [get]
+func (h *KeyHandler) ListKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "keys retrieved successfully",
+		"data":    h.keyInfos(),
+	})
+}
+
+// RotateKey godoc
+// @Summary      Rotar clave de firma
+// @Description  Genera una clave nueva y la promueve; la anterior verifica tokens hasta que expiren los refresh tokens
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body RotateKeyRequest false "Algoritmo de la nueva clave (por defecto el de la clave activa)"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/keys/rotate [post]
+func (h *KeyHandler) RotateKey(c *gin.Context) {
+	var req RotateKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if !h.keyRing.IsPersistent() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key rotation requires JWT_KEYS_DIR"})
+		return
+	}
+
+	key, err := h.keyRing.Rotate(req.Algorithm, h.keyRetirement)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "signing key rotated successfully",
+		"data": gin.H{
+			"kid":  key.ID,
+			"keys": h.keyInfos(),
+		},
+	})
+}
+
+// PromoteKey godoc
+// @Summary      Promover clave de firma
+// @Description  Convierte una clave preparada del anillo en la clave activa
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        kid path string true "Identificador de la clave"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/keys/{kid}/promote [post]
+func (h *KeyHandler) PromoteKey(c *gin.Context) {
+	if !h.keyRing.IsPersistent() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key rotation requires JWT_KEYS_DIR"})
+		return
+	}
+
+	if err := h.keyRing.Promote(c.Param("kid"), h.keyRetirement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "signing key promoted successfully",
+		"data":    h.keyInfos(),
+	})
+}
+
+// keyInfos describe las claves vigentes del anillo
+func (h *KeyHandler) keyInfos() []KeyInfo {
+	active := h.keyRing.Active()
+
+	var infos []KeyInfo
+	for _, key := range h.keyRing.Keys() {
+		info := KeyInfo{
+			ID:        key.ID,
+			Algorithm: key.Algorithm(),
+			Status:    "staged",
+		}
+		switch {
+		case key == active:
+			info.Status = "active"
+		case !key.NotAfter.IsZero():
+			notAfter := key.NotAfter
+			info.Status = "retiring"
+			info.NotAfter = &notAfter
+		}
+		infos = append(infos, info)
+	}
+
+	return infos
+}
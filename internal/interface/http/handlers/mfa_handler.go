@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler maneja las peticiones HTTP de gestión del segundo factor
+type MFAHandler struct {
+	mfaUseCase *usecase.MFAUseCase
+}
+
+// NewMFAHandler crea una nueva instancia de MFAHandler
+func NewMFAHandler(mfaUseCase *usecase.MFAUseCase) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase: mfaUseCase,
+	}
+}
+
+// SetupTOTP godoc
+// @Summary      Iniciar alta de TOTP
+// @Description  Genera un secreto TOTP y su URI otpauth:// para cargar en la aplicación de autenticación
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/mfa/totp/setup [post]
+func (h *MFAHandler) SetupTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	req := &usecase.SetupTOTPRequest{
+		UserID: userID.(string),
+	}
+
+	response, err := h.mfaUseCase.SetupTOTP(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "totp setup started",
+		"data":    response,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirmar alta de TOTP
+// @Description  Activa TOTP verificando un código y retorna los códigos de recuperación (se muestran una sola vez)
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.ConfirmTOTPRequest true "Código TOTP"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/mfa/totp/verify [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.UserID = userID.(string)
+
+	response, err := h.mfaUseCase.ConfirmTOTP(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "totp enabled successfully",
+		"data":    response,
+	})
+}
+
+// ResetUserMFA godoc
+// @Summary      Restablecer MFA de un usuario
+// @Description  Elimina el segundo factor y los códigos de recuperación de un usuario (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/mfa [delete]
+func (h *MFAHandler) ResetUserMFA(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user id is required"})
+		return
+	}
+
+	if err := h.mfaUseCase.ResetMFA(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "mfa reset successfully",
+	})
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthHandler maneja las peticiones HTTP del servidor de autorización OAuth 2.0
+type OAuthHandler struct {
+	oauthUseCase         *usecase.OAuthUseCase
+	introspectionUseCase *usecase.TokenIntrospectionUseCase
+}
+
+// NewOAuthHandler crea una nueva instancia de OAuthHandler
+func NewOAuthHandler(oauthUseCase *usecase.OAuthUseCase, introspectionUseCase *usecase.TokenIntrospectionUseCase) *OAuthHandler {
+	return &OAuthHandler{
+		oauthUseCase:         oauthUseCase,
+		introspectionUseCase: introspectionUseCase,
+	}
+}
+
+// Authorize godoc
+// @Summary      Solicitud de autorización OAuth
+// @Description  Valida la solicitud (RFC 6749 4.1.1, PKCE S256 obligatorio para clientes públicos) y redirige a la página de consentimiento del frontend. Los errores de parámetros se informan en el redirect_uri; si el cliente o el redirect_uri son inválidos responde 400
+// @Tags         oauth
+// @Produce      json
+// @Param        response_type         query string true  "code"
+// @Param        client_id             query string true  "Client ID"
+// @Param        redirect_uri          query string false "URI de redirección registrada"
+// @Param        scope                 query string false "Scopes separados por espacios"
+// @Param        state                 query string false "Valor opaco que se devuelve al cliente"
+// @Param        nonce                 query string false "Valor que se devuelve en el ID token (OpenID Connect)"
+// @Param        code_challenge        query string false "Code challenge PKCE"
+// @Param        code_challenge_method query string false "S256"
+// @Success      302
+// @Failure      400  {object}  usecase.OAuthError
+// @Router       /oauth/authorize [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var req usecase.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, usecase.OAuthError{Code: usecase.OAuthErrInvalidRequest, Description: err.Error()})
+		return
+	}
+
+	location, err := h.oauthUseCase.StartAuthorization(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, toOAuthError(err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, location)
+}
+
+// Token godoc
+// @Summary      Endpoint de tokens OAuth
+// @Description  Canjea un código de autorización (authorization_code), rota un refresh token (refresh_token) o emite un token a una cuenta de servicio (client_credentials). Los clientes confidenciales se autentican con HTTP Basic o client_secret en el cuerpo; las cuentas de servicio también con private_key_jwt
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type            formData string true  "authorization_code, refresh_token o client_credentials"
+// @Param        code                  formData string false "Código de autorización"
+// @Param        redirect_uri          formData string false "URI usada en la solicitud de autorización"
+// @Param        code_verifier         formData string false "Code verifier PKCE"
+// @Param        refresh_token         formData string false "Refresh token"
+// @Param        scope                 formData string false "Subconjunto de los scopes concedidos"
+// @Param        client_id             formData string false "Client ID"
+// @Param        client_secret         formData string false "Secreto del cliente"
+// @Param        client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+// @Param        client_assertion      formData string false "JWT firmado con la clave privada de la cuenta de servicio"
+// @Success      200  {object}  usecase.TokenResponse
+// @Failure      400  {object}  usecase.OAuthError
+// @Failure      401  {object}  usecase.OAuthError
+// @Router       /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	// Las respuestas con tokens no se deben cachear (RFC 6749 sección 5.1)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	req := usecase.TokenRequest{
+		GrantType:         c.PostForm("grant_type"),
+		Code:              c.PostForm("code"),
+		RedirectURI:       c.PostForm("redirect_uri"),
+		CodeVerifier:      c.PostForm("code_verifier"),
+		RefreshToken:      c.PostForm("refresh_token"),
+		Scope:             c.PostForm("scope"),
+		UserAgent:         c.Request.UserAgent(),
+		IPAddress:         c.ClientIP(),
+		ClientCredentials: clientCredentials(c),
+	}
+
+	response, err := h.oauthUseCase.Token(c.Request.Context(), &req)
+	if err != nil {
+		writeClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Introspect godoc
+// @Summary      Introspección de tokens
+// @Description  Informa si un access o refresh token está activo (RFC 7662). Requiere un cliente confidencial o una cuenta de servicio; los clientes OAuth solo ven los tokens emitidos a ellos. Con Keycloak se reenvía a Keycloak
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token                 formData string true  "Token a consultar"
+// @Param        token_type_hint       formData string false "access_token o refresh_token"
+// @Param        client_id             formData string false "Client ID"
+// @Param        client_secret         formData string false "Secreto del cliente"
+// @Param        client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+// @Param        client_assertion      formData string false "JWT firmado con la clave privada de la cuenta de servicio"
+// @Success      200  {object}  usecase.IntrospectionResponse
+// @Failure      400  {object}  usecase.OAuthError
+// @Failure      401  {object}  usecase.OAuthError
+// @Router       /oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	response, err := h.introspectionUseCase.Introspect(c.Request.Context(), &usecase.TokenOperationRequest{
+		Token:             c.PostForm("token"),
+		TokenTypeHint:     c.PostForm("token_type_hint"),
+		ClientCredentials: clientCredentials(c),
+	})
+	if err != nil {
+		writeClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Revoke godoc
+// @Summary      Revocación de tokens
+// @Description  Revoca un access o refresh token emitido al cliente (RFC 7009); revocar un refresh token cierra su sesión. Responde 200 aunque el token sea inválido o ya esté revocado. Con Keycloak se reenvía a Keycloak
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token                 formData string true  "Token a revocar"
+// @Param        token_type_hint       formData string false "access_token o refresh_token"
+// @Param        client_id             formData string false "Client ID"
+// @Param        client_secret         formData string false "Secreto del cliente"
+// @Param        client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+// @Param        client_assertion      formData string false "JWT firmado con la clave privada de la cuenta de servicio"
+// @Success      200
+// @Failure      400  {object}  usecase.OAuthError
+// @Failure      401  {object}  usecase.OAuthError
+// @Router       /oauth/revoke [post]
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	err := h.introspectionUseCase.Revoke(c.Request.Context(), &usecase.TokenOperationRequest{
+		Token:             c.PostForm("token"),
+		TokenTypeHint:     c.PostForm("token_type_hint"),
+		ClientCredentials: clientCredentials(c),
+	})
+	if err != nil {
+		writeClientError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetConsent godoc
+// @Summary      Obtener solicitud de consentimiento
+// @Description  Valida la solicitud de autorización recibida por la página de consentimiento y retorna el cliente, los scopes y si el usuario debe concederlos
+// @Tags         oauth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        client_id             query string true  "Client ID"
+// @Param        redirect_uri          query string false "URI de redirección"
+// @Param        response_type         query string true  "code"
+// @Param        scope                 query string false "Scopes separados por espacios"
+// @Param        code_challenge        query string false "Code challenge PKCE"
+// @Param        code_challenge_method query string false "S256"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /oauth/consent [get]
+func (h *OAuthHandler) GetConsent(c *gin.Context) {
+	var req usecase.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consent, err := h.oauthUseCase.GetConsent(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "consent request retrieved successfully",
+		"data":    consent,
+	})
+}
+
+// Consent godoc
+// @Summary      Conceder o denegar autorización
+// @Description  Registra la decisión del usuario autenticado y retorna la URL del cliente a la que redirigir el navegador, con el código de autorización o el error access_denied
+// @Tags         oauth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.AuthorizeDecisionRequest true "Parámetros de la solicitud y decisión"
+// @Success      200  {object}  usecase.AuthorizeDecisionResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /oauth/consent [post]
+func (h *OAuthHandler) Consent(c *gin.Context) {
+	var req usecase.AuthorizeDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = c.GetString("user_id")
+	if claims, ok := c.Get("token_claims"); ok {
+		if tokenClaims, ok := claims.(*jwt.Claims); ok {
+			req.Authentication = tokenClaims.Authentication()
+		}
+	}
+
+	response, err := h.oauthUseCase.Authorize(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UserInfo godoc
+// @Summary      UserInfo de OpenID Connect
+// @Description  Retorna los claims del usuario del access token según sus scopes: sub siempre, name, given_name, family_name y updated_at con profile, email y email_verified con email. Requiere un access token emitido a un cliente con el scope openid
+// @Tags         oauth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  usecase.UserInfo
+// @Failure      401  {object}  usecase.OAuthError
+// @Failure      403  {object}  usecase.OAuthError
+// @Router       /oauth/userinfo [get]
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	var scope string
+	if claims, ok := c.Get("token_claims"); ok {
+		if tokenClaims, ok := claims.(*jwt.Claims); ok {
+			scope = tokenClaims.Scope
+		}
+	}
+
+	info, err := h.oauthUseCase.GetUserInfo(c.Request.Context(), c.GetString("user_id"), scope)
+	if err != nil {
+		oauthErr := toOAuthError(err)
+		c.Header("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`"`)
+		if oauthErr.Code == "invalid_token" {
+			c.JSON(http.StatusUnauthorized, oauthErr)
+			return
+		}
+		c.JSON(http.StatusForbidden, oauthErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// OpenIDConfiguration godoc
+// @Summary      Discovery de OpenID Connect
+// @Description  Documento de configuración del proveedor OpenID Connect con los endpoints, scopes y algoritmos soportados
+// @Tags         oauth
+// @Produce      json
+// @Success      200  {object}  usecase.OpenIDConfiguration
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /.well-known/openid-configuration [get]
+func (h *OAuthHandler) OpenIDConfiguration(c *gin.Context) {
+	configuration, err := h.oauthUseCase.Discovery()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, configuration)
+}
+
+// CreateClient godoc
+// @Summary      Registrar cliente OAuth
+// @Description  Registra una aplicación cliente. El secreto de los clientes confidenciales solo se retorna en esta respuesta
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateOAuthClientRequest true "Nombre, tipo, URIs de redirección y scopes"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req usecase.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.CreatedBy = c.GetString("user_id")
+
+	response, err := h.oauthUseCase.CreateClient(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "oauth client created successfully",
+		"data":    response,
+	})
+}
+
+// ListClients godoc
+// @Summary      Listar clientes OAuth
+// @Description  Lista las aplicaciones cliente registradas
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauthUseCase.ListClients(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "oauth clients retrieved successfully",
+		"data":    clients,
+	})
+}
+
+// GetClient godoc
+// @Summary      Obtener cliente OAuth
+// @Description  Obtiene una aplicación cliente
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del cliente"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients/{id} [get]
+func (h *OAuthHandler) GetClient(c *gin.Context) {
+	client, err := h.oauthUseCase.GetClient(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "oauth client retrieved successfully",
+		"data":    client,
+	})
+}
+
+// UpdateClient godoc
+// @Summary      Actualizar cliente OAuth
+// @Description  Modifica el nombre, las URIs de redirección, los scopes o el consentimiento del cliente, o rota su secreto
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path string                           true "ID del cliente"
+// @Param        request body usecase.UpdateOAuthClientRequest true "Campos a modificar"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients/{id} [put]
+func (h *OAuthHandler) UpdateClient(c *gin.Context) {
+	var req usecase.UpdateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = c.Param("id")
+
+	response, err := h.oauthUseCase.UpdateClient(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "oauth client updated successfully",
+		"data":    response,
+	})
+}
+
+// DeleteClient godoc
+// @Summary      Eliminar cliente OAuth
+// @Description  Elimina el cliente y revoca sus refresh tokens y sesiones
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del cliente"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients/{id} [delete]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	if err := h.oauthUseCase.DeleteClient(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "oauth client deleted successfully",
+	})
+}
+
+// clientCredentials lee la autenticación del cliente: HTTP Basic, client_id y client_secret en el
+// cuerpo o un client assertion
+func clientCredentials(c *gin.Context) usecase.ClientCredentials {
+	creds := usecase.ClientCredentials{
+		ClientID:            c.PostForm("client_id"),
+		ClientSecret:        c.PostForm("client_secret"),
+		ClientAssertionType: c.PostForm("client_assertion_type"),
+		ClientAssertion:     c.PostForm("client_assertion"),
+	}
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		creds.ClientID, creds.ClientSecret = clientID, clientSecret
+	}
+	return creds
+}
+
+// writeClientError responde un error de los endpoints autenticados por el cliente: 401 para
+// invalid_client, 400 para los demás errores del protocolo y 500 para los inesperados
+func writeClientError(c *gin.Context, err error) {
+	var oauthErr *usecase.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, usecase.OAuthError{Code: "server_error"})
+		return
+	}
+	if oauthErr.Code == usecase.OAuthErrInvalidClient {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		c.JSON(http.StatusUnauthorized, oauthErr)
+		return
+	}
+	c.JSON(http.StatusBadRequest, oauthErr)
+}
+
+// toOAuthError convierte un error del caso de uso en un error del protocolo OAuth
+func toOAuthError(err error) *usecase.OAuthError {
+	var oauthErr *usecase.OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr
+	}
+	return &usecase.OAuthError{Code: usecase.OAuthErrInvalidRequest, Description: err.Error()}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler maneja las peticiones HTTP de organizaciones y sus miembros
+type OrganizationHandler struct {
+	organizationUseCase *usecase.OrganizationUseCase
+}
+
+// NewOrganizationHandler crea una nueva instancia de OrganizationHandler
+func NewOrganizationHandler(organizationUseCase *usecase.OrganizationUseCase) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationUseCase: organizationUseCase,
+	}
+}
+
+// CreateOrganization godoc
+// @Summary      Crear organización
+// @Description  Crea una organización y, si se indica owner_id, la agrega como org_admin (administración de la plataforma)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateOrganizationRequest true "Nombre, slug y administrador inicial"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/orgs [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req usecase.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organization, err := h.organizationUseCase.CreateOrganization(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "organization created successfully",
+		"data":    organization,
+	})
+}
+
+// ListOrganizations godoc
+// @Summary      Listar organizaciones
+// @Description  Lista todas las organizaciones con paginación (administración de la plataforma)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        offset query int false "Offset para paginación" default(0)
+// @Param        limit  query int false "Límite de resultados" default(10)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/orgs [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	req := &usecase.ListOrganizationsRequest{
+		Offset: offset,
+		Limit:  limit,
+	}
+
+	response, err := h.organizationUseCase.ListOrganizations(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "organizations retrieved successfully",
+		"data":    response,
+	})
+}
+
+// DeleteOrganization godoc
+// @Summary      Eliminar organización
+// @Description  Elimina una organización y todas sus membresías (administración de la plataforma)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la organización"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/orgs/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	if err := h.organizationUseCase.DeleteOrganization(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "organization deleted successfully",
+	})
+}
+
+// ListMyOrganizations godoc
+// @Summary      Listar mis organizaciones
+// @Description  Lista las organizaciones a las que pertenece el usuario autenticado con su rol en cada una
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/orgs [get]
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	memberships, err := h.organizationUseCase.ListUserOrganizations(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "organizations retrieved successfully",
+		"data": gin.H{
+			"organizations": memberships,
+			"active_org_id": c.GetString("org_id"),
+		},
+	})
+}
+
+// GetOrganization godoc
+// @Summary      Obtener organización
+// @Description  Obtiene los datos de la organización (requiere ser miembro)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        org_id path string true "ID de la organización"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /orgs/{org_id} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	organization, err := h.organizationUseCase.GetOrganization(c.Request.Context(), c.Param("org_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "organization retrieved successfully",
+		"data":    organization,
+	})
+}
+
+// ListMembers godoc
+// @Summary      Listar miembros
+// @Description  Lista los miembros de la organización con su rol (requiere members:read en la organización)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        org_id path string true "ID de la organización"
+// @Param        offset query int false "Offset para paginación" default(0)
+// @Param        limit  query int false "Límite de resultados" default(10)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /orgs/{org_id}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	req := &usecase.ListMembersRequest{
+		OrganizationID: c.Param("org_id"),
+		Offset:         offset,
+		Limit:          limit,
+	}
+
+	response, err := h.organizationUseCase.ListMembers(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "members retrieved successfully",
+		"data":    response,
+	})
+}
+
+// AddMember godoc
+// @Summary      Agregar miembro
+// @Description  Agrega un usuario existente, por user_id o email, con un rol de la organización (requiere members:write)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        org_id path string true "ID de la organización"
+// @Param        request body usecase.AddMemberRequest true "Usuario y rol"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /orgs/{org_id}/members [post]
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	var req usecase.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.OrganizationID = c.Param("org_id")
+
+	member, err := h.organizationUseCase.AddMember(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "member added successfully",
+		"data":    member,
+	})
+}
+
+// UpdateMember godoc
+// @Summary      Cambiar rol de un miembro
+// @Description  Cambia el rol del usuario dentro de la organización (requiere members:write)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        org_id  path string true "ID de la organización"
+// @Param        user_id path string true "ID del usuario"
+// @Param        request body usecase.UpdateMemberRoleRequest true "Rol"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /orgs/{org_id}/members/{user_id} [put]
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	var req usecase.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.OrganizationID = c.Param("org_id")
+	req.UserID = c.Param("user_id")
+
+	if err := h.organizationUseCase.UpdateMemberRole(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "member updated successfully",
+	})
+}
+
+// RemoveMember godoc
+// @Summary      Quitar miembro
+// @Description  Quita al usuario de la organización e invalida sus access tokens (requiere members:write)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        org_id  path string true "ID de la organización"
+// @Param        user_id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /orgs/{org_id}/members/{user_id} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	if err := h.organizationUseCase.RemoveMember(c.Request.Context(), c.Param("org_id"), c.Param("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "member removed successfully",
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetHandler maneja las peticiones HTTP de recuperación de contraseña
+type PasswordResetHandler struct {
+	passwordResetUseCase *usecase.PasswordResetUseCase
+}
+
+// NewPasswordResetHandler crea una nueva instancia de PasswordResetHandler
+func NewPasswordResetHandler(passwordResetUseCase *usecase.PasswordResetUseCase) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetUseCase: passwordResetUseCase,
+	}
+}
+
+// ForgotPassword godoc
+// @Summary      Recuperar contraseña
+// @Description  Envía un enlace para restablecer la contraseña. Siempre responde 202 para no revelar qué cuentas existen
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.ForgotPasswordRequest true "Email de la cuenta"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req usecase.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.passwordResetUseCase.ForgotPassword(c.Request.Context(), &req)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "if the account exists, a password reset email has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary      Restablecer contraseña
+// @Description  Establece una nueva contraseña con el token recibido por email y cierra todas las sesiones
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.ResetPasswordRequest true "Token y nueva contraseña"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req usecase.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passwordResetUseCase.ResetPassword(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "password reset successfully",
+	})
+}
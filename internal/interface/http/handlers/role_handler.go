@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler maneja las peticiones HTTP de administración de roles y permisos
+type RoleHandler struct {
+	roleUseCase *usecase.RoleUseCase
+}
+
+// NewRoleHandler crea una nueva instancia de RoleHandler
+func NewRoleHandler(roleUseCase *usecase.RoleUseCase) *RoleHandler {
+	return &RoleHandler{
+		roleUseCase: roleUseCase,
+	}
+}
+
+// ListPermissions godoc
+// @Summary      Listar permisos
+// @Description  Lista el catálogo de permisos que se pueden asignar a los roles
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "permissions retrieved successfully",
+		"data":    entities.Permissions,
+	})
+}
+
+// ListRoles godoc
+// @Summary      Listar roles
+// @Description  Lista los roles con sus permisos
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleUseCase.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "roles retrieved successfully",
+		"data":    roles,
+	})
+}
+
+// CreateRole godoc
+// @Summary      Crear rol
+// @Description  Crea un rol con los permisos indicados
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateRoleRequest true "Nombre, descripción y permisos del rol"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req usecase.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleUseCase.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "role created successfully",
+		"data":    role,
+	})
+}
+
+// SetRolePermissions godoc
+// @Summary      Reemplazar permisos de un rol
+// @Description  Reemplaza la lista de permisos de un rol
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del rol"
+// @Param        request body usecase.SetRolePermissionsRequest true "Permisos del rol"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/roles/{id}/permissions [put]
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	var req usecase.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.RoleID = c.Param("id")
+
+	role, err := h.roleUseCase.SetRolePermissions(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role permissions updated successfully",
+		"data":    role,
+	})
+}
+
+// DeleteRole godoc
+// @Summary      Eliminar rol
+// @Description  Elimina un rol que no es de sistema ni es el rol principal de ningún usuario
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del rol"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	if err := h.roleUseCase.DeleteRole(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role deleted successfully",
+	})
+}
+
+// ListUserRoles godoc
+// @Summary      Listar roles de un usuario
+// @Description  Lista los roles efectivos de un usuario: el principal y los asignados
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/roles [get]
+func (h *RoleHandler) ListUserRoles(c *gin.Context) {
+	roles, err := h.roleUseCase.ListUserRoles(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "user roles retrieved successfully",
+		"data":    roles,
+	})
+}
+
+// AssignRole godoc
+// @Summary      Asignar rol a un usuario
+// @Description  Asigna un rol adicional al usuario e invalida sus access tokens
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Param        role_id path string true "ID del rol"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/roles/{role_id} [put]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	if err := h.roleUseCase.AssignRole(c.Request.Context(), c.Param("id"), c.Param("role_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role assigned successfully",
+	})
+}
+
+// RemoveRole godoc
+// @Summary      Quitar rol a un usuario
+// @Description  Quita un rol adicional del usuario e invalida sus access tokens
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Param        role_id path string true "ID del rol"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/roles/{role_id} [delete]
+func (h *RoleHandler) RemoveRole(c *gin.Context) {
+	if err := h.roleUseCase.RemoveRole(c.Request.Context(), c.Param("id"), c.Param("role_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role removed successfully",
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceAccountHandler maneja las peticiones HTTP de administración de cuentas de servicio
+type ServiceAccountHandler struct {
+	serviceAccountUseCase *usecase.ServiceAccountUseCase
+}
+
+// NewServiceAccountHandler crea una nueva instancia de ServiceAccountHandler
+func NewServiceAccountHandler(serviceAccountUseCase *usecase.ServiceAccountUseCase) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		serviceAccountUseCase: serviceAccountUseCase,
+	}
+}
+
+// CreateServiceAccount godoc
+// @Summary      Crear cuenta de servicio
+// @Description  Registra un principal de máquina que obtiene tokens con el grant client_credentials. Con client_secret, el secreto solo se retorna en esta respuesta; con private_key_jwt se registra la clave pública en PEM
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateServiceAccountRequest true "Nombre, método de autenticación y scopes permitidos"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req usecase.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.CreatedBy = c.GetString("user_id")
+
+	response, err := h.serviceAccountUseCase.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "service account created successfully",
+		"data":    response,
+	})
+}
+
+// ListServiceAccounts godoc
+// @Summary      Listar cuentas de servicio
+// @Description  Lista las cuentas de servicio registradas
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/service-accounts [get]
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.serviceAccountUseCase.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "service accounts retrieved successfully",
+		"data":    accounts,
+	})
+}
+
+// GetServiceAccount godoc
+// @Summary      Obtener cuenta de servicio
+// @Description  Obtiene una cuenta de servicio
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la cuenta de servicio"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/service-accounts/{id} [get]
+func (h *ServiceAccountHandler) GetServiceAccount(c *gin.Context) {
+	account, err := h.serviceAccountUseCase.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "service account retrieved successfully",
+		"data":    account,
+	})
+}
+
+// UpdateServiceAccount godoc
+// @Summary      Actualizar cuenta de servicio
+// @Description  Modifica el nombre, la descripción, los scopes permitidos, la clave pública o el estado de la cuenta. Deshabilitarla impide emitirle tokens nuevos
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path string                              true "ID de la cuenta de servicio"
+// @Param        request body usecase.UpdateServiceAccountRequest true "Campos a modificar"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/service-accounts/{id} [put]
+func (h *ServiceAccountHandler) UpdateServiceAccount(c *gin.Context) {
+	var req usecase.UpdateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = c.Param("id")
+	req.UpdatedBy = c.GetString("user_id")
+
+	account, err := h.serviceAccountUseCase.Update(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "service account updated successfully",
+		"data":    account,
+	})
+}
+
+// RotateServiceAccountSecret godoc
+// @Summary      Rotar secreto de cuenta de servicio
+// @Description  Genera un secreto nuevo para una cuenta client_secret; el anterior deja de ser válido. El secreto solo se retorna en esta respuesta
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la cuenta de servicio"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/service-accounts/{id}/rotate-secret [post]
+func (h *ServiceAccountHandler) RotateServiceAccountSecret(c *gin.Context) {
+	response, err := h.serviceAccountUseCase.RotateSecret(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "service account secret rotated successfully",
+		"data":    response,
+	})
+}
+
+// DeleteServiceAccount godoc
+// @Summary      Eliminar cuenta de servicio
+// @Description  Elimina la cuenta de servicio; los access tokens ya emitidos siguen siendo válidos hasta expirar
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la cuenta de servicio"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/service-accounts/{id} [delete]
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	if err := h.serviceAccountUseCase.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "service account deleted successfully",
+	})
+}
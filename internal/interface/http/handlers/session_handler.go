@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler maneja las peticiones HTTP de sesiones
+type SessionHandler struct {
+	sessionUseCase *usecase.SessionUseCase
+}
+
+// NewSessionHandler crea una nueva instancia de SessionHandler
+func NewSessionHandler(sessionUseCase *usecase.SessionUseCase) *SessionHandler {
+	return &SessionHandler{
+		sessionUseCase: sessionUseCase,
+	}
+}
+
+// ListSessions godoc
+// @Summary      Listar sesiones
+// @Description  Lista los dispositivos en los que el usuario autenticado tiene sesión iniciada
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	h.listSessions(c, userID.(string))
+}
+
+// RevokeSession godoc
+// @Summary      Cerrar sesión remota
+// @Description  Cierra una sesión del usuario autenticado en otro dispositivo
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la sesión"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /users/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	h.revokeSession(c, userID.(string), c.Param("id"))
+}
+
+// RevokeAllSessions godoc
+// @Summary      Cerrar todas las sesiones
+// @Description  Cierra la sesión del usuario autenticado en todos sus dispositivos
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/sessions [delete]
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	h.revokeAllSessions(c, userID.(string))
+}
+
+// ListUserSessions godoc
+// @Summary      Listar sesiones de un usuario
+// @Description  Lista las sesiones activas de un usuario (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	h.listSessions(c, c.Param("id"))
+}
+
+// RevokeUserSession godoc
+// @Summary      Cerrar sesión de un usuario
+// @Description  Cierra una sesión específica de un usuario (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path string true "ID del usuario"
+// @Param        session_id path string true "ID de la sesión"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/sessions/{session_id} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	h.revokeSession(c, c.Param("id"), c.Param("session_id"))
+}
+
+// RevokeAllUserSessions godoc
+// @Summary      Cerrar todas las sesiones de un usuario
+// @Description  Cierra la sesión de un usuario en todos sus dispositivos (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	h.revokeAllSessions(c, c.Param("id"))
+}
+
+func (h *SessionHandler) listSessions(c *gin.Context, userID string) {
+	req := &usecase.ListSessionsRequest{
+		UserID: userID,
+	}
+
+	sessions, err := h.sessionUseCase.ListSessions(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "sessions retrieved successfully",
+		"data":    sessions,
+	})
+}
+
+func (h *SessionHandler) revokeSession(c *gin.Context, userID, sessionID string) {
+	req := &usecase.RevokeSessionRequest{
+		UserID:    userID,
+		SessionID: sessionID,
+	}
+
+	err := h.sessionUseCase.RevokeSession(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "session revoked successfully",
+	})
+}
+
+func (h *SessionHandler) revokeAllSessions(c *gin.Context, userID string) {
+	req := &usecase.RevokeAllSessionsRequest{
+		UserID: userID,
+	}
+
+	err := h.sessionUseCase.RevokeAllSessions(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "all sessions revoked successfully",
+	})
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"auth-go-microservicio/internal/usecase"
+	"auth-go-microservicio/pkg/password"
 
 	"github.com/gin-gonic/gin"
 )
@@ -123,7 +125,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	err := h.userUseCase.ChangePassword(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, errorBody(err))
 		return
 	}
 
@@ -167,14 +169,16 @@ func (h *UserHandler) DeleteAccount(c *gin.Context) {
 
 // ListUsers godoc
 // @Summary      Listar usuarios
-// @Description  Lista todos los usuarios con paginación (solo para administradores)
+// @Description  Lista todos los usuarios con paginación, opcionalmente los de una organización (solo para administradores)
 // @Tags         admin
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        offset query int false "Offset para paginación" default(0)
 // @Param        limit  query int false "Límite de resultados" default(10)
+// @Param        org_id query string false "ID de la organización"
 // @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
 // @Failure      403  {object}  map[string]interface{}
 // @Router       /admin/users [get]
@@ -183,13 +187,14 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
 	req := &usecase.ListUsersRequest{
-		Offset: offset,
-		Limit:  limit,
+		OrganizationID: c.Query("org_id"),
+		Offset:         offset,
+		Limit:          limit,
 	}
 
 	response, err := h.userUseCase.ListUsers(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -230,7 +235,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	user, err := h.userUseCase.UpdateUser(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, errorBody(err))
 		return
 	}
 
@@ -274,3 +279,43 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		"message": "user deleted successfully",
 	})
 }
+
+// UnlockUser godoc
+// @Summary      Desbloquear usuario
+// @Description  Reinicia los intentos de login fallidos y quita el bloqueo temporal de la cuenta (solo para administradores)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del usuario"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	req := &usecase.UnlockUserRequest{
+		UserID: c.Param("id"),
+	}
+
+	if err := h.userUseCase.UnlockUser(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "user unlocked successfully",
+	})
+}
+
+// errorBody arma la respuesta de error; si la contraseña no cumple la política incluye
+// las reglas incumplidas con su código para que el frontend las muestre
+func errorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		body["violations"] = policyErr.Violations
+	}
+
+	return body
+}
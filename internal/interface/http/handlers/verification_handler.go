@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationHandler maneja las peticiones HTTP de verificación de email
+type VerificationHandler struct {
+	verificationUseCase *usecase.VerificationUseCase
+}
+
+// NewVerificationHandler crea una nueva instancia de VerificationHandler
+func NewVerificationHandler(verificationUseCase *usecase.VerificationUseCase) *VerificationHandler {
+	return &VerificationHandler{
+		verificationUseCase: verificationUseCase,
+	}
+}
+
+// VerifyEmail godoc
+// @Summary      Verificar email
+// @Description  Marca el email del usuario como verificado usando el token recibido por email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.VerifyEmailRequest true "Token de verificación"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/verify-email [post]
+func (h *VerificationHandler) VerifyEmail(c *gin.Context) {
+	var req usecase.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.verificationUseCase.VerifyEmail(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "email verified successfully",
+		"data":    user,
+	})
+}
+
+// ResendVerification godoc
+// @Summary      Reenviar email de verificación
+// @Description  Reenvía el email de verificación. Siempre responde 202 para no revelar qué cuentas existen
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.ResendVerificationRequest true "Email de la cuenta"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/resend-verification [post]
+func (h *VerificationHandler) ResendVerification(c *gin.Context) {
+	var req usecase.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.verificationUseCase.ResendVerification(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error sending verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "if the account exists and is not verified, a verification email has been sent",
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebAuthnHandler maneja las ceremonias WebAuthn de registro y login
+type WebAuthnHandler struct {
+	webauthnUseCase *usecase.WebAuthnUseCase
+	authUseCase     *usecase.AuthUseCase
+}
+
+// NewWebAuthnHandler crea una nueva instancia de WebAuthnHandler
+func NewWebAuthnHandler(webauthnUseCase *usecase.WebAuthnUseCase, authUseCase *usecase.AuthUseCase) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webauthnUseCase: webauthnUseCase,
+		authUseCase:     authUseCase,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary      Iniciar registro de passkey
+// @Description  Genera las opciones para navigator.credentials.create()
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/webauthn/register/begin [post]
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	req := &usecase.BeginRegistrationRequest{
+		UserID: userID.(string),
+	}
+
+	options, err := h.webauthnUseCase.BeginRegistration(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webauthn registration started",
+		"data":    options,
+	})
+}
+
+// FinishRegistration godoc
+// @Summary      Completar registro de passkey
+// @Description  Verifica la respuesta de navigator.credentials.create() y guarda la credencial
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.FinishRegistrationRequest true "Credencial serializada con toJSON()"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/webauthn/register/finish [post]
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.FinishRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.UserID = userID.(string)
+
+	credential, err := h.webauthnUseCase.FinishRegistration(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "webauthn credential registered successfully",
+		"data":    credential,
+	})
+}
+
+// ListCredentials godoc
+// @Summary      Listar passkeys
+// @Description  Lista las credenciales WebAuthn del usuario autenticado
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /users/webauthn/credentials [get]
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	credentials, err := h.webauthnUseCase.ListCredentials(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webauthn credentials retrieved successfully",
+		"data":    credentials,
+	})
+}
+
+// DeleteCredential godoc
+// @Summary      Eliminar passkey
+// @Description  Elimina una credencial WebAuthn del usuario autenticado
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID de la credencial"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /users/webauthn/credentials/{id} [delete]
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.webauthnUseCase.DeleteCredential(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webauthn credential deleted successfully",
+	})
+}
+
+// BeginLogin godoc
+// @Summary      Iniciar login con passkey
+// @Description  Genera las opciones para navigator.credentials.get(). Con mfa_token se usa como segundo factor
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.BeginWebAuthnLoginRequest false "mfa_token o email opcionales"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /auth/webauthn/login/begin [post]
+func (h *WebAuthnHandler) BeginLogin(c *gin.Context) {
+	var req usecase.BeginWebAuthnLoginRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	options, err := h.authUseCase.BeginWebAuthnLogin(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webauthn login started",
+		"data":    options,
+	})
+}
+
+// FinishLogin godoc
+// @Summary      Completar login con passkey
+// @Description  Verifica la respuesta de navigator.credentials.get() y retorna los tokens de acceso
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body usecase.FinishWebAuthnLoginRequest true "Aserción serializada con toJSON()"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      429  {object}  map[string]interface{}
+// @Router       /auth/webauthn/login/finish [post]
+func (h *WebAuthnHandler) FinishLogin(c *gin.Context) {
+	var req usecase.FinishWebAuthnLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	response, err := h.authUseCase.FinishWebAuthnLogin(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(loginErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "login successful",
+		"data":    response,
+	})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"auth-go-microservicio/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler maneja las peticiones HTTP de administración de webhooks
+type WebhookHandler struct {
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+// NewWebhookHandler crea una nueva instancia de WebhookHandler
+func NewWebhookHandler(webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary      Crear webhook
+// @Description  Suscribe un endpoint HTTP a eventos de dominio. El secreto de firma solo se retorna en esta respuesta
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body usecase.CreateWebhookRequest true "URL, eventos y secreto opcional"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req usecase.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.CreatedBy = c.GetString("user_id")
+
+	response, err := h.webhookUseCase.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "webhook created successfully",
+		"data":    response,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary      Listar webhooks
+// @Description  Lista las suscripciones de webhooks
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookUseCase.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhooks retrieved successfully",
+		"data":    subscriptions,
+	})
+}
+
+// GetWebhook godoc
+// @Summary      Obtener webhook
+// @Description  Obtiene una suscripción de webhook
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del webhook"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, err := h.webhookUseCase.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook retrieved successfully",
+		"data":    subscription,
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary      Actualizar webhook
+// @Description  Modifica la URL, la descripción, los eventos o el estado de la suscripción, o rota su secreto
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path string                       true "ID del webhook"
+// @Param        request body usecase.UpdateWebhookRequest true "Campos a modificar"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req usecase.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = c.Param("id")
+
+	response, err := h.webhookUseCase.UpdateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook updated successfully",
+		"data":    response,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary      Eliminar webhook
+// @Description  Elimina la suscripción y su historial de entregas
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "ID del webhook"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookUseCase.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook deleted successfully",
+	})
+}
+
+// ListDeliveries godoc
+// @Summary      Historial de entregas
+// @Description  Lista las entregas del webhook, de la más reciente a la más antigua
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path  string true  "ID del webhook"
+// @Param        status query string false "pending, succeeded o failed"
+// @Param        offset query int    false "Offset para paginación" default(0)
+// @Param        limit  query int    false "Límite de resultados" default(20)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.webhookUseCase.ListDeliveries(c.Request.Context(), &usecase.ListWebhookDeliveriesRequest{
+		SubscriptionID: c.Param("id"),
+		Status:         c.Query("status"),
+		Offset:         offset,
+		Limit:          limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "deliveries retrieved successfully",
+		"data":    response,
+	})
+}
+
+// GetDelivery godoc
+// @Summary      Obtener entrega
+// @Description  Obtiene una entrega con todos sus intentos, incluidos el código y el cuerpo de cada respuesta
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path string true "ID del webhook"
+// @Param        delivery_id path string true "ID de la entrega"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	response, err := h.webhookUseCase.GetDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "delivery retrieved successfully",
+		"data":    response,
+	})
+}
+
+// RedeliverDelivery godoc
+// @Summary      Reenviar entrega
+// @Description  Vuelve a encolar la entrega con los intentos reiniciados; el historial se conserva
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path string true "ID del webhook"
+// @Param        delivery_id path string true "ID de la entrega"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverDelivery(c *gin.Context) {
+	if err := h.webhookUseCase.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "delivery queued for redelivery",
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WellKnownHandler expone los documentos públicos bajo /.well-known
+type WellKnownHandler struct {
+	jwtService jwt.Service
+}
+
+// NewWellKnownHandler crea una nueva instancia de WellKnownHandler
+func NewWellKnownHandler(jwtService jwt.Service) *WellKnownHandler {
+	return &WellKnownHandler{
+		jwtService: jwtService,
+	}
+}
+
+// JWKS retorna las claves públicas con las que se firman los tokens locales.
+// Con claves HS256 el conjunto está vacío porque el secreto no se puede publicar.
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
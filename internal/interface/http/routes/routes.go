@@ -2,6 +2,7 @@ package routes
 
 import (
 	"auth-go-microservicio/configs"
+	"auth-go-microservicio/internal/domain/entities"
 	"auth-go-microservicio/internal/interface/http/handlers"
 	"auth-go-microservicio/pkg/middleware"
 
@@ -18,6 +19,21 @@ import (
 func SetupRoutes(
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
+	sessionHandler *handlers.SessionHandler,
+	verificationHandler *handlers.VerificationHandler,
+	passwordResetHandler *handlers.PasswordResetHandler,
+	mfaHandler *handlers.MFAHandler,
+	webauthnHandler *handlers.WebAuthnHandler,
+	wellKnownHandler *handlers.WellKnownHandler,
+	keyHandler *handlers.KeyHandler,
+	roleHandler *handlers.RoleHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	invitationHandler *handlers.InvitationHandler,
+	auditHandler *handlers.AuditHandler,
+	webhookHandler *handlers.WebhookHandler,
+	oauthHandler *handlers.OAuthHandler,
+	serviceAccountHandler *handlers.ServiceAccountHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
 	keycloakHandler *handlers.KeycloakHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	keycloakMiddleware *middleware.KeycloakMiddleware,
@@ -32,9 +48,25 @@ func SetupRoutes(
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// IP y user agent para el log de auditoría
+	router.Use(middleware.AuditContext())
+
 	// Swagger UI
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Claves públicas para verificar tokens locales
+	router.GET("/.well-known/jwks.json", wellKnownHandler.JWKS)
+
+	// Servidor de autorización OAuth 2.0 y proveedor OpenID Connect (públicas; los endpoints de
+	// tokens, introspección y revocación autentican al cliente y userinfo requiere su access token)
+	router.GET("/.well-known/openid-configuration", oauthHandler.OpenIDConfiguration)
+	router.GET("/oauth/authorize", oauthHandler.Authorize)
+	router.POST("/oauth/token", oauthHandler.Token)
+	router.POST("/oauth/introspect", oauthHandler.Introspect)
+	router.POST("/oauth/revoke", oauthHandler.Revoke)
+	router.GET("/oauth/userinfo", authMiddleware.Authenticate(), oauthHandler.UserInfo)
+	router.POST("/oauth/userinfo", authMiddleware.Authenticate(), oauthHandler.UserInfo)
+
 	// API v1
 	v1 := router.Group("/api/v1")
 	{
@@ -42,30 +74,151 @@ func SetupRoutes(
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
-			auth.POST("/register-admin", authHandler.RegisterAdmin)
+			auth.POST("/accept-invite", invitationHandler.AcceptInvitation)
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/switch-organization", authHandler.SwitchOrganization)
 			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/verify-email", verificationHandler.VerifyEmail)
+			auth.POST("/resend-verification", verificationHandler.ResendVerification)
+			auth.POST("/forgot-password", passwordResetHandler.ForgotPassword)
+			auth.POST("/reset-password", passwordResetHandler.ResetPassword)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA)
+			auth.POST("/webauthn/login/begin", webauthnHandler.BeginLogin)
+			auth.POST("/webauthn/login/finish", webauthnHandler.FinishLogin)
 		}
 
-		// Rutas de usuario (requieren autenticación)
+		// Rutas de usuario (requieren la sesión propia del usuario; no aceptan API keys ni tokens
+		// emitidos a clientes OAuth)
 		users := v1.Group("/users")
-		users.Use(authMiddleware.Authenticate())
+		users.Use(authMiddleware.Authenticate(), authMiddleware.RequireFirstParty())
 		{
 			users.GET("/profile", userHandler.GetProfile)
 			users.PUT("/profile", userHandler.UpdateProfile)
 			users.DELETE("/profile", userHandler.DeleteAccount)
 			users.PUT("/change-password", userHandler.ChangePassword)
+
+			// Sesiones del usuario en sus dispositivos
+			users.GET("/sessions", sessionHandler.ListSessions)
+			users.DELETE("/sessions", sessionHandler.RevokeAllSessions)
+			users.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+
+			// Segundo factor TOTP
+			users.POST("/mfa/totp/setup", mfaHandler.SetupTOTP)
+			users.POST("/mfa/totp/verify", mfaHandler.ConfirmTOTP)
+
+			// Passkeys y llaves de seguridad WebAuthn
+			users.POST("/webauthn/register/begin", webauthnHandler.BeginRegistration)
+			users.POST("/webauthn/register/finish", webauthnHandler.FinishRegistration)
+			users.GET("/webauthn/credentials", webauthnHandler.ListCredentials)
+			users.DELETE("/webauthn/credentials/:id", webauthnHandler.DeleteCredential)
+
+			// API keys personales
+			users.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+			users.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+			users.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+			// Organizaciones del usuario
+			users.GET("/orgs", organizationHandler.ListMyOrganizations)
+		}
+
+		// Consentimiento OAuth, lo usa la página de consentimiento del frontend con la sesión propia del
+		// usuario (no acepta API keys ni tokens emitidos a clientes OAuth)
+		oauth := v1.Group("/oauth")
+		oauth.Use(authMiddleware.Authenticate(), authMiddleware.RequireFirstParty())
+		{
+			oauth.GET("/consent", oauthHandler.GetConsent)
+			oauth.POST("/consent", oauthHandler.Consent)
+		}
+
+		// Rutas de una organización (requieren ser miembro; cada ruta requiere su permiso en la organización)
+		orgCan := func(permission entities.Permission) gin.HandlerFunc {
+			return authMiddleware.RequireOrgPermission(string(permission))
+		}
+		orgs := v1.Group("/orgs/:org_id")
+		orgs.Use(authMiddleware.Authenticate(), authMiddleware.RequireOrgMember())
+		{
+			orgs.GET("", organizationHandler.GetOrganization)
+			orgs.GET("/members", orgCan(entities.PermissionMembersRead), organizationHandler.ListMembers)
+			orgs.POST("/members", orgCan(entities.PermissionMembersWrite), organizationHandler.AddMember)
+			orgs.PUT("/members/:user_id", orgCan(entities.PermissionMembersWrite), organizationHandler.UpdateMember)
+			orgs.DELETE("/members/:user_id", orgCan(entities.PermissionMembersWrite), organizationHandler.RemoveMember)
+			orgs.GET("/invitations", orgCan(entities.PermissionMembersWrite), invitationHandler.ListInvitations)
+			orgs.POST("/invitations", orgCan(entities.PermissionMembersWrite), invitationHandler.CreateInvitation)
+			orgs.DELETE("/invitations/:id", orgCan(entities.PermissionMembersWrite), invitationHandler.RevokeInvitation)
 		}
 
-		// Rutas de administración (requieren rol de admin)
+		// Rutas de administración (cada ruta requiere su permiso; no aceptan tokens emitidos a clientes
+		// OAuth en nombre del usuario)
+		can := func(permission entities.Permission) gin.HandlerFunc {
+			return authMiddleware.RequirePermission(string(permission))
+		}
 		admin := v1.Group("/admin")
-		admin.Use(authMiddleware.Authenticate())
-		admin.Use(authMiddleware.RequireRole("admin"))
+		admin.Use(authMiddleware.Authenticate(), authMiddleware.RejectDelegated())
 		{
-			admin.GET("/users", userHandler.ListUsers)
-			admin.PUT("/users/:id", userHandler.UpdateUser)
-			admin.DELETE("/users/:id", userHandler.DeleteUser)
+			admin.GET("/users", can(entities.PermissionUsersRead), userHandler.ListUsers)
+			admin.PUT("/users/:id", can(entities.PermissionUsersWrite), userHandler.UpdateUser)
+			admin.DELETE("/users/:id", can(entities.PermissionUsersDelete), userHandler.DeleteUser)
+			admin.POST("/users/:id/unlock", can(entities.PermissionUsersWrite), userHandler.UnlockUser)
+			admin.GET("/users/:id/sessions", can(entities.PermissionSessionsRead), sessionHandler.ListUserSessions)
+			admin.DELETE("/users/:id/sessions", can(entities.PermissionSessionsRevoke), sessionHandler.RevokeAllUserSessions)
+			admin.DELETE("/users/:id/sessions/:session_id", can(entities.PermissionSessionsRevoke), sessionHandler.RevokeUserSession)
+			admin.DELETE("/users/:id/mfa", can(entities.PermissionMFAReset), mfaHandler.ResetUserMFA)
+
+			// Gestión de claves de firma JWT
+			admin.GET("/keys", can(entities.PermissionKeysRead), keyHandler.ListKeys)
+			admin.POST("/keys/rotate", can(entities.PermissionKeysWrite), keyHandler.RotateKey)
+			admin.POST("/keys/:kid/promote", can(entities.PermissionKeysWrite), keyHandler.PromoteKey)
+
+			// Roles y permisos
+			admin.GET("/permissions", can(entities.PermissionRolesRead), roleHandler.ListPermissions)
+			admin.GET("/roles", can(entities.PermissionRolesRead), roleHandler.ListRoles)
+			admin.POST("/roles", can(entities.PermissionRolesWrite), roleHandler.CreateRole)
+			admin.PUT("/roles/:id/permissions", can(entities.PermissionRolesWrite), roleHandler.SetRolePermissions)
+			admin.DELETE("/roles/:id", can(entities.PermissionRolesWrite), roleHandler.DeleteRole)
+			admin.GET("/users/:id/roles", can(entities.PermissionRolesRead), roleHandler.ListUserRoles)
+			admin.PUT("/users/:id/roles/:role_id", can(entities.PermissionRolesAssign), roleHandler.AssignRole)
+			admin.DELETE("/users/:id/roles/:role_id", can(entities.PermissionRolesAssign), roleHandler.RemoveRole)
+
+			// Organizaciones (tenants)
+			admin.GET("/orgs", can(entities.PermissionOrgsRead), organizationHandler.ListOrganizations)
+			admin.POST("/orgs", can(entities.PermissionOrgsWrite), organizationHandler.CreateOrganization)
+			admin.DELETE("/orgs/:id", can(entities.PermissionOrgsWrite), organizationHandler.DeleteOrganization)
+
+			// Invitaciones
+			admin.GET("/invitations", can(entities.PermissionInvitesRead), invitationHandler.ListInvitations)
+			admin.POST("/invitations", can(entities.PermissionInvitesWrite), invitationHandler.CreateInvitation)
+			admin.DELETE("/invitations/:id", can(entities.PermissionInvitesWrite), invitationHandler.RevokeInvitation)
+
+			// Log de auditoría
+			admin.GET("/audit/events", can(entities.PermissionAuditRead), auditHandler.ListEvents)
+			admin.GET("/audit/events/export", can(entities.PermissionAuditRead), auditHandler.ExportEvents)
+			admin.GET("/audit/verify", can(entities.PermissionAuditRead), auditHandler.VerifyChain)
+
+			// Webhooks
+			admin.GET("/webhooks", can(entities.PermissionWebhooksRead), webhookHandler.ListWebhooks)
+			admin.POST("/webhooks", can(entities.PermissionWebhooksWrite), webhookHandler.CreateWebhook)
+			admin.GET("/webhooks/:id", can(entities.PermissionWebhooksRead), webhookHandler.GetWebhook)
+			admin.PUT("/webhooks/:id", can(entities.PermissionWebhooksWrite), webhookHandler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", can(entities.PermissionWebhooksWrite), webhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", can(entities.PermissionWebhooksRead), webhookHandler.ListDeliveries)
+			admin.GET("/webhooks/:id/deliveries/:delivery_id", can(entities.PermissionWebhooksRead), webhookHandler.GetDelivery)
+			admin.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", can(entities.PermissionWebhooksWrite), webhookHandler.RedeliverDelivery)
+
+			// Clientes OAuth
+			admin.GET("/oauth/clients", can(entities.PermissionClientsRead), oauthHandler.ListClients)
+			admin.POST("/oauth/clients", can(entities.PermissionClientsWrite), oauthHandler.CreateClient)
+			admin.GET("/oauth/clients/:id", can(entities.PermissionClientsRead), oauthHandler.GetClient)
+			admin.PUT("/oauth/clients/:id", can(entities.PermissionClientsWrite), oauthHandler.UpdateClient)
+			admin.DELETE("/oauth/clients/:id", can(entities.PermissionClientsWrite), oauthHandler.DeleteClient)
+
+			// Cuentas de servicio (grant client_credentials)
+			admin.GET("/service-accounts", can(entities.PermissionServiceAccountsRead), serviceAccountHandler.ListServiceAccounts)
+			admin.POST("/service-accounts", can(entities.PermissionServiceAccountsWrite), serviceAccountHandler.CreateServiceAccount)
+			admin.GET("/service-accounts/:id", can(entities.PermissionServiceAccountsRead), serviceAccountHandler.GetServiceAccount)
+			admin.PUT("/service-accounts/:id", can(entities.PermissionServiceAccountsWrite), serviceAccountHandler.UpdateServiceAccount)
+			admin.DELETE("/service-accounts/:id", can(entities.PermissionServiceAccountsWrite), serviceAccountHandler.DeleteServiceAccount)
+			admin.POST("/service-accounts/:id/rotate-secret", can(entities.PermissionServiceAccountsWrite), serviceAccountHandler.RotateServiceAccountSecret)
 		}
 
 		// Rutas de Keycloak (si está habilitado)
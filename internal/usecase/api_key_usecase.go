@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix identifica las API keys personales; el formato es ak_<prefijo>_<secreto>
+const apiKeyPrefix = "ak_"
+
+// apiKeyLastUsedInterval limita cada cuánto se registra el último uso de una clave
+const apiKeyLastUsedInterval = time.Minute
+
+var (
+	// ErrAPIKeysUnavailable indica que las API keys no están disponibles con Keycloak
+	ErrAPIKeysUnavailable = errors.New("api keys are not supported with keycloak")
+
+	// errInvalidAPIKey es el error de autenticación con una clave inexistente, expirada o de un usuario inactivo
+	errInvalidAPIKey = errors.New("invalid api key")
+)
+
+// APIKeyUseCase administra las API keys personales de los usuarios y las autentica. Una clave
+// actúa como el usuario; si tiene scopes, sus permisos se limitan a los del usuario incluidos en ellos.
+type APIKeyUseCase struct {
+	apiKeyRepo  repositories.APIKeyRepository
+	userRepo    repositories.UserRepository
+	roleUC      *RoleUseCase
+	auditLogger *AuditLogger
+	maxPerUser  int
+	useKeycloak bool
+}
+
+// NewAPIKeyUseCase crea una nueva instancia de APIKeyUseCase
+func NewAPIKeyUseCase(
+	apiKeyRepo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	roleUC *RoleUseCase,
+	auditLogger *AuditLogger,
+	maxPerUser int,
+	useKeycloak bool,
+) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		roleUC:      roleUC,
+		auditLogger: auditLogger,
+		maxPerUser:  maxPerUser,
+		useKeycloak: useKeycloak,
+	}
+}
+
+// CreateAPIKeyRequest representa la solicitud de creación de una API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes"`     // permisos del catálogo; vacío concede todos los del usuario
+	ExpiresAt *time.Time `json:"expires_at"` // opcional; sin expiración la clave vale hasta revocarla
+	UserID    string     `json:"-"`
+}
+
+// CreateAPIKeyResponse representa la clave creada; Key solo se muestra en esta respuesta
+type CreateAPIKeyResponse struct {
+	*entities.APIKey
+	Key string `json:"key"`
+}
+
+// Create genera una API key para el usuario. Los scopes deben ser permisos que el usuario tiene.
+func (uc *APIKeyUseCase) Create(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	if uc.useKeycloak {
+		return nil, ErrAPIKeysUnavailable
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	scopes, err := parseScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) > 0 {
+		granted, err := uc.roleUC.UserPermissions(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, scope := range scopes {
+			if !entities.IsValidPermission(entities.Permission(scope)) {
+				return nil, errors.New("invalid scope: " + scope)
+			}
+			if !containsScope(granted, scope) {
+				return nil, errors.New("cannot grant a permission you do not have: " + scope)
+			}
+		}
+	}
+
+	if uc.maxPerUser > 0 {
+		count, err := uc.apiKeyRepo.CountByUserID(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= uc.maxPerUser {
+			return nil, errors.New("maximum number of api keys reached")
+		}
+	}
+
+	prefix, err := newAPIKeyPrefix()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + prefix + "_" + secret
+
+	apiKey := entities.NewAPIKey(userID, req.Name, prefix, hashToken(key), scopes, req.ExpiresAt)
+	if err := uc.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionAPIKeyCreated,
+		TargetID: req.UserID,
+		Metadata: map[string]interface{}{"api_key_id": apiKey.ID.String(), "name": apiKey.Name, "scopes": scopes},
+	})
+
+	return &CreateAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// List lista las API keys del usuario
+func (uc *APIKeyUseCase) List(ctx context.Context, userID string) ([]*entities.APIKey, error) {
+	return uc.apiKeyRepo.ListByUserID(ctx, userID)
+}
+
+// Revoke elimina una API key del usuario; deja de autenticar de inmediato
+func (uc *APIKeyUseCase) Revoke(ctx context.Context, userID, id string) error {
+	if err := uc.apiKeyRepo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionAPIKeyRevoked,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"api_key_id": id},
+	})
+	return nil
+}
+
+// AuthenticateAPIKey valida una API key y retorna los claims del usuario con los que el
+// middleware arma el contexto, igual que con un access token. ID es el de la clave y, si la
+// clave tiene scopes, Permissions son los permisos del usuario incluidos en ellos.
+func (uc *APIKeyUseCase) AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, error) {
+	if uc.useKeycloak {
+		return nil, ErrAPIKeysUnavailable
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, apiKeyPrefix), "_", 2)
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(parts) != 2 || parts[0] == "" {
+		return nil, errInvalidAPIKey
+	}
+
+	apiKey, err := uc.apiKeyRepo.GetByPrefix(ctx, parts[0])
+	if err != nil {
+		return nil, errInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(apiKey.KeyHash)) != 1 || apiKey.IsExpired() {
+		return nil, errInvalidAPIKey
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, apiKey.UserID.String())
+	if err != nil || !user.IsActive {
+		return nil, errInvalidAPIKey
+	}
+
+	roles, permissions, err := uc.roleUC.Authorization(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &jwt.Claims{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Role:   string(user.Role),
+		Roles:  roles,
+	}
+	claims.ID = apiKey.ID.String()
+	if len(apiKey.Scopes) > 0 {
+		claims.Scope = strings.Join(apiKey.Scopes, " ")
+		claims.Permissions = []string{}
+		for _, permission := range permissions {
+			if containsScope(apiKey.Scopes, permission) {
+				claims.Permissions = append(claims.Permissions, permission)
+			}
+		}
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := uc.apiKeyRepo.UpdateLastUsed(ctx, apiKey.ID.String(), now); err != nil {
+			log.Printf("Error updating last use of api key %s: %v", apiKey.ID, err)
+		}
+	}
+
+	return claims, nil
+}
+
+// newAPIKeyPrefix genera el prefijo público de una API key
+func newAPIKeyPrefix() (string, error) {
+	raw := make([]byte, 6)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
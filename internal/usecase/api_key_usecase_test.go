@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newTestAPIKeyUseCase crea un APIKeyUseCase para un usuario con permisos de lectura y borrado de usuarios
+func newTestAPIKeyUseCase(t *testing.T) (*APIKeyUseCase, *memoryAPIKeyRepo, *entities.User) {
+	t.Helper()
+	store := newMemoryStore()
+	user := newTestUser(store)
+	roleRepo := &memoryRoleRepo{roles: []*entities.RoleDefinition{
+		entities.NewRoleDefinition("support", "", []entities.Permission{entities.PermissionUsersRead, entities.PermissionUsersDelete}),
+	}}
+	roleUC := NewRoleUseCase(roleRepo, &memoryUserRepo{store: store}, nil, nil, time.Minute)
+	apiKeyRepo := newMemoryAPIKeyRepo()
+	return NewAPIKeyUseCase(apiKeyRepo, &memoryUserRepo{store: store}, roleUC, nil, 0, false), apiKeyRepo, user
+}
+
+// newAPIKeyRouter arma rutas de administración autenticadas con el middleware y el caso de uso
+func newAPIKeyRouter(uc *APIKeyUseCase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := middleware.NewAuthMiddleware(nil, nil, uc.roleUC, nil, uc, nil, false)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	admin := router.Group("/admin", m.Authenticate(), m.RejectDelegated())
+	admin.GET("/users", m.RequirePermission(string(entities.PermissionUsersRead)), ok)
+	admin.DELETE("/users/:id", m.RequirePermission(string(entities.PermissionUsersDelete)), ok)
+	return router
+}
+
+// callWithKey ejecuta la petición autenticada con la API key y retorna el código de respuesta
+func callWithKey(router *gin.Engine, method, path, key string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// createKey crea una API key para el usuario con los scopes indicados
+func createKey(t *testing.T, uc *APIKeyUseCase, user *entities.User, scopes ...string) *CreateAPIKeyResponse {
+	t.Helper()
+	response, err := uc.Create(context.Background(), &CreateAPIKeyRequest{Name: "cli", Scopes: scopes, UserID: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestAPIKeyScopesLimitPermissions(t *testing.T) {
+	uc, _, user := newTestAPIKeyUseCase(t)
+	router := newAPIKeyRouter(uc)
+
+	scoped := createKey(t, uc, user, string(entities.PermissionUsersRead))
+	if code := callWithKey(router, http.MethodGet, "/admin/users", scoped.Key); code != http.StatusNoContent {
+		t.Errorf("scoped permission was rejected: %d", code)
+	}
+	if code := callWithKey(router, http.MethodDelete, "/admin/users/1", scoped.Key); code != http.StatusForbidden {
+		t.Errorf("permission outside the key scopes was allowed: %d", code)
+	}
+
+	// Sin scopes la clave tiene todos los permisos del usuario
+	unscoped := createKey(t, uc, user)
+	if code := callWithKey(router, http.MethodDelete, "/admin/users/1", unscoped.Key); code != http.StatusNoContent {
+		t.Errorf("unscoped key was rejected: %d", code)
+	}
+}
+
+func TestCreateAPIKeyRejectsScopesTheUserDoesNotHave(t *testing.T) {
+	uc, _, user := newTestAPIKeyUseCase(t)
+
+	_, err := uc.Create(context.Background(), &CreateAPIKeyRequest{
+		Name:   "cli",
+		Scopes: []string{string(entities.PermissionRolesWrite)},
+		UserID: user.ID.String(),
+	})
+	if err == nil {
+		t.Fatal("key was created with a permission the user does not have")
+	}
+}
+
+func TestExpiredAPIKeyIsRejected(t *testing.T) {
+	uc, apiKeyRepo, user := newTestAPIKeyUseCase(t)
+	router := newAPIKeyRouter(uc)
+	key := createKey(t, uc, user)
+
+	apiKeyRepo.expire(key.ID)
+
+	if code := callWithKey(router, http.MethodGet, "/admin/users", key.Key); code != http.StatusUnauthorized {
+		t.Errorf("expired key was accepted: %d", code)
+	}
+}
+
+func TestRevokedAPIKeyIsRejected(t *testing.T) {
+	uc, _, user := newTestAPIKeyUseCase(t)
+	router := newAPIKeyRouter(uc)
+	key := createKey(t, uc, user)
+
+	if code := callWithKey(router, http.MethodGet, "/admin/users", key.Key); code != http.StatusNoContent {
+		t.Fatalf("key was rejected before revoking it: %d", code)
+	}
+	if err := uc.Revoke(context.Background(), user.ID.String(), key.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := callWithKey(router, http.MethodGet, "/admin/users", key.Key); code != http.StatusUnauthorized {
+		t.Errorf("revoked key was accepted: %d", code)
+	}
+}
+
+func TestAPIKeyWithWrongSecretIsRejected(t *testing.T) {
+	uc, _, user := newTestAPIKeyUseCase(t)
+	router := newAPIKeyRouter(uc)
+	key := createKey(t, uc, user)
+
+	forged := apiKeyPrefix + key.Prefix + "_" + uuid.NewString()
+	if code := callWithKey(router, http.MethodGet, "/admin/users", forged); code != http.StatusUnauthorized {
+		t.Errorf("key with a wrong secret was accepted: %d", code)
+	}
+}
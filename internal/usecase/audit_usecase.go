@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/audit"
+)
+
+// AuditLogger registra los eventos de autenticación y administración en el log de auditoría.
+// Un error al escribir no interrumpe la operación auditada: se informa en el log del proceso.
+type AuditLogger struct {
+	auditRepo repositories.AuditRepository
+}
+
+// NewAuditLogger crea una nueva instancia de AuditLogger
+func NewAuditLogger(auditRepo repositories.AuditRepository) *AuditLogger {
+	return &AuditLogger{auditRepo: auditRepo}
+}
+
+// AuditEntry representa un evento a registrar. Los campos vacíos de actor, IP y user agent
+// se completan con los datos de la petición guardados en el contexto.
+type AuditEntry struct {
+	Action    entities.AuditAction
+	Outcome   entities.AuditOutcome // por defecto success
+	ActorID   string
+	TargetID  string
+	IPAddress string
+	UserAgent string
+	Metadata  map[string]interface{}
+}
+
+// Log registra el evento
+func (l *AuditLogger) Log(ctx context.Context, entry AuditEntry) {
+	if l == nil {
+		return
+	}
+
+	request := audit.FromContext(ctx)
+	if entry.ActorID == "" {
+		entry.ActorID = request.ActorID
+	}
+	if entry.IPAddress == "" {
+		entry.IPAddress = request.IPAddress
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent = request.UserAgent
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = entities.AuditOutcomeSuccess
+	}
+
+	var metadata json.RawMessage
+	if len(entry.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(entry.Metadata); err != nil {
+			log.Printf("Error encoding audit metadata for %s: %v", entry.Action, err)
+		}
+	}
+
+	event := entities.NewAuditEvent(entry.Action, entry.Outcome, entry.ActorID, entry.TargetID, metadata)
+	event.IPAddress = entry.IPAddress
+	event.UserAgent = entry.UserAgent
+
+	// El evento se registra aunque el cliente haya cancelado la petición
+	if err := l.auditRepo.Append(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Error writing audit event %s actor_id=%s target_id=%s outcome=%s: %v",
+			entry.Action, entry.ActorID, entry.TargetID, entry.Outcome, err)
+	}
+}
+
+// AuditUseCase consulta, exporta y verifica el log de auditoría
+type AuditUseCase struct {
+	auditRepo repositories.AuditRepository
+}
+
+// NewAuditUseCase crea una nueva instancia de AuditUseCase
+func NewAuditUseCase(auditRepo repositories.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{auditRepo: auditRepo}
+}
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// ListAuditEventsRequest representa la solicitud para consultar el log de auditoría
+type ListAuditEventsRequest struct {
+	UserID string     `json:"user_id"` // actor o destinatario
+	Action string     `json:"action"`
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+	Cursor string     `json:"cursor"` // next_cursor de la página anterior
+	Limit  int        `json:"limit"`
+}
+
+// ListAuditEventsResponse representa una página del log de auditoría
+type ListAuditEventsResponse struct {
+	Events     []*entities.AuditEvent `json:"events"`
+	NextCursor string                 `json:"next_cursor,omitempty"` // vacío en la última página
+}
+
+// ListEvents obtiene una página de eventos, del más reciente al más antiguo
+func (uc *AuditUseCase) ListEvents(ctx context.Context, req *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	filter, err := auditFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != "" {
+		before, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || before <= 0 {
+			return nil, errors.New("invalid cursor")
+		}
+		filter.Before = before
+	}
+
+	filter.Limit = req.Limit
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+
+	events, err := uc.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListAuditEventsResponse{Events: events}
+	if len(events) == filter.Limit {
+		response.NextCursor = strconv.FormatInt(events[len(events)-1].Sequence, 10)
+	}
+
+	return response, nil
+}
+
+// ExportEvents escribe los eventos que cumplen el filtro en formato JSON Lines, en orden de secuencia
+func (uc *AuditUseCase) ExportEvents(ctx context.Context, req *ListAuditEventsRequest, w io.Writer) error {
+	filter, err := auditFilter(req)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	return uc.auditRepo.Walk(ctx, filter, func(event *entities.AuditEvent) error {
+		return encoder.Encode(event)
+	})
+}
+
+// VerifyChainResponse representa el resultado de verificar la cadena de hashes
+type VerifyChainResponse struct {
+	Valid    bool   `json:"valid"`
+	Events   int64  `json:"events"`              // entradas verificadas
+	BrokenAt int64  `json:"broken_at,omitempty"` // secuencia de la primera entrada inválida
+	Reason   string `json:"reason,omitempty"`
+}
+
+// errAuditChainBroken detiene el recorrido en la primera entrada inválida
+var errAuditChainBroken = errors.New("audit chain broken")
+
+// VerifyChain recalcula la cadena completa y reporta la primera entrada modificada, borrada o reordenada
+func (uc *AuditUseCase) VerifyChain(ctx context.Context) (*VerifyChainResponse, error) {
+	response := &VerifyChainResponse{Valid: true}
+	prevHash := ""
+
+	err := uc.auditRepo.Walk(ctx, &repositories.AuditFilter{}, func(event *entities.AuditEvent) error {
+		switch {
+		case event.Sequence != response.Events+1:
+			response.Reason = "missing entries before this sequence"
+		case event.PrevHash != prevHash:
+			response.Reason = "previous hash does not match"
+		case event.ComputeHash() != event.Hash:
+			response.Reason = "entry hash does not match its contents"
+		default:
+			response.Events++
+			prevHash = event.Hash
+			return nil
+		}
+
+		response.Valid = false
+		response.BrokenAt = event.Sequence
+		return errAuditChainBroken
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// auditFilter valida los filtros comunes a la consulta y la exportación
+func auditFilter(req *ListAuditEventsRequest) (*repositories.AuditFilter, error) {
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	return &repositories.AuditFilter{
+		UserID: req.UserID,
+		Action: req.Action,
+		From:   req.From,
+		To:     req.To,
+	}, nil
+}
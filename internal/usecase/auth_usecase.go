@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -15,19 +16,76 @@ import (
 	"auth-go-microservicio/pkg/jwt"
 	"auth-go-microservicio/pkg/keycloak"
 	"auth-go-microservicio/pkg/password"
+	"auth-go-microservicio/pkg/ratelimit"
+	"auth-go-microservicio/pkg/webauthn"
+
+	"github.com/google/uuid"
 )
 
 // AuthUseCase maneja la lógica de negocio para autenticación
 type AuthUseCase struct {
-	userRepo        repositories.UserRepository
-	tokenRepo       repositories.TokenRepository
-	jwtSvc          jwt.Service
-	passSvc         password.Service
-	keycloakService keycloak.Service
-	keycloakConfig  *KeycloakConfig
-	useKeycloak     bool
+	userRepo         repositories.UserRepository
+	tokenRepo        repositories.TokenRepository
+	sessionRepo      repositories.SessionRepository
+	uow              repositories.UnitOfWork // agrupa las escrituras del login y la renovación
+	revocationUC     *RevocationUseCase
+	verificationUC   *VerificationUseCase
+	mfaUC            *MFAUseCase
+	webauthnUC       *WebAuthnUseCase
+	roleUC           *RoleUseCase
+	orgUC            *OrganizationUseCase
+	jwtSvc           jwt.Service
+	passSvc          password.Service
+	passwordPolicyUC *PasswordPolicyUseCase
+	keycloakService  keycloak.Service
+	keycloakConfig   *KeycloakConfig
+	policy           AuthPolicy
+	auditLogger      *AuditLogger
+	useKeycloak      bool
+
+	// ipFailures limita los intentos fallidos por IP; dummyHash iguala el tiempo de respuesta
+	// cuando el email no existe
+	ipFailures *ratelimit.FailureCounter
+	dummyHash  string
+}
+
+// AuthPolicy agrupa las reglas configurables del flujo de autenticación
+type AuthPolicy struct {
+	// AllowSelfRegistration habilita el registro público; si es false las cuentas se crean por invitación
+	AllowSelfRegistration bool
+
+	// RequireEmailVerification bloquea el login local de cuentas sin email verificado
+	RequireEmailVerification bool
+
+	// EmbedPermissions incluye los permisos en el access token; si es false se resuelven en cada petición
+	EmbedPermissions bool
+
+	// LockoutThreshold es la cantidad de intentos fallidos que bloquea la cuenta (0 desactiva el bloqueo)
+	LockoutThreshold int
+	// LockoutDuration es el primer bloqueo; se duplica con cada fallo posterior hasta LockoutMaxDuration
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+
+	// IPMaxFailures es la cantidad de fallos por IP permitidos en IPWindow (0 desactiva el límite)
+	IPMaxFailures int
+	IPWindow      time.Duration
 }
 
+var (
+	// ErrAccountLocked indica que la cuenta está bloqueada temporalmente por intentos fallidos. Solo
+	// se retorna después de un primer factor válido; el login con contraseña no lo distingue.
+	ErrAccountLocked = errors.New("account temporarily locked due to too many failed attempts")
+	// ErrTooManyAttempts indica que la IP superó el límite de intentos fallidos
+	ErrTooManyAttempts = errors.New("too many failed attempts, try again later")
+	// ErrRegistrationDisabled indica que el registro público está deshabilitado
+	ErrRegistrationDisabled = errors.New("self-registration is disabled, an invitation is required")
+	// ErrNotOrganizationMember indica que el usuario no pertenece a la organización solicitada
+	ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+
+	// errRefreshTokenReused revierte la rotación de un refresh token que otra petición ya revocó
+	errRefreshTokenReused = errors.New("refresh token already rotated")
+)
+
 // KeycloakConfig configuración para Keycloak
 type KeycloakConfig struct {
 	BaseURL      string
@@ -40,43 +98,83 @@ type KeycloakConfig struct {
 func NewAuthUseCase(
 	userRepo repositories.UserRepository,
 	tokenRepo repositories.TokenRepository,
+	sessionRepo repositories.SessionRepository,
+	uow repositories.UnitOfWork,
+	revocationUC *RevocationUseCase,
+	verificationUC *VerificationUseCase,
+	mfaUC *MFAUseCase,
+	webauthnUC *WebAuthnUseCase,
+	roleUC *RoleUseCase,
+	orgUC *OrganizationUseCase,
 	jwtSvc jwt.Service,
 	passSvc password.Service,
+	passwordPolicyUC *PasswordPolicyUseCase,
 	keycloakService keycloak.Service,
 	keycloakConfig *KeycloakConfig,
+	policy AuthPolicy,
+	auditLogger *AuditLogger,
 ) *AuthUseCase {
 	// Determinar si usar Keycloak basado en la configuración
 	useKeycloak := keycloakService != nil && keycloakConfig != nil &&
 		keycloakConfig.BaseURL != "" && keycloakConfig.ClientID != "" && keycloakConfig.ClientSecret != ""
 
+	// Hash de referencia para verificar contraseñas de emails inexistentes con el mismo costo
+	dummyHash, err := passSvc.Hash(uuid.NewString())
+	if err != nil {
+		log.Printf("Error generating dummy password hash: %v", err)
+	}
+
 	return &AuthUseCase{
-		userRepo:        userRepo,
-		tokenRepo:       tokenRepo,
-		jwtSvc:          jwtSvc,
-		passSvc:         passSvc,
-		keycloakService: keycloakService,
-		keycloakConfig:  keycloakConfig,
-		useKeycloak:     useKeycloak,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		uow:              uow,
+		revocationUC:     revocationUC,
+		verificationUC:   verificationUC,
+		mfaUC:            mfaUC,
+		webauthnUC:       webauthnUC,
+		roleUC:           roleUC,
+		orgUC:            orgUC,
+		jwtSvc:           jwtSvc,
+		passSvc:          passSvc,
+		passwordPolicyUC: passwordPolicyUC,
+		keycloakService:  keycloakService,
+		keycloakConfig:   keycloakConfig,
+		policy:           policy,
+		auditLogger:      auditLogger,
+		useKeycloak:      useKeycloak,
+		ipFailures:       ratelimit.NewFailureCounter(policy.IPMaxFailures, policy.IPWindow),
+		dummyHash:        dummyHash,
 	}
 }
 
 // RegisterRequest representa la solicitud de registro
 type RegisterRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=8"`
+	Password  string `json:"password" binding:"required"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
-	Role      string `json:"role" binding:"omitempty,oneof=user admin moderator"`
 }
 
 // RegisterResponse representa la respuesta del registro
 type RegisterResponse struct {
 	User  *entities.User `json:"user"`
 	Token string         `json:"token"`
+
+	// EmailVerificationRequired indica que no se emitió token hasta verificar el email
+	EmailVerificationRequired bool `json:"email_verification_required,omitempty"`
 }
 
-// Register registra un nuevo usuario
+// Register registra un nuevo usuario con el rol user; los demás roles se otorgan por invitación
 func (uc *AuthUseCase) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+	if !uc.policy.AllowSelfRegistration {
+		return nil, ErrRegistrationDisabled
+	}
+
+	if err := uc.passwordPolicyUC.Validate(ctx, nil, req.Password, req.Email, req.FirstName, req.LastName); err != nil {
+		return nil, err
+	}
+
 	if uc.useKeycloak {
 		return uc.registerWithKeycloak(ctx, req)
 	}
@@ -85,21 +183,6 @@ func (uc *AuthUseCase) Register(ctx context.Context, req *RegisterRequest) (*Reg
 
 // registerWithKeycloak registra un usuario en Keycloak
 func (uc *AuthUseCase) registerWithKeycloak(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
-	// Determinar el rol del usuario
-	role := "user" // rol por defecto
-	if req.Role != "" {
-		switch req.Role {
-		case "admin":
-			role = "admin"
-		case "moderator":
-			role = "moderator"
-		case "user":
-			role = "user"
-		default:
-			return nil, errors.New("invalid role")
-		}
-	}
-
 	// Crear usuario en Keycloak
 	createUserReq := &keycloak.CreateUserRequest{
 		Username:      req.Email,
@@ -107,7 +190,7 @@ func (uc *AuthUseCase) registerWithKeycloak(ctx context.Context, req *RegisterRe
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
 		Enabled:       true,
-		EmailVerified: true,
+		EmailVerified: !uc.policy.RequireEmailVerification, // Keycloak envía el email si la verificación es obligatoria
 		Credentials: []*keycloak.Credential{
 			{
 				Type:      "password",
@@ -130,12 +213,17 @@ func (uc *AuthUseCase) registerWithKeycloak(ctx context.Context, req *RegisterRe
 
 	// Crear entidad de usuario local para respuesta
 	user := &entities.User{
-		Email:     req.Email,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Role:      entities.Role(role),
-		IsActive:  true,
+		Email:         req.Email,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Role:          entities.RoleUser,
+		IsActive:      true,
+		EmailVerified: !uc.policy.RequireEmailVerification,
 	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRegistered,
+		Metadata: map[string]interface{}{"email": req.Email, "provider": "keycloak"},
+	})
 
 	return &RegisterResponse{
 		User:  user,
@@ -160,31 +248,38 @@ func (uc *AuthUseCase) registerLocal(ctx context.Context, req *RegisterRequest)
 		return nil, err
 	}
 
-	// Determinar el rol del usuario
-	role := entities.RoleUser // rol por defecto
-	if req.Role != "" {
-		switch req.Role {
-		case "admin":
-			role = entities.RoleAdmin
-		case "moderator":
-			role = entities.RoleModerator
-		case "user":
-			role = entities.RoleUser
-		default:
-			return nil, errors.New("invalid role")
-		}
-	}
-
 	// Crear usuario
-	user := entities.NewUserWithRole(req.Email, hashedPassword, req.FirstName, req.LastName, role)
+	user := entities.NewUserWithRole(req.Email, hashedPassword, req.FirstName, req.LastName, entities.RoleUser)
+	user.RecordEvent(entities.EventUserRegistered, userRegisteredPayload(user, "self_registration"))
 
 	// Guardar en la base de datos
 	if err := uc.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	if err := uc.passwordPolicyUC.Record(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Error recording password history for user %s: %v", user.ID, err)
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRegistered,
+		ActorID:  user.ID.String(),
+		TargetID: user.ID.String(),
+		Metadata: map[string]interface{}{"email": user.Email},
+	})
+
+	// Enviar el email de verificación; si falla el usuario puede pedir el reenvío
+	if err := uc.verificationUC.SendVerification(ctx, user); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+	}
+
+	if uc.policy.RequireEmailVerification {
+		return &RegisterResponse{
+			User:                      user,
+			EmailVerificationRequired: true,
+		}, nil
+	}
 
-	// Generar token JWT
-	token, err := uc.jwtSvc.GenerateToken(user.ID.String(), user.Email, string(user.Role))
+	// Generar token JWT; el alta cuenta como un login con contraseña
+	token, err := uc.generateAccessToken(ctx, user, "", jwt.Authentication{Time: time.Now(), Methods: authMethods("password")})
 	if err != nil {
 		return nil, err
 	}
@@ -197,15 +292,23 @@ func (uc *AuthUseCase) registerLocal(ctx context.Context, req *RegisterRequest)
 
 // LoginRequest representa la solicitud de login
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name" binding:"omitempty,max=255"`
+	UserAgent  string `json:"-"`
+	IPAddress  string `json:"-"`
 }
 
 // LoginResponse representa la respuesta del login
 type LoginResponse struct {
-	User         *entities.User `json:"user"`
-	AccessToken  string         `json:"access_token"`
-	RefreshToken string         `json:"refresh_token"`
+	User         *entities.User `json:"user,omitempty"`
+	AccessToken  string         `json:"access_token,omitempty"`
+	RefreshToken string         `json:"refresh_token,omitempty"`
+
+	// MFARequired indica que MFAToken debe intercambiarse en /auth/mfa/verify junto con un código
+	MFARequired bool     `json:"mfa_required,omitempty"`
+	MFAToken    string   `json:"mfa_token,omitempty"`
+	MFAMethods  []string `json:"mfa_methods,omitempty"`
 }
 
 // Login autentica un usuario
@@ -221,6 +324,7 @@ func (uc *AuthUseCase) loginWithKeycloak(ctx context.Context, req *LoginRequest)
 	// Obtener token de acceso de Keycloak
 	accessToken, err := uc.getKeycloakToken(req.Email, req.Password)
 	if err != nil {
+		uc.auditLogin(ctx, "", req.Email, "keycloak", "invalid_credentials", req.IPAddress, req.UserAgent)
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -256,6 +360,7 @@ func (uc *AuthUseCase) loginWithKeycloak(ctx context.Context, req *LoginRequest)
 	// Para Keycloak, el refresh token se maneja automáticamente
 	// No necesitamos almacenarlo localmente
 	refreshToken := "" // Keycloak maneja esto internamente
+	uc.auditLogin(ctx, "", req.Email, "keycloak", "", req.IPAddress, req.UserAgent)
 
 	return &LoginResponse{
 		User:         user,
@@ -264,52 +369,267 @@ func (uc *AuthUseCase) loginWithKeycloak(ctx context.Context, req *LoginRequest)
 	}, nil
 }
 
-// loginLocal autentica un usuario usando la base de datos local
+// loginLocal autentica un usuario usando la base de datos local. Un email inexistente y una
+// contraseña incorrecta recorren el mismo camino para que no se distingan por la respuesta ni por el tiempo.
 func (uc *AuthUseCase) loginLocal(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	if blocked, _ := uc.ipFailures.Blocked(req.IPAddress); blocked {
+		uc.auditLogin(ctx, "", req.Email, "password", "ip_throttled", req.IPAddress, req.UserAgent)
+		return nil, ErrTooManyAttempts
+	}
+
 	// Obtener usuario por email
 	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
+		uc.passSvc.Verify(req.Password, uc.dummyHash)
+		uc.ipFailures.Fail(req.IPAddress)
+		uc.auditLogin(ctx, "", req.Email, "password", "unknown_email", req.IPAddress, req.UserAgent)
+		return nil, errors.New("invalid credentials")
+	}
+	userID := user.ID.String()
+
+	// Una cuenta bloqueada responde igual que un email inexistente: un error distinto revelaría
+	// que la cuenta existe. El motivo real queda en el log de auditoría.
+	if user.IsLocked(time.Now()) {
+		uc.passSvc.Verify(req.Password, uc.dummyHash)
+		uc.ipFailures.Fail(req.IPAddress)
+		uc.auditLogin(ctx, userID, req.Email, "password", "account_locked", req.IPAddress, req.UserAgent)
+		return nil, errors.New("invalid credentials")
+	}
+
+	// Verificar contraseña antes que el estado de la cuenta para no revelar qué cuentas existen
+	if !uc.passSvc.Verify(req.Password, user.Password) {
+		uc.ipFailures.Fail(req.IPAddress)
+		uc.auditLogin(ctx, userID, req.Email, "password", "invalid_password", req.IPAddress, req.UserAgent)
+		uc.recordFailedLogin(ctx, userID)
 		return nil, errors.New("invalid credentials")
 	}
 
 	// Verificar si el usuario está activo
 	if !user.IsActive {
+		uc.auditLogin(ctx, userID, req.Email, "password", "account_deactivated", req.IPAddress, req.UserAgent)
 		return nil, errors.New("user account is deactivated")
 	}
 
-	// Verificar contraseña
-	if !uc.passSvc.Verify(req.Password, user.Password) {
-		return nil, errors.New("invalid credentials")
+	// Migrar el hash al algoritmo y los parámetros actuales sin pedir un cambio de contraseña
+	if uc.passSvc.NeedsRehash(user.Password) {
+		uc.rehashPassword(ctx, user, req.Password)
 	}
 
-	// Actualizar último login
-	user.UpdateLastLogin()
-	if err := uc.userRepo.Update(ctx, user); err != nil {
+	// Verificar el email después de la contraseña para no revelar qué cuentas existen
+	if uc.policy.RequireEmailVerification && !user.EmailVerified {
+		uc.auditLogin(ctx, userID, req.Email, "password", "email_not_verified", req.IPAddress, req.UserAgent)
+		return nil, errors.New("email not verified")
+	}
+
+	// Con un segundo factor activo solo se emite el token de desafío
+	mfaMethods, err := uc.mfaUC.Methods(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(mfaMethods) > 0 {
+		mfaToken, err := uc.mfaUC.CreateChallenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			MFAMethods:  mfaMethods,
+		}, nil
+	}
+
+	return uc.issueTokens(ctx, user, "password", req.DeviceName, req.UserAgent, req.IPAddress)
+}
+
+// VerifyMFA completa el login de un usuario con segundo factor y emite los tokens
+func (uc *AuthUseCase) VerifyMFA(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("mfa is handled by Keycloak")
+	}
+
+	if blocked, _ := uc.ipFailures.Blocked(req.IPAddress); blocked {
+		return nil, ErrTooManyAttempts
+	}
+
+	// Los códigos fallidos cuentan para el bloqueo de la cuenta igual que las contraseñas
+	userID, err := uc.mfaUC.ValidateChallenge(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+	if pending.IsLocked(time.Now()) {
+		uc.auditLogin(ctx, userID, pending.Email, "mfa", "account_locked", req.IPAddress, req.UserAgent)
+		return nil, ErrAccountLocked
+	}
+
+	user, err := uc.mfaUC.VerifyChallenge(ctx, req)
+	if err != nil {
+		uc.ipFailures.Fail(req.IPAddress)
+		uc.recordFailedLogin(ctx, userID)
+		return nil, err
+	}
+
+	if !user.IsActive {
+		uc.auditLogin(ctx, userID, user.Email, "mfa", "account_deactivated", req.IPAddress, req.UserAgent)
+		return nil, errors.New("user account is deactivated")
+	}
+
+	return uc.issueTokens(ctx, user, "mfa", req.DeviceName, req.UserAgent, req.IPAddress)
+}
+
+// BeginWebAuthnLoginRequest representa el inicio del login con WebAuthn.
+// Con MFAToken la credencial se usa como segundo factor; sin él, como credencial primaria.
+type BeginWebAuthnLoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Email    string `json:"email" binding:"omitempty,email"`
+}
+
+// BeginWebAuthnLogin genera las opciones para navigator.credentials.get()
+func (uc *AuthUseCase) BeginWebAuthnLogin(ctx context.Context, req *BeginWebAuthnLoginRequest) (*webauthn.RequestOptions, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("webauthn is handled by Keycloak")
+	}
+
+	if req.MFAToken != "" {
+		userID, err := uc.mfaUC.ValidateChallenge(req.MFAToken)
+		if err != nil {
+			return nil, err
+		}
+		parsedUserID, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, errors.New("invalid or expired mfa token")
+		}
+		return uc.webauthnUC.BeginLogin(ctx, &parsedUserID, entities.WebAuthnCeremonyMFA)
+	}
+
+	// Un email desconocido recibe las mismas opciones que el login sin usuario para no revelar qué cuentas existen
+	var userID *uuid.UUID
+	if req.Email != "" {
+		if user, err := uc.userRepo.GetByEmail(ctx, req.Email); err == nil {
+			userID = &user.ID
+		}
+	}
+
+	return uc.webauthnUC.BeginLogin(ctx, userID, entities.WebAuthnCeremonyLogin)
+}
+
+// FinishWebAuthnLoginRequest representa la aserción del navegador para completar el login
+type FinishWebAuthnLoginRequest struct {
+	MFAToken   string                     `json:"mfa_token"`
+	Credential webauthn.AssertionResponse `json:"credential"`
+	DeviceName string                     `json:"device_name" binding:"omitempty,max=255"`
+	UserAgent  string                     `json:"-"`
+	IPAddress  string                     `json:"-"`
+}
+
+// FinishWebAuthnLogin verifica la aserción y emite los tokens igual que el login con contraseña
+func (uc *AuthUseCase) FinishWebAuthnLogin(ctx context.Context, req *FinishWebAuthnLoginRequest) (*LoginResponse, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("webauthn is handled by Keycloak")
+	}
+
+	ceremony := entities.WebAuthnCeremonyLogin
+	if req.MFAToken != "" {
+		ceremony = entities.WebAuthnCeremonyMFA
+	}
+
+	user, err := uc.webauthnUC.FinishLogin(ctx, ceremony, &req.Credential)
+	if err != nil {
+		uc.auditLogin(ctx, "", "", "webauthn", "invalid_assertion", req.IPAddress, req.UserAgent)
+		return nil, err
+	}
+	userID := user.ID.String()
+
+	// Una passkey no salta el bloqueo por intentos fallidos
+	if user.IsLocked(time.Now()) {
+		uc.auditLogin(ctx, userID, user.Email, "webauthn", "account_locked", req.IPAddress, req.UserAgent)
+		return nil, ErrAccountLocked
+	}
+
+	if req.MFAToken != "" {
+		// El desafío MFA debe corresponder al mismo usuario que firmó la aserción
+		if err := uc.mfaUC.ConsumeChallenge(ctx, req.MFAToken, userID); err != nil {
+			uc.auditLogin(ctx, userID, user.Email, "webauthn", "invalid_mfa_token", req.IPAddress, req.UserAgent)
+			return nil, err
+		}
+	} else if uc.policy.RequireEmailVerification && !user.EmailVerified {
+		uc.auditLogin(ctx, userID, user.Email, "webauthn", "email_not_verified", req.IPAddress, req.UserAgent)
+		return nil, errors.New("email not verified")
+	}
+
+	if !user.IsActive {
+		uc.auditLogin(ctx, userID, user.Email, "webauthn", "account_deactivated", req.IPAddress, req.UserAgent)
+		return nil, errors.New("user account is deactivated")
+	}
+
+	return uc.issueTokens(ctx, user, "webauthn", req.DeviceName, req.UserAgent, req.IPAddress)
+}
+
+// issueTokens registra el login y emite el access token, el refresh token y la sesión del dispositivo.
+// El último login, el reinicio de intentos fallidos, el refresh token y la sesión se guardan en una
+// transacción: si falla cualquiera de ellos no queda ninguno.
+func (uc *AuthUseCase) issueTokens(ctx context.Context, user *entities.User, method, deviceName, userAgent, ipAddress string) (*LoginResponse, error) {
+	// Generar tokens con la organización activa por defecto
+	orgID, err := uc.orgUC.DefaultOrganization(ctx, user.ID.String())
+	if err != nil {
 		return nil, err
 	}
 
-	// Generar tokens
-	accessToken, err := uc.jwtSvc.GenerateToken(user.ID.String(), user.Email, string(user.Role))
+	auth := jwt.Authentication{Time: time.Now(), Methods: authMethods(method)}
+	accessToken, err := uc.generateAccessToken(ctx, user, orgID, auth)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := uc.jwtSvc.GenerateRefreshToken(user.ID.String())
+	refreshToken, err := uc.jwtSvc.GenerateRefreshToken(user.ID.String(), orgID, auth)
 	if err != nil {
 		return nil, err
 	}
 
-	// Guardar refresh token en la base de datos
 	refreshTokenEntity := entities.NewToken(
 		user.ID,
 		refreshToken,
 		entities.TokenTypeRefresh,
 		time.Now().Add(24*7*time.Hour), // 7 días
 	)
-	if err := uc.tokenRepo.Create(ctx, refreshTokenEntity); err != nil {
+	session := entities.NewSession(user.ID, refreshTokenEntity.FamilyID, deviceName, userAgent, ipAddress)
+
+	user.UpdateLastLogin()
+	user.RecordEvent(entities.EventUserLoggedIn, map[string]interface{}{
+		"email":  user.Email,
+		"method": method,
+	})
+
+	err = uc.uow.Do(ctx, func(repos *repositories.TxRepositories) error {
+		// Actualizar último login
+		if err := repos.Users.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Un login completo reinicia el contador de intentos fallidos
+		if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+			if err := repos.Users.ResetFailedLogins(ctx, user.ID.String()); err != nil {
+				return err
+			}
+		}
+
+		// Guardar refresh token y registrar la sesión del dispositivo
+		if err := repos.Tokens.Create(ctx, refreshTokenEntity); err != nil {
+			return err
+		}
+		return repos.Sessions.Create(ctx, session)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	uc.auditLogin(ctx, user.ID.String(), user.Email, method, "", ipAddress, userAgent)
+
 	return &LoginResponse{
 		User:         user,
 		AccessToken:  accessToken,
@@ -320,6 +640,7 @@ func (uc *AuthUseCase) loginLocal(ctx context.Context, req *LoginRequest) (*Logi
 // LogoutRequest representa la solicitud de logout
 type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+	AccessToken  string `json:"-"` // tomado del header Authorization si está presente
 }
 
 // Logout cierra la sesión del usuario
@@ -329,13 +650,39 @@ func (uc *AuthUseCase) Logout(ctx context.Context, req *LogoutRequest) error {
 		// No necesitamos hacer nada especial aquí
 		return nil
 	}
-	// Revocar el refresh token local
-	return uc.tokenRepo.RevokeToken(ctx, req.RefreshToken)
+
+	// Revocar el access token actual para que no siga siendo válido hasta su expiración
+	if req.AccessToken != "" {
+		if claims, err := uc.jwtSvc.ValidateToken(req.AccessToken); err == nil {
+			if err := uc.revocationUC.RevokeAccessToken(ctx, claims); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Revocar la familia completa del refresh token local
+	token, err := uc.tokenRepo.GetByToken(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if err := revokeFamily(ctx, uc.tokenRepo, uc.sessionRepo, token.FamilyID.String()); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionLogout,
+		ActorID:  token.UserID.String(),
+		TargetID: token.UserID.String(),
+		Metadata: map[string]interface{}{"family_id": token.FamilyID.String()},
+	})
+	return nil
 }
 
 // RefreshRequest representa la solicitud de refresh
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+	UserAgent    string `json:"-"`
+	IPAddress    string `json:"-"`
 }
 
 // RefreshResponse representa la respuesta del refresh
@@ -344,12 +691,33 @@ type RefreshResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-// Refresh renueva el token de acceso
+// Refresh renueva el token de acceso manteniendo la organización activa
 func (uc *AuthUseCase) Refresh(ctx context.Context, req *RefreshRequest) (*RefreshResponse, error) {
 	if uc.useKeycloak {
 		return uc.refreshWithKeycloak(ctx, req)
 	}
-	return uc.refreshLocal(ctx, req)
+	return uc.refreshLocal(ctx, req, nil)
+}
+
+// SwitchOrganizationRequest representa la solicitud de cambio de organización activa
+type SwitchOrganizationRequest struct {
+	RefreshToken   string `json:"refresh_token" binding:"required"`
+	OrganizationID string `json:"org_id" binding:"required"`
+	UserAgent      string `json:"-"`
+	IPAddress      string `json:"-"`
+}
+
+// SwitchOrganization rota el refresh token y emite tokens con otra organización activa
+func (uc *AuthUseCase) SwitchOrganization(ctx context.Context, req *SwitchOrganizationRequest) (*RefreshResponse, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("organization switching is not supported with keycloak")
+	}
+
+	return uc.refreshLocal(ctx, &RefreshRequest{
+		RefreshToken: req.RefreshToken,
+		UserAgent:    req.UserAgent,
+		IPAddress:    req.IPAddress,
+	}, &req.OrganizationID)
 }
 
 // refreshWithKeycloak renueva un token usando Keycloak
@@ -366,17 +734,27 @@ func (uc *AuthUseCase) refreshWithKeycloak(ctx context.Context, req *RefreshRequ
 	}, nil
 }
 
-// refreshLocal renueva un token usando la base de datos local
-func (uc *AuthUseCase) refreshLocal(ctx context.Context, req *RefreshRequest) (*RefreshResponse, error) {
+// refreshLocal renueva un token usando la base de datos local. Si switchTo es nil se mantiene
+// la organización activa del refresh token.
+func (uc *AuthUseCase) refreshLocal(ctx context.Context, req *RefreshRequest, switchTo *string) (*RefreshResponse, error) {
 	// Verificar el refresh token
 	claims, err := uc.jwtSvc.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
-	// Verificar si el token existe en la base de datos
+	// Verificar si el token existe en la base de datos; los emitidos a clientes OAuth solo se
+	// renuevan en /oauth/token
 	token, err := uc.tokenRepo.GetByToken(ctx, req.RefreshToken)
-	if err != nil {
+	if err != nil || token.ClientID != "" {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Un token ya rotado que vuelve a presentarse indica robo: se revoca toda la familia
+	if token.IsRevoked {
+		if err := handleRevokedRefreshToken(ctx, uc.tokenRepo, uc.sessionRepo, uc.auditLogger, token); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid refresh token")
 	}
 
@@ -396,30 +774,73 @@ func (uc *AuthUseCase) refreshLocal(ctx context.Context, req *RefreshRequest) (*
 		return nil, errors.New("user account is deactivated")
 	}
 
-	// Generar nuevos tokens
-	accessToken, err := uc.jwtSvc.GenerateToken(user.ID.String(), user.Email, string(user.Role))
-	if err != nil {
-		return nil, err
+	// Verificar la membresía en la organización activa
+	orgID := claims.OrgID
+	if switchTo != nil {
+		orgID = *switchTo
+	}
+	if orgID != "" {
+		isMember, err := uc.orgUC.IsMember(ctx, orgID, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			if switchTo != nil {
+				return nil, ErrNotOrganizationMember
+			}
+			// El usuario dejó la organización: los nuevos tokens no la representan
+			orgID = ""
+		}
 	}
 
-	newRefreshToken, err := uc.jwtSvc.GenerateRefreshToken(user.ID.String())
+	// Generar nuevos tokens; conservan el login que originó la familia
+	accessToken, err := uc.generateAccessToken(ctx, user, orgID, claims.Authentication())
 	if err != nil {
 		return nil, err
 	}
 
-	// Revocar el token anterior
-	if err := uc.tokenRepo.RevokeToken(ctx, req.RefreshToken); err != nil {
+	newRefreshToken, err := uc.jwtSvc.GenerateRefreshToken(user.ID.String(), orgID, claims.Authentication())
+	if err != nil {
 		return nil, err
 	}
 
-	// Guardar el nuevo refresh token
-	newRefreshTokenEntity := entities.NewToken(
+	newRefreshTokenEntity := entities.NewTokenInFamily(
 		user.ID,
+		token.FamilyID,
 		newRefreshToken,
 		entities.TokenTypeRefresh,
 		time.Now().Add(24*7*time.Hour), // 7 días
 	)
-	if err := uc.tokenRepo.Create(ctx, newRefreshTokenEntity); err != nil {
+
+	// La rotación es atómica: si falla el alta del token nuevo o de la sesión, el anterior sigue vigente
+	err = uc.uow.Do(ctx, func(repos *repositories.TxRepositories) error {
+		// Revocar el token anterior; si otra petición lo revocó primero se rechaza la rotación
+		if err := repos.Tokens.RotateToken(ctx, req.RefreshToken, newRefreshTokenEntity.ID.String()); err != nil {
+			if errors.Is(err, repositories.ErrTokenNotRevocable) {
+				return errRefreshTokenReused
+			}
+			return err
+		}
+
+		// Guardar el nuevo refresh token en la misma familia
+		if err := repos.Tokens.Create(ctx, newRefreshTokenEntity); err != nil {
+			return err
+		}
+
+		// Actualizar la actividad de la sesión (las familias previas a las sesiones reciben una nueva)
+		return touchSession(ctx, repos.Sessions, user, token.FamilyID, req.UserAgent, req.IPAddress)
+	})
+	if errors.Is(err, errRefreshTokenReused) {
+		// Se relee el token para saber si lo rotó otra petición o lo revocó un logout; la familia
+		// se revoca fuera de la transacción revertida
+		if current, getErr := uc.tokenRepo.GetByToken(ctx, req.RefreshToken); getErr == nil {
+			if revokeErr := handleRevokedRefreshToken(ctx, uc.tokenRepo, uc.sessionRepo, uc.auditLogger, current); revokeErr != nil {
+				return nil, revokeErr
+			}
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -429,6 +850,147 @@ func (uc *AuthUseCase) refreshLocal(ctx context.Context, req *RefreshRequest) (*
 	}, nil
 }
 
+// generateAccessToken genera el access token con la organización activa, los roles efectivos
+// del usuario y, según la política, sus permisos
+func (uc *AuthUseCase) generateAccessToken(ctx context.Context, user *entities.User, orgID string, auth jwt.Authentication) (string, error) {
+	roles, permissions, err := uc.roleUC.Authorization(ctx, user.ID.String())
+	if err != nil {
+		return "", err
+	}
+	if !uc.policy.EmbedPermissions {
+		permissions = nil
+	}
+
+	return uc.jwtSvc.GenerateToken(user.ID.String(), user.Email, string(user.Role), orgID, roles, permissions, auth)
+}
+
+// authMethods traduce el método de login a los valores amr de RFC 8176
+func authMethods(method string) []string {
+	switch method {
+	case "mfa":
+		return []string{"pwd", "otp", "mfa"}
+	case "webauthn":
+		return []string{"hwk"}
+	default:
+		return []string{"pwd"}
+	}
+}
+
+// rehashPassword regenera el hash de la contraseña ya verificada; un error no impide el login
+func (uc *AuthUseCase) rehashPassword(ctx context.Context, user *entities.User, plainPassword string) {
+	hashedPassword, err := uc.passSvc.Hash(plainPassword)
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %v", user.ID, err)
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		log.Printf("Error rehashing password for user %s: %v", user.ID, err)
+	}
+}
+
+// recordFailedLogin suma un intento fallido y bloquea la cuenta al alcanzar el umbral.
+// Cada fallo posterior duplica el bloqueo hasta LockoutMaxDuration.
+func (uc *AuthUseCase) recordFailedLogin(ctx context.Context, userID string) {
+	if uc.policy.LockoutThreshold <= 0 {
+		return
+	}
+
+	attempts, err := uc.userRepo.RecordFailedLogin(ctx, userID)
+	if err != nil {
+		log.Printf("Error recording failed login for user %s: %v", userID, err)
+		return
+	}
+	if attempts < uc.policy.LockoutThreshold {
+		return
+	}
+
+	duration := uc.policy.LockoutDuration
+	for i := uc.policy.LockoutThreshold; i < attempts && duration < uc.policy.LockoutMaxDuration; i++ {
+		duration *= 2
+	}
+	if uc.policy.LockoutMaxDuration > 0 && duration > uc.policy.LockoutMaxDuration {
+		duration = uc.policy.LockoutMaxDuration
+	}
+
+	if err := uc.userRepo.LockUntil(ctx, userID, time.Now().Add(duration)); err != nil {
+		log.Printf("Error locking user %s: %v", userID, err)
+		return
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionAccountLocked,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"attempts": attempts, "duration": duration.String()},
+	})
+}
+
+// touchSession actualiza la sesión de una familia o la crea si no existe
+func touchSession(ctx context.Context, sessionRepo repositories.SessionRepository, user *entities.User, familyID uuid.UUID, userAgent, ipAddress string) error {
+	session, err := sessionRepo.GetByFamilyID(ctx, familyID.String())
+	if err != nil {
+		return sessionRepo.Create(ctx, entities.NewSession(user.ID, familyID, "", userAgent, ipAddress))
+	}
+
+	session.Touch(userAgent, ipAddress)
+	return sessionRepo.Touch(ctx, session)
+}
+
+// revokeFamily revoca los refresh tokens de una familia y su sesión
+func revokeFamily(ctx context.Context, tokenRepo repositories.TokenRepository, sessionRepo repositories.SessionRepository, familyID string) error {
+	if err := tokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return sessionRepo.RevokeByFamilyID(ctx, familyID)
+}
+
+// handleRevokedRefreshToken responde a la presentación de un refresh token revocado. Solo un token
+// rotado indica robo y revoca su familia; los revocados por logout o cierre de sesión se rechazan
+// sin tratarlos como reutilización.
+func handleRevokedRefreshToken(ctx context.Context, tokenRepo repositories.TokenRepository, sessionRepo repositories.SessionRepository, auditLogger *AuditLogger, token *entities.Token) error {
+	if !token.IsRotated() {
+		return nil
+	}
+	if err := revokeFamily(ctx, tokenRepo, sessionRepo, token.FamilyID.String()); err != nil {
+		return err
+	}
+	reportTokenReuse(ctx, auditLogger, token)
+	return nil
+}
+
+// reportTokenReuse registra la reutilización de un refresh token ya rotado
+func reportTokenReuse(ctx context.Context, auditLogger *AuditLogger, token *entities.Token) {
+	auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRefreshTokenReuse,
+		Outcome:  entities.AuditOutcomeFailure,
+		TargetID: token.UserID.String(),
+		Metadata: map[string]interface{}{"family_id": token.FamilyID.String()},
+	})
+}
+
+// auditLogin registra un intento de login; failure vacío indica un login exitoso.
+// userID es vacío si el email no corresponde a una cuenta.
+func (uc *AuthUseCase) auditLogin(ctx context.Context, userID, email, method, failure, ipAddress, userAgent string) {
+	entry := AuditEntry{
+		Action:    entities.AuditActionLoginSucceeded,
+		ActorID:   userID,
+		TargetID:  userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Metadata:  map[string]interface{}{"method": method},
+	}
+	if email != "" {
+		entry.Metadata["email"] = email
+	}
+	if failure != "" {
+		entry.Action = entities.AuditActionLoginFailed
+		entry.Outcome = entities.AuditOutcomeFailure
+		entry.Metadata["reason"] = failure
+	}
+
+	uc.auditLogger.Log(ctx, entry)
+}
+
 // getKeycloakToken obtiene un token de acceso de Keycloak
 func (uc *AuthUseCase) getKeycloakToken(username, password string) (string, error) {
 	return uc.keycloakService.Login(username, password)
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/password"
+	"auth-go-microservicio/pkg/ratelimit"
+)
+
+// newTestAuthUseCase crea un AuthUseCase local sobre el almacenamiento en memoria
+func newTestAuthUseCase(t *testing.T, store *memoryStore, auditRepo *memoryAuditRepo) *AuthUseCase {
+	t.Helper()
+	roleUC := NewRoleUseCase(&memoryRoleRepo{}, &memoryUserRepo{store: store}, nil, nil, time.Minute)
+	return &AuthUseCase{
+		userRepo:    &memoryUserRepo{store: store},
+		tokenRepo:   &memoryTokenRepo{store: store},
+		sessionRepo: &memorySessionRepo{store: store},
+		uow:         &memoryUnitOfWork{store: store},
+		roleUC:      roleUC,
+		orgUC:       NewOrganizationUseCase(&memoryOrganizationRepo{}, &memoryUserRepo{store: store}, roleUC, nil, nil, time.Minute),
+		jwtSvc:      newTestJWTService(t),
+		auditLogger: NewAuditLogger(auditRepo),
+	}
+}
+
+// newTestUser guarda un usuario activo con un intento fallido previo
+func newTestUser(store *memoryStore) *entities.User {
+	user := entities.NewUserWithRole("ana@example.com", "hash", "Ana", "Pérez", entities.RoleUser)
+	user.FailedLoginAttempts = 1
+	store.users[user.ID] = *user
+	return user
+}
+
+func TestIssueTokensRollsBackWhenSessionCreateFails(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestAuthUseCase(t, store, &memoryAuditRepo{})
+	user := newTestUser(store)
+	store.failures["sessions.create"] = errors.New("insert session: connection reset")
+
+	if _, err := uc.issueTokens(context.Background(), user, "password", "laptop", "agent", "10.0.0.1"); err == nil {
+		t.Fatal("expected the session create error")
+	}
+
+	if len(store.tokens) != 0 {
+		t.Errorf("refresh token was kept after rollback: %d tokens", len(store.tokens))
+	}
+	if len(store.sessions) != 0 {
+		t.Errorf("session was kept after rollback: %d sessions", len(store.sessions))
+	}
+	stored := store.users[user.ID]
+	if stored.LastLoginAt != nil {
+		t.Error("last login was updated after rollback")
+	}
+	if stored.FailedLoginAttempts != 1 {
+		t.Errorf("failed login attempts were reset after rollback: %d", stored.FailedLoginAttempts)
+	}
+}
+
+func TestIssueTokensCommitsAllWrites(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestAuthUseCase(t, store, &memoryAuditRepo{})
+	user := newTestUser(store)
+
+	response, err := uc.issueTokens(context.Background(), user, "password", "laptop", "agent", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.tokens[response.RefreshToken]; !ok {
+		t.Error("refresh token was not stored")
+	}
+	if len(store.sessions) != 1 {
+		t.Errorf("expected one session, got %d", len(store.sessions))
+	}
+	stored := store.users[user.ID]
+	if stored.LastLoginAt == nil || stored.FailedLoginAttempts != 0 {
+		t.Errorf("login was not recorded: last_login_at=%v failed_login_attempts=%d", stored.LastLoginAt, stored.FailedLoginAttempts)
+	}
+}
+
+// loginForRefresh emite tokens para el usuario y retorna el refresh token guardado
+func loginForRefresh(t *testing.T, uc *AuthUseCase, user *entities.User) string {
+	t.Helper()
+	response, err := uc.issueTokens(context.Background(), user, "password", "laptop", "agent", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.RefreshToken
+}
+
+func TestRefreshLocalRollsBackWhenSessionTouchFails(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestAuthUseCase(t, store, auditRepo)
+	refreshToken := loginForRefresh(t, uc, newTestUser(store))
+	store.failures["sessions.touch"] = errors.New("update session: connection reset")
+
+	if _, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil); err == nil {
+		t.Fatal("expected the session touch error")
+	}
+
+	if store.tokens[refreshToken].IsRevoked {
+		t.Error("previous refresh token was revoked after rollback")
+	}
+	if len(store.tokens) != 1 {
+		t.Errorf("new refresh token was kept after rollback: %d tokens", len(store.tokens))
+	}
+
+	// El token anterior sigue sirviendo para renovar
+	delete(store.failures, "sessions.touch")
+	if _, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil); err != nil {
+		t.Fatalf("refresh after rollback failed: %v", err)
+	}
+	if containsAction(auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Error("rollback was reported as refresh token reuse")
+	}
+}
+
+func TestRefreshLocalDoesNotTreatStorageErrorsAsReuse(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestAuthUseCase(t, store, auditRepo)
+	refreshToken := loginForRefresh(t, uc, newTestUser(store))
+	store.failures["tokens.revoke"] = context.DeadlineExceeded
+
+	_, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the storage error, got %v", err)
+	}
+
+	for _, token := range store.tokens {
+		if token.IsRevoked {
+			t.Error("token family was revoked after a storage error")
+		}
+	}
+	if containsAction(auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Error("storage error was reported as refresh token reuse")
+	}
+}
+
+func TestRefreshLocalRevokesFamilyOnReuse(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestAuthUseCase(t, store, auditRepo)
+	refreshToken := loginForRefresh(t, uc, newTestUser(store))
+
+	rotated, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil); err == nil {
+		t.Fatal("expected the reused refresh token to be rejected")
+	}
+
+	if !store.tokens[rotated.RefreshToken].IsRevoked {
+		t.Error("token family was not revoked on reuse")
+	}
+	if !containsAction(auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Error("refresh token reuse was not reported")
+	}
+}
+
+func TestRefreshLocalRejectsLoggedOutTokenWithoutReportingReuse(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestAuthUseCase(t, store, auditRepo)
+	refreshToken := loginForRefresh(t, uc, newTestUser(store))
+
+	if err := uc.Logout(context.Background(), &LogoutRequest{RefreshToken: refreshToken}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.refreshLocal(context.Background(), &RefreshRequest{RefreshToken: refreshToken}, nil); err == nil {
+		t.Fatal("expected the logged out refresh token to be rejected")
+	}
+
+	if containsAction(auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Error("logged out refresh token was reported as reuse")
+	}
+}
+
+// containsAction indica si la acción está en la lista
+func containsAction(actions []entities.AuditAction, action entities.AuditAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// withPasswordLogin configura el login con contraseña con un hash de bajo costo
+func withPasswordLogin(t *testing.T, uc *AuthUseCase) password.Service {
+	t.Helper()
+	passSvc := password.NewService(password.Config{Algorithm: "bcrypt", BcryptCost: 4})
+	dummyHash, err := passSvc.Hash("dummy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc.passSvc = passSvc
+	uc.dummyHash = dummyHash
+	uc.ipFailures = ratelimit.NewFailureCounter(0, time.Minute)
+	return passSvc
+}
+
+func TestLoginLocalLockedAccountLooksLikeInvalidCredentials(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestAuthUseCase(t, store, auditRepo)
+	passSvc := withPasswordLogin(t, uc)
+
+	user := newTestUser(store)
+	hash, err := passSvc.Hash("Correct-horse-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockedUntil := time.Now().Add(time.Hour)
+	user.Password = hash
+	user.LockedUntil = &lockedUntil
+	store.users[user.ID] = *user
+
+	_, unknownErr := uc.loginLocal(context.Background(), &LoginRequest{Email: "nadie@example.com", Password: "Correct-horse-42"})
+	if unknownErr == nil {
+		t.Fatal("expected login with an unknown email to fail")
+	}
+	for _, attempt := range []string{"wrong-password", "Correct-horse-42"} {
+		_, err := uc.loginLocal(context.Background(), &LoginRequest{Email: user.Email, Password: attempt})
+		if err == nil {
+			t.Fatalf("locked account logged in with %q", attempt)
+		}
+		if errors.Is(err, ErrAccountLocked) || err.Error() != unknownErr.Error() {
+			t.Errorf("locked account error %q differs from unknown email error %q", err, unknownErr)
+		}
+	}
+
+	if len(store.tokens) != 0 {
+		t.Error("tokens were issued to a locked account")
+	}
+	var reasons []string
+	for _, event := range auditRepo.events {
+		var metadata struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(event.Metadata, &metadata); err != nil {
+			t.Fatal(err)
+		}
+		reasons = append(reasons, metadata.Reason)
+	}
+	if len(reasons) != 3 || reasons[1] != "account_locked" || reasons[2] != "account_locked" {
+		t.Errorf("lock was not recorded in the audit log: %v", reasons)
+	}
+}
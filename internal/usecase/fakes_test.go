@@ -0,0 +1,1295 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+	"auth-go-microservicio/pkg/mailer"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore guarda usuarios, refresh tokens, sesiones e invitaciones en memoria. Las
+// operaciones listadas en failures fallan con el error indicado para simular errores de la base de datos.
+type memoryStore struct {
+	mu          sync.Mutex
+	users       map[uuid.UUID]entities.User
+	tokens      map[string]entities.Token
+	sessions    map[uuid.UUID]entities.Session
+	invitations map[uuid.UUID]entities.Invitation
+	failures    map[string]error
+}
+
+// newMemoryStore crea un almacenamiento vacío
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		users:       map[uuid.UUID]entities.User{},
+		tokens:      map[string]entities.Token{},
+		sessions:    map[uuid.UUID]entities.Session{},
+		invitations: map[uuid.UUID]entities.Invitation{},
+		failures:    map[string]error{},
+	}
+}
+
+// fail retorna el error configurado para la operación
+func (s *memoryStore) fail(operation string) error {
+	return s.failures[operation]
+}
+
+// clone copia el contenido para ejecutar una transacción sobre la copia
+func (s *memoryStore) clone() *memoryStore {
+	c := newMemoryStore()
+	for k, v := range s.users {
+		c.users[k] = v
+	}
+	for k, v := range s.tokens {
+		c.tokens[k] = v
+	}
+	for k, v := range s.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range s.invitations {
+		c.invitations[k] = v
+	}
+	c.failures = s.failures
+	return c
+}
+
+// memoryUnitOfWork ejecuta fn sobre una copia del almacenamiento y solo la confirma si fn no
+// retorna error, como la transacción de la implementación de PostgreSQL. Los repositorios OAuth,
+// de roles y de organizaciones, si se indican, se usan sin transacción.
+type memoryUnitOfWork struct {
+	store *memoryStore
+	oauth repositories.OAuthRepository
+	roles repositories.RoleRepository
+	orgs  repositories.OrganizationRepository
+}
+
+func (u *memoryUnitOfWork) Do(ctx context.Context, fn func(repos *repositories.TxRepositories) error) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	tx := u.store.clone()
+	if err := fn(&repositories.TxRepositories{
+		Users:         &memoryUserRepo{store: tx},
+		Tokens:        &memoryTokenRepo{store: tx},
+		Sessions:      &memorySessionRepo{store: tx},
+		OAuth:         u.oauth,
+		Invitations:   &memoryInvitationRepo{store: tx},
+		Roles:         u.roles,
+		Organizations: u.orgs,
+	}); err != nil {
+		return err
+	}
+
+	u.store.users, u.store.tokens, u.store.sessions = tx.users, tx.tokens, tx.sessions
+	u.store.invitations = tx.invitations
+	return nil
+}
+
+// memoryUserRepo implementa las operaciones de UserRepository que usan las pruebas
+type memoryUserRepo struct {
+	repositories.UserRepository
+	store *memoryStore
+}
+
+func (r *memoryUserRepo) Create(ctx context.Context, user *entities.User) error {
+	if err := r.store.fail("users.create"); err != nil {
+		return err
+	}
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepo) GetByID(ctx context.Context, id string) (*entities.User, error) {
+	for _, user := range r.store.users {
+		if user.ID.String() == id {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *memoryUserRepo) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *memoryUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *memoryUserRepo) Update(ctx context.Context, user *entities.User) error {
+	if err := r.store.fail("users.update"); err != nil {
+		return err
+	}
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepo) RecordFailedLogin(ctx context.Context, id string) (int, error) {
+	user := r.store.users[uuid.MustParse(id)]
+	user.FailedLoginAttempts++
+	r.store.users[user.ID] = user
+	return user.FailedLoginAttempts, nil
+}
+
+func (r *memoryUserRepo) LockUntil(ctx context.Context, id string, until time.Time) error {
+	user := r.store.users[uuid.MustParse(id)]
+	user.LockedUntil = &until
+	r.store.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryUserRepo) ResetFailedLogins(ctx context.Context, id string) error {
+	user := r.store.users[uuid.MustParse(id)]
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	r.store.users[user.ID] = user
+	return nil
+}
+
+// memoryTokenRepo implementa TokenRepository en memoria
+type memoryTokenRepo struct {
+	repositories.TokenRepository
+	store *memoryStore
+}
+
+func (r *memoryTokenRepo) Create(ctx context.Context, token *entities.Token) error {
+	if err := r.store.fail("tokens.create"); err != nil {
+		return err
+	}
+	r.store.tokens[token.Token] = *token
+	return nil
+}
+
+func (r *memoryTokenRepo) GetByToken(ctx context.Context, value string) (*entities.Token, error) {
+	token, ok := r.store.tokens[value]
+	if !ok {
+		return nil, errors.New("token not found")
+	}
+	return &token, nil
+}
+
+func (r *memoryTokenRepo) RevokeToken(ctx context.Context, value string) error {
+	if err := r.store.fail("tokens.revoke"); err != nil {
+		return err
+	}
+	token, ok := r.store.tokens[value]
+	if !ok || token.IsRevoked {
+		return repositories.ErrTokenNotRevocable
+	}
+	token.IsRevoked = true
+	r.store.tokens[value] = token
+	return nil
+}
+
+func (r *memoryTokenRepo) RotateToken(ctx context.Context, value, replacedByID string) error {
+	if err := r.store.fail("tokens.revoke"); err != nil {
+		return err
+	}
+	token, ok := r.store.tokens[value]
+	if !ok || token.IsRevoked {
+		return repositories.ErrTokenNotRevocable
+	}
+	replacedBy := uuid.MustParse(replacedByID)
+	token.IsRevoked = true
+	token.ReplacedBy = &replacedBy
+	r.store.tokens[value] = token
+	return nil
+}
+
+func (r *memoryTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	for value, token := range r.store.tokens {
+		if token.FamilyID.String() == familyID {
+			token.IsRevoked = true
+			r.store.tokens[value] = token
+		}
+	}
+	return nil
+}
+
+// memorySessionRepo implementa las operaciones de SessionRepository que usan las pruebas
+type memorySessionRepo struct {
+	repositories.SessionRepository
+	store *memoryStore
+}
+
+func (r *memorySessionRepo) Create(ctx context.Context, session *entities.Session) error {
+	if err := r.store.fail("sessions.create"); err != nil {
+		return err
+	}
+	r.store.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepo) GetByFamilyID(ctx context.Context, familyID string) (*entities.Session, error) {
+	for _, session := range r.store.sessions {
+		if session.FamilyID.String() == familyID {
+			return &session, nil
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+func (r *memorySessionRepo) Touch(ctx context.Context, session *entities.Session) error {
+	if err := r.store.fail("sessions.touch"); err != nil {
+		return err
+	}
+	r.store.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepo) RevokeByFamilyID(ctx context.Context, familyID string) error {
+	for id, session := range r.store.sessions {
+		if session.FamilyID.String() == familyID {
+			session.IsRevoked = true
+			r.store.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+// memoryRoleRepo asigna los mismos roles a todos los usuarios; holders indica los usuarios que
+// tiene cada rol
+type memoryRoleRepo struct {
+	repositories.RoleRepository
+	roles   []*entities.RoleDefinition
+	holders map[string][]string
+}
+
+func (r *memoryRoleRepo) ListByUserID(ctx context.Context, userID string) ([]*entities.RoleDefinition, error) {
+	return r.roles, nil
+}
+
+func (r *memoryRoleRepo) GetByID(ctx context.Context, id string) (*entities.RoleDefinition, error) {
+	return &entities.RoleDefinition{ID: uuid.MustParse(id)}, nil
+}
+
+func (r *memoryRoleRepo) GetByName(ctx context.Context, name string) (*entities.RoleDefinition, error) {
+	for _, role := range r.roles {
+		if string(role.Name) == name {
+			return role, nil
+		}
+	}
+	return nil, errors.New("role not found")
+}
+
+func (r *memoryRoleRepo) AssignToUser(ctx context.Context, userID, roleID string) error {
+	if r.holders == nil {
+		r.holders = map[string][]string{}
+	}
+	r.holders[roleID] = append(r.holders[roleID], userID)
+	return nil
+}
+
+func (r *memoryRoleRepo) SetPermissions(ctx context.Context, roleID string, permissions []entities.Permission) error {
+	return nil
+}
+
+func (r *memoryRoleRepo) Delete(ctx context.Context, id string) error {
+	delete(r.holders, id)
+	return nil
+}
+
+func (r *memoryRoleRepo) ListUserIDs(ctx context.Context, roleID string) ([]string, error) {
+	return r.holders[roleID], nil
+}
+
+// memoryOrganizationRepo guarda organizaciones y membresías en memoria; la clave de members es
+// el ID de la organización y el del usuario separados por un espacio
+type memoryOrganizationRepo struct {
+	repositories.OrganizationRepository
+	organizations map[uuid.UUID]*entities.Organization
+	members       map[string]*entities.OrganizationMember
+}
+
+func (r *memoryOrganizationRepo) GetByID(ctx context.Context, id string) (*entities.Organization, error) {
+	for _, organization := range r.organizations {
+		if organization.ID.String() == id {
+			return organization, nil
+		}
+	}
+	return nil, errors.New("organization not found")
+}
+
+func (r *memoryOrganizationRepo) AddMember(ctx context.Context, member *entities.OrganizationMember) error {
+	if r.members == nil {
+		r.members = map[string]*entities.OrganizationMember{}
+	}
+	r.members[member.OrganizationID.String()+" "+member.UserID.String()] = member
+	return nil
+}
+
+func (r *memoryOrganizationRepo) FindMember(ctx context.Context, organizationID, userID string) (*entities.OrganizationMember, error) {
+	return r.members[organizationID+" "+userID], nil
+}
+
+func (r *memoryOrganizationRepo) UpdateMemberRole(ctx context.Context, organizationID, userID string, role entities.Role) error {
+	member, ok := r.members[organizationID+" "+userID]
+	if !ok {
+		return errors.New("member not found")
+	}
+	member.Role = role
+	return nil
+}
+
+func (r *memoryOrganizationRepo) ListByUserID(ctx context.Context, userID string) ([]*entities.OrganizationMember, error) {
+	var memberships []*entities.OrganizationMember
+	for _, member := range r.members {
+		if member.UserID.String() == userID {
+			memberships = append(memberships, member)
+		}
+	}
+	return memberships, nil
+}
+
+// memoryAuditRepo guarda las entradas del log de auditoría
+type memoryAuditRepo struct {
+	repositories.AuditRepository
+	mu     sync.Mutex
+	events []*entities.AuditEvent
+}
+
+func (r *memoryAuditRepo) Append(ctx context.Context, event *entities.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// actions retorna las acciones registradas en orden
+func (r *memoryAuditRepo) actions() []entities.AuditAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := make([]entities.AuditAction, len(r.events))
+	for i, event := range r.events {
+		actions[i] = event.Action
+	}
+	return actions
+}
+
+// memoryRevocationRepo implementa RevocationRepository en memoria
+type memoryRevocationRepo struct {
+	mu         sync.Mutex
+	jtis       map[string]time.Time
+	watermarks map[string]time.Time
+}
+
+// newMemoryRevocationRepo crea un repositorio sin revocaciones
+func newMemoryRevocationRepo() *memoryRevocationRepo {
+	return &memoryRevocationRepo{jtis: map[string]time.Time{}, watermarks: map[string]time.Time{}}
+}
+
+func (r *memoryRevocationRepo) RevokeAccessToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jtis[jti] = expiresAt
+	return nil
+}
+
+func (r *memoryRevocationRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.jtis[jti]
+	return ok, nil
+}
+
+func (r *memoryRevocationRepo) SetUserWatermark(ctx context.Context, userID string, notBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if notBefore.After(r.watermarks[userID]) {
+		r.watermarks[userID] = notBefore
+	}
+	return nil
+}
+
+func (r *memoryRevocationRepo) GetUserWatermark(ctx context.Context, userID string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.watermarks[userID], nil
+}
+
+func (r *memoryRevocationRepo) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+// watermark retorna la marca de agua guardada del usuario
+func (r *memoryRevocationRepo) watermark(userID string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.watermarks[userID]
+}
+
+// newTestJWTService crea un servicio JWT con una clave HMAC
+func newTestJWTService(t *testing.T) jwt.Service {
+	t.Helper()
+	ring, err := jwt.NewKeyRing(jwt.NewHMACKey("test", "test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwt.NewService(ring, 15*time.Minute, 24*time.Hour)
+}
+
+// memoryMFARepo implementa MFARepository en memoria
+type memoryMFARepo struct {
+	repositories.MFARepository
+	mu            sync.Mutex
+	credentials   map[string]entities.TOTPCredential
+	recoveryCodes map[string][]entities.RecoveryCode
+}
+
+// newMemoryMFARepo crea un repositorio sin credenciales
+func newMemoryMFARepo() *memoryMFARepo {
+	return &memoryMFARepo{
+		credentials:   map[string]entities.TOTPCredential{},
+		recoveryCodes: map[string][]entities.RecoveryCode{},
+	}
+}
+
+func (r *memoryMFARepo) SaveTOTP(ctx context.Context, credential *entities.TOTPCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.credentials[credential.UserID.String()] = *credential
+	return nil
+}
+
+func (r *memoryMFARepo) GetTOTP(ctx context.Context, userID string) (*entities.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return nil, errors.New("totp credential not found")
+	}
+	return &credential, nil
+}
+
+func (r *memoryMFARepo) ConfirmTOTP(ctx context.Context, userID string, confirmedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return errors.New("totp credential not found")
+	}
+	credential.ConfirmedAt = &confirmedAt
+	r.credentials[userID] = credential
+	return nil
+}
+
+func (r *memoryMFARepo) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok || credential.LastUsedStep >= step {
+		return errors.New("totp step already used")
+	}
+	credential.LastUsedStep = step
+	r.credentials[userID] = credential
+	return nil
+}
+
+func (r *memoryMFARepo) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.RecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := make([]entities.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		stored = append(stored, *code)
+	}
+	r.recoveryCodes[userID] = stored
+	return nil
+}
+
+func (r *memoryMFARepo) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	codes := r.recoveryCodes[userID]
+	for i := range codes {
+		if codes[i].CodeHash == codeHash && codes[i].UsedAt == nil {
+			now := time.Now()
+			codes[i].UsedAt = &now
+			return nil
+		}
+	}
+	return errors.New("recovery code not found")
+}
+
+func (r *memoryMFARepo) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, code := range r.recoveryCodes[userID] {
+		if code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// memoryServiceAccountRepo implementa ServiceAccountRepository en memoria
+type memoryServiceAccountRepo struct {
+	repositories.ServiceAccountRepository
+	mu         sync.Mutex
+	accounts   map[uuid.UUID]entities.ServiceAccount
+	assertions map[string]time.Time // expiración por "clientID jti"
+}
+
+// newMemoryServiceAccountRepo crea un repositorio sin cuentas
+func newMemoryServiceAccountRepo() *memoryServiceAccountRepo {
+	return &memoryServiceAccountRepo{
+		accounts:   map[uuid.UUID]entities.ServiceAccount{},
+		assertions: map[string]time.Time{},
+	}
+}
+
+func (r *memoryServiceAccountRepo) Create(ctx context.Context, account *entities.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *memoryServiceAccountRepo) GetByID(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, account := range r.accounts {
+		if account.ID.String() == id {
+			return &account, nil
+		}
+	}
+	return nil, errors.New("service account not found")
+}
+
+func (r *memoryServiceAccountRepo) GetByClientID(ctx context.Context, clientID string) (*entities.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, account := range r.accounts {
+		if account.ClientID == clientID {
+			return &account, nil
+		}
+	}
+	return nil, errors.New("service account not found")
+}
+
+func (r *memoryServiceAccountRepo) Update(ctx context.Context, account *entities.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[account.ID]; !ok {
+		return errors.New("service account not found")
+	}
+	r.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *memoryServiceAccountRepo) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, account := range r.accounts {
+		if account.ID.String() == id {
+			account.LastUsedAt = &usedAt
+			r.accounts[key] = account
+		}
+	}
+	return nil
+}
+
+func (r *memoryServiceAccountRepo) UseAssertion(ctx context.Context, clientID, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := clientID + " " + jti
+	if _, ok := r.assertions[key]; ok {
+		return errors.New("client assertion already used")
+	}
+	r.assertions[key] = expiresAt
+	return nil
+}
+
+// memoryWebAuthnRepo implementa WebAuthnRepository en memoria
+type memoryWebAuthnRepo struct {
+	repositories.WebAuthnRepository
+	mu          sync.Mutex
+	credentials map[string]entities.WebAuthnCredential
+	challenges  map[string]entities.WebAuthnChallenge
+}
+
+// newMemoryWebAuthnRepo crea un repositorio sin credenciales ni desafíos
+func newMemoryWebAuthnRepo() *memoryWebAuthnRepo {
+	return &memoryWebAuthnRepo{
+		credentials: map[string]entities.WebAuthnCredential{},
+		challenges:  map[string]entities.WebAuthnChallenge{},
+	}
+}
+
+func (r *memoryWebAuthnRepo) CreateCredential(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.credentials[credential.CredentialID] = *credential
+	return nil
+}
+
+func (r *memoryWebAuthnRepo) GetCredential(ctx context.Context, credentialID string) (*entities.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[credentialID]
+	if !ok {
+		return nil, errors.New("credential not found")
+	}
+	return &credential, nil
+}
+
+func (r *memoryWebAuthnRepo) ListCredentialsByUserID(ctx context.Context, userID string) ([]*entities.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var credentials []*entities.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID.String() == userID {
+			credential := credential
+			credentials = append(credentials, &credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *memoryWebAuthnRepo) UpdateCredentialUsage(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.credentials[credential.CredentialID] = *credential
+	return nil
+}
+
+func (r *memoryWebAuthnRepo) CreateChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.challenges[challenge.Challenge] = *challenge
+	return nil
+}
+
+func (r *memoryWebAuthnRepo) ConsumeChallenge(ctx context.Context, value string, ceremony entities.WebAuthnCeremony) (*entities.WebAuthnChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.challenges[value]
+	if !ok || challenge.Ceremony != ceremony || time.Now().After(challenge.ExpiresAt) {
+		return nil, errors.New("challenge not found")
+	}
+	delete(r.challenges, value)
+	return &challenge, nil
+}
+
+// memoryWebhookRepo implementa WebhookRepository en memoria
+type memoryWebhookRepo struct {
+	repositories.WebhookRepository
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]entities.WebhookSubscription
+	deliveries    map[uuid.UUID]entities.WebhookDelivery
+	attempts      []entities.WebhookAttempt
+}
+
+// newMemoryWebhookRepo crea un repositorio sin suscripciones
+func newMemoryWebhookRepo() *memoryWebhookRepo {
+	return &memoryWebhookRepo{
+		subscriptions: map[uuid.UUID]entities.WebhookSubscription{},
+		deliveries:    map[uuid.UUID]entities.WebhookDelivery{},
+	}
+}
+
+func (r *memoryWebhookRepo) CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscription.ID] = *subscription
+	return nil
+}
+
+func (r *memoryWebhookRepo) GetSubscription(ctx context.Context, id string) (*entities.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[uuid.MustParse(id)]
+	if !ok {
+		return nil, errors.New("webhook not found")
+	}
+	return &subscription, nil
+}
+
+func (r *memoryWebhookRepo) ListActiveSubscriptions(ctx context.Context, eventType entities.EventType) ([]*entities.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subscriptions []*entities.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.IsActive && subscription.Accepts(eventType) {
+			subscription := subscription
+			subscriptions = append(subscriptions, &subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *memoryWebhookRepo) UpdateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscription.ID] = *subscription
+	return nil
+}
+
+func (r *memoryWebhookRepo) RecordDeliveryFailure(ctx context.Context, id string, disableAfter int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription := r.subscriptions[uuid.MustParse(id)]
+	subscription.ConsecutiveFailures++
+	disabled := subscription.IsActive && disableAfter > 0 && subscription.ConsecutiveFailures >= disableAfter
+	if disabled {
+		now := time.Now()
+		subscription.IsActive = false
+		subscription.DisabledAt = &now
+	}
+	r.subscriptions[subscription.ID] = subscription
+	return disabled, nil
+}
+
+func (r *memoryWebhookRepo) ResetDeliveryFailures(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription := r.subscriptions[uuid.MustParse(id)]
+	subscription.ConsecutiveFailures = 0
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *memoryWebhookRepo) CreateDeliveries(ctx context.Context, deliveries []*entities.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range deliveries {
+		r.deliveries[delivery.ID] = *delivery
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepo) ClaimDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []*entities.WebhookDelivery
+	for id, delivery := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if delivery.Status != entities.WebhookDeliveryPending || delivery.NextAttemptAt.After(time.Now()) ||
+			!r.subscriptions[delivery.SubscriptionID].IsActive {
+			continue
+		}
+		delivery := delivery
+		delivery.NextAttemptAt = &leaseUntil
+		r.deliveries[id] = delivery
+		claimed = append(claimed, &delivery)
+	}
+	return claimed, nil
+}
+
+func (r *memoryWebhookRepo) RecordAttempt(ctx context.Context, delivery *entities.WebhookDelivery, attempt *entities.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID] = *delivery
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+// delivery retorna la única entrega guardada
+func (r *memoryWebhookRepo) delivery(t *testing.T) entities.WebhookDelivery {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(r.deliveries))
+	}
+	for _, delivery := range r.deliveries {
+		return delivery
+	}
+	return entities.WebhookDelivery{}
+}
+
+// makeDue adelanta el próximo intento de las entregas pendientes para no esperar el backoff
+func (r *memoryWebhookRepo) makeDue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	past := time.Now().Add(-time.Second)
+	for id, delivery := range r.deliveries {
+		if delivery.Status == entities.WebhookDeliveryPending {
+			delivery.NextAttemptAt = &past
+			r.deliveries[id] = delivery
+		}
+	}
+}
+
+// memoryOutboxRepo implementa OutboxRepository en memoria
+type memoryOutboxRepo struct {
+	mu          sync.Mutex
+	events      map[uuid.UUID]entities.OutboxEvent
+	failPublish error // error de MarkPublished
+}
+
+// newMemoryOutboxRepo crea un outbox con los eventos indicados pendientes
+func newMemoryOutboxRepo(events ...*entities.DomainEvent) *memoryOutboxRepo {
+	r := &memoryOutboxRepo{events: map[uuid.UUID]entities.OutboxEvent{}}
+	for _, event := range events {
+		r.events[event.ID] = entities.OutboxEvent{DomainEvent: *event, NextAttemptAt: time.Now()}
+	}
+	return r
+}
+
+func (r *memoryOutboxRepo) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*entities.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []*entities.OutboxEvent
+	for id, event := range r.events {
+		if len(claimed) == limit {
+			break
+		}
+		if event.PublishedAt != nil || event.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		event := event
+		event.Attempts++
+		event.NextAttemptAt = leaseUntil
+		r.events[id] = event
+		claimed = append(claimed, &event)
+	}
+	return claimed, nil
+}
+
+func (r *memoryOutboxRepo) MarkPublished(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failPublish != nil {
+		return r.failPublish
+	}
+	event := r.events[uuid.MustParse(id)]
+	now := time.Now()
+	event.PublishedAt = &now
+	r.events[event.ID] = event
+	return nil
+}
+
+func (r *memoryOutboxRepo) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event := r.events[uuid.MustParse(id)]
+	event.LastError = lastError
+	event.NextAttemptAt = nextAttemptAt
+	r.events[event.ID] = event
+	return nil
+}
+
+func (r *memoryOutboxRepo) DeletePublished(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, event := range r.events {
+		if event.PublishedAt != nil && event.PublishedAt.Before(before) {
+			delete(r.events, id)
+		}
+	}
+	return nil
+}
+
+// get retorna el estado guardado del evento
+func (r *memoryOutboxRepo) get(id uuid.UUID) entities.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[id]
+}
+
+// makeDue adelanta el próximo intento de los eventos pendientes para no esperar el backoff ni la reserva
+func (r *memoryOutboxRepo) makeDue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, event := range r.events {
+		if event.PublishedAt == nil {
+			event.NextAttemptAt = time.Now().Add(-time.Second)
+			r.events[id] = event
+		}
+	}
+}
+
+// memoryOneTimeTokenRepo implementa OneTimeTokenRepository en memoria
+type memoryOneTimeTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]entities.OneTimeToken // por hash
+}
+
+// newMemoryOneTimeTokenRepo crea un repositorio sin tokens
+func newMemoryOneTimeTokenRepo() *memoryOneTimeTokenRepo {
+	return &memoryOneTimeTokenRepo{tokens: map[string]entities.OneTimeToken{}}
+}
+
+func (r *memoryOneTimeTokenRepo) Create(ctx context.Context, token *entities.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *memoryOneTimeTokenRepo) Consume(ctx context.Context, tokenHash string, purpose entities.TokenPurpose) (*entities.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, errors.New("token not found")
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	r.tokens[tokenHash] = token
+	return &token, nil
+}
+
+func (r *memoryOneTimeTokenRepo) InvalidateByUserID(ctx context.Context, userID string, purpose entities.TokenPurpose) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for hash, token := range r.tokens {
+		if token.UserID.String() == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryOneTimeTokenRepo) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+// expireAll vence los tokens guardados
+func (r *memoryOneTimeTokenRepo) expireAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.tokens {
+		token.ExpiresAt = time.Now().Add(-time.Second)
+		r.tokens[hash] = token
+	}
+}
+
+// capturingMailer guarda los mensajes enviados en lugar de enviarlos
+type capturingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	err      error
+}
+
+func (m *capturingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// sent retorna los mensajes enviados en orden
+func (m *capturingMailer) sent() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mailer.Message(nil), m.messages...)
+}
+
+// linkTokenPattern extrae el token del enlace de un email
+var linkTokenPattern = regexp.MustCompile(`token=([^\s]+)`)
+
+// linkToken retorna el token del enlace del mensaje
+func linkToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	match := linkTokenPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("message has no token link: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// memoryAPIKeyRepo implementa APIKeyRepository en memoria
+type memoryAPIKeyRepo struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]entities.APIKey
+}
+
+// newMemoryAPIKeyRepo crea un repositorio sin claves
+func newMemoryAPIKeyRepo() *memoryAPIKeyRepo {
+	return &memoryAPIKeyRepo{keys: map[uuid.UUID]entities.APIKey{}}
+}
+
+func (r *memoryAPIKeyRepo) Create(ctx context.Context, key *entities.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (r *memoryAPIKeyRepo) ListByUserID(ctx context.Context, userID string) ([]*entities.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []*entities.APIKey
+	for _, key := range r.keys {
+		if key.UserID.String() == userID {
+			key := key
+			keys = append(keys, &key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepo) CountByUserID(ctx context.Context, userID string) (int, error) {
+	keys, err := r.ListByUserID(ctx, userID)
+	return len(keys), err
+}
+
+func (r *memoryAPIKeyRepo) Delete(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[uuid.MustParse(id)]
+	if !ok || key.UserID.String() != userID {
+		return errors.New("api key not found")
+	}
+	delete(r.keys, key.ID)
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := r.keys[uuid.MustParse(id)]
+	key.LastUsedAt = &usedAt
+	r.keys[key.ID] = key
+	return nil
+}
+
+// expire vence la clave
+func (r *memoryAPIKeyRepo) expire(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := r.keys[id]
+	past := time.Now().Add(-time.Second)
+	key.ExpiresAt = &past
+	r.keys[id] = key
+}
+
+// memoryOAuthRepo implementa OAuthRepository en memoria. failMarkUsed es el error de
+// MarkAuthorizationCodeUsed para simular errores de la base de datos.
+type memoryOAuthRepo struct {
+	mu           sync.Mutex
+	clients      map[string]entities.OAuthClient // por client_id
+	codes        map[string]entities.AuthorizationCode
+	consents     map[string]entities.OAuthConsent // por usuario y client_id
+	failMarkUsed error
+}
+
+// newMemoryOAuthRepo crea un repositorio sin clientes
+func newMemoryOAuthRepo() *memoryOAuthRepo {
+	return &memoryOAuthRepo{
+		clients:  map[string]entities.OAuthClient{},
+		codes:    map[string]entities.AuthorizationCode{},
+		consents: map[string]entities.OAuthConsent{},
+	}
+}
+
+func (r *memoryOAuthRepo) CreateClient(ctx context.Context, client *entities.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ClientID] = *client
+	return nil
+}
+
+func (r *memoryOAuthRepo) GetClient(ctx context.Context, id string) (*entities.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, client := range r.clients {
+		if client.ID.String() == id {
+			return &client, nil
+		}
+	}
+	return nil, errors.New("client not found")
+}
+
+func (r *memoryOAuthRepo) GetClientByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, errors.New("client not found")
+	}
+	return &client, nil
+}
+
+func (r *memoryOAuthRepo) ListClients(ctx context.Context) ([]*entities.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var clients []*entities.OAuthClient
+	for _, client := range r.clients {
+		client := client
+		clients = append(clients, &client)
+	}
+	return clients, nil
+}
+
+func (r *memoryOAuthRepo) UpdateClient(ctx context.Context, client *entities.OAuthClient) error {
+	return r.CreateClient(ctx, client)
+}
+
+func (r *memoryOAuthRepo) DeleteClient(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for clientID, client := range r.clients {
+		if client.ID.String() == id {
+			delete(r.clients, clientID)
+			return nil
+		}
+	}
+	return errors.New("client not found")
+}
+
+func (r *memoryOAuthRepo) CreateAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[code.CodeHash] = *code
+	return nil
+}
+
+func (r *memoryOAuthRepo) GetAuthorizationCode(ctx context.Context, codeHash string) (*entities.AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return nil, errors.New("authorization code not found")
+	}
+	return &code, nil
+}
+
+func (r *memoryOAuthRepo) MarkAuthorizationCodeUsed(ctx context.Context, codeHash, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failMarkUsed != nil {
+		return r.failMarkUsed
+	}
+	code, ok := r.codes[codeHash]
+	if !ok || code.UsedAt != nil {
+		return repositories.ErrAuthorizationCodeUsed
+	}
+	now := time.Now()
+	family := uuid.MustParse(familyID)
+	code.UsedAt = &now
+	code.FamilyID = &family
+	r.codes[codeHash] = code
+	return nil
+}
+
+func (r *memoryOAuthRepo) DeleteExpiredAuthorizationCodes(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (r *memoryOAuthRepo) GetConsent(ctx context.Context, userID, clientID string) (*entities.OAuthConsent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	consent, ok := r.consents[userID+" "+clientID]
+	if !ok {
+		return nil, errors.New("consent not found")
+	}
+	return &consent, nil
+}
+
+func (r *memoryOAuthRepo) SaveConsent(ctx context.Context, consent *entities.OAuthConsent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consents[consent.UserID.String()+" "+consent.ClientID] = *consent
+	return nil
+}
+
+// memoryInvitationRepo implementa InvitationRepository sobre el almacenamiento en memoria
+type memoryInvitationRepo struct {
+	repositories.InvitationRepository
+	store *memoryStore
+}
+
+func (r *memoryInvitationRepo) Create(ctx context.Context, invitation *entities.Invitation) error {
+	r.store.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+func (r *memoryInvitationRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error) {
+	for _, invitation := range r.store.invitations {
+		if invitation.TokenHash == tokenHash {
+			return &invitation, nil
+		}
+	}
+	return nil, errors.New("invitation not found")
+}
+
+func (r *memoryInvitationRepo) Claim(ctx context.Context, id string) error {
+	invitation := r.store.invitations[uuid.MustParse(id)]
+	now := time.Now()
+	if invitation.Status(now) != entities.InvitationStatusPending {
+		return repositories.ErrInvitationNotPending
+	}
+	invitation.AcceptedAt = &now
+	r.store.invitations[invitation.ID] = invitation
+	return nil
+}
+
+func (r *memoryInvitationRepo) SetAcceptedBy(ctx context.Context, id, userID string) error {
+	invitation := r.store.invitations[uuid.MustParse(id)]
+	acceptedBy := uuid.MustParse(userID)
+	invitation.AcceptedBy = &acceptedBy
+	r.store.invitations[invitation.ID] = invitation
+	return nil
+}
+
+// memoryPasswordHistoryRepo guarda el historial de contraseñas en orden de alta
+type memoryPasswordHistoryRepo struct {
+	entries []*entities.PasswordHistory
+}
+
+func (r *memoryPasswordHistoryRepo) Add(ctx context.Context, entry *entities.PasswordHistory, keep int) error {
+	r.entries = append(r.entries, entry)
+
+	// Descartar las entradas más antiguas del usuario que exceden keep
+	count := 0
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].UserID != entry.UserID {
+			continue
+		}
+		if count++; count > keep {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPasswordHistoryRepo) ListRecent(ctx context.Context, userID string, limit int) ([]*entities.PasswordHistory, error) {
+	var recent []*entities.PasswordHistory
+	for i := len(r.entries) - 1; i >= 0 && len(recent) < limit; i-- {
+		if r.entries[i].UserID.String() == userID {
+			recent = append(recent, r.entries[i])
+		}
+	}
+	return recent, nil
+}
@@ -0,0 +1,486 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/mailer"
+	"auth-go-microservicio/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+// InvitationUseCase maneja el alta de cuentas por invitación. Un administrador invita un email
+// con un rol y, opcionalmente, una organización; al aceptar se crea la cuenta o se vincula la existente.
+type InvitationUseCase struct {
+	invitationRepo   repositories.InvitationRepository
+	userRepo         repositories.UserRepository
+	orgRepo          repositories.OrganizationRepository
+	uow              repositories.UnitOfWork
+	roleUC           *RoleUseCase
+	orgUC            *OrganizationUseCase
+	passSvc          password.Service
+	passwordPolicyUC *PasswordPolicyUseCase
+	mailer           mailer.Mailer
+	frontendURL      string
+	expiration       time.Duration
+	auditLogger      *AuditLogger
+	useKeycloak      bool
+}
+
+// NewInvitationUseCase crea una nueva instancia de InvitationUseCase.
+// Con Keycloak las cuentas se administran en el realm y las invitaciones no están disponibles.
+func NewInvitationUseCase(
+	invitationRepo repositories.InvitationRepository,
+	userRepo repositories.UserRepository,
+	orgRepo repositories.OrganizationRepository,
+	uow repositories.UnitOfWork,
+	roleUC *RoleUseCase,
+	orgUC *OrganizationUseCase,
+	passSvc password.Service,
+	passwordPolicyUC *PasswordPolicyUseCase,
+	mailer mailer.Mailer,
+	frontendURL string,
+	expiration time.Duration,
+	auditLogger *AuditLogger,
+	useKeycloak bool,
+) *InvitationUseCase {
+	return &InvitationUseCase{
+		invitationRepo:   invitationRepo,
+		userRepo:         userRepo,
+		orgRepo:          orgRepo,
+		uow:              uow,
+		roleUC:           roleUC,
+		orgUC:            orgUC,
+		passSvc:          passSvc,
+		passwordPolicyUC: passwordPolicyUC,
+		mailer:           mailer,
+		frontendURL:      frontendURL,
+		expiration:       expiration,
+		auditLogger:      auditLogger,
+		useKeycloak:      useKeycloak,
+	}
+}
+
+// CreateInvitationRequest representa la solicitud de creación de una invitación
+type CreateInvitationRequest struct {
+	Email            string `json:"email" binding:"required,email"`
+	Role             string `json:"role"`              // rol principal de la cuenta; por defecto user
+	OrganizationID   string `json:"organization_id"`   // opcional
+	OrganizationRole string `json:"organization_role"` // por defecto org_member
+	ExpiresInHours   int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720"`
+	InvitedBy        string `json:"-"`
+}
+
+// CreateInvitation guarda la invitación y envía el enlace por email
+func (uc *InvitationUseCase) CreateInvitation(ctx context.Context, req *CreateInvitationRequest) (*entities.Invitation, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("invitations are not supported with keycloak")
+	}
+
+	role := entities.RoleUser
+	if req.Role != "" {
+		if err := uc.roleUC.ValidateRole(ctx, req.Role); err != nil {
+			return nil, err
+		}
+		role = entities.Role(req.Role)
+	}
+
+	var organization *entities.Organization
+	organizationRole := entities.RoleOrgMember
+	if req.OrganizationID != "" {
+		var err error
+		organization, err = uc.orgRepo.GetByID(ctx, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if req.OrganizationRole != "" {
+			if err := uc.roleUC.ValidateRole(ctx, req.OrganizationRole); err != nil {
+				return nil, err
+			}
+			organizationRole = entities.Role(req.OrganizationRole)
+		}
+	} else if req.OrganizationRole != "" {
+		return nil, errors.New("organization_role requires organization_id")
+	}
+
+	var invitedBy *uuid.UUID
+	if req.InvitedBy != "" {
+		id, err := uuid.Parse(req.InvitedBy)
+		if err != nil {
+			return nil, errors.New("invalid user id")
+		}
+		invitedBy = &id
+	}
+
+	expiration := uc.expiration
+	if req.ExpiresInHours > 0 {
+		expiration = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := entities.NewInvitation(req.Email, role, hashToken(token), invitedBy, time.Now().Add(expiration))
+	if organization != nil {
+		invitation.OrganizationID = &organization.ID
+		invitation.OrganizationRole = organizationRole
+	}
+
+	if err := uc.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+	uc.auditInvitation(ctx, entities.AuditActionInvitationCreated, invitation, req.InvitedBy)
+
+	// Si el envío falla la invitación se puede revocar y volver a emitir
+	if err := uc.sendInvitation(ctx, invitation, organization, token, expiration); err != nil {
+		log.Printf("Error sending invitation %s: %v", invitation.ID, err)
+	}
+
+	return invitation, nil
+}
+
+// ListInvitationsRequest representa la solicitud para listar invitaciones
+type ListInvitationsRequest struct {
+	OrganizationID string `json:"organization_id"`
+	Email          string `json:"email"`
+	PendingOnly    bool   `json:"pending_only"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+}
+
+// ListInvitationsResponse representa la respuesta para listar invitaciones
+type ListInvitationsResponse struct {
+	Invitations []*InvitationInfo `json:"invitations"`
+	Total       int64             `json:"total"`
+}
+
+// InvitationInfo representa una invitación con su estado calculado
+type InvitationInfo struct {
+	*entities.Invitation
+	Status entities.InvitationStatus `json:"status"`
+}
+
+// ListInvitations lista las invitaciones con quién las emitió, aceptó o revocó
+func (uc *InvitationUseCase) ListInvitations(ctx context.Context, req *ListInvitationsRequest) (*ListInvitationsResponse, error) {
+	if req.OrganizationID != "" {
+		if _, err := uuid.Parse(req.OrganizationID); err != nil {
+			return nil, errors.New("invalid organization id")
+		}
+	}
+
+	filter := &repositories.InvitationFilter{
+		OrganizationID: req.OrganizationID,
+		Email:          req.Email,
+		PendingOnly:    req.PendingOnly,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+	}
+
+	invitations, err := uc.invitationRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.invitationRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	infos := make([]*InvitationInfo, len(invitations))
+	for i, invitation := range invitations {
+		infos[i] = &InvitationInfo{Invitation: invitation, Status: invitation.Status(now)}
+	}
+
+	return &ListInvitationsResponse{
+		Invitations: infos,
+		Total:       total,
+	}, nil
+}
+
+// RevokeInvitationRequest representa la solicitud de revocación de una invitación
+type RevokeInvitationRequest struct {
+	InvitationID   string `json:"-"`
+	OrganizationID string `json:"-"` // si se indica, la invitación debe ser de esa organización
+	RevokedBy      string `json:"-"`
+}
+
+// RevokeInvitation revoca una invitación pendiente
+func (uc *InvitationUseCase) RevokeInvitation(ctx context.Context, req *RevokeInvitationRequest) error {
+	invitation, err := uc.invitationRepo.GetByID(ctx, req.InvitationID)
+	if err != nil {
+		return err
+	}
+	if req.OrganizationID != "" && (invitation.OrganizationID == nil || invitation.OrganizationID.String() != req.OrganizationID) {
+		return errors.New("invitation not found")
+	}
+
+	if err := uc.invitationRepo.Revoke(ctx, req.InvitationID, req.RevokedBy); err != nil {
+		return err
+	}
+	uc.auditInvitation(ctx, entities.AuditActionInvitationRevoked, invitation, req.RevokedBy)
+
+	return nil
+}
+
+// AcceptInvitationRequest representa la solicitud de aceptación de una invitación. Los datos de
+// la cuenta solo se requieren si el email no tiene una cuenta.
+type AcceptInvitationRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name" binding:"max=100"`
+	LastName  string `json:"last_name" binding:"max=100"`
+}
+
+// AcceptInvitationResponse representa la respuesta de la aceptación de una invitación
+type AcceptInvitationResponse struct {
+	User    *entities.User `json:"user"`
+	Created bool           `json:"created"` // false si se vinculó una cuenta existente
+}
+
+// AcceptInvitation crea la cuenta con el rol invitado o, si el email ya tiene cuenta, le asigna el
+// rol como adicional. El token llegó al email invitado, por lo que el email queda verificado. Todo
+// ocurre en una transacción que empieza por reclamar la invitación: si otra aceptación la reclamó
+// antes o falla algún paso, no queda ningún cambio.
+func (uc *InvitationUseCase) AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*AcceptInvitationResponse, error) {
+	if uc.useKeycloak {
+		return nil, errors.New("invitations are not supported with keycloak")
+	}
+
+	invitation, err := uc.invitationRepo.GetByTokenHash(ctx, hashToken(req.Token))
+	if err != nil || invitation.Status(time.Now()) != entities.InvitationStatusPending {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	exists, err := uc.userRepo.ExistsByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// La cuenta nueva se valida y se prepara fuera de la transacción
+	var user *entities.User
+	if exists {
+		user, err = uc.userRepo.GetByEmail(ctx, invitation.Email)
+	} else {
+		user, err = uc.newAccount(ctx, invitation, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var assignedRole *entities.RoleDefinition
+	var membershipAdded bool
+	err = uc.uow.Do(ctx, func(repos *repositories.TxRepositories) error {
+		if err := repos.Invitations.Claim(ctx, invitation.ID.String()); err != nil {
+			return err
+		}
+
+		var err error
+		if exists {
+			assignedRole, err = linkAccount(ctx, repos, invitation, user)
+		} else {
+			err = repos.Users.Create(ctx, user)
+		}
+		if err != nil {
+			return err
+		}
+
+		if invitation.OrganizationID != nil {
+			if membershipAdded, err = joinOrganization(ctx, repos, invitation, user); err != nil {
+				return err
+			}
+		}
+
+		return repos.Invitations.SetAcceptedBy(ctx, invitation.ID.String(), user.ID.String())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.accepted(ctx, invitation, user, !exists, assignedRole, membershipAdded)
+
+	return &AcceptInvitationResponse{
+		User:    user,
+		Created: !exists,
+	}, nil
+}
+
+// BootstrapAdmin invita al primer administrador si todavía no hay ninguno ni una invitación
+// pendiente para ese email. Reemplaza el alta pública de administradores.
+func (uc *InvitationUseCase) BootstrapAdmin(ctx context.Context, email string) error {
+	admins, err := uc.userRepo.Count(ctx, &repositories.UserFilter{Role: string(entities.RoleAdmin)})
+	if err != nil || admins > 0 {
+		return err
+	}
+
+	pending, err := uc.invitationRepo.Count(ctx, &repositories.InvitationFilter{Email: email, PendingOnly: true})
+	if err != nil || pending > 0 {
+		return err
+	}
+
+	_, err = uc.CreateInvitation(ctx, &CreateInvitationRequest{
+		Email: email,
+		Role:  string(entities.RoleAdmin),
+	})
+	return err
+}
+
+// newAccount valida los datos de la cuenta del email invitado y la prepara con el rol de la invitación
+func (uc *InvitationUseCase) newAccount(ctx context.Context, invitation *entities.Invitation, req *AcceptInvitationRequest) (*entities.User, error) {
+	if req.Password == "" || req.FirstName == "" || req.LastName == "" {
+		return nil, errors.New("password, first_name and last_name are required to create the account")
+	}
+
+	if err := uc.passwordPolicyUC.Validate(ctx, nil, req.Password, invitation.Email, req.FirstName, req.LastName); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := uc.passSvc.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := entities.NewUserWithRole(invitation.Email, hashedPassword, req.FirstName, req.LastName, invitation.Role)
+	user.MarkEmailVerified()
+	user.RecordEvent(entities.EventUserRegistered, userRegisteredPayload(user, "invitation"))
+
+	return user, nil
+}
+
+// linkAccount asigna el rol invitado a una cuenta existente y verifica su email. Retorna el rol
+// asignado o nil si la cuenta ya lo tenía como principal.
+func linkAccount(ctx context.Context, repos *repositories.TxRepositories, invitation *entities.Invitation, user *entities.User) (*entities.RoleDefinition, error) {
+	var assigned *entities.RoleDefinition
+	if invitation.Role != entities.RoleUser && invitation.Role != user.Role {
+		role, err := repos.Roles.GetByName(ctx, string(invitation.Role))
+		if err != nil {
+			return nil, errors.New("invalid role")
+		}
+		if err := repos.Roles.AssignToUser(ctx, user.ID.String(), role.ID.String()); err != nil {
+			return nil, err
+		}
+		assigned = role
+	}
+
+	if !user.EmailVerified {
+		user.MarkEmailVerified()
+		if err := repos.Users.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return assigned, nil
+}
+
+// joinOrganization agrega al usuario a la organización de la invitación o actualiza su rol si ya
+// es miembro. Retorna true si lo agregó.
+func joinOrganization(ctx context.Context, repos *repositories.TxRepositories, invitation *entities.Invitation, user *entities.User) (bool, error) {
+	organizationID := invitation.OrganizationID.String()
+
+	member, err := repos.Organizations.FindMember(ctx, organizationID, user.ID.String())
+	if err != nil {
+		return false, err
+	}
+
+	if member != nil {
+		return false, repos.Organizations.UpdateMemberRole(ctx, organizationID, user.ID.String(), invitation.OrganizationRole)
+	}
+
+	member = entities.NewOrganizationMember(*invitation.OrganizationID, user.ID, invitation.OrganizationRole)
+	return true, repos.Organizations.AddMember(ctx, member)
+}
+
+// accepted completa la aceptación confirmada: guarda el historial de contraseñas de la cuenta
+// creada, descarta los permisos en caché del usuario y registra los cambios en la auditoría
+func (uc *InvitationUseCase) accepted(ctx context.Context, invitation *entities.Invitation, user *entities.User, created bool, assignedRole *entities.RoleDefinition, membershipAdded bool) {
+	userID := user.ID.String()
+
+	if created {
+		if err := uc.passwordPolicyUC.Record(ctx, user.ID, user.Password); err != nil {
+			log.Printf("Error recording password history for user %s: %v", user.ID, err)
+		}
+	}
+
+	if assignedRole != nil {
+		uc.auditLogger.Log(ctx, AuditEntry{
+			Action:   entities.AuditActionRoleAssigned,
+			TargetID: userID,
+			Metadata: map[string]interface{}{"role_id": assignedRole.ID.String(), "role": assignedRole.Name},
+		})
+		// La cuenta ya puede tener access tokens con los permisos anteriores
+		if err := uc.roleUC.userRolesChanged(ctx, userID); err != nil {
+			log.Printf("Error revoking access tokens of user %s: %v", user.ID, err)
+		}
+	}
+
+	if invitation.OrganizationID != nil {
+		organizationID := invitation.OrganizationID.String()
+		uc.orgUC.invalidate(organizationID, userID)
+
+		action := entities.AuditActionMemberRoleChanged
+		if membershipAdded {
+			action = entities.AuditActionMemberAdded
+		}
+		uc.auditLogger.Log(ctx, AuditEntry{
+			Action:   action,
+			TargetID: userID,
+			Metadata: map[string]interface{}{"organization_id": organizationID, "role": invitation.OrganizationRole},
+		})
+	}
+
+	uc.auditInvitation(ctx, entities.AuditActionInvitationAccepted, invitation, userID)
+}
+
+// sendInvitation envía el enlace de aceptación al email invitado
+func (uc *InvitationUseCase) sendInvitation(ctx context.Context, invitation *entities.Invitation, organization *entities.Organization, token string, expiration time.Duration) error {
+	target := "la plataforma"
+	if organization != nil {
+		target = organization.Name
+	}
+
+	link := fmt.Sprintf("%s/accept-invite?token=%s", uc.frontendURL, url.QueryEscape(token))
+	return uc.mailer.Send(ctx, &mailer.Message{
+		To:      invitation.Email,
+		Subject: "Recibiste una invitación",
+		Body: fmt.Sprintf(
+			"Hola,\n\nTe invitaron a %s con el rol %s. Para aceptar la invitación ingresá al siguiente enlace:\n\n%s\n\nEl enlace vence en %s. Si no esperabas esta invitación podés ignorar este mensaje.\n",
+			target, invitation.Role, link, expiration,
+		),
+	})
+}
+
+// auditInvitation registra un evento del ciclo de vida de una invitación
+func (uc *InvitationUseCase) auditInvitation(ctx context.Context, action entities.AuditAction, invitation *entities.Invitation, actorID string) {
+	metadata := map[string]interface{}{"email": invitation.Email, "role": invitation.Role}
+	if invitation.OrganizationID != nil {
+		metadata["organization_id"] = invitation.OrganizationID.String()
+		metadata["organization_role"] = invitation.OrganizationRole
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   action,
+		ActorID:  actorID,
+		TargetID: invitation.ID.String(),
+		Metadata: metadata,
+	})
+}
+
+// newRandomToken genera un token aleatorio de 256 bits
+func newRandomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+// invitationFixture agrupa el caso de uso de invitaciones y los repositorios en memoria sobre los que trabaja
+type invitationFixture struct {
+	uc             *InvitationUseCase
+	store          *memoryStore
+	roleRepo       *memoryRoleRepo
+	orgRepo        *memoryOrganizationRepo
+	revocationRepo *memoryRevocationRepo
+	auditRepo      *memoryAuditRepo
+	mailer         *capturingMailer
+	passSvc        password.Service
+	organization   *entities.Organization
+}
+
+// newInvitationFixture crea el caso de uso con los roles de sistema y una organización
+func newInvitationFixture(t *testing.T) *invitationFixture {
+	t.Helper()
+	f := &invitationFixture{
+		store:          newMemoryStore(),
+		revocationRepo: newMemoryRevocationRepo(),
+		auditRepo:      &memoryAuditRepo{},
+		mailer:         &capturingMailer{},
+		passSvc:        password.NewService(password.Config{Algorithm: "bcrypt", BcryptCost: 4}),
+		organization:   &entities.Organization{ID: uuid.New(), Name: "Acme", Slug: "acme"},
+	}
+	f.roleRepo = &memoryRoleRepo{}
+	for _, name := range []entities.Role{entities.RoleUser, entities.RoleAdmin, entities.RoleOrgAdmin, entities.RoleOrgMember} {
+		f.roleRepo.roles = append(f.roleRepo.roles, &entities.RoleDefinition{ID: uuid.New(), Name: name})
+	}
+	f.orgRepo = &memoryOrganizationRepo{organizations: map[uuid.UUID]*entities.Organization{f.organization.ID: f.organization}}
+
+	policy, err := password.NewPolicy(password.PolicyConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := &memoryUserRepo{store: f.store}
+	auditLogger := NewAuditLogger(f.auditRepo)
+	revocationUC := NewRevocationUseCase(f.revocationRepo, time.Minute)
+	roleUC := NewRoleUseCase(f.roleRepo, userRepo, revocationUC, auditLogger, time.Minute)
+	f.uc = NewInvitationUseCase(
+		&memoryInvitationRepo{store: f.store},
+		userRepo,
+		f.orgRepo,
+		&memoryUnitOfWork{store: f.store, roles: f.roleRepo, orgs: f.orgRepo},
+		roleUC,
+		NewOrganizationUseCase(f.orgRepo, userRepo, roleUC, revocationUC, auditLogger, time.Minute),
+		f.passSvc,
+		NewPasswordPolicyUseCase(policy, nil, f.passSvc, 0),
+		f.mailer,
+		"https://app.example.com",
+		72*time.Hour,
+		auditLogger,
+		false,
+	)
+	return f
+}
+
+// invite crea la invitación y retorna el token enviado por email
+func (f *invitationFixture) invite(t *testing.T, req *CreateInvitationRequest) (*entities.Invitation, string) {
+	t.Helper()
+	invitation, err := f.uc.CreateInvitation(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := f.mailer.sent()
+	return invitation, linkToken(t, sent[len(sent)-1])
+}
+
+// role retorna la definición del rol de sistema indicado
+func (f *invitationFixture) role(name entities.Role) *entities.RoleDefinition {
+	role, _ := f.roleRepo.GetByName(context.Background(), string(name))
+	return role
+}
+
+// member retorna la membresía del usuario en la organización de la prueba
+func (f *invitationFixture) member(userID uuid.UUID) *entities.OrganizationMember {
+	member, _ := f.orgRepo.FindMember(context.Background(), f.organization.ID.String(), userID.String())
+	return member
+}
+
+func TestAcceptInvitationCreatesAccount(t *testing.T) {
+	f := newInvitationFixture(t)
+	invitation, token := f.invite(t, &CreateInvitationRequest{
+		Email:            "nuevo@example.com",
+		Role:             string(entities.RoleAdmin),
+		OrganizationID:   f.organization.ID.String(),
+		OrganizationRole: string(entities.RoleOrgAdmin),
+	})
+
+	response, err := f.uc.AcceptInvitation(context.Background(), &AcceptInvitationRequest{
+		Token:     token,
+		Password:  "Correct-horse-42",
+		FirstName: "Nuevo",
+		LastName:  "Usuario",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.Created {
+		t.Error("account was not reported as created")
+	}
+	user, ok := f.store.users[response.User.ID]
+	if !ok {
+		t.Fatal("account was not saved")
+	}
+	if user.Role != entities.RoleAdmin || !user.EmailVerified {
+		t.Errorf("account has role %q and verified email %t", user.Role, user.EmailVerified)
+	}
+	if !f.passSvc.Verify("Correct-horse-42", user.Password) {
+		t.Error("account password was not set")
+	}
+	if member := f.member(user.ID); member == nil || member.Role != entities.RoleOrgAdmin {
+		t.Errorf("account did not join the organization as %s: %+v", entities.RoleOrgAdmin, member)
+	}
+
+	stored := f.store.invitations[invitation.ID]
+	if stored.AcceptedAt == nil || stored.AcceptedBy == nil || *stored.AcceptedBy != user.ID {
+		t.Errorf("invitation was not marked as accepted by the account: %+v", stored)
+	}
+	actions := f.auditRepo.actions()
+	if !containsAction(actions, entities.AuditActionInvitationAccepted) || !containsAction(actions, entities.AuditActionMemberAdded) {
+		t.Errorf("acceptance was not audited: %v", actions)
+	}
+}
+
+func TestAcceptInvitationRequiresAccountDetails(t *testing.T) {
+	f := newInvitationFixture(t)
+	invitation, token := f.invite(t, &CreateInvitationRequest{Email: "nuevo@example.com"})
+
+	for name, req := range map[string]*AcceptInvitationRequest{
+		"missing password": {Token: token, FirstName: "Nuevo", LastName: "Usuario"},
+		"weak password":    {Token: token, Password: "short", FirstName: "Nuevo", LastName: "Usuario"},
+	} {
+		if _, err := f.uc.AcceptInvitation(context.Background(), req); err == nil {
+			t.Errorf("%s: invitation was accepted", name)
+		}
+	}
+
+	if len(f.store.users) != 0 {
+		t.Error("account was created")
+	}
+	if f.store.invitations[invitation.ID].AcceptedAt != nil {
+		t.Error("invitation was claimed by a rejected acceptance")
+	}
+}
+
+func TestAcceptInvitationLinksExistingAccount(t *testing.T) {
+	f := newInvitationFixture(t)
+	user := newTestUser(f.store)
+	if err := f.orgRepo.AddMember(context.Background(), entities.NewOrganizationMember(f.organization.ID, user.ID, entities.RoleOrgMember)); err != nil {
+		t.Fatal(err)
+	}
+	_, token := f.invite(t, &CreateInvitationRequest{
+		Email:            user.Email,
+		Role:             string(entities.RoleAdmin),
+		OrganizationID:   f.organization.ID.String(),
+		OrganizationRole: string(entities.RoleOrgAdmin),
+	})
+
+	// La cuenta existente no necesita contraseña ni nombre
+	response, err := f.uc.AcceptInvitation(context.Background(), &AcceptInvitationRequest{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Created || response.User.ID != user.ID {
+		t.Fatalf("existing account was not linked: created=%t user=%s", response.Created, response.User.ID)
+	}
+	if len(f.store.users) != 1 {
+		t.Errorf("a second account was created: %d accounts", len(f.store.users))
+	}
+	stored := f.store.users[user.ID]
+	if stored.Role != entities.RoleUser || !stored.EmailVerified {
+		t.Errorf("account has role %q and verified email %t", stored.Role, stored.EmailVerified)
+	}
+	holders := f.roleRepo.holders[f.role(entities.RoleAdmin).ID.String()]
+	if len(holders) != 1 || holders[0] != user.ID.String() {
+		t.Errorf("invited role was not assigned as additional role: %v", holders)
+	}
+	if member := f.member(user.ID); member == nil || member.Role != entities.RoleOrgAdmin {
+		t.Errorf("membership role was not updated: %+v", member)
+	}
+
+	// Los access tokens emitidos con los roles anteriores dejan de valer
+	if f.revocationRepo.watermark(user.ID.String()).IsZero() {
+		t.Error("access tokens were not revoked after the role was assigned")
+	}
+	actions := f.auditRepo.actions()
+	if !containsAction(actions, entities.AuditActionRoleAssigned) || !containsAction(actions, entities.AuditActionMemberRoleChanged) {
+		t.Errorf("role and membership changes were not audited: %v", actions)
+	}
+}
+
+func TestAcceptInvitationCanOnlyBeAcceptedOnce(t *testing.T) {
+	f := newInvitationFixture(t)
+	_, token := f.invite(t, &CreateInvitationRequest{Email: "nuevo@example.com"})
+	req := &AcceptInvitationRequest{Token: token, Password: "Correct-horse-42", FirstName: "Nuevo", LastName: "Usuario"}
+
+	if _, err := f.uc.AcceptInvitation(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.uc.AcceptInvitation(context.Background(), req); err == nil {
+		t.Fatal("invitation was accepted twice")
+	}
+	if len(f.store.users) != 1 {
+		t.Errorf("expected one account, got %d", len(f.store.users))
+	}
+}
+
+// claimingInvitationRepo simula otra aceptación que reclama la invitación justo después de leerla
+type claimingInvitationRepo struct {
+	*memoryInvitationRepo
+}
+
+func (r *claimingInvitationRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error) {
+	invitation, err := r.memoryInvitationRepo.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, r.Claim(ctx, invitation.ID.String())
+}
+
+func TestAcceptInvitationClaimsBeforeChangingTheAccount(t *testing.T) {
+	f := newInvitationFixture(t)
+	user := newTestUser(f.store)
+	_, token := f.invite(t, &CreateInvitationRequest{
+		Email:          user.Email,
+		Role:           string(entities.RoleAdmin),
+		OrganizationID: f.organization.ID.String(),
+	})
+	f.uc.invitationRepo = &claimingInvitationRepo{&memoryInvitationRepo{store: f.store}}
+
+	if _, err := f.uc.AcceptInvitation(context.Background(), &AcceptInvitationRequest{Token: token}); err == nil {
+		t.Fatal("invitation claimed by another acceptance was accepted")
+	}
+
+	if len(f.roleRepo.holders) != 0 {
+		t.Errorf("role was assigned: %v", f.roleRepo.holders)
+	}
+	if member := f.member(user.ID); member != nil {
+		t.Errorf("account joined the organization: %+v", member)
+	}
+	if f.store.users[user.ID].EmailVerified {
+		t.Error("email was verified")
+	}
+	if containsAction(f.auditRepo.actions(), entities.AuditActionInvitationAccepted) {
+		t.Error("acceptance was audited")
+	}
+}
+
+func TestAcceptInvitationRollsBackWhenAStepFails(t *testing.T) {
+	f := newInvitationFixture(t)
+	invitation, token := f.invite(t, &CreateInvitationRequest{Email: "nuevo@example.com"})
+	req := &AcceptInvitationRequest{Token: token, Password: "Correct-horse-42", FirstName: "Nuevo", LastName: "Usuario"}
+	f.store.failures["users.create"] = errors.New("insert user: connection reset")
+
+	if _, err := f.uc.AcceptInvitation(context.Background(), req); err == nil {
+		t.Fatal("expected the account creation error")
+	}
+	if f.store.invitations[invitation.ID].AcceptedAt != nil {
+		t.Fatal("invitation stayed claimed after rollback")
+	}
+
+	// La invitación se puede volver a aceptar
+	delete(f.store.failures, "users.create")
+	if _, err := f.uc.AcceptInvitation(context.Background(), req); err != nil {
+		t.Fatalf("accepting after rollback failed: %v", err)
+	}
+}
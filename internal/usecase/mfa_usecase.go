@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/encryption"
+	"auth-go-microservicio/pkg/jwt"
+	"auth-go-microservicio/pkg/totp"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// recoveryCodeCount es la cantidad de códigos de recuperación que se entregan al activar MFA
+	recoveryCodeCount = 10
+	// totpSkew es la cantidad de pasos de desfase de reloj que se toleran
+	totpSkew = 1
+)
+
+// MFAUseCase maneja el segundo factor de autenticación de las cuentas locales
+type MFAUseCase struct {
+	mfaRepo             repositories.MFARepository
+	webauthnRepo        repositories.WebAuthnRepository
+	userRepo            repositories.UserRepository
+	oneTimeTokenRepo    repositories.OneTimeTokenRepository
+	jwtSvc              jwt.Service
+	encryptionSvc       encryption.Service
+	auditLogger         *AuditLogger
+	issuer              string
+	challengeExpiration time.Duration
+}
+
+// NewMFAUseCase crea una nueva instancia de MFAUseCase
+func NewMFAUseCase(
+	mfaRepo repositories.MFARepository,
+	webauthnRepo repositories.WebAuthnRepository,
+	userRepo repositories.UserRepository,
+	oneTimeTokenRepo repositories.OneTimeTokenRepository,
+	jwtSvc jwt.Service,
+	encryptionSvc encryption.Service,
+	auditLogger *AuditLogger,
+	issuer string,
+	challengeExpiration time.Duration,
+) *MFAUseCase {
+	return &MFAUseCase{
+		mfaRepo:             mfaRepo,
+		webauthnRepo:        webauthnRepo,
+		userRepo:            userRepo,
+		oneTimeTokenRepo:    oneTimeTokenRepo,
+		jwtSvc:              jwtSvc,
+		encryptionSvc:       encryptionSvc,
+		auditLogger:         auditLogger,
+		issuer:              issuer,
+		challengeExpiration: challengeExpiration,
+	}
+}
+
+// SetupTOTPRequest representa la solicitud de alta de TOTP
+type SetupTOTPRequest struct {
+	UserID string `json:"-"`
+}
+
+// SetupTOTPResponse representa el secreto a cargar en la aplicación de autenticación
+type SetupTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// SetupTOTP genera un secreto TOTP pendiente de confirmación.
+// Repetir el alta antes de confirmar reemplaza el secreto anterior.
+func (uc *MFAUseCase) SetupTOTP(ctx context.Context, req *SetupTOTPRequest) (*SetupTOTPResponse, error) {
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if existing, err := uc.mfaRepo.GetTOTP(ctx, req.UserID); err == nil && existing.IsConfirmed() {
+		return nil, errors.New("totp is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := uc.encryptionSvc.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.mfaRepo.SaveTOTP(ctx, entities.NewTOTPCredential(user.ID, encryptedSecret)); err != nil {
+		return nil, err
+	}
+
+	return &SetupTOTPResponse{
+		Secret: secret,
+		URI:    totp.URI(uc.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPRequest representa la confirmación del alta de TOTP
+type ConfirmTOTPRequest struct {
+	UserID string `json:"-"`
+	Code   string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// ConfirmTOTPResponse contiene los códigos de recuperación; solo se muestran una vez
+type ConfirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ConfirmTOTP activa TOTP tras verificar un primer código y genera los códigos de recuperación
+func (uc *MFAUseCase) ConfirmTOTP(ctx context.Context, req *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error) {
+	credential, err := uc.mfaRepo.GetTOTP(ctx, req.UserID)
+	if err != nil {
+		return nil, errors.New("totp setup not started")
+	}
+
+	if credential.IsConfirmed() {
+		return nil, errors.New("totp is already enabled")
+	}
+
+	if err := uc.verifyTOTP(ctx, credential, req.Code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := uc.generateRecoveryCodes(ctx, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.mfaRepo.ConfirmTOTP(ctx, req.UserID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionMFAEnabled,
+		TargetID: req.UserID,
+		Metadata: map[string]interface{}{"method": MFAMethodTOTP},
+	})
+
+	return &ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// ResetMFA elimina los segundos factores de un usuario, incluidas sus credenciales WebAuthn (acción de administrador)
+func (uc *MFAUseCase) ResetMFA(ctx context.Context, userID string) error {
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := uc.mfaRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err := uc.webauthnRepo.DeleteCredentialsByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionMFAReset,
+		TargetID: userID,
+	})
+	return nil
+}
+
+// Métodos de segundo factor que puede ofrecer el login
+const (
+	MFAMethodTOTP     = "totp"
+	MFAMethodWebAuthn = "webauthn"
+)
+
+// Methods retorna los segundos factores activos del usuario; vacío si no tiene ninguno
+func (uc *MFAUseCase) Methods(ctx context.Context, userID string) ([]string, error) {
+	var methods []string
+
+	// Sin credencial TOTP no hay ese factor
+	if credential, err := uc.mfaRepo.GetTOTP(ctx, userID); err == nil && credential.IsConfirmed() {
+		methods = append(methods, MFAMethodTOTP)
+	}
+
+	credentials, err := uc.webauthnRepo.ListCredentialsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) > 0 {
+		methods = append(methods, MFAMethodWebAuthn)
+	}
+
+	return methods, nil
+}
+
+// CreateChallenge emite el token de desafío que se intercambia en /auth/mfa/verify
+func (uc *MFAUseCase) CreateChallenge(ctx context.Context, user *entities.User) (string, error) {
+	purpose := entities.TokenPurposeMFAChallenge
+
+	token, err := uc.jwtSvc.GenerateActionToken(user.ID.String(), string(purpose), uc.challengeExpiration)
+	if err != nil {
+		return "", err
+	}
+
+	oneTimeToken := entities.NewOneTimeToken(user.ID, purpose, hashToken(token), time.Now().Add(uc.challengeExpiration))
+	if err := uc.oneTimeTokenRepo.Create(ctx, oneTimeToken); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// VerifyMFARequest representa la segunda etapa del login
+type VerifyMFARequest struct {
+	MFAToken   string `json:"mfa_token" binding:"required"`
+	Code       string `json:"code" binding:"required"` // código TOTP o código de recuperación
+	DeviceName string `json:"device_name" binding:"omitempty,max=255"`
+	UserAgent  string `json:"-"`
+	IPAddress  string `json:"-"`
+}
+
+// VerifyChallenge valida el token de desafío y el código, y retorna el usuario autenticado.
+// El token de desafío se consume solo si el código es correcto.
+func (uc *MFAUseCase) VerifyChallenge(ctx context.Context, req *VerifyMFARequest) (*entities.User, error) {
+	userID, err := uc.ValidateChallenge(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.VerifyCode(ctx, userID, req.Code); err != nil {
+		uc.auditLogger.Log(ctx, AuditEntry{
+			Action:   entities.AuditActionMFAFailed,
+			Outcome:  entities.AuditOutcomeFailure,
+			ActorID:  userID,
+			TargetID: userID,
+		})
+		return nil, err
+	}
+
+	if err := uc.ConsumeChallenge(ctx, req.MFAToken, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return user, nil
+}
+
+// ValidateChallenge verifica la firma y vigencia del token de desafío y retorna el ID del usuario
+func (uc *MFAUseCase) ValidateChallenge(mfaToken string) (string, error) {
+	claims, err := uc.jwtSvc.ValidateActionToken(mfaToken, string(entities.TokenPurposeMFAChallenge))
+	if err != nil {
+		return "", errors.New("invalid or expired mfa token")
+	}
+	return claims.UserID, nil
+}
+
+// ConsumeChallenge marca el token de desafío como usado una vez superado el segundo factor
+func (uc *MFAUseCase) ConsumeChallenge(ctx context.Context, mfaToken, userID string) error {
+	oneTimeToken, err := uc.oneTimeTokenRepo.Consume(ctx, hashToken(mfaToken), entities.TokenPurposeMFAChallenge)
+	if err != nil || oneTimeToken.UserID.String() != userID {
+		return errors.New("invalid or expired mfa token")
+	}
+	return nil
+}
+
+// VerifyCode verifica un código TOTP o, si no tiene ese formato, un código de recuperación
+func (uc *MFAUseCase) VerifyCode(ctx context.Context, userID, code string) error {
+	credential, err := uc.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil || !credential.IsConfirmed() {
+		return errors.New("mfa is not enabled")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return uc.verifyTOTP(ctx, credential, code)
+	}
+
+	if err := uc.mfaRepo.ConsumeRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		return errors.New("invalid mfa code")
+	}
+
+	remaining, err := uc.mfaRepo.CountRecoveryCodes(ctx, userID)
+	if err == nil {
+		uc.auditLogger.Log(ctx, AuditEntry{
+			Action:   entities.AuditActionRecoveryCodeUsed,
+			ActorID:  userID,
+			TargetID: userID,
+			Metadata: map[string]interface{}{"remaining": remaining},
+		})
+	}
+
+	return nil
+}
+
+// verifyTOTP valida un código TOTP y registra su paso para impedir que se reutilice
+func (uc *MFAUseCase) verifyTOTP(ctx context.Context, credential *entities.TOTPCredential, code string) error {
+	secret, err := uc.encryptionSvc.Decrypt(credential.EncryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, err := totp.Validate(string(secret), code, time.Now(), totpSkew)
+	if err != nil {
+		return errors.New("invalid mfa code")
+	}
+
+	if step <= credential.LastUsedStep {
+		return errors.New("invalid mfa code")
+	}
+
+	if err := uc.mfaRepo.UseTOTPStep(ctx, credential.UserID.String(), step); err != nil {
+		return errors.New("invalid mfa code")
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes genera y guarda nuevos códigos de recuperación, reemplazando los anteriores
+func (uc *MFAUseCase) generateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	recoveryCodes := make([]*entities.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		code := encoded[:8] + "-" + encoded[8:]
+
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, entities.NewRecoveryCode(userID, hashToken(normalizeRecoveryCode(code))))
+	}
+
+	if err := uc.mfaRepo.ReplaceRecoveryCodes(ctx, userID.String(), recoveryCodes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignora mayúsculas, espacios y guiones al comparar códigos de recuperación
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
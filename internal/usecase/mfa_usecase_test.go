@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/encryption"
+	"auth-go-microservicio/pkg/totp"
+)
+
+// newTestMFAUseCase crea un MFAUseCase con repositorios en memoria
+func newTestMFAUseCase(t *testing.T, store *memoryStore, mfaRepo *memoryMFARepo, auditRepo *memoryAuditRepo) *MFAUseCase {
+	t.Helper()
+	encryptionSvc, err := encryption.NewService("test-encryption-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewMFAUseCase(mfaRepo, newMemoryWebAuthnRepo(), &memoryUserRepo{store: store}, newMemoryOneTimeTokenRepo(),
+		newTestJWTService(t), encryptionSvc, NewAuditLogger(auditRepo), "Auth Service", time.Minute)
+}
+
+// currentTOTPStep retorna el paso actual; si está por terminar espera al siguiente para que los
+// códigos calculados por el test no salgan de la ventana antes de validarse
+func currentTOTPStep(t *testing.T) int64 {
+	t.Helper()
+	remaining := totp.Period - time.Duration(time.Now().UnixNano()%int64(totp.Period))
+	if remaining < 2*time.Second {
+		time.Sleep(remaining)
+	}
+	return totp.Step(time.Now())
+}
+
+// totpCode calcula el código de un paso o falla el test
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// setupTOTP inicia el alta de TOTP y retorna el secreto
+func setupTOTP(t *testing.T, uc *MFAUseCase, user *entities.User) string {
+	t.Helper()
+	setup, err := uc.SetupTOTP(context.Background(), &SetupTOTPRequest{UserID: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return setup.Secret
+}
+
+func TestConfirmTOTPRejectsInvalidCode(t *testing.T) {
+	store := newMemoryStore()
+	user := newTestUser(store)
+	mfaRepo := newMemoryMFARepo()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestMFAUseCase(t, store, mfaRepo, auditRepo)
+	ctx := context.Background()
+
+	secret := setupTOTP(t, uc, user)
+	step := currentTOTPStep(t)
+
+	for _, code := range []string{totpCode(t, secret, step+3), totpCode(t, secret, step-3)} {
+		if _, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: code}); err == nil {
+			t.Fatalf("code %s outside the window confirmed totp", code)
+		}
+	}
+
+	credential, _ := mfaRepo.GetTOTP(ctx, user.ID.String())
+	if credential.IsConfirmed() {
+		t.Fatal("credential confirmed with an invalid code")
+	}
+	if count, _ := mfaRepo.CountRecoveryCodes(ctx, user.ID.String()); count != 0 {
+		t.Errorf("expected no recovery codes, got %d", count)
+	}
+	if methods, _ := uc.Methods(ctx, user.ID.String()); len(methods) != 0 {
+		t.Errorf("expected no mfa methods, got %v", methods)
+	}
+	if containsAction(auditRepo.actions(), entities.AuditActionMFAEnabled) {
+		t.Error("mfa_enabled audited for a rejected confirmation")
+	}
+
+	response, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: totpCode(t, secret, step)})
+	if err != nil {
+		t.Fatalf("valid code rejected: %v", err)
+	}
+	if len(response.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", recoveryCodeCount, len(response.RecoveryCodes))
+	}
+	if _, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: totpCode(t, secret, step+1)}); err == nil {
+		t.Error("totp confirmed twice")
+	}
+}
+
+func TestVerifyCodeRejectsReusedStep(t *testing.T) {
+	store := newMemoryStore()
+	user := newTestUser(store)
+	mfaRepo := newMemoryMFARepo()
+	uc := newTestMFAUseCase(t, store, mfaRepo, &memoryAuditRepo{})
+	ctx := context.Background()
+
+	secret := setupTOTP(t, uc, user)
+	step := currentTOTPStep(t)
+	code := totpCode(t, secret, step)
+	if _, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: code}); err != nil {
+		t.Fatal(err)
+	}
+
+	// El código de confirmación ya consumió su paso, y uno anterior dentro de la ventana tampoco sirve
+	if err := uc.VerifyCode(ctx, user.ID.String(), code); err == nil {
+		t.Fatal("code accepted twice")
+	}
+	if err := uc.VerifyCode(ctx, user.ID.String(), totpCode(t, secret, step-1)); err == nil {
+		t.Fatal("code from a step before the last used one accepted")
+	}
+
+	if err := uc.VerifyCode(ctx, user.ID.String(), totpCode(t, secret, step+1)); err != nil {
+		t.Fatalf("code from the next step rejected: %v", err)
+	}
+	credential, _ := mfaRepo.GetTOTP(ctx, user.ID.String())
+	if credential.LastUsedStep != step+1 {
+		t.Errorf("expected last used step %d, got %d", step+1, credential.LastUsedStep)
+	}
+}
+
+func TestVerifyCodeAcceptsOneStepOfClockSkew(t *testing.T) {
+	store := newMemoryStore()
+	user := newTestUser(store)
+	uc := newTestMFAUseCase(t, store, newMemoryMFARepo(), &memoryAuditRepo{})
+	ctx := context.Background()
+
+	secret := setupTOTP(t, uc, user)
+	step := currentTOTPStep(t)
+
+	if _, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: totpCode(t, secret, step-1)}); err != nil {
+		t.Fatalf("code from the previous step rejected: %v", err)
+	}
+	if err := uc.VerifyCode(ctx, user.ID.String(), totpCode(t, secret, step+2)); err == nil {
+		t.Fatal("code two steps ahead accepted")
+	}
+	if err := uc.VerifyCode(ctx, user.ID.String(), totpCode(t, secret, step+1)); err != nil {
+		t.Fatalf("code from the next step rejected: %v", err)
+	}
+}
+
+func TestRecoveryCodeCanBeUsedOnce(t *testing.T) {
+	store := newMemoryStore()
+	user := newTestUser(store)
+	mfaRepo := newMemoryMFARepo()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestMFAUseCase(t, store, mfaRepo, auditRepo)
+	ctx := context.Background()
+
+	secret := setupTOTP(t, uc, user)
+	response, err := uc.ConfirmTOTP(ctx, &ConfirmTOTPRequest{UserID: user.ID.String(), Code: totpCode(t, secret, currentTOTPStep(t))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCode := response.RecoveryCodes[0]
+
+	// Mayúsculas, espacios y guiones se ignoran
+	typed := " " + strings.ToUpper(strings.ReplaceAll(recoveryCode, "-", "")) + " "
+	if err := uc.VerifyCode(ctx, user.ID.String(), typed); err != nil {
+		t.Fatalf("recovery code rejected: %v", err)
+	}
+	if err := uc.VerifyCode(ctx, user.ID.String(), recoveryCode); err == nil {
+		t.Fatal("recovery code accepted twice")
+	}
+	if err := uc.VerifyCode(ctx, user.ID.String(), "aaaaaaaa-bbbbbbbb"); err == nil {
+		t.Fatal("unknown recovery code accepted")
+	}
+
+	if count, _ := mfaRepo.CountRecoveryCodes(ctx, user.ID.String()); count != recoveryCodeCount-1 {
+		t.Errorf("expected %d remaining recovery codes, got %d", recoveryCodeCount-1, count)
+	}
+	if !containsAction(auditRepo.actions(), entities.AuditActionRecoveryCodeUsed) {
+		t.Error("expected recovery_code_used to be audited")
+	}
+}
+
+func TestVerifyCodeRequiresConfirmedTOTP(t *testing.T) {
+	store := newMemoryStore()
+	user := newTestUser(store)
+	uc := newTestMFAUseCase(t, store, newMemoryMFARepo(), &memoryAuditRepo{})
+
+	secret := setupTOTP(t, uc, user)
+	if err := uc.VerifyCode(context.Background(), user.ID.String(), totpCode(t, secret, currentTOTPStep(t))); err == nil {
+		t.Fatal("code accepted before totp was confirmed")
+	}
+}
@@ -0,0 +1,1119 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+// Códigos de error del protocolo OAuth 2.0 (RFC 6749, secciones 4.1.2.1 y 5.2)
+const (
+	OAuthErrInvalidRequest          = "invalid_request"
+	OAuthErrInvalidClient           = "invalid_client"
+	OAuthErrInvalidGrant            = "invalid_grant"
+	OAuthErrUnauthorizedClient      = "unauthorized_client"
+	OAuthErrUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrInvalidScope            = "invalid_scope"
+	OAuthErrAccessDenied            = "access_denied"
+)
+
+// codeChallengeMethodS256 es el único método PKCE soportado (RFC 7636)
+const codeChallengeMethodS256 = "S256"
+
+// Scopes de OpenID Connect; openid pide un ID token y los demás liberan claims del usuario
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var (
+	// ErrOAuthUnavailable indica que el servidor de autorización no está disponible con Keycloak
+	ErrOAuthUnavailable = errors.New("oauth authorization server is not supported with keycloak")
+
+	// errAuthorizationCodeReused revierte el canje de un código que otra petición ya canjeó
+	errAuthorizationCodeReused = errors.New("authorization code already used")
+)
+
+// OAuthError representa un error del protocolo OAuth que se informa al cliente
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// Error implementa la interfaz error
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// newOAuthError crea un error del protocolo OAuth
+func newOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// OAuthConfig configura el servidor de autorización OAuth
+type OAuthConfig struct {
+	Issuer           string        // URL pública del servicio; iss de los ID tokens y base del discovery
+	ConsentURL       string        // página del frontend que autentica al usuario y pide el consentimiento
+	CodeExpiry       time.Duration // vigencia de los códigos de autorización
+	AccessTokenTTL   time.Duration // vigencia de los access tokens, informada en expires_in
+	EmbedPermissions bool          // incluye los permisos en el access token
+}
+
+// OAuthUseCase implementa el servidor de autorización OAuth 2.0 del modo local: registro de
+// clientes, flujo authorization_code con PKCE y renovación con refresh_token. Los tokens se
+// emiten con jwt.Service y los refresh tokens se guardan con el TokenRepository, ligados al cliente.
+// El grant client_credentials se delega en ServiceAccountUseCase.
+type OAuthUseCase struct {
+	oauthRepo        repositories.OAuthRepository
+	userRepo         repositories.UserRepository
+	tokenRepo        repositories.TokenRepository
+	sessionRepo      repositories.SessionRepository
+	uow              repositories.UnitOfWork
+	roleUC           *RoleUseCase
+	orgUC            *OrganizationUseCase
+	serviceAccountUC *ServiceAccountUseCase
+	jwtSvc           jwt.Service
+	auditLogger      *AuditLogger
+	config           OAuthConfig
+	useKeycloak      bool
+}
+
+// NewOAuthUseCase crea una nueva instancia de OAuthUseCase
+func NewOAuthUseCase(
+	oauthRepo repositories.OAuthRepository,
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.TokenRepository,
+	sessionRepo repositories.SessionRepository,
+	uow repositories.UnitOfWork,
+	roleUC *RoleUseCase,
+	orgUC *OrganizationUseCase,
+	serviceAccountUC *ServiceAccountUseCase,
+	jwtSvc jwt.Service,
+	auditLogger *AuditLogger,
+	config OAuthConfig,
+	useKeycloak bool,
+) *OAuthUseCase {
+	return &OAuthUseCase{
+		oauthRepo:        oauthRepo,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		uow:              uow,
+		roleUC:           roleUC,
+		orgUC:            orgUC,
+		serviceAccountUC: serviceAccountUC,
+		jwtSvc:           jwtSvc,
+		auditLogger:      auditLogger,
+		config:           config,
+		useKeycloak:      useKeycloak,
+	}
+}
+
+// CreateOAuthClientRequest representa la solicitud de registro de un cliente
+type CreateOAuthClientRequest struct {
+	Name          string   `json:"name" binding:"required,max=255"`
+	Type          string   `json:"type" binding:"required,oneof=public confidential"`
+	RedirectURIs  []string `json:"redirect_uris" binding:"required,min=1"`
+	AllowedScopes []string `json:"allowed_scopes"`
+	SkipConsent   bool     `json:"skip_consent"` // solo para aplicaciones propias
+	CreatedBy     string   `json:"-"`
+}
+
+// OAuthClientResponse representa un cliente; ClientSecret solo se incluye al crearlo o rotarlo
+type OAuthClientResponse struct {
+	*entities.OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// CreateClient registra un cliente y, si es confidencial, retorna su secreto
+func (uc *OAuthUseCase) CreateClient(ctx context.Context, req *CreateOAuthClientRequest) (*OAuthClientResponse, error) {
+	if uc.useKeycloak {
+		return nil, ErrOAuthUnavailable
+	}
+
+	redirectURIs, err := parseRedirectURIs(req.RedirectURIs)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := parseScopes(req.AllowedScopes)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdBy *uuid.UUID
+	if req.CreatedBy != "" {
+		id, err := uuid.Parse(req.CreatedBy)
+		if err != nil {
+			return nil, errors.New("invalid user id")
+		}
+		createdBy = &id
+	}
+
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, err
+	}
+
+	client := entities.NewOAuthClient(clientID, req.Name, entities.OAuthClientType(req.Type), redirectURIs, scopes, createdBy)
+	client.SkipConsent = req.SkipConsent
+
+	response := &OAuthClientResponse{OAuthClient: client}
+	if !client.IsPublic() {
+		if response.ClientSecret, err = newRandomToken(); err != nil {
+			return nil, err
+		}
+		client.SecretHash = hashToken(response.ClientSecret)
+	}
+
+	if err := uc.oauthRepo.CreateClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthClientCreated,
+		TargetID: client.ID.String(),
+		Metadata: map[string]interface{}{"client_id": client.ClientID, "name": client.Name, "type": client.Type},
+	})
+
+	return response, nil
+}
+
+// ListClients lista todos los clientes
+func (uc *OAuthUseCase) ListClients(ctx context.Context) ([]*entities.OAuthClient, error) {
+	return uc.oauthRepo.ListClients(ctx)
+}
+
+// GetClient obtiene un cliente por su ID interno
+func (uc *OAuthUseCase) GetClient(ctx context.Context, id string) (*entities.OAuthClient, error) {
+	return uc.oauthRepo.GetClient(ctx, id)
+}
+
+// UpdateOAuthClientRequest representa la solicitud de actualización de un cliente; los campos
+// omitidos no se modifican. El tipo de cliente no se puede cambiar.
+type UpdateOAuthClientRequest struct {
+	ID            string    `json:"-"`
+	Name          string    `json:"name" binding:"max=255"`
+	RedirectURIs  *[]string `json:"redirect_uris"`
+	AllowedScopes *[]string `json:"allowed_scopes"`
+	SkipConsent   *bool     `json:"skip_consent"`
+	RotateSecret  bool      `json:"rotate_secret"` // genera un secreto nuevo y lo retorna
+}
+
+// UpdateClient actualiza el cliente
+func (uc *OAuthUseCase) UpdateClient(ctx context.Context, req *UpdateOAuthClientRequest) (*OAuthClientResponse, error) {
+	client, err := uc.oauthRepo.GetClient(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if req.Name != "" {
+		client.Name = req.Name
+		changes["name"] = req.Name
+	}
+	if req.RedirectURIs != nil {
+		if client.RedirectURIs, err = parseRedirectURIs(*req.RedirectURIs); err != nil {
+			return nil, err
+		}
+		changes["redirect_uris"] = client.RedirectURIs
+	}
+	if req.AllowedScopes != nil {
+		if client.AllowedScopes, err = parseScopes(*req.AllowedScopes); err != nil {
+			return nil, err
+		}
+		changes["allowed_scopes"] = client.AllowedScopes
+	}
+	if req.SkipConsent != nil {
+		client.SkipConsent = *req.SkipConsent
+		changes["skip_consent"] = client.SkipConsent
+	}
+
+	response := &OAuthClientResponse{OAuthClient: client}
+	if req.RotateSecret {
+		if client.IsPublic() {
+			return nil, errors.New("public clients do not have a secret")
+		}
+		if response.ClientSecret, err = newRandomToken(); err != nil {
+			return nil, err
+		}
+		client.SecretHash = hashToken(response.ClientSecret)
+		changes["secret_rotated"] = true
+	}
+
+	client.UpdatedAt = time.Now()
+	if err := uc.oauthRepo.UpdateClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthClientUpdated,
+		TargetID: client.ID.String(),
+		Metadata: changes,
+	})
+
+	return response, nil
+}
+
+// DeleteClient elimina el cliente y revoca sus refresh tokens y sesiones; los access tokens ya
+// emitidos siguen vigentes hasta expirar
+func (uc *OAuthUseCase) DeleteClient(ctx context.Context, id string) error {
+	if err := uc.oauthRepo.DeleteClient(ctx, id); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthClientDeleted,
+		TargetID: id,
+	})
+	return nil
+}
+
+// AuthorizeRequest representa los parámetros de la solicitud de autorización (RFC 6749
+// sección 4.1.1 y RFC 7636 sección 4.3)
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"` // se devuelve en el ID token
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// authorization representa una solicitud de autorización cuyo cliente y redirect_uri son válidos
+type authorization struct {
+	client      *entities.OAuthClient
+	redirectURI string
+	scopes      []string
+	req         *AuthorizeRequest
+}
+
+// StartAuthorization valida la solicitud y retorna la URL a la que se redirige el navegador:
+// la página de consentimiento del frontend o, si la solicitud es inválida, el redirect_uri del
+// cliente con el error. Si el cliente o el redirect_uri no son válidos retorna un error y no
+// se redirige (RFC 6749 sección 4.1.2.1).
+func (uc *OAuthUseCase) StartAuthorization(ctx context.Context, req *AuthorizeRequest) (string, error) {
+	auth, err := uc.validateAuthorization(ctx, req)
+	if auth == nil {
+		return "", err
+	}
+	if err != nil {
+		return auth.errorRedirect(err), nil
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", auth.client.ClientID)
+	query.Set("redirect_uri", auth.redirectURI)
+	query.Set("scope", strings.Join(auth.scopes, " "))
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	if req.Nonce != "" {
+		query.Set("nonce", req.Nonce)
+	}
+	if req.CodeChallenge != "" {
+		query.Set("code_challenge", req.CodeChallenge)
+		query.Set("code_challenge_method", req.CodeChallengeMethod)
+	}
+
+	return appendQuery(uc.config.ConsentURL, query), nil
+}
+
+// OAuthConsentClient representa los datos públicos del cliente que se muestran al usuario
+type OAuthConsentClient struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+}
+
+// OAuthConsentResponse representa lo que la página de consentimiento muestra al usuario
+type OAuthConsentResponse struct {
+	Client          OAuthConsentClient `json:"client"`
+	Scopes          []string           `json:"scopes"`
+	ConsentRequired bool               `json:"consent_required"` // false si ya se concedieron los scopes o el cliente no lo pide
+}
+
+// GetConsent valida la solicitud de autorización e indica si el usuario debe dar su consentimiento
+func (uc *OAuthUseCase) GetConsent(ctx context.Context, userID string, req *AuthorizeRequest) (*OAuthConsentResponse, error) {
+	auth, err := uc.validateAuthorization(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OAuthConsentResponse{
+		Client:          OAuthConsentClient{ClientID: auth.client.ClientID, Name: auth.client.Name},
+		Scopes:          auth.scopes,
+		ConsentRequired: uc.consentRequired(ctx, userID, auth),
+	}, nil
+}
+
+// AuthorizeDecisionRequest representa la decisión del usuario sobre una solicitud de autorización
+type AuthorizeDecisionRequest struct {
+	AuthorizeRequest
+	Approved       bool               `json:"approved"`
+	UserID         string             `json:"-"`
+	Authentication jwt.Authentication `json:"-"` // login de la sesión que concede, para auth_time y amr
+}
+
+// AuthorizeDecisionResponse representa la URL del cliente a la que el frontend redirige el navegador
+type AuthorizeDecisionResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// Authorize registra la decisión del usuario autenticado y emite el código de autorización
+func (uc *OAuthUseCase) Authorize(ctx context.Context, req *AuthorizeDecisionRequest) (*AuthorizeDecisionResponse, error) {
+	auth, err := uc.validateAuthorization(ctx, &req.AuthorizeRequest)
+	if auth == nil {
+		return nil, err
+	}
+	if err != nil {
+		return &AuthorizeDecisionResponse{RedirectTo: auth.errorRedirect(err)}, nil
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	if !req.Approved {
+		return &AuthorizeDecisionResponse{
+			RedirectTo: auth.errorRedirect(newOAuthError(OAuthErrAccessDenied, "the user denied the request")),
+		}, nil
+	}
+
+	// Recordar el consentimiento sumando los scopes concedidos antes
+	if uc.consentRequired(ctx, user.ID.String(), auth) {
+		consent := &entities.OAuthConsent{
+			UserID:    user.ID,
+			ClientID:  auth.client.ClientID,
+			Scopes:    auth.scopes,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if previous, err := uc.oauthRepo.GetConsent(ctx, user.ID.String(), auth.client.ClientID); err == nil {
+			consent.Scopes = mergeScopes(previous.Scopes, auth.scopes)
+			consent.CreatedAt = previous.CreatedAt
+		}
+		if err := uc.oauthRepo.SaveConsent(ctx, consent); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	scope := strings.Join(auth.scopes, " ")
+	authorizationCode := entities.NewAuthorizationCode(
+		hashToken(code),
+		auth.client.ClientID,
+		user.ID,
+		auth.redirectURI,
+		scope,
+		req.CodeChallenge,
+		req.CodeChallengeMethod,
+		time.Now().Add(uc.config.CodeExpiry),
+	)
+	authorizationCode.RedirectURIProvided = auth.req.RedirectURI != ""
+	authorizationCode.Nonce = req.Nonce
+	authorizationCode.AuthMethods = req.Authentication.Methods
+	if !req.Authentication.Time.IsZero() {
+		authorizationCode.AuthTime = &req.Authentication.Time
+	} else {
+		// Tokens anteriores a auth_time: el login más reciente del usuario
+		authorizationCode.AuthTime = user.LastLoginAt
+	}
+	if err := uc.oauthRepo.CreateAuthorizationCode(ctx, authorizationCode); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthAuthorized,
+		ActorID:  user.ID.String(),
+		TargetID: user.ID.String(),
+		Metadata: map[string]interface{}{"client_id": auth.client.ClientID, "scope": scope},
+	})
+
+	query := url.Values{}
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	return &AuthorizeDecisionResponse{RedirectTo: appendQuery(auth.redirectURI, query)}, nil
+}
+
+// TokenRequest representa la solicitud al endpoint de tokens (RFC 6749 secciones 4.1.3, 4.4 y 6)
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	UserAgent    string
+	IPAddress    string
+	ClientCredentials
+}
+
+// ClientCredentials representa la autenticación del cliente en los endpoints OAuth: client_id y
+// client_secret (HTTP Basic o en el cuerpo) o, para las cuentas de servicio, un client assertion
+// private_key_jwt (RFC 7523)
+type ClientCredentials struct {
+	ClientID            string
+	ClientSecret        string
+	ClientAssertionType string
+	ClientAssertion     string
+}
+
+// TokenResponse representa la respuesta del endpoint de tokens (RFC 6749 sección 5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"` // solo con el scope openid
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Token autentica al cliente y emite tokens para el grant solicitado. Los errores del protocolo
+// se retornan como *OAuthError.
+func (uc *OAuthUseCase) Token(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	if uc.useKeycloak {
+		return nil, newOAuthError(OAuthErrInvalidRequest, ErrOAuthUnavailable.Error())
+	}
+
+	switch req.GrantType {
+	case "authorization_code", "refresh_token":
+	case "client_credentials":
+		return uc.serviceAccountUC.IssueToken(ctx, req)
+	case "":
+		return nil, newOAuthError(OAuthErrInvalidRequest, "grant_type is required")
+	default:
+		return nil, newOAuthError(OAuthErrUnsupportedGrantType, "")
+	}
+
+	client, err := uc.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GrantType == "authorization_code" {
+		return uc.exchangeCode(ctx, client, req)
+	}
+	return uc.refresh(ctx, client, req)
+}
+
+// CleanupAuthorizationCodes elimina los códigos de autorización expirados
+func (uc *OAuthUseCase) CleanupAuthorizationCodes(ctx context.Context) error {
+	return uc.oauthRepo.DeleteExpiredAuthorizationCodes(ctx, time.Now())
+}
+
+// validateAuthorization valida una solicitud de autorización. Retorna nil y el error si el
+// cliente o el redirect_uri son inválidos; si lo inválido es otro parámetro retorna también la
+// autorización para informar el error en el redirect_uri.
+func (uc *OAuthUseCase) validateAuthorization(ctx context.Context, req *AuthorizeRequest) (*authorization, error) {
+	if uc.useKeycloak {
+		return nil, ErrOAuthUnavailable
+	}
+
+	if req.ClientID == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "client_id is required")
+	}
+	client, err := uc.oauthRepo.GetClientByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, newOAuthError(OAuthErrInvalidClient, "unknown client")
+	}
+
+	// Sin redirect_uri solo se acepta si el cliente registró una única URI
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.AllowsRedirectURI(redirectURI) {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "redirect_uri is not registered for the client")
+	}
+
+	auth := &authorization{client: client, redirectURI: redirectURI, req: req}
+
+	if req.ResponseType != "code" {
+		return auth, newOAuthError(OAuthErrUnsupportedResponseType, "only the code response type is supported")
+	}
+
+	// Sin scope se conceden todos los scopes permitidos al cliente
+	if req.Scope == "" {
+		auth.scopes = client.AllowedScopes
+	} else {
+		auth.scopes = mergeScopes(nil, strings.Fields(req.Scope))
+		if !client.AllowsScopes(auth.scopes) {
+			return auth, newOAuthError(OAuthErrInvalidScope, "the requested scope is not allowed for the client")
+		}
+	}
+
+	// PKCE es obligatorio para los clientes públicos y opcional para los confidenciales
+	if req.CodeChallenge == "" {
+		if client.IsPublic() {
+			return auth, newOAuthError(OAuthErrInvalidRequest, "code_challenge is required for public clients")
+		}
+		if req.CodeChallengeMethod != "" {
+			return auth, newOAuthError(OAuthErrInvalidRequest, "code_challenge_method requires code_challenge")
+		}
+		return auth, nil
+	}
+	if req.CodeChallengeMethod != codeChallengeMethodS256 {
+		return auth, newOAuthError(OAuthErrInvalidRequest, "code_challenge_method must be S256")
+	}
+	if !isPKCEValue(req.CodeChallenge) {
+		return auth, newOAuthError(OAuthErrInvalidRequest, "invalid code_challenge")
+	}
+
+	return auth, nil
+}
+
+// consentRequired indica si el usuario debe conceder los scopes de la autorización
+func (uc *OAuthUseCase) consentRequired(ctx context.Context, userID string, auth *authorization) bool {
+	if auth.client.SkipConsent {
+		return false
+	}
+	consent, err := uc.oauthRepo.GetConsent(ctx, userID, auth.client.ClientID)
+	return err != nil || !consent.Covers(auth.scopes)
+}
+
+// authenticateClient autentica al cliente; los confidenciales deben presentar su secreto
+func (uc *OAuthUseCase) authenticateClient(ctx context.Context, clientID, clientSecret string) (*entities.OAuthClient, error) {
+	if clientID == "" {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication is required")
+	}
+	client, err := uc.oauthRepo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+
+	if !client.IsPublic() {
+		if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+	}
+
+	return client, nil
+}
+
+// exchangeCode canjea un código de autorización por tokens. El código, el refresh token y la
+// sesión se guardan en una transacción; un código canjeado que vuelve a presentarse revoca los
+// tokens emitidos con él (RFC 6749 sección 4.1.2).
+func (uc *OAuthUseCase) exchangeCode(ctx context.Context, client *entities.OAuthClient, req *TokenRequest) (*TokenResponse, error) {
+	if req.Code == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "code is required")
+	}
+
+	code, err := uc.oauthRepo.GetAuthorizationCode(ctx, hashToken(req.Code))
+	if err != nil || code.ClientID != client.ClientID {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid authorization code")
+	}
+	if code.UsedAt != nil {
+		if err := uc.reportCodeReuse(ctx, code); err != nil {
+			return nil, err
+		}
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid authorization code")
+	}
+	if code.IsExpired() {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "authorization code expired")
+	}
+	// redirect_uri es obligatorio solo si la solicitud de autorización lo incluyó (RFC 6749 sección 4.1.3)
+	if (code.RedirectURIProvided || req.RedirectURI != "") && req.RedirectURI != code.RedirectURI {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if code.CodeChallenge != "" && !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid code_verifier")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, code.UserID.String())
+	if err != nil || !user.IsActive {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "user account is not available")
+	}
+
+	orgID, err := uc.orgUC.DefaultOrganization(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	authentication := jwt.Authentication{Methods: code.AuthMethods}
+	if code.AuthTime != nil {
+		authentication.Time = *code.AuthTime
+	}
+	accessToken, err := uc.generateAccessToken(ctx, user, orgID, client, code.Scope, authentication)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := uc.jwtSvc.GenerateClientRefreshToken(user.ID.String(), orgID, client.ClientID, code.Scope, authentication)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := uc.generateIDToken(user, client, code.Scope, code.Nonce, authentication)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenEntity := entities.NewToken(
+		user.ID,
+		refreshToken,
+		entities.TokenTypeRefresh,
+		time.Now().Add(24*7*time.Hour), // 7 días
+	)
+	refreshTokenEntity.ClientID = client.ClientID
+	refreshTokenEntity.Scope = code.Scope
+	session := entities.NewSession(user.ID, refreshTokenEntity.FamilyID, client.Name, req.UserAgent, req.IPAddress)
+
+	err = uc.uow.Do(ctx, func(repos *repositories.TxRepositories) error {
+		if err := repos.OAuth.MarkAuthorizationCodeUsed(ctx, code.CodeHash, refreshTokenEntity.FamilyID.String()); err != nil {
+			if errors.Is(err, repositories.ErrAuthorizationCodeUsed) {
+				return errAuthorizationCodeReused
+			}
+			return err
+		}
+		if err := repos.Tokens.Create(ctx, refreshTokenEntity); err != nil {
+			return err
+		}
+		return repos.Sessions.Create(ctx, session)
+	})
+	if errors.Is(err, errAuthorizationCodeReused) {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid authorization code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(uc.config.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+		Scope:        code.Scope,
+	}, nil
+}
+
+// refresh rota un refresh token del cliente dentro de su familia. Se puede pedir un subconjunto
+// de los scopes concedidos para el access token; el refresh token conserva los originales.
+func (uc *OAuthUseCase) refresh(ctx context.Context, client *entities.OAuthClient, req *TokenRequest) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "refresh_token is required")
+	}
+
+	claims, err := uc.jwtSvc.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid refresh token")
+	}
+
+	token, err := uc.tokenRepo.GetByToken(ctx, req.RefreshToken)
+	if err != nil || token.ClientID != client.ClientID {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid refresh token")
+	}
+
+	// Un token ya rotado que vuelve a presentarse indica robo: se revoca toda la familia
+	if token.IsRevoked {
+		if err := handleRevokedRefreshToken(ctx, uc.tokenRepo, uc.sessionRepo, uc.auditLogger, token); err != nil {
+			return nil, err
+		}
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid refresh token")
+	}
+	if !token.IsValid() {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid refresh token")
+	}
+
+	// Los scopes que el cliente ya no tiene permitidos dejan de concederse
+	granted := make([]string, 0)
+	for _, scope := range strings.Fields(token.Scope) {
+		if client.AllowsScopes([]string{scope}) {
+			granted = append(granted, scope)
+		}
+	}
+	scopes := granted
+	if req.Scope != "" {
+		scopes = mergeScopes(nil, strings.Fields(req.Scope))
+		if !(&entities.OAuthConsent{Scopes: granted}).Covers(scopes) {
+			return nil, newOAuthError(OAuthErrInvalidScope, "the requested scope exceeds the granted scope")
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	user, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || !user.IsActive {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "user account is not available")
+	}
+
+	// El usuario que dejó la organización activa deja de representarla
+	orgID := claims.OrgID
+	if orgID != "" {
+		isMember, err := uc.orgUC.IsMember(ctx, orgID, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			orgID = ""
+		}
+	}
+
+	accessToken, err := uc.generateAccessToken(ctx, user, orgID, client, scope, claims.Authentication())
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, err := uc.jwtSvc.GenerateClientRefreshToken(user.ID.String(), orgID, client.ClientID, token.Scope, claims.Authentication())
+	if err != nil {
+		return nil, err
+	}
+	// El ID token renovado no lleva nonce (OpenID Connect Core, sección 12.2)
+	idToken, err := uc.generateIDToken(user, client, scope, "", claims.Authentication())
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshTokenEntity := entities.NewTokenInFamily(
+		user.ID,
+		token.FamilyID,
+		newRefreshToken,
+		entities.TokenTypeRefresh,
+		time.Now().Add(24*7*time.Hour), // 7 días
+	)
+	newRefreshTokenEntity.ClientID = client.ClientID
+	newRefreshTokenEntity.Scope = token.Scope
+
+	err = uc.uow.Do(ctx, func(repos *repositories.TxRepositories) error {
+		if err := repos.Tokens.RotateToken(ctx, req.RefreshToken, newRefreshTokenEntity.ID.String()); err != nil {
+			if errors.Is(err, repositories.ErrTokenNotRevocable) {
+				return errRefreshTokenReused
+			}
+			return err
+		}
+		if err := repos.Tokens.Create(ctx, newRefreshTokenEntity); err != nil {
+			return err
+		}
+		return touchSession(ctx, repos.Sessions, user, token.FamilyID, req.UserAgent, req.IPAddress)
+	})
+	if errors.Is(err, errRefreshTokenReused) {
+		if current, getErr := uc.tokenRepo.GetByToken(ctx, req.RefreshToken); getErr == nil {
+			if revokeErr := handleRevokedRefreshToken(ctx, uc.tokenRepo, uc.sessionRepo, uc.auditLogger, current); revokeErr != nil {
+				return nil, revokeErr
+			}
+		}
+		return nil, newOAuthError(OAuthErrInvalidGrant, "invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(uc.config.AccessTokenTTL.Seconds()),
+		RefreshToken: newRefreshToken,
+		IDToken:      idToken,
+		Scope:        scope,
+	}, nil
+}
+
+// generateAccessToken genera el access token del usuario para el cliente. No incluye los roles
+// del usuario y, según la configuración, incluye solo los permisos del usuario concedidos como scopes.
+func (uc *OAuthUseCase) generateAccessToken(ctx context.Context, user *entities.User, orgID string, client *entities.OAuthClient, scope string, auth jwt.Authentication) (string, error) {
+	var permissions []string
+	if uc.config.EmbedPermissions {
+		granted, err := uc.roleUC.UserPermissions(ctx, user.ID.String())
+		if err != nil {
+			return "", err
+		}
+		permissions = scopedPermissions(granted, scope)
+	}
+
+	return uc.jwtSvc.GenerateClientToken(user.ID.String(), user.Email, orgID, client.ClientID, scope, permissions, auth)
+}
+
+// generateIDToken genera el ID token si se concedió el scope openid; sin él retorna vacío
+func (uc *OAuthUseCase) generateIDToken(user *entities.User, client *entities.OAuthClient, scope, nonce string, auth jwt.Authentication) (string, error) {
+	scopes := strings.Fields(scope)
+	if !containsScope(scopes, ScopeOpenID) {
+		return "", nil
+	}
+
+	issuer := strings.TrimRight(uc.config.Issuer, "/")
+	return uc.jwtSvc.GenerateIDToken(issuer, user.ID.String(), client.ClientID, nonce, releaseClaims(user, scopes).IdentityClaims, auth)
+}
+
+// reportCodeReuse revoca los tokens emitidos con un código ya canjeado y registra la reutilización
+func (uc *OAuthUseCase) reportCodeReuse(ctx context.Context, code *entities.AuthorizationCode) error {
+	metadata := map[string]interface{}{"client_id": code.ClientID}
+	if code.FamilyID != nil {
+		if err := revokeFamily(ctx, uc.tokenRepo, uc.sessionRepo, code.FamilyID.String()); err != nil {
+			return err
+		}
+		metadata["family_id"] = code.FamilyID.String()
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthCodeReuse,
+		Outcome:  entities.AuditOutcomeFailure,
+		TargetID: code.UserID.String(),
+		Metadata: metadata,
+	})
+	return nil
+}
+
+// UserInfo representa los claims del usuario que libera el endpoint userinfo de OpenID Connect
+type UserInfo struct {
+	Subject string `json:"sub"`
+	jwt.IdentityClaims
+}
+
+// GetUserInfo retorna los claims del usuario liberados por los scopes del access token
+func (uc *OAuthUseCase) GetUserInfo(ctx context.Context, userID, scope string) (*UserInfo, error) {
+	scopes := strings.Fields(scope)
+	if !containsScope(scopes, ScopeOpenID) {
+		return nil, newOAuthError("insufficient_scope", "the access token does not have the openid scope")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		return nil, newOAuthError("invalid_token", "user account is not available")
+	}
+
+	info := releaseClaims(user, scopes)
+	return &info, nil
+}
+
+// OpenIDConfiguration representa el documento de discovery de OpenID Connect
+type OpenIDConfiguration struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint"`
+	UserInfoEndpoint                           string   `json:"userinfo_endpoint"`
+	JWKSURI                                    string   `json:"jwks_uri"`
+	ScopesSupported                            []string `json:"scopes_supported"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	ClaimsSupported                            []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+}
+
+// Discovery retorna el documento de discovery de OpenID Connect (OpenID Connect Discovery 1.0)
+func (uc *OAuthUseCase) Discovery() (*OpenIDConfiguration, error) {
+	if uc.useKeycloak {
+		return nil, ErrOAuthUnavailable
+	}
+
+	issuer := strings.TrimRight(uc.config.Issuer, "/")
+	return &OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		RevocationEndpoint:                issuer + "/oauth/revoke",
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{ScopeOpenID, ScopeProfile, ScopeEmail},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{uc.jwtSvc.SigningAlgorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "private_key_jwt", "none"},
+		TokenEndpointAuthSigningAlgValuesSupported: []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "amr",
+			"name", "given_name", "family_name", "updated_at", "email", "email_verified",
+		},
+		CodeChallengeMethodsSupported: []string{codeChallengeMethodS256},
+	}, nil
+}
+
+// releaseClaims arma los claims estándar del usuario que liberan los scopes profile y email
+func releaseClaims(user *entities.User, scopes []string) UserInfo {
+	info := UserInfo{Subject: user.ID.String()}
+	if containsScope(scopes, ScopeProfile) {
+		info.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		info.GivenName = user.FirstName
+		info.FamilyName = user.LastName
+		info.UpdatedAt = user.UpdatedAt.Unix()
+	}
+	if containsScope(scopes, ScopeEmail) {
+		emailVerified := user.EmailVerified
+		info.Email = user.Email
+		info.EmailVerified = &emailVerified
+	}
+	return info
+}
+
+// scopedPermissions retorna los permisos incluidos en los scopes concedidos a un cliente; los
+// scopes que no son permisos del catálogo, como openid o profile, no conceden ninguno
+func scopedPermissions(permissions []string, scope string) []string {
+	scopes := strings.Fields(scope)
+	scoped := []string{}
+	for _, permission := range permissions {
+		if containsScope(scopes, permission) {
+			scoped = append(scoped, permission)
+		}
+	}
+	return scoped
+}
+
+// containsScope indica si la lista de scopes incluye el scope
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// errorRedirect retorna el redirect_uri con el error y el state de la solicitud
+func (a *authorization) errorRedirect(err error) string {
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		oauthErr = newOAuthError(OAuthErrInvalidRequest, err.Error())
+	}
+
+	query := url.Values{}
+	query.Set("error", oauthErr.Code)
+	if oauthErr.Description != "" {
+		query.Set("error_description", oauthErr.Description)
+	}
+	if a.req.State != "" {
+		query.Set("state", a.req.State)
+	}
+	return appendQuery(a.redirectURI, query)
+}
+
+// appendQuery agrega parámetros a una URL que puede tener su propia query
+func appendQuery(rawURL string, query url.Values) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + query.Encode()
+}
+
+// verifyCodeChallenge verifica el code_verifier contra el code_challenge S256 (RFC 7636 sección 4.6)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if !isPKCEValue(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// isPKCEValue valida el formato de un code_verifier o code_challenge: 43 a 128 caracteres no reservados
+func isPKCEValue(value string) bool {
+	if len(value) < 43 || len(value) > 128 {
+		return false
+	}
+	for _, r := range value {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.', r == '_', r == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseRedirectURIs valida las URIs de redirección de un cliente
+func parseRedirectURIs(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, errors.New("at least one redirect uri is required")
+	}
+	for _, value := range values {
+		if err := validateRedirectURI(value); err != nil {
+			return nil, err
+		}
+	}
+	return mergeScopes(nil, values), nil
+}
+
+// validateRedirectURI valida una URI de redirección: https, http solo en loopback (desarrollo y
+// apps nativas, RFC 8252) o un esquema privado de una app nativa; nunca con fragmento
+func validateRedirectURI(rawURI string) error {
+	parsed, err := url.Parse(rawURI)
+	if err != nil || parsed.Scheme == "" || strings.Contains(rawURI, "#") {
+		return errors.New("redirect uri must be an absolute uri without a fragment: " + rawURI)
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "https":
+		if parsed.Host == "" {
+			return errors.New("redirect uri must include a host: " + rawURI)
+		}
+	case "http":
+		host := parsed.Hostname()
+		ip := net.ParseIP(host)
+		if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return errors.New("http redirect uris are only allowed for loopback hosts: " + rawURI)
+		}
+	case "javascript", "data", "file", "vbscript":
+		return errors.New("redirect uri scheme is not allowed: " + rawURI)
+	}
+	return nil
+}
+
+// parseScopes valida los scopes permitidos de un cliente (RFC 6749 sección 3.3)
+func parseScopes(values []string) ([]string, error) {
+	for _, value := range values {
+		if value == "" {
+			return nil, errors.New("scope must not be empty")
+		}
+		for _, r := range value {
+			if r < 0x21 || r == 0x22 || r == 0x5C || r > 0x7E {
+				return nil, errors.New("invalid scope: " + value)
+			}
+		}
+	}
+	return mergeScopes(nil, values), nil
+}
+
+// mergeScopes une dos listas sin repetidos conservando el orden
+func mergeScopes(first, second []string) []string {
+	merged := make([]string, 0, len(first)+len(second))
+	seen := map[string]bool{}
+	for _, value := range append(append([]string{}, first...), second...) {
+		if !seen[value] {
+			seen[value] = true
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// newClientID genera un client_id aleatorio de 128 bits
+func newClientID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,452 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/jwt"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+// Par code_verifier / code_challenge S256 del apéndice B de RFC 7636
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// testRedirectURI es la URI de redirección registrada por los clientes de prueba
+const testRedirectURI = "https://app.example.com/callback"
+
+// oauthFixture agrupa el caso de uso OAuth y los repositorios en memoria sobre los que trabaja
+type oauthFixture struct {
+	uc        *OAuthUseCase
+	store     *memoryStore
+	oauthRepo *memoryOAuthRepo
+	auditRepo *memoryAuditRepo
+	user      *entities.User
+}
+
+// newOAuthFixture crea un servidor de autorización con un usuario activo
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	f := &oauthFixture{store: newMemoryStore(), oauthRepo: newMemoryOAuthRepo(), auditRepo: &memoryAuditRepo{}}
+	f.user = newTestUser(f.store)
+
+	userRepo := &memoryUserRepo{store: f.store}
+	roleUC := NewRoleUseCase(&memoryRoleRepo{}, userRepo, nil, nil, time.Minute)
+	orgUC := NewOrganizationUseCase(&memoryOrganizationRepo{}, userRepo, roleUC, nil, nil, time.Minute)
+	f.uc = NewOAuthUseCase(
+		f.oauthRepo,
+		userRepo,
+		&memoryTokenRepo{store: f.store},
+		&memorySessionRepo{store: f.store},
+		&memoryUnitOfWork{store: f.store, oauth: f.oauthRepo},
+		roleUC,
+		orgUC,
+		nil,
+		newTestJWTService(t),
+		NewAuditLogger(f.auditRepo),
+		OAuthConfig{Issuer: "https://auth.example.com", CodeExpiry: time.Minute, AccessTokenTTL: 15 * time.Minute},
+		false,
+	)
+	return f
+}
+
+// registerClient registra un cliente con las URIs de redirección indicadas, o testRedirectURI
+func (f *oauthFixture) registerClient(t *testing.T, clientType entities.OAuthClientType, scopes []string, redirectURIs ...string) *OAuthClientResponse {
+	t.Helper()
+	if len(redirectURIs) == 0 {
+		redirectURIs = []string{testRedirectURI}
+	}
+	client, err := f.uc.CreateClient(context.Background(), &CreateOAuthClientRequest{
+		Name:          "App",
+		Type:          string(clientType),
+		RedirectURIs:  redirectURIs,
+		AllowedScopes: scopes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// authorize concede la solicitud como el usuario y retorna el código de la redirección
+func (f *oauthFixture) authorize(t *testing.T, req AuthorizeRequest) string {
+	t.Helper()
+	req.ResponseType = "code"
+	response, err := f.uc.Authorize(context.Background(), &AuthorizeDecisionRequest{
+		AuthorizeRequest: req,
+		Approved:         true,
+		UserID:           f.user.ID.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, err := url.Parse(response.RedirectTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := redirect.Query().Get("code")
+	if code == "" {
+		t.Fatalf("authorization was not granted: %s", response.RedirectTo)
+	}
+	return code
+}
+
+// exchange canjea el código en el endpoint de tokens
+func (f *oauthFixture) exchange(client *OAuthClientResponse, code, redirectURI, verifier string) (*TokenResponse, error) {
+	return f.uc.Token(context.Background(), &TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  redirectURI,
+		CodeVerifier: verifier,
+		ClientCredentials: ClientCredentials{
+			ClientID:     client.ClientID,
+			ClientSecret: client.ClientSecret,
+		},
+	})
+}
+
+// assertOAuthError verifica que err sea el error del protocolo indicado
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("expected the %s error, got %v", code, err)
+	}
+}
+
+func TestExchangeCodeVerifiesPKCE(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientPublic, []string{ScopeOpenID})
+	code := f.authorize(t, AuthorizeRequest{
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: codeChallengeMethodS256,
+	})
+
+	for _, verifier := range []string{"", strings.Repeat("a", 43), testCodeChallenge} {
+		_, err := f.exchange(client, code, testRedirectURI, verifier)
+		assertOAuthError(t, err, OAuthErrInvalidGrant)
+	}
+
+	response, err := f.exchange(client, code, testRedirectURI, testCodeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.AccessToken == "" || response.RefreshToken == "" {
+		t.Error("tokens were not issued")
+	}
+}
+
+func TestAuthorizeRequiresS256ForPublicClients(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientPublic, []string{ScopeOpenID})
+
+	for name, req := range map[string]AuthorizeRequest{
+		"missing": {},
+		"plain":   {CodeChallenge: testCodeVerifier, CodeChallengeMethod: "plain"},
+		"short":   {CodeChallenge: "abc", CodeChallengeMethod: codeChallengeMethodS256},
+	} {
+		req.ResponseType = "code"
+		req.ClientID = client.ClientID
+		response, err := f.uc.Authorize(context.Background(), &AuthorizeDecisionRequest{
+			AuthorizeRequest: req,
+			Approved:         true,
+			UserID:           f.user.ID.String(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(response.RedirectTo, "error="+OAuthErrInvalidRequest) {
+			t.Errorf("%s: challenge was accepted: %s", name, response.RedirectTo)
+		}
+	}
+}
+
+func TestExchangeCodeRejectsReuseAndRevokesIssuedTokens(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID})
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI})
+
+	first, err := f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.exchange(client, code, testRedirectURI, "")
+	assertOAuthError(t, err, OAuthErrInvalidGrant)
+
+	if !f.store.tokens[first.RefreshToken].IsRevoked {
+		t.Error("refresh token issued with the reused code was not revoked")
+	}
+	if !containsAction(f.auditRepo.actions(), entities.AuditActionOAuthCodeReuse) {
+		t.Error("code reuse was not reported")
+	}
+}
+
+func TestExchangeCodeDoesNotTreatStorageErrorsAsReuse(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID})
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI})
+	f.oauthRepo.failMarkUsed = context.DeadlineExceeded
+
+	_, err := f.exchange(client, code, testRedirectURI, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the storage error, got %v", err)
+	}
+
+	// El código sigue sin canjear y se puede volver a intentar
+	f.oauthRepo.failMarkUsed = nil
+	if _, err := f.exchange(client, code, testRedirectURI, ""); err != nil {
+		t.Fatalf("exchange after the storage error failed: %v", err)
+	}
+	if containsAction(f.auditRepo.actions(), entities.AuditActionOAuthCodeReuse) {
+		t.Error("storage error was reported as code reuse")
+	}
+}
+
+func TestExchangeCodeRequiresTheAuthorizedRedirectURI(t *testing.T) {
+	f := newOAuthFixture(t)
+	other := "https://app.example.com/other"
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID}, testRedirectURI, other)
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI})
+
+	for _, redirectURI := range []string{"", other, testRedirectURI + "/"} {
+		_, err := f.exchange(client, code, redirectURI, "")
+		assertOAuthError(t, err, OAuthErrInvalidGrant)
+	}
+
+	if _, err := f.exchange(client, code, testRedirectURI, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthorizeRejectsUnregisteredRedirectURI(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID})
+
+	_, err := f.uc.Authorize(context.Background(), &AuthorizeDecisionRequest{
+		AuthorizeRequest: AuthorizeRequest{ResponseType: "code", ClientID: client.ClientID, RedirectURI: "https://evil.example.com/callback"},
+		Approved:         true,
+		UserID:           f.user.ID.String(),
+	})
+	assertOAuthError(t, err, OAuthErrInvalidRequest)
+}
+
+func TestRefreshNarrowsScopeWithoutShrinkingTheGrant(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID, ScopeProfile, ScopeEmail})
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI, Scope: "openid profile"})
+	tokens, err := f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := func(refreshToken, scope string) (*TokenResponse, error) {
+		return f.uc.Token(context.Background(), &TokenRequest{
+			GrantType:         "refresh_token",
+			RefreshToken:      refreshToken,
+			Scope:             scope,
+			ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+		})
+	}
+
+	// Un scope que no se concedió no se puede pedir al renovar
+	_, err = refresh(tokens.RefreshToken, "openid email")
+	assertOAuthError(t, err, OAuthErrInvalidScope)
+
+	narrowed, err := refresh(tokens.RefreshToken, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if narrowed.Scope != "profile" || narrowed.IDToken != "" {
+		t.Errorf("access token was not narrowed: scope=%q id_token=%t", narrowed.Scope, narrowed.IDToken != "")
+	}
+
+	// El refresh token rotado conserva los scopes concedidos originalmente
+	full, err := refresh(narrowed.RefreshToken, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.Scope != "openid profile" || full.IDToken == "" {
+		t.Errorf("refresh token lost the granted scope: scope=%q", full.Scope)
+	}
+}
+
+func TestRefreshReportsReuseOnlyForRotatedTokens(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID})
+	refresh := func(refreshToken string) (*TokenResponse, error) {
+		return f.uc.Token(context.Background(), &TokenRequest{
+			GrantType:         "refresh_token",
+			RefreshToken:      refreshToken,
+			ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+		})
+	}
+
+	// Un token revocado al cerrar la sesión se rechaza sin tratarlo como robo
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI})
+	closed, err := f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.uc.tokenRepo.RevokeFamily(context.Background(), f.store.tokens[closed.RefreshToken].FamilyID.String()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = refresh(closed.RefreshToken)
+	assertOAuthError(t, err, OAuthErrInvalidGrant)
+	if containsAction(f.auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Fatal("revoked refresh token was reported as reuse")
+	}
+
+	// Volver a presentar un token rotado revoca la familia
+	code = f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI})
+	tokens, err := f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = refresh(tokens.RefreshToken)
+	assertOAuthError(t, err, OAuthErrInvalidGrant)
+	if !f.store.tokens[rotated.RefreshToken].IsRevoked {
+		t.Error("token family was not revoked on reuse")
+	}
+	if !containsAction(f.auditRepo.actions(), entities.AuditActionRefreshTokenReuse) {
+		t.Error("refresh token reuse was not reported")
+	}
+}
+
+// parseIDToken verifica la firma del ID token con la clave de newTestJWTService y retorna sus claims
+func parseIDToken(t *testing.T, idToken string) *jwt.IDTokenClaims {
+	t.Helper()
+	claims := &jwt.IDTokenClaims{}
+	if _, err := jwtlib.ParseWithClaims(idToken, claims, func(*jwtlib.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	}); err != nil {
+		t.Fatalf("invalid id token: %v", err)
+	}
+	return claims
+}
+
+func TestReleaseClaimsByScope(t *testing.T) {
+	user := entities.NewUserWithRole("ana@example.com", "hash", "Ana", "Pérez", entities.RoleUser)
+	user.EmailVerified = true
+
+	tests := []struct {
+		scope       string
+		wantProfile bool
+		wantEmail   bool
+	}{
+		{"openid", false, false},
+		{"openid profile", true, false},
+		{"openid email", false, true},
+		{"openid profile email", true, true},
+	}
+	for _, tt := range tests {
+		info := releaseClaims(user, strings.Fields(tt.scope))
+		if info.Subject != user.ID.String() {
+			t.Errorf("%q: sub = %q", tt.scope, info.Subject)
+		}
+		if hasProfile := info.Name != "" || info.GivenName != "" || info.FamilyName != "" || info.UpdatedAt != 0; hasProfile != tt.wantProfile {
+			t.Errorf("%q: profile claims released = %t, want %t", tt.scope, hasProfile, tt.wantProfile)
+		}
+		if hasEmail := info.Email != "" || info.EmailVerified != nil; hasEmail != tt.wantEmail {
+			t.Errorf("%q: email claims released = %t, want %t", tt.scope, hasEmail, tt.wantEmail)
+		}
+		if tt.wantProfile && (info.Name != "Ana Pérez" || info.GivenName != "Ana" || info.FamilyName != "Pérez") {
+			t.Errorf("%q: unexpected profile claims %+v", tt.scope, info.IdentityClaims)
+		}
+		if tt.wantEmail && (info.Email != "ana@example.com" || !*info.EmailVerified) {
+			t.Errorf("%q: unexpected email claims %+v", tt.scope, info.IdentityClaims)
+		}
+	}
+}
+
+func TestGetUserInfoRequiresOpenIDAndAnActiveUser(t *testing.T) {
+	f := newOAuthFixture(t)
+	ctx := context.Background()
+
+	_, err := f.uc.GetUserInfo(ctx, f.user.ID.String(), "profile email")
+	assertOAuthError(t, err, "insufficient_scope")
+
+	info, err := f.uc.GetUserInfo(ctx, f.user.ID.String(), "openid email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Subject != f.user.ID.String() || info.Email != f.user.Email || info.GivenName != "" {
+		t.Errorf("unexpected userinfo for the email scope: %+v", info)
+	}
+
+	inactive := f.store.users[f.user.ID]
+	inactive.IsActive = false
+	f.store.users[f.user.ID] = inactive
+	_, err = f.uc.GetUserInfo(ctx, f.user.ID.String(), "openid")
+	assertOAuthError(t, err, "invalid_token")
+}
+
+func TestExchangeCodeIssuesIDTokenForOpenIDScope(t *testing.T) {
+	f := newOAuthFixture(t)
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID, ScopeProfile, ScopeEmail})
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	response, err := f.uc.Authorize(context.Background(), &AuthorizeDecisionRequest{
+		AuthorizeRequest: AuthorizeRequest{
+			ResponseType: "code",
+			ClientID:     client.ClientID,
+			RedirectURI:  testRedirectURI,
+			Scope:        "openid profile",
+			Nonce:        "n-0S6_WzA2Mj",
+		},
+		Approved:       true,
+		UserID:         f.user.ID.String(),
+		Authentication: jwt.Authentication{Time: authTime, Methods: []string{"pwd", "otp"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, _ := url.Parse(response.RedirectTo)
+	tokens, err := f.exchange(client, redirect.Query().Get("code"), testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := parseIDToken(t, tokens.IDToken)
+	if claims.Issuer != "https://auth.example.com" || claims.Subject != f.user.ID.String() {
+		t.Errorf("unexpected iss/sub: %s %s", claims.Issuer, claims.Subject)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != client.ClientID {
+		t.Errorf("id token audience = %v, want the client", claims.Audience)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" {
+		t.Errorf("nonce = %q", claims.Nonce)
+	}
+	if claims.AuthTime == nil || !claims.AuthTime.Time.Equal(authTime) {
+		t.Errorf("auth_time = %v, want %v", claims.AuthTime, authTime)
+	}
+	if strings.Join(claims.AMR, " ") != "pwd otp" {
+		t.Errorf("amr = %v", claims.AMR)
+	}
+	if claims.GivenName != "Ana" || claims.FamilyName != "Pérez" || claims.Email != "" {
+		t.Errorf("id token released the wrong claims for the profile scope: %+v", claims.IdentityClaims)
+	}
+
+	// Sin el scope openid no se emite ID token
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI, Scope: "profile"})
+	tokens, err = f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.IDToken != "" {
+		t.Error("id token issued without the openid scope")
+	}
+}
@@ -0,0 +1,365 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// organizationSlugPattern restringe los slugs a minúsculas, dígitos y guiones
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,99}$`)
+
+// OrganizationUseCase administra las organizaciones (tenants), sus miembros y los permisos
+// que cada miembro tiene dentro de ellas. Las membresías resueltas se cachean durante cacheTTL.
+type OrganizationUseCase struct {
+	orgRepo      repositories.OrganizationRepository
+	userRepo     repositories.UserRepository
+	roleUC       *RoleUseCase
+	revocationUC *RevocationUseCase
+	auditLogger  *AuditLogger
+	cacheTTL     time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]membershipCacheEntry
+}
+
+// membershipCacheEntry representa la membresía cacheada de un usuario en una organización
+type membershipCacheEntry struct {
+	isMember    bool
+	permissions []string
+	expiresAt   time.Time
+}
+
+// NewOrganizationUseCase crea una nueva instancia de OrganizationUseCase
+func NewOrganizationUseCase(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	roleUC *RoleUseCase,
+	revocationUC *RevocationUseCase,
+	auditLogger *AuditLogger,
+	cacheTTL time.Duration,
+) *OrganizationUseCase {
+	return &OrganizationUseCase{
+		orgRepo:      orgRepo,
+		userRepo:     userRepo,
+		roleUC:       roleUC,
+		revocationUC: revocationUC,
+		auditLogger:  auditLogger,
+		cacheTTL:     cacheTTL,
+		cache:        make(map[string]membershipCacheEntry),
+	}
+}
+
+// CreateOrganizationRequest representa la solicitud de creación de una organización
+type CreateOrganizationRequest struct {
+	Name    string `json:"name" binding:"required,max=255"`
+	Slug    string `json:"slug" binding:"required"`
+	OwnerID string `json:"owner_id"` // opcional; se agrega como org_admin
+}
+
+// CreateOrganization crea una organización y, si se indica, su primer administrador
+func (uc *OrganizationUseCase) CreateOrganization(ctx context.Context, req *CreateOrganizationRequest) (*entities.Organization, error) {
+	if !organizationSlugPattern.MatchString(req.Slug) {
+		return nil, errors.New("invalid organization slug")
+	}
+
+	organization := entities.NewOrganization(req.Name, req.Slug)
+
+	var owner *entities.OrganizationMember
+	if req.OwnerID != "" {
+		user, err := uc.userRepo.GetByID(ctx, req.OwnerID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		owner = entities.NewOrganizationMember(organization.ID, user.ID, entities.RoleOrgAdmin)
+	}
+
+	if err := uc.orgRepo.Create(ctx, organization, owner); err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		uc.invalidate(organization.ID.String(), req.OwnerID)
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOrganizationCreated,
+		TargetID: organization.ID.String(),
+		Metadata: map[string]interface{}{"slug": organization.Slug, "owner_id": req.OwnerID},
+	})
+
+	return organization, nil
+}
+
+// ListOrganizationsRequest representa la solicitud para listar organizaciones
+type ListOrganizationsRequest struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// ListOrganizationsResponse representa la respuesta para listar organizaciones
+type ListOrganizationsResponse struct {
+	Organizations []*entities.Organization `json:"organizations"`
+	Total         int64                    `json:"total"`
+}
+
+// ListOrganizations lista todas las organizaciones (administración de la plataforma)
+func (uc *OrganizationUseCase) ListOrganizations(ctx context.Context, req *ListOrganizationsRequest) (*ListOrganizationsResponse, error) {
+	organizations, err := uc.orgRepo.List(ctx, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.orgRepo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListOrganizationsResponse{
+		Organizations: organizations,
+		Total:         total,
+	}, nil
+}
+
+// GetOrganization obtiene una organización
+func (uc *OrganizationUseCase) GetOrganization(ctx context.Context, organizationID string) (*entities.Organization, error) {
+	return uc.orgRepo.GetByID(ctx, organizationID)
+}
+
+// DeleteOrganization elimina una organización y sus membresías
+func (uc *OrganizationUseCase) DeleteOrganization(ctx context.Context, organizationID string) error {
+	if err := uc.orgRepo.Delete(ctx, organizationID); err != nil {
+		return err
+	}
+	uc.invalidateAll()
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOrganizationDeleted,
+		TargetID: organizationID,
+	})
+	return nil
+}
+
+// ListUserOrganizations obtiene las organizaciones a las que pertenece el usuario
+func (uc *OrganizationUseCase) ListUserOrganizations(ctx context.Context, userID string) ([]*entities.OrganizationMember, error) {
+	return uc.orgRepo.ListByUserID(ctx, userID)
+}
+
+// ListMembersRequest representa la solicitud para listar los miembros de una organización
+type ListMembersRequest struct {
+	OrganizationID string `json:"-"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+}
+
+// ListMembersResponse representa la respuesta para listar los miembros de una organización
+type ListMembersResponse struct {
+	Members []*entities.OrganizationMember `json:"members"`
+	Total   int64                          `json:"total"`
+}
+
+// ListMembers lista los miembros de una organización con sus datos de usuario
+func (uc *OrganizationUseCase) ListMembers(ctx context.Context, req *ListMembersRequest) (*ListMembersResponse, error) {
+	memberships, err := uc.orgRepo.ListMembers(ctx, req.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &repositories.UserFilter{
+		OrganizationID: req.OrganizationID,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+	}
+	users, err := uc.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.userRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byUserID := make(map[uuid.UUID]*entities.OrganizationMember, len(memberships))
+	for _, membership := range memberships {
+		byUserID[membership.UserID] = membership
+	}
+
+	members := make([]*entities.OrganizationMember, 0, len(users))
+	for _, user := range users {
+		// Un miembro agregado entre ambas consultas no tiene membresía cargada
+		if membership, ok := byUserID[user.ID]; ok {
+			membership.User = user
+			members = append(members, membership)
+		}
+	}
+
+	return &ListMembersResponse{
+		Members: members,
+		Total:   total,
+	}, nil
+}
+
+// AddMemberRequest representa la solicitud para agregar un usuario existente a una organización
+type AddMemberRequest struct {
+	OrganizationID string `json:"-"`
+	UserID         string `json:"user_id"`
+	Email          string `json:"email" binding:"omitempty,email"`
+	Role           string `json:"role" binding:"required"`
+}
+
+// AddMember agrega un usuario existente, identificado por ID o email, a la organización
+func (uc *OrganizationUseCase) AddMember(ctx context.Context, req *AddMemberRequest) (*entities.OrganizationMember, error) {
+	organization, err := uc.orgRepo.GetByID(ctx, req.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *entities.User
+	switch {
+	case req.UserID != "":
+		user, err = uc.userRepo.GetByID(ctx, req.UserID)
+	case req.Email != "":
+		user, err = uc.userRepo.GetByEmail(ctx, req.Email)
+	default:
+		return nil, errors.New("user_id or email is required")
+	}
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := uc.roleUC.ValidateRole(ctx, req.Role); err != nil {
+		return nil, err
+	}
+
+	member := entities.NewOrganizationMember(organization.ID, user.ID, entities.Role(req.Role))
+	if err := uc.orgRepo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	uc.invalidate(req.OrganizationID, user.ID.String())
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionMemberAdded,
+		TargetID: user.ID.String(),
+		Metadata: map[string]interface{}{"organization_id": req.OrganizationID, "role": req.Role},
+	})
+
+	member.User = user
+	return member, nil
+}
+
+// UpdateMemberRoleRequest representa la solicitud para cambiar el rol de un miembro
+type UpdateMemberRoleRequest struct {
+	OrganizationID string `json:"-"`
+	UserID         string `json:"-"`
+	Role           string `json:"role" binding:"required"`
+}
+
+// UpdateMemberRole cambia el rol del miembro; los permisos dentro de la organización
+// se resuelven en cada petición, por lo que no es necesario revocar sus tokens
+func (uc *OrganizationUseCase) UpdateMemberRole(ctx context.Context, req *UpdateMemberRoleRequest) error {
+	if err := uc.roleUC.ValidateRole(ctx, req.Role); err != nil {
+		return err
+	}
+
+	if err := uc.orgRepo.UpdateMemberRole(ctx, req.OrganizationID, req.UserID, entities.Role(req.Role)); err != nil {
+		return err
+	}
+	uc.invalidate(req.OrganizationID, req.UserID)
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionMemberRoleChanged,
+		TargetID: req.UserID,
+		Metadata: map[string]interface{}{"organization_id": req.OrganizationID, "role": req.Role},
+	})
+
+	return nil
+}
+
+// RemoveMember quita un usuario de la organización e invalida sus access tokens,
+// que pueden llevarla como organización activa
+func (uc *OrganizationUseCase) RemoveMember(ctx context.Context, organizationID, userID string) error {
+	if err := uc.orgRepo.RemoveMember(ctx, organizationID, userID); err != nil {
+		return err
+	}
+	uc.invalidate(organizationID, userID)
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionMemberRemoved,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"organization_id": organizationID},
+	})
+
+	return uc.revocationUC.RevokeAllForUser(ctx, userID)
+}
+
+// IsMember indica si el usuario pertenece a la organización
+func (uc *OrganizationUseCase) IsMember(ctx context.Context, organizationID, userID string) (bool, error) {
+	_, isMember, err := uc.MemberPermissions(ctx, organizationID, userID)
+	return isMember, err
+}
+
+// DefaultOrganization retorna la organización activa al iniciar sesión: la única a la
+// que pertenece el usuario o vacío si pertenece a ninguna o a varias
+func (uc *OrganizationUseCase) DefaultOrganization(ctx context.Context, userID string) (string, error) {
+	memberships, err := uc.orgRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(memberships) != 1 {
+		return "", nil
+	}
+	return memberships[0].OrganizationID.String(), nil
+}
+
+// MemberPermissions obtiene los permisos del usuario dentro de la organización según el rol
+// de su membresía, usando la caché. isMember es false si no pertenece a la organización.
+func (uc *OrganizationUseCase) MemberPermissions(ctx context.Context, organizationID, userID string) ([]string, bool, error) {
+	if _, err := uuid.Parse(organizationID); err != nil {
+		return nil, false, nil
+	}
+
+	key := organizationID + ":" + userID
+	now := time.Now()
+
+	uc.mu.RLock()
+	entry, ok := uc.cache[key]
+	uc.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, entry.isMember, nil
+	}
+
+	member, err := uc.orgRepo.FindMember(ctx, organizationID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry = membershipCacheEntry{isMember: member != nil, expiresAt: now.Add(uc.cacheTTL)}
+	if member != nil {
+		entry.permissions, err = uc.roleUC.RolePermissions(ctx, []string{string(member.Role)})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	uc.mu.Lock()
+	uc.cache[key] = entry
+	uc.mu.Unlock()
+
+	return entry.permissions, entry.isMember, nil
+}
+
+// invalidate descarta la membresía cacheada del usuario en la organización
+func (uc *OrganizationUseCase) invalidate(organizationID, userID string) {
+	uc.mu.Lock()
+	delete(uc.cache, organizationID+":"+userID)
+	uc.mu.Unlock()
+}
+
+// invalidateAll descarta toda la caché tras eliminar una organización
+func (uc *OrganizationUseCase) invalidateAll() {
+	uc.mu.Lock()
+	uc.cache = make(map[string]membershipCacheEntry)
+	uc.mu.Unlock()
+}
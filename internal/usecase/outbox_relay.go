@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/events"
+)
+
+// publishedOutboxRetention es el tiempo que se conservan los eventos ya publicados
+const publishedOutboxRetention = 7 * 24 * time.Hour
+
+// OutboxRelayConfig configura la publicación de los eventos del outbox
+type OutboxRelayConfig struct {
+	BatchSize     int           // eventos reservados por ronda
+	Lease         time.Duration // tiempo que un evento reservado queda oculto a otras réplicas
+	RetryBase     time.Duration // espera tras el primer fallo; se duplica en cada intento
+	RetryMaxDelay time.Duration
+}
+
+// OutboxRelay publica los eventos del outbox. La entrega es al menos una vez: un evento se
+// marca publicado recién después de que el publisher lo acepta y se reintenta sin límite.
+type OutboxRelay struct {
+	outboxRepo repositories.OutboxRepository
+	publisher  events.Publisher
+	config     OutboxRelayConfig
+}
+
+// NewOutboxRelay crea una nueva instancia de OutboxRelay
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, publisher events.Publisher, config OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		config:     config,
+	}
+}
+
+// Run publica los eventos pendientes cada interval hasta que se cancela el contexto
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Mientras haya lotes completos se siguen publicando sin esperar al siguiente tick
+		for {
+			published, err := r.RelayPending(ctx)
+			if err != nil {
+				log.Printf("Error relaying outbox events: %v", err)
+				break
+			}
+			if published < r.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending reserva un lote de eventos pendientes, los publica y retorna cuántos procesó
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	pending, err := r.outboxRepo.Claim(ctx, r.config.BatchSize, time.Now().Add(r.config.Lease))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range pending {
+		if err := r.publisher.Publish(ctx, toPublishedEvent(event)); err != nil {
+			nextAttempt := time.Now().Add(retryDelay(r.config.RetryBase, r.config.RetryMaxDelay, event.Attempts))
+			log.Printf("Error publishing event %s (%s), attempt %d: %v", event.ID, event.Type, event.Attempts, err)
+			if err := r.outboxRepo.MarkFailed(ctx, event.ID.String(), err.Error(), nextAttempt); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		// Si no se puede marcar, el evento se vuelve a publicar al vencer la reserva
+		if err := r.outboxRepo.MarkPublished(ctx, event.ID.String()); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// Cleanup elimina los eventos publicados hace más de una semana
+func (r *OutboxRelay) Cleanup(ctx context.Context) error {
+	return r.outboxRepo.DeletePublished(ctx, time.Now().Add(-publishedOutboxRetention))
+}
+
+// retryDelay calcula la espera exponencial tras el intento fallido número attempts
+func retryDelay(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// toPublishedEvent convierte el evento del outbox al formato que reciben los publishers
+func toPublishedEvent(event *entities.OutboxEvent) *events.Event {
+	return &events.Event{
+		ID:          event.ID.String(),
+		Type:        string(event.Type),
+		AggregateID: event.AggregateID,
+		OccurredAt:  event.OccurredAt,
+		Payload:     event.Payload,
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/events"
+)
+
+// testOutboxRelayConfig reintenta tras un minuto, duplicando hasta una hora
+var testOutboxRelayConfig = OutboxRelayConfig{BatchSize: 10, Lease: time.Minute, RetryBase: time.Minute, RetryMaxDelay: time.Hour}
+
+// relayPending ejecuta una ronda del relay
+func relayPending(t *testing.T, relay *OutboxRelay) int {
+	t.Helper()
+	processed, err := relay.RelayPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return processed
+}
+
+func TestOutboxRelayMarksEventPublishedAfterPublish(t *testing.T) {
+	event := entities.NewDomainEvent(entities.EventUserRegistered, "user-1", map[string]interface{}{"email": "ana@example.com"})
+	outboxRepo := newMemoryOutboxRepo(event)
+	publisher := events.NewMemoryPublisher()
+	relay := NewOutboxRelay(outboxRepo, publisher, testOutboxRelayConfig)
+
+	if processed := relayPending(t, relay); processed != 1 {
+		t.Fatalf("expected one event, processed %d", processed)
+	}
+
+	published := publisher.Events()
+	if len(published) != 1 {
+		t.Fatalf("expected one published event, got %d", len(published))
+	}
+	if published[0].ID != event.ID.String() || published[0].Type != string(event.Type) || string(published[0].Payload) != string(event.Payload) {
+		t.Errorf("unexpected published event %+v", published[0])
+	}
+	if outboxRepo.get(event.ID).PublishedAt == nil {
+		t.Fatal("event was not marked published")
+	}
+
+	// Un evento publicado no se vuelve a publicar
+	outboxRepo.makeDue()
+	if processed := relayPending(t, relay); processed != 0 {
+		t.Errorf("published event was claimed again")
+	}
+	if len(publisher.Events()) != 1 {
+		t.Errorf("published event was published again")
+	}
+}
+
+func TestOutboxRelayRetriesAfterPublishFailure(t *testing.T) {
+	event := entities.NewDomainEvent(entities.EventUserLoggedIn, "user-1", nil)
+	outboxRepo := newMemoryOutboxRepo(event)
+	publisher := events.NewMemoryPublisher()
+	publisher.FailWith(errors.New("broker unavailable"))
+	relay := NewOutboxRelay(outboxRepo, publisher, testOutboxRelayConfig)
+
+	for attempt, expectedDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now()
+		relayPending(t, relay)
+
+		stored := outboxRepo.get(event.ID)
+		if stored.PublishedAt != nil {
+			t.Fatalf("attempt %d: event was marked published although the publish failed", attempt+1)
+		}
+		if stored.Attempts != attempt+1 || stored.LastError != "broker unavailable" {
+			t.Errorf("attempt %d: failure was not recorded: attempts=%d last_error=%q", attempt+1, stored.Attempts, stored.LastError)
+		}
+		if delay := stored.NextAttemptAt.Sub(start); delay < expectedDelay || delay > expectedDelay+5*time.Second {
+			t.Errorf("attempt %d: next attempt in %s, expected %s", attempt+1, delay, expectedDelay)
+		}
+
+		// Antes de vencer la espera el evento no se reintenta
+		if processed := relayPending(t, relay); processed != 0 {
+			t.Fatalf("attempt %d: event was retried before its backoff expired", attempt+1)
+		}
+		outboxRepo.makeDue()
+	}
+
+	publisher.FailWith(nil)
+	relayPending(t, relay)
+
+	if len(publisher.Events()) != 1 {
+		t.Fatalf("expected the event to be published once after recovering, got %d", len(publisher.Events()))
+	}
+	stored := outboxRepo.get(event.ID)
+	if stored.PublishedAt == nil || stored.Attempts != 3 {
+		t.Errorf("event was not marked published on the third attempt: published_at=%v attempts=%d", stored.PublishedAt, stored.Attempts)
+	}
+}
+
+func TestOutboxRelayRepublishesWhenMarkPublishedFails(t *testing.T) {
+	event := entities.NewDomainEvent(entities.EventUserDeleted, "user-1", nil)
+	outboxRepo := newMemoryOutboxRepo(event)
+	outboxRepo.failPublish = errors.New("update outbox_events: connection reset")
+	publisher := events.NewMemoryPublisher()
+	relay := NewOutboxRelay(outboxRepo, publisher, testOutboxRelayConfig)
+
+	if _, err := relay.RelayPending(context.Background()); err == nil {
+		t.Fatal("expected the mark published error")
+	}
+	if outboxRepo.get(event.ID).PublishedAt != nil {
+		t.Fatal("event was marked published")
+	}
+
+	// Al vencer la reserva se publica otra vez: la entrega es al menos una vez
+	outboxRepo.failPublish = nil
+	outboxRepo.makeDue()
+	relayPending(t, relay)
+
+	published := publisher.Events()
+	if len(published) != 2 || published[0].ID != published[1].ID {
+		t.Fatalf("expected the same event to be published twice, got %d events", len(published))
+	}
+	if outboxRepo.get(event.ID).PublishedAt == nil {
+		t.Error("event was not marked published after the retry")
+	}
+}
+
+func TestRetryDelayDoublesUpToMax(t *testing.T) {
+	cases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, 30 * time.Second},
+	}
+	for _, c := range cases {
+		if got := retryDelay(time.Second, 30*time.Second, c.attempts); got != c.expected {
+			t.Errorf("retryDelay after %d attempts = %s, expected %s", c.attempts, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+// PasswordPolicyUseCase aplica la política de contraseñas y el historial de contraseñas
+// en el registro, el cambio, el restablecimiento y la asignación por un administrador
+type PasswordPolicyUseCase struct {
+	policy      *password.Policy
+	historyRepo repositories.PasswordHistoryRepository
+	passSvc     password.Service
+	historySize int
+}
+
+// NewPasswordPolicyUseCase crea una nueva instancia de PasswordPolicyUseCase.
+// Con historySize en cero no se guarda ni se consulta el historial.
+func NewPasswordPolicyUseCase(
+	policy *password.Policy,
+	historyRepo repositories.PasswordHistoryRepository,
+	passSvc password.Service,
+	historySize int,
+) *PasswordPolicyUseCase {
+	return &PasswordPolicyUseCase{
+		policy:      policy,
+		historyRepo: historyRepo,
+		passSvc:     passSvc,
+		historySize: historySize,
+	}
+}
+
+// Validate verifica una contraseña nueva. Para un usuario existente también la compara con
+// su contraseña actual y su historial. Las reglas incumplidas se retornan en un *password.PolicyError.
+func (uc *PasswordPolicyUseCase) Validate(ctx context.Context, user *entities.User, newPassword, email, firstName, lastName string) error {
+	var violations []password.Violation
+	if err := uc.policy.Validate(newPassword, email, firstName, lastName); err != nil {
+		var policyErr *password.PolicyError
+		if !errors.As(err, &policyErr) {
+			return err
+		}
+		violations = policyErr.Violations
+	}
+
+	if user != nil && uc.historySize > 0 {
+		reused, err := uc.isReused(ctx, user, newPassword)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, password.Violation{
+				Code:    password.ViolationReused,
+				Message: fmt.Sprintf("password must not match any of your last %d passwords", uc.historySize),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &password.PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Record guarda el hash de la contraseña asignada en el historial del usuario
+func (uc *PasswordPolicyUseCase) Record(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	if uc.historySize <= 0 {
+		return nil
+	}
+	return uc.historyRepo.Add(ctx, entities.NewPasswordHistory(userID, passwordHash), uc.historySize)
+}
+
+// isReused compara la contraseña con la actual y con las últimas del historial
+func (uc *PasswordPolicyUseCase) isReused(ctx context.Context, user *entities.User, newPassword string) (bool, error) {
+	if uc.passSvc.Verify(newPassword, user.Password) {
+		return true, nil
+	}
+
+	entries, err := uc.historyRepo.ListRecent(ctx, user.ID.String(), uc.historySize)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.PasswordHash != user.Password && uc.passSvc.Verify(newPassword, entry.PasswordHash) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
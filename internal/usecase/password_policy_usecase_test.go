@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/password"
+)
+
+// testPasswordService crea un servicio de contraseñas de bajo costo con el algoritmo indicado
+func testPasswordService(algorithm string) password.Service {
+	return password.NewService(password.Config{
+		Algorithm:         algorithm,
+		BcryptCost:        4,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+}
+
+// newTestPasswordPolicyUseCase crea el caso de uso con la política por defecto y un historial de historySize
+func newTestPasswordPolicyUseCase(t *testing.T, passSvc password.Service, historyRepo *memoryPasswordHistoryRepo, historySize int) *PasswordPolicyUseCase {
+	t.Helper()
+	policy, err := password.NewPolicy(password.PolicyConfig{DisallowPersonalInfo: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPasswordPolicyUseCase(policy, historyRepo, passSvc, historySize)
+}
+
+// changePassword asigna la contraseña al usuario y la guarda en el historial, como el cambio de contraseña
+func changePassword(t *testing.T, uc *PasswordPolicyUseCase, passSvc password.Service, user *entities.User, newPassword string) {
+	t.Helper()
+	hash, err := passSvc.Hash(newPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Password = hash
+	if err := uc.Record(context.Background(), user.ID, hash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// policyViolations retorna los códigos de las reglas incumplidas, o nil si la contraseña es válida
+func policyViolations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a *password.PolicyError, got %v", err)
+	}
+	codes := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		codes[i] = violation.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicyRejectsRecentPasswords(t *testing.T) {
+	passSvc := testPasswordService(password.AlgorithmArgon2id)
+	uc := newTestPasswordPolicyUseCase(t, passSvc, &memoryPasswordHistoryRepo{}, 3)
+	user := entities.NewUser("ana@example.com", "", "Ana", "Pérez")
+	for _, pw := range []string{"first-Secret-1", "second-Secret-2", "third-Secret-3", "fourth-Secret-4"} {
+		changePassword(t, uc, passSvc, user, pw)
+	}
+
+	tests := map[string]bool{
+		"fourth-Secret-4": true,  // la contraseña actual
+		"third-Secret-3":  true,  // en el historial
+		"second-Secret-2": true,  // la más antigua que se conserva
+		"first-Secret-1":  false, // quedó fuera de las últimas 3
+		"fifth-Secret-5":  false,
+	}
+	for pw, reused := range tests {
+		got := policyViolations(t, uc.Validate(context.Background(), user, pw, user.Email, user.FirstName, user.LastName))
+		if reused != reflect.DeepEqual(got, []string{password.ViolationReused}) {
+			t.Errorf("%q: expected reused=%t, got %v", pw, reused, got)
+		}
+	}
+}
+
+func TestPasswordPolicyDetectsReuseAcrossHashAlgorithms(t *testing.T) {
+	historyRepo := &memoryPasswordHistoryRepo{}
+	user := entities.NewUser("ana@example.com", "", "Ana", "Pérez")
+
+	// Contraseñas guardadas con bcrypt antes de migrar a argon2id
+	bcryptSvc := testPasswordService(password.AlgorithmBcrypt)
+	legacy := newTestPasswordPolicyUseCase(t, bcryptSvc, historyRepo, 3)
+	changePassword(t, legacy, bcryptSvc, user, "old-Secret-1")
+	changePassword(t, legacy, bcryptSvc, user, "old-Secret-2")
+
+	argon2Svc := testPasswordService(password.AlgorithmArgon2id)
+	uc := newTestPasswordPolicyUseCase(t, argon2Svc, historyRepo, 3)
+	changePassword(t, uc, argon2Svc, user, "new-Secret-3")
+
+	for _, pw := range []string{"old-Secret-1", "old-Secret-2", "new-Secret-3"} {
+		got := policyViolations(t, uc.Validate(context.Background(), user, pw, user.Email, user.FirstName, user.LastName))
+		if !reflect.DeepEqual(got, []string{password.ViolationReused}) {
+			t.Errorf("%q: expected %s, got %v", pw, password.ViolationReused, got)
+		}
+	}
+}
+
+func TestPasswordPolicyCombinesRulesAndHistory(t *testing.T) {
+	passSvc := testPasswordService(password.AlgorithmArgon2id)
+	uc := newTestPasswordPolicyUseCase(t, passSvc, &memoryPasswordHistoryRepo{}, 3)
+	user := entities.NewUser("ana@example.com", "", "Ana", "Pérez")
+	changePassword(t, uc, passSvc, user, "ana12")
+
+	got := policyViolations(t, uc.Validate(context.Background(), user, "ana12", user.Email, user.FirstName, user.LastName))
+	want := []string{password.ViolationTooShort, password.ViolationPersonalInfo, password.ViolationReused}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPasswordPolicySkipsHistoryForNewAccountsAndWhenDisabled(t *testing.T) {
+	passSvc := testPasswordService(password.AlgorithmArgon2id)
+	historyRepo := &memoryPasswordHistoryRepo{}
+	user := entities.NewUser("ana@example.com", "", "Ana", "Pérez")
+
+	disabled := newTestPasswordPolicyUseCase(t, passSvc, historyRepo, 0)
+	changePassword(t, disabled, passSvc, user, "first-Secret-1")
+	if len(historyRepo.entries) != 0 {
+		t.Errorf("history was recorded while disabled: %d entries", len(historyRepo.entries))
+	}
+	if err := disabled.Validate(context.Background(), user, "first-Secret-1", user.Email, user.FirstName, user.LastName); err != nil {
+		t.Errorf("history was checked while disabled: %v", err)
+	}
+
+	// En el alta todavía no hay usuario con el que comparar
+	enabled := newTestPasswordPolicyUseCase(t, passSvc, historyRepo, 3)
+	if err := enabled.Validate(context.Background(), nil, "first-Secret-1", user.Email, user.FirstName, user.LastName); err != nil {
+		t.Errorf("history was checked for a new account: %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+	"auth-go-microservicio/pkg/keycloak"
+	"auth-go-microservicio/pkg/mailer"
+	"auth-go-microservicio/pkg/password"
+)
+
+// PasswordResetUseCase maneja la recuperación de contraseña
+type PasswordResetUseCase struct {
+	userRepo         repositories.UserRepository
+	tokenRepo        repositories.TokenRepository
+	sessionRepo      repositories.SessionRepository
+	oneTimeTokenRepo repositories.OneTimeTokenRepository
+	revocationUC     *RevocationUseCase
+	jwtSvc           jwt.Service
+	passSvc          password.Service
+	passwordPolicyUC *PasswordPolicyUseCase
+	mailer           mailer.Mailer
+	keycloakService  keycloak.Service
+	auditLogger      *AuditLogger
+	frontendURL      string
+	tokenExpiration  time.Duration
+
+	// pending cuenta los envíos en segundo plano que todavía no terminaron
+	pending sync.WaitGroup
+}
+
+// forgotPasswordTimeout limita el envío en segundo plano de un enlace de recuperación
+const forgotPasswordTimeout = 30 * time.Second
+
+// NewPasswordResetUseCase crea una nueva instancia de PasswordResetUseCase.
+// Si keycloakService no es nil la recuperación se delega en Keycloak.
+func NewPasswordResetUseCase(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.TokenRepository,
+	sessionRepo repositories.SessionRepository,
+	oneTimeTokenRepo repositories.OneTimeTokenRepository,
+	revocationUC *RevocationUseCase,
+	jwtSvc jwt.Service,
+	passSvc password.Service,
+	passwordPolicyUC *PasswordPolicyUseCase,
+	mailer mailer.Mailer,
+	keycloakService keycloak.Service,
+	auditLogger *AuditLogger,
+	frontendURL string,
+	tokenExpiration time.Duration,
+) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		oneTimeTokenRepo: oneTimeTokenRepo,
+		revocationUC:     revocationUC,
+		jwtSvc:           jwtSvc,
+		passSvc:          passSvc,
+		passwordPolicyUC: passwordPolicyUC,
+		mailer:           mailer,
+		keycloakService:  keycloakService,
+		auditLogger:      auditLogger,
+		frontendURL:      frontendURL,
+		tokenExpiration:  tokenExpiration,
+	}
+}
+
+// ForgotPasswordRequest representa la solicitud de recuperación de contraseña
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword envía el enlace de recuperación en segundo plano y retorna de inmediato. No
+// informa si la cuenta existe ni si el envío falló, para que ni la respuesta ni su tiempo permitan
+// enumerar usuarios; los errores quedan en el log del servidor.
+func (uc *PasswordResetUseCase) ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) {
+	// El envío sigue aunque termine la petición; conserva los datos del contexto para la auditoría
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), forgotPasswordTimeout)
+
+	uc.pending.Add(1)
+	go func() {
+		defer uc.pending.Done()
+		defer cancel()
+
+		var err error
+		if uc.keycloakService != nil {
+			err = uc.forgotPasswordWithKeycloak(ctx, req)
+		} else {
+			err = uc.forgotPasswordLocal(ctx, req)
+		}
+		if err != nil {
+			log.Printf("Error sending password reset email: %v", err)
+		}
+	}()
+}
+
+// forgotPasswordWithKeycloak pide a Keycloak que envíe su email de actualización de contraseña
+func (uc *PasswordResetUseCase) forgotPasswordWithKeycloak(ctx context.Context, req *ForgotPasswordRequest) error {
+	userInfo, err := uc.keycloakService.GetUserByEmail(req.Email)
+	if err != nil || !userInfo.Enabled {
+		return nil
+	}
+
+	if err := uc.keycloakService.ExecuteActionsEmail(userInfo.ID, []string{"UPDATE_PASSWORD"}, uc.tokenExpiration); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionPasswordResetRequest,
+		TargetID: userInfo.ID,
+	})
+	return nil
+}
+
+// forgotPasswordLocal genera un token de recuperación y lo envía al email del usuario
+func (uc *PasswordResetUseCase) forgotPasswordLocal(ctx context.Context, req *ForgotPasswordRequest) error {
+	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	purpose := entities.TokenPurposePasswordReset
+
+	token, err := uc.jwtSvc.GenerateActionToken(user.ID.String(), string(purpose), uc.tokenExpiration)
+	if err != nil {
+		return err
+	}
+
+	// Solo el último enlace enviado es válido
+	if err := uc.oneTimeTokenRepo.InvalidateByUserID(ctx, user.ID.String(), purpose); err != nil {
+		return err
+	}
+
+	oneTimeToken := entities.NewOneTimeToken(user.ID, purpose, hashToken(token), time.Now().Add(uc.tokenExpiration))
+	if err := uc.oneTimeTokenRepo.Create(ctx, oneTimeToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", uc.frontendURL, url.QueryEscape(token))
+	if err := uc.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Restablecé tu contraseña",
+		Body: fmt.Sprintf(
+			"Hola %s,\n\nRecibimos una solicitud para restablecer tu contraseña. Podés elegir una nueva en el siguiente enlace:\n\n%s\n\nEl enlace vence en %s y solo puede usarse una vez. Si no fuiste vos, ignorá este mensaje: tu contraseña no cambió.\n",
+			user.FirstName, link, uc.tokenExpiration,
+		),
+	}); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionPasswordResetRequest,
+		TargetID: user.ID.String(),
+	})
+	return nil
+}
+
+// ResetPasswordRequest representa la solicitud de restablecimiento de contraseña
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ResetPassword establece una nueva contraseña usando un token de un solo uso
+// y cierra todas las sesiones del usuario
+func (uc *PasswordResetUseCase) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	if uc.keycloakService != nil {
+		return errors.New("password reset is handled by Keycloak")
+	}
+
+	purpose := entities.TokenPurposePasswordReset
+
+	// La firma se valida antes de consultar la base de datos
+	claims, err := uc.jwtSvc.ValidateActionToken(req.Token, string(purpose))
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !user.IsActive {
+		return errors.New("user account is deactivated")
+	}
+
+	// La política se valida antes de consumir el token para que el usuario pueda reintentar
+	if err := uc.passwordPolicyUC.Validate(ctx, user, req.NewPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
+	oneTimeToken, err := uc.oneTimeTokenRepo.Consume(ctx, hashToken(req.Token), purpose)
+	if err != nil || oneTimeToken.UserID.String() != claims.UserID {
+		return errors.New("invalid or expired reset token")
+	}
+
+	hashedPassword, err := uc.passSvc.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// El enlace llegó al email del usuario, por lo que también queda verificado
+	user.Password = hashedPassword
+	if !user.EmailVerified {
+		user.MarkEmailVerified()
+	}
+	user.RecordEvent(entities.EventPasswordChanged, map[string]interface{}{"reason": "reset"})
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := uc.passwordPolicyUC.Record(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Error recording password history for user %s: %v", user.ID, err)
+	}
+
+	// Cerrar todas las sesiones abiertas con la contraseña anterior
+	if err := uc.tokenRepo.RevokeByUserID(ctx, user.ID.String()); err != nil {
+		return err
+	}
+	if err := uc.sessionRepo.RevokeByUserID(ctx, user.ID.String()); err != nil {
+		return err
+	}
+	if err := uc.revocationUC.RevokeAllForUser(ctx, user.ID.String()); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionPasswordReset,
+		ActorID:  user.ID.String(),
+		TargetID: user.ID.String(),
+	})
+	return nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/pkg/mailer"
+)
+
+// newTestPasswordResetUseCase crea un PasswordResetUseCase local con enlaces de una hora
+func newTestPasswordResetUseCase(t *testing.T, store *memoryStore, tokenRepo *memoryOneTimeTokenRepo, mail *capturingMailer) *PasswordResetUseCase {
+	t.Helper()
+	return NewPasswordResetUseCase(
+		&memoryUserRepo{store: store},
+		&memoryTokenRepo{store: store},
+		&memorySessionRepo{store: store},
+		tokenRepo,
+		nil,
+		newTestJWTService(t),
+		nil,
+		nil,
+		mail,
+		nil,
+		nil,
+		"https://app.example.com",
+		time.Hour,
+	)
+}
+
+// forgotPassword pide el enlace y espera a que termine el envío en segundo plano
+func forgotPassword(uc *PasswordResetUseCase, email string) {
+	uc.ForgotPassword(context.Background(), &ForgotPasswordRequest{Email: email})
+	uc.pending.Wait()
+}
+
+func TestForgotPasswordEmailsResetLink(t *testing.T) {
+	store := newMemoryStore()
+	tokenRepo := newMemoryOneTimeTokenRepo()
+	mail := &capturingMailer{}
+	uc := newTestPasswordResetUseCase(t, store, tokenRepo, mail)
+	user := newTestUser(store)
+
+	forgotPassword(uc, user.Email)
+
+	messages := mail.sent()
+	if len(messages) != 1 || messages[0].To != user.Email {
+		t.Fatalf("expected one message to %s, got %+v", user.Email, messages)
+	}
+	if !strings.Contains(messages[0].Body, "https://app.example.com/reset-password?token=") {
+		t.Errorf("message does not link to the frontend: %q", messages[0].Body)
+	}
+	if _, ok := tokenRepo.tokens[hashToken(linkToken(t, messages[0]))]; !ok {
+		t.Error("reset token was not stored")
+	}
+}
+
+func TestForgotPasswordIgnoresUnknownEmail(t *testing.T) {
+	store := newMemoryStore()
+	tokenRepo := newMemoryOneTimeTokenRepo()
+	mail := &capturingMailer{}
+	uc := newTestPasswordResetUseCase(t, store, tokenRepo, mail)
+
+	forgotPassword(uc, "nadie@example.com")
+
+	if len(mail.sent()) != 0 || len(tokenRepo.tokens) != 0 {
+		t.Errorf("unknown email got %d messages and %d tokens", len(mail.sent()), len(tokenRepo.tokens))
+	}
+}
+
+func TestForgotPasswordDoesNotWaitForTheMailer(t *testing.T) {
+	store := newMemoryStore()
+	mail := &blockingMailer{release: make(chan struct{}), err: errors.New("smtp: connection refused")}
+	uc := NewPasswordResetUseCase(&memoryUserRepo{store: store}, nil, nil, newMemoryOneTimeTokenRepo(), nil,
+		newTestJWTService(t), nil, nil, mail, nil, nil, "https://app.example.com", time.Hour)
+	user := newTestUser(store)
+
+	returned := make(chan struct{})
+	go func() {
+		uc.ForgotPassword(context.Background(), &ForgotPasswordRequest{Email: user.Email})
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForgotPassword waited for the mailer")
+	}
+
+	// El error del envío no llega al llamador
+	close(mail.release)
+	uc.pending.Wait()
+	if !mail.called {
+		t.Error("mailer was not called")
+	}
+}
+
+// blockingMailer no responde hasta que se cierra release y luego falla con err
+type blockingMailer struct {
+	release chan struct{}
+	err     error
+	called  bool
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	<-m.release
+	m.called = true
+	return m.err
+}
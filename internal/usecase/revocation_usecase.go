@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+)
+
+// RevocationUseCase mantiene la lista de access tokens revocados con una caché en memoria.
+// Los jti revocados se cachean hasta su expiración; los resultados negativos y las marcas
+// de agua se cachean durante cacheTTL para que otras réplicas vean los cambios en ese plazo.
+type RevocationUseCase struct {
+	revocationRepo repositories.RevocationRepository
+	cacheTTL       time.Duration
+
+	mu         sync.RWMutex
+	jtis       map[string]revocationCacheEntry
+	watermarks map[string]watermarkCacheEntry
+}
+
+// revocationCacheEntry representa el estado cacheado de un jti
+type revocationCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// watermarkCacheEntry representa la marca de agua cacheada de un usuario
+type watermarkCacheEntry struct {
+	notBefore time.Time
+	expiresAt time.Time
+}
+
+// NewRevocationUseCase crea una nueva instancia de RevocationUseCase
+func NewRevocationUseCase(revocationRepo repositories.RevocationRepository, cacheTTL time.Duration) *RevocationUseCase {
+	return &RevocationUseCase{
+		revocationRepo: revocationRepo,
+		cacheTTL:       cacheTTL,
+		jtis:           make(map[string]revocationCacheEntry),
+		watermarks:     make(map[string]watermarkCacheEntry),
+	}
+}
+
+// RevokeAccessToken revoca un access token hasta su expiración
+func (uc *RevocationUseCase) RevokeAccessToken(ctx context.Context, claims *jwt.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		// Tokens emitidos antes de incorporar jti: solo se pueden invalidar con la marca de agua
+		if claims.UserID == "" {
+			return errors.New("token cannot be revoked")
+		}
+		return uc.RevokeAllForUser(ctx, claims.UserID)
+	}
+
+	if err := uc.revocationRepo.RevokeAccessToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	uc.jtis[claims.ID] = revocationCacheEntry{revoked: true, expiresAt: claims.ExpiresAt.Time}
+	uc.mu.Unlock()
+
+	return nil
+}
+
+// RevokeAllForUser invalida todos los access tokens emitidos hasta ahora para el usuario.
+// iat tiene resolución de segundos, así que la marca se redondea al segundo siguiente: un token
+// emitido en el mismo segundo que la revocación también queda invalidado.
+func (uc *RevocationUseCase) RevokeAllForUser(ctx context.Context, userID string) error {
+	now := time.Now()
+	notBefore := now.Truncate(time.Second).Add(time.Second)
+	if err := uc.revocationRepo.SetUserWatermark(ctx, userID, notBefore); err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	uc.watermarks[userID] = watermarkCacheEntry{notBefore: notBefore, expiresAt: now.Add(uc.cacheTTL)}
+	uc.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked verifica si un access token fue revocado por jti o por la marca de agua del usuario
+func (uc *RevocationUseCase) IsRevoked(ctx context.Context, claims *jwt.Claims) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := uc.isJTIRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	// Los tokens de cuentas de servicio no tienen usuario ni marca de revocación
+	if claims.UserID == "" {
+		return false, nil
+	}
+
+	notBefore, err := uc.watermark(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	if !notBefore.IsZero() && claims.IssuedAt != nil && claims.IssuedAt.Time.Before(notBefore) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Cleanup elimina de la base de datos y de la caché las entradas expiradas
+func (uc *RevocationUseCase) Cleanup(ctx context.Context) error {
+	now := time.Now()
+
+	uc.mu.Lock()
+	for jti, entry := range uc.jtis {
+		if now.After(entry.expiresAt) {
+			delete(uc.jtis, jti)
+		}
+	}
+	for userID, entry := range uc.watermarks {
+		if now.After(entry.expiresAt) {
+			delete(uc.watermarks, userID)
+		}
+	}
+	uc.mu.Unlock()
+
+	return uc.revocationRepo.DeleteExpired(ctx)
+}
+
+// isJTIRevoked consulta la caché y, si no hay una entrada vigente, la base de datos
+func (uc *RevocationUseCase) isJTIRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+
+	uc.mu.RLock()
+	entry, ok := uc.jtis[jti]
+	uc.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := uc.revocationRepo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	// Un jti revocado no vuelve a ser válido; se cachea durante más tiempo
+	ttl := uc.cacheTTL
+	if revoked {
+		ttl = time.Hour
+	}
+
+	uc.mu.Lock()
+	uc.jtis[jti] = revocationCacheEntry{revoked: revoked, expiresAt: now.Add(ttl)}
+	uc.mu.Unlock()
+
+	return revoked, nil
+}
+
+// watermark consulta la marca de agua del usuario usando la caché
+func (uc *RevocationUseCase) watermark(ctx context.Context, userID string) (time.Time, error) {
+	now := time.Now()
+
+	uc.mu.RLock()
+	entry, ok := uc.watermarks[userID]
+	uc.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.notBefore, nil
+	}
+
+	notBefore, err := uc.revocationRepo.GetUserWatermark(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	uc.mu.Lock()
+	uc.watermarks[userID] = watermarkCacheEntry{notBefore: notBefore, expiresAt: now.Add(uc.cacheTTL)}
+	uc.mu.Unlock()
+
+	return notBefore, nil
+}
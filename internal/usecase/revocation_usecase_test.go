@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/pkg/jwt"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// claimsIssuedAt crea los claims de un access token del usuario emitido en issuedAt
+func claimsIssuedAt(userID string, issuedAt time.Time) *jwt.Claims {
+	return &jwt.Claims{
+		UserID:           userID,
+		RegisteredClaims: jwtlib.RegisteredClaims{IssuedAt: jwtlib.NewNumericDate(issuedAt)},
+	}
+}
+
+func TestRevokeAllForUserRevokesTokensIssuedInTheSameSecond(t *testing.T) {
+	revocationRepo := newMemoryRevocationRepo()
+	uc := NewRevocationUseCase(revocationRepo, time.Minute)
+	userID := uuid.NewString()
+
+	claims := claimsIssuedAt(userID, time.Now())
+	if err := uc.RevokeAllForUser(context.Background(), userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// La réplica que revoca usa la caché; otra réplica lee la marca guardada
+	for name, replica := range map[string]*RevocationUseCase{
+		"cached": uc,
+		"stored": NewRevocationUseCase(revocationRepo, time.Minute),
+	} {
+		revoked, err := replica.IsRevoked(context.Background(), claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !revoked {
+			t.Errorf("%s: token issued in the same second as the revocation is still valid", name)
+		}
+	}
+}
+
+func TestRevokeAllForUserKeepsTokensIssuedLater(t *testing.T) {
+	uc := NewRevocationUseCase(newMemoryRevocationRepo(), time.Minute)
+	userID := uuid.NewString()
+
+	if err := uc.RevokeAllForUser(context.Background(), userID); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := uc.IsRevoked(context.Background(), claimsIssuedAt(userID, time.Now().Add(time.Second)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked {
+		t.Error("token issued after the revocation was rejected")
+	}
+}
@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+)
+
+// roleNamePattern restringe los nombres de rol a minúsculas, dígitos, guiones y guiones bajos
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleUseCase administra los roles y resuelve los permisos efectivos de los usuarios.
+// Los permisos resueltos se cachean durante cacheTTL para no consultar la base de datos
+// en cada petición cuando no viajan en el access token.
+type RoleUseCase struct {
+	roleRepo     repositories.RoleRepository
+	userRepo     repositories.UserRepository
+	revocationUC *RevocationUseCase
+	auditLogger  *AuditLogger
+	cacheTTL     time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]permissionCacheEntry
+}
+
+// permissionCacheEntry representa los permisos cacheados de un usuario o conjunto de roles
+type permissionCacheEntry struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+// NewRoleUseCase crea una nueva instancia de RoleUseCase
+func NewRoleUseCase(
+	roleRepo repositories.RoleRepository,
+	userRepo repositories.UserRepository,
+	revocationUC *RevocationUseCase,
+	auditLogger *AuditLogger,
+	cacheTTL time.Duration,
+) *RoleUseCase {
+	return &RoleUseCase{
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		revocationUC: revocationUC,
+		auditLogger:  auditLogger,
+		cacheTTL:     cacheTTL,
+		cache:        make(map[string]permissionCacheEntry),
+	}
+}
+
+// ListRoles obtiene todos los roles con sus permisos
+func (uc *RoleUseCase) ListRoles(ctx context.Context) ([]*entities.RoleDefinition, error) {
+	return uc.roleRepo.List(ctx)
+}
+
+// CreateRoleRequest representa la solicitud de creación de un rol
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// CreateRole crea un rol con los permisos indicados
+func (uc *RoleUseCase) CreateRole(ctx context.Context, req *CreateRoleRequest) (*entities.RoleDefinition, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, errors.New("invalid role name")
+	}
+
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := entities.NewRoleDefinition(entities.Role(req.Name), req.Description, permissions)
+	if err := uc.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRoleCreated,
+		TargetID: role.ID.String(),
+		Metadata: map[string]interface{}{"name": role.Name, "permissions": permissions},
+	})
+
+	return role, nil
+}
+
+// SetRolePermissionsRequest representa la solicitud de reemplazo de los permisos de un rol
+type SetRolePermissionsRequest struct {
+	RoleID      string   `json:"-"`
+	Permissions []string `json:"permissions"`
+}
+
+// SetRolePermissions reemplaza los permisos de un rol e invalida los access tokens de los
+// usuarios que lo tienen, que pueden llevar los permisos anteriores
+func (uc *RoleUseCase) SetRolePermissions(ctx context.Context, req *SetRolePermissionsRequest) (*entities.RoleDefinition, error) {
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.roleRepo.SetPermissions(ctx, req.RoleID, permissions); err != nil {
+		return nil, err
+	}
+	uc.invalidateAll()
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRolePermissions,
+		TargetID: req.RoleID,
+		Metadata: map[string]interface{}{"permissions": permissions},
+	})
+
+	holders, err := uc.roleRepo.ListUserIDs(ctx, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.revokeHolders(ctx, holders); err != nil {
+		return nil, err
+	}
+
+	return uc.roleRepo.GetByID(ctx, req.RoleID)
+}
+
+// DeleteRole elimina un rol que no es de sistema e invalida los access tokens de los
+// usuarios que lo tenían asignado
+func (uc *RoleUseCase) DeleteRole(ctx context.Context, roleID string) error {
+	// Las asignaciones se borran junto con el rol; se obtienen antes
+	holders, err := uc.roleRepo.ListUserIDs(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.roleRepo.Delete(ctx, roleID); err != nil {
+		return err
+	}
+	uc.invalidateAll()
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRoleDeleted,
+		TargetID: roleID,
+	})
+
+	return uc.revokeHolders(ctx, holders)
+}
+
+// ListUserRoles obtiene los roles efectivos de un usuario
+func (uc *RoleUseCase) ListUserRoles(ctx context.Context, userID string) ([]*entities.RoleDefinition, error) {
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+	return uc.roleRepo.ListByUserID(ctx, userID)
+}
+
+// AssignRole asigna un rol adicional al usuario e invalida sus access tokens,
+// que llevan los roles y permisos anteriores
+func (uc *RoleUseCase) AssignRole(ctx context.Context, userID, roleID string) error {
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return errors.New("user not found")
+	}
+	role, err := uc.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.roleRepo.AssignToUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRoleAssigned,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"role_id": roleID, "role": role.Name},
+	})
+
+	return uc.userRolesChanged(ctx, userID)
+}
+
+// AssignRoleByName asigna un rol adicional identificado por su nombre
+func (uc *RoleUseCase) AssignRoleByName(ctx context.Context, userID, name string) error {
+	role, err := uc.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return errors.New("invalid role")
+	}
+	return uc.AssignRole(ctx, userID, role.ID.String())
+}
+
+// RemoveRole quita un rol adicional del usuario. El rol principal se cambia actualizando el usuario.
+func (uc *RoleUseCase) RemoveRole(ctx context.Context, userID, roleID string) error {
+	if err := uc.roleRepo.RemoveFromUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionRoleRemoved,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"role_id": roleID},
+	})
+
+	return uc.userRolesChanged(ctx, userID)
+}
+
+// ValidateRole verifica que el rol exista
+func (uc *RoleUseCase) ValidateRole(ctx context.Context, name string) error {
+	if _, err := uc.roleRepo.GetByName(ctx, name); err != nil {
+		return errors.New("invalid role")
+	}
+	return nil
+}
+
+// Authorization obtiene los nombres de los roles efectivos del usuario y la unión de sus permisos
+func (uc *RoleUseCase) Authorization(ctx context.Context, userID string) ([]string, []string, error) {
+	roles, err := uc.roleRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role.Name)
+	}
+
+	return names, mergePermissions(roles), nil
+}
+
+// UserPermissions obtiene los permisos efectivos del usuario usando la caché
+func (uc *RoleUseCase) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	return uc.cached("user:"+userID, func() ([]string, error) {
+		_, permissions, err := uc.Authorization(ctx, userID)
+		return permissions, err
+	})
+}
+
+// RolePermissions obtiene la unión de los permisos de los roles indicados usando la caché.
+// Se usa con los roles de Keycloak, que se corresponden con los roles locales por nombre.
+func (uc *RoleUseCase) RolePermissions(ctx context.Context, roleNames []string) ([]string, error) {
+	sorted := append([]string(nil), roleNames...)
+	sort.Strings(sorted)
+
+	return uc.cached("roles:"+strings.Join(sorted, ","), func() ([]string, error) {
+		roles, err := uc.roleRepo.ListByNames(ctx, sorted)
+		if err != nil {
+			return nil, err
+		}
+		return mergePermissions(roles), nil
+	})
+}
+
+// InvalidateUser descarta los permisos cacheados del usuario
+func (uc *RoleUseCase) InvalidateUser(userID string) {
+	uc.mu.Lock()
+	delete(uc.cache, "user:"+userID)
+	uc.mu.Unlock()
+}
+
+// userRolesChanged descarta la caché del usuario e invalida sus access tokens
+func (uc *RoleUseCase) userRolesChanged(ctx context.Context, userID string) error {
+	uc.InvalidateUser(userID)
+	return uc.revocationUC.RevokeAllForUser(ctx, userID)
+}
+
+// revokeHolders invalida los access tokens de los usuarios que tienen un rol modificado
+func (uc *RoleUseCase) revokeHolders(ctx context.Context, userIDs []string) error {
+	for _, userID := range userIDs {
+		if err := uc.revocationUC.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cached consulta la caché y, si no hay una entrada vigente, resuelve los permisos
+func (uc *RoleUseCase) cached(key string, resolve func() ([]string, error)) ([]string, error) {
+	now := time.Now()
+
+	uc.mu.RLock()
+	entry, ok := uc.cache[key]
+	uc.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	permissions, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	uc.cache[key] = permissionCacheEntry{permissions: permissions, expiresAt: now.Add(uc.cacheTTL)}
+	uc.mu.Unlock()
+
+	return permissions, nil
+}
+
+// invalidateAll descarta toda la caché tras modificar un rol
+func (uc *RoleUseCase) invalidateAll() {
+	uc.mu.Lock()
+	uc.cache = make(map[string]permissionCacheEntry)
+	uc.mu.Unlock()
+}
+
+// parsePermissions valida los permisos contra el catálogo
+func parsePermissions(values []string) ([]entities.Permission, error) {
+	permissions := make([]entities.Permission, 0, len(values))
+	for _, value := range values {
+		permission := entities.Permission(value)
+		if !entities.IsValidPermission(permission) {
+			return nil, errors.New("unknown permission: " + value)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+// mergePermissions retorna la unión ordenada de los permisos de los roles
+func mergePermissions(roles []*entities.RoleDefinition) []string {
+	seen := make(map[entities.Permission]struct{})
+	var permissions []string
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if _, ok := seen[permission]; ok {
+				continue
+			}
+			seen[permission] = struct{}{}
+			permissions = append(permissions, string(permission))
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
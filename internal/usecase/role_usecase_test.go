@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestRoleUseCase crea un RoleUseCase con un rol que tienen los usuarios indicados
+func newTestRoleUseCase(roleID string, holders ...string) (*RoleUseCase, *memoryRevocationRepo) {
+	revocationRepo := newMemoryRevocationRepo()
+	roleRepo := &memoryRoleRepo{holders: map[string][]string{roleID: holders}}
+	uc := NewRoleUseCase(roleRepo, &memoryUserRepo{store: newMemoryStore()}, NewRevocationUseCase(revocationRepo, time.Minute), nil, time.Minute)
+	return uc, revocationRepo
+}
+
+func TestSetRolePermissionsRevokesTokensOfHolders(t *testing.T) {
+	roleID, holder, other := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	uc, revocationRepo := newTestRoleUseCase(roleID, holder)
+
+	req := &SetRolePermissionsRequest{RoleID: roleID, Permissions: []string{"users:read"}}
+	if _, err := uc.SetRolePermissions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if revocationRepo.watermark(holder).IsZero() {
+		t.Error("tokens of the role holder were not revoked")
+	}
+	if !revocationRepo.watermark(other).IsZero() {
+		t.Error("tokens of a user without the role were revoked")
+	}
+}
+
+func TestDeleteRoleRevokesTokensOfHolders(t *testing.T) {
+	roleID, holder := uuid.NewString(), uuid.NewString()
+	uc, revocationRepo := newTestRoleUseCase(roleID, holder)
+
+	if err := uc.DeleteRole(context.Background(), roleID); err != nil {
+		t.Fatal(err)
+	}
+
+	if revocationRepo.watermark(holder).IsZero() {
+		t.Error("tokens of the role holder were not revoked")
+	}
+}
@@ -0,0 +1,381 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+// serviceAccountClientIDPrefix distingue los client_id de las cuentas de servicio de los de los clientes OAuth
+const serviceAccountClientIDPrefix = "sa_"
+
+// ServiceAccountConfig configura la emisión de tokens a cuentas de servicio
+type ServiceAccountConfig struct {
+	Issuer         string        // URL pública del servicio; el aud de los client assertions es el token endpoint o el issuer
+	AccessTokenTTL time.Duration // vigencia de los access tokens, informada en expires_in
+}
+
+// ServiceAccountUseCase administra las cuentas de servicio y emite sus access tokens con el grant
+// client_credentials. Los scopes que coinciden con permisos del catálogo habilitan las rutas que
+// requieren ese permiso.
+type ServiceAccountUseCase struct {
+	serviceAccountRepo repositories.ServiceAccountRepository
+	roleUC             *RoleUseCase
+	jwtSvc             jwt.Service
+	auditLogger        *AuditLogger
+	config             ServiceAccountConfig
+	useKeycloak        bool
+}
+
+// NewServiceAccountUseCase crea una nueva instancia de ServiceAccountUseCase
+func NewServiceAccountUseCase(
+	serviceAccountRepo repositories.ServiceAccountRepository,
+	roleUC *RoleUseCase,
+	jwtSvc jwt.Service,
+	auditLogger *AuditLogger,
+	config ServiceAccountConfig,
+	useKeycloak bool,
+) *ServiceAccountUseCase {
+	return &ServiceAccountUseCase{
+		serviceAccountRepo: serviceAccountRepo,
+		roleUC:             roleUC,
+		jwtSvc:             jwtSvc,
+		auditLogger:        auditLogger,
+		config:             config,
+		useKeycloak:        useKeycloak,
+	}
+}
+
+// CreateServiceAccountRequest representa la solicitud de alta de una cuenta de servicio
+type CreateServiceAccountRequest struct {
+	Name          string   `json:"name" binding:"required,max=255"`
+	Description   string   `json:"description" binding:"max=1000"`
+	AuthMethod    string   `json:"auth_method" binding:"omitempty,oneof=client_secret private_key_jwt"` // client_secret por defecto
+	PublicKey     string   `json:"public_key"`                                                          // PEM, requerido con private_key_jwt
+	AllowedScopes []string `json:"allowed_scopes"`
+	CreatedBy     string   `json:"-"`
+}
+
+// ServiceAccountResponse representa una cuenta; ClientSecret solo se incluye al crearla o rotarlo
+type ServiceAccountResponse struct {
+	*entities.ServiceAccount
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// Create registra una cuenta de servicio y, si se autentica con secreto, lo retorna
+func (uc *ServiceAccountUseCase) Create(ctx context.Context, req *CreateServiceAccountRequest) (*ServiceAccountResponse, error) {
+	if uc.useKeycloak {
+		return nil, ErrOAuthUnavailable
+	}
+
+	authMethod := entities.ServiceAccountAuthMethod(req.AuthMethod)
+	if authMethod == "" {
+		authMethod = entities.ServiceAccountAuthSecret
+	}
+
+	scopes, err := parseScopes(req.AllowedScopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkGrantablePermissions(ctx, req.CreatedBy, scopes); err != nil {
+		return nil, err
+	}
+
+	var createdBy *uuid.UUID
+	if req.CreatedBy != "" {
+		id, err := uuid.Parse(req.CreatedBy)
+		if err != nil {
+			return nil, errors.New("invalid user id")
+		}
+		createdBy = &id
+	}
+
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, err
+	}
+
+	account := entities.NewServiceAccount(serviceAccountClientIDPrefix+clientID, req.Name, req.Description, authMethod, scopes, createdBy)
+	response := &ServiceAccountResponse{ServiceAccount: account}
+
+	if account.UsesPrivateKeyJWT() {
+		if err := validatePublicKey(req.PublicKey); err != nil {
+			return nil, err
+		}
+		account.PublicKey = strings.TrimSpace(req.PublicKey)
+	} else {
+		if req.PublicKey != "" {
+			return nil, errors.New("public_key is only allowed with private_key_jwt")
+		}
+		if response.ClientSecret, err = newRandomToken(); err != nil {
+			return nil, err
+		}
+		account.SecretHash = hashToken(response.ClientSecret)
+	}
+
+	if err := uc.serviceAccountRepo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionServiceAccountCreated,
+		TargetID: account.ID.String(),
+		Metadata: map[string]interface{}{"client_id": account.ClientID, "name": account.Name, "auth_method": account.AuthMethod, "scopes": account.AllowedScopes},
+	})
+
+	return response, nil
+}
+
+// List lista todas las cuentas de servicio
+func (uc *ServiceAccountUseCase) List(ctx context.Context) ([]*entities.ServiceAccount, error) {
+	return uc.serviceAccountRepo.List(ctx)
+}
+
+// Get obtiene una cuenta de servicio por su ID interno
+func (uc *ServiceAccountUseCase) Get(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	return uc.serviceAccountRepo.GetByID(ctx, id)
+}
+
+// UpdateServiceAccountRequest representa la solicitud de actualización de una cuenta; los campos
+// omitidos no se modifican. El método de autenticación no se puede cambiar.
+type UpdateServiceAccountRequest struct {
+	ID            string    `json:"-"`
+	Name          string    `json:"name" binding:"max=255"`
+	Description   *string   `json:"description" binding:"omitempty,max=1000"`
+	PublicKey     string    `json:"public_key"` // reemplaza la clave de una cuenta private_key_jwt
+	AllowedScopes *[]string `json:"allowed_scopes"`
+	IsActive      *bool     `json:"is_active"` // false deshabilita la emisión de tokens
+	UpdatedBy     string    `json:"-"`
+}
+
+// Update actualiza la cuenta de servicio
+func (uc *ServiceAccountUseCase) Update(ctx context.Context, req *UpdateServiceAccountRequest) (*entities.ServiceAccount, error) {
+	account, err := uc.serviceAccountRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if req.Name != "" {
+		account.Name = req.Name
+		changes["name"] = req.Name
+	}
+	if req.Description != nil {
+		account.Description = *req.Description
+		changes["description"] = account.Description
+	}
+	if req.PublicKey != "" {
+		if !account.UsesPrivateKeyJWT() {
+			return nil, errors.New("public_key is only allowed with private_key_jwt")
+		}
+		if err := validatePublicKey(req.PublicKey); err != nil {
+			return nil, err
+		}
+		account.PublicKey = strings.TrimSpace(req.PublicKey)
+		changes["public_key_replaced"] = true
+	}
+	if req.AllowedScopes != nil {
+		scopes, err := parseScopes(*req.AllowedScopes)
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.checkGrantablePermissions(ctx, req.UpdatedBy, scopes); err != nil {
+			return nil, err
+		}
+		account.AllowedScopes = scopes
+		changes["scopes"] = scopes
+	}
+	if req.IsActive != nil {
+		account.IsActive = *req.IsActive
+		changes["is_active"] = account.IsActive
+	}
+
+	account.UpdatedAt = time.Now()
+	if err := uc.serviceAccountRepo.Update(ctx, account); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionServiceAccountUpdated,
+		TargetID: account.ID.String(),
+		Metadata: changes,
+	})
+
+	return account, nil
+}
+
+// RotateSecret genera un secreto nuevo para la cuenta; el anterior deja de ser válido
+func (uc *ServiceAccountUseCase) RotateSecret(ctx context.Context, id string) (*ServiceAccountResponse, error) {
+	account, err := uc.serviceAccountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if account.UsesPrivateKeyJWT() {
+		return nil, errors.New("service accounts with private_key_jwt do not have a secret; replace the public key instead")
+	}
+
+	response := &ServiceAccountResponse{ServiceAccount: account}
+	if response.ClientSecret, err = newRandomToken(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account.SecretHash = hashToken(response.ClientSecret)
+	account.SecretRotatedAt = &now
+	account.UpdatedAt = now
+	if err := uc.serviceAccountRepo.Update(ctx, account); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionServiceAccountRotated,
+		TargetID: account.ID.String(),
+		Metadata: map[string]interface{}{"client_id": account.ClientID},
+	})
+
+	return response, nil
+}
+
+// Delete elimina la cuenta de servicio; sus access tokens ya emitidos siguen vigentes hasta expirar
+func (uc *ServiceAccountUseCase) Delete(ctx context.Context, id string) error {
+	if err := uc.serviceAccountRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionServiceAccountDeleted,
+		TargetID: id,
+	})
+	return nil
+}
+
+// IssueToken autentica la cuenta de servicio y emite un access token con el grant
+// client_credentials (RFC 6749 sección 4.4). No se emite refresh token: la cuenta pide uno nuevo
+// con sus credenciales. Los errores del protocolo se retornan como *OAuthError.
+func (uc *ServiceAccountUseCase) IssueToken(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	if uc.useKeycloak {
+		return nil, newOAuthError(OAuthErrInvalidRequest, ErrOAuthUnavailable.Error())
+	}
+
+	account, err := uc.authenticate(ctx, req.ClientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sin scope se conceden todos los scopes permitidos a la cuenta
+	scopes := account.AllowedScopes
+	if req.Scope != "" {
+		scopes = mergeScopes(nil, strings.Fields(req.Scope))
+		if !account.AllowsScopes(scopes) {
+			return nil, newOAuthError(OAuthErrInvalidScope, "the requested scope is not allowed for the service account")
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	accessToken, err := uc.jwtSvc.GenerateServiceToken(account.ClientID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.serviceAccountRepo.UpdateLastUsed(ctx, account.ID.String(), time.Now()); err != nil {
+		log.Printf("Error updating last use of service account %s: %v", account.ClientID, err)
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(uc.config.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// CleanupAssertions elimina los jti de client assertions que ya expiraron
+func (uc *ServiceAccountUseCase) CleanupAssertions(ctx context.Context) error {
+	return uc.serviceAccountRepo.DeleteExpiredAssertions(ctx)
+}
+
+// authenticate autentica la cuenta con su secreto o con un client assertion según su método
+func (uc *ServiceAccountUseCase) authenticate(ctx context.Context, creds ClientCredentials) (*entities.ServiceAccount, error) {
+	clientID := creds.ClientID
+	if creds.ClientAssertion != "" {
+		if creds.ClientAssertionType != jwt.ClientAssertionType {
+			return nil, newOAuthError(OAuthErrInvalidClient, "unsupported client_assertion_type")
+		}
+		subject, err := jwt.AssertionSubject(creds.ClientAssertion)
+		if err != nil {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+		if clientID != "" && clientID != subject {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+		clientID = subject
+	}
+	if clientID == "" {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication is required")
+	}
+
+	account, err := uc.serviceAccountRepo.GetByClientID(ctx, clientID)
+	if err != nil || !account.IsActive {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+
+	if account.UsesPrivateKeyJWT() {
+		if creds.ClientAssertion == "" {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client_assertion is required")
+		}
+		issuer := strings.TrimRight(uc.config.Issuer, "/")
+		assertion, err := jwt.VerifyClientAssertion(creds.ClientAssertion, []byte(account.PublicKey), account.ClientID, []string{issuer + "/oauth/token", issuer})
+		if err != nil {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+		if err := uc.serviceAccountRepo.UseAssertion(ctx, account.ClientID, assertion.JTI, assertion.ExpiresAt); err != nil {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client assertion already used")
+		}
+		return account, nil
+	}
+
+	if creds.ClientAssertion != "" || creds.ClientSecret == "" ||
+		subtle.ConstantTimeCompare([]byte(hashToken(creds.ClientSecret)), []byte(account.SecretHash)) != 1 {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+	return account, nil
+}
+
+// checkGrantablePermissions impide que quien administra cuentas de servicio les conceda, como
+// scopes, permisos del catálogo que no tiene
+func (uc *ServiceAccountUseCase) checkGrantablePermissions(ctx context.Context, userID string, scopes []string) error {
+	if userID == "" {
+		return nil
+	}
+
+	granted, err := uc.roleUC.UserPermissions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if entities.IsValidPermission(entities.Permission(scope)) && !containsScope(granted, scope) {
+			return errors.New("cannot grant a permission you do not have: " + scope)
+		}
+	}
+	return nil
+}
+
+// validatePublicKey valida la clave pública PEM de una cuenta private_key_jwt
+func validatePublicKey(publicKey string) error {
+	if strings.TrimSpace(publicKey) == "" {
+		return errors.New("public_key is required with private_key_jwt")
+	}
+	if _, _, err := jwt.ParsePublicKeyPEM([]byte(publicKey)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/pkg/jwt"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// testTokenEndpoint es el aud que aceptan los client assertions en los tests
+const testTokenEndpoint = "https://auth.example.com/oauth/token"
+
+// newTestServiceAccountUseCase crea un ServiceAccountUseCase con el issuer de los tests OAuth
+func newTestServiceAccountUseCase(t *testing.T, repo *memoryServiceAccountRepo) *ServiceAccountUseCase {
+	t.Helper()
+	store := newMemoryStore()
+	roleUC := NewRoleUseCase(&memoryRoleRepo{}, &memoryUserRepo{store: store}, nil, nil, time.Minute)
+	return NewServiceAccountUseCase(repo, roleUC, newTestJWTService(t), NewAuditLogger(&memoryAuditRepo{}), ServiceAccountConfig{
+		Issuer:         "https://auth.example.com/",
+		AccessTokenTTL: 15 * time.Minute,
+	}, false)
+}
+
+// newAssertionKey genera un par Ed25519 y retorna la clave privada y la pública en PEM
+func newAssertionKey(t *testing.T) (ed25519.PrivateKey, string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// signAssertion firma un client assertion con los claims indicados
+func signAssertion(t *testing.T, key ed25519.PrivateKey, claims jwtlib.RegisteredClaims) string {
+	t.Helper()
+	assertion, err := jwtlib.NewWithClaims(jwtlib.SigningMethodEdDSA, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assertion
+}
+
+// assertionClaims retorna claims válidos de un client assertion de la cuenta
+func assertionClaims(clientID string) jwtlib.RegisteredClaims {
+	return jwtlib.RegisteredClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  jwtlib.ClaimStrings{testTokenEndpoint},
+		ID:        uuid.NewString(),
+		ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(5 * time.Minute)),
+	}
+}
+
+// assertionRequest arma la solicitud client_credentials autenticada con el assertion
+func assertionRequest(assertion string) *TokenRequest {
+	return &TokenRequest{
+		GrantType: "client_credentials",
+		ClientCredentials: ClientCredentials{
+			ClientAssertionType: jwt.ClientAssertionType,
+			ClientAssertion:     assertion,
+		},
+	}
+}
+
+func TestIssueTokenVerifiesPrivateKeyJWTAssertion(t *testing.T) {
+	uc := newTestServiceAccountUseCase(t, newMemoryServiceAccountRepo())
+	ctx := context.Background()
+	key, publicKey := newAssertionKey(t)
+	otherKey, _ := newAssertionKey(t)
+
+	account, err := uc.Create(ctx, &CreateServiceAccountRequest{Name: "billing", AuthMethod: "private_key_jwt", PublicKey: publicKey, AllowedScopes: []string{"users:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ClientSecret != "" {
+		t.Fatal("a private_key_jwt account was given a secret")
+	}
+	clientID := account.ClientID
+
+	rejected := map[string]func(claims *jwtlib.RegisteredClaims) string{
+		"wrong audience": func(c *jwtlib.RegisteredClaims) string {
+			c.Audience = jwtlib.ClaimStrings{"https://other.example.com/oauth/token"}
+			return signAssertion(t, key, *c)
+		},
+		"expired": func(c *jwtlib.RegisteredClaims) string {
+			c.ExpiresAt = jwtlib.NewNumericDate(time.Now().Add(-time.Minute))
+			return signAssertion(t, key, *c)
+		},
+		"expiry too far": func(c *jwtlib.RegisteredClaims) string {
+			c.ExpiresAt = jwtlib.NewNumericDate(time.Now().Add(2 * time.Hour))
+			return signAssertion(t, key, *c)
+		},
+		"missing expiry": func(c *jwtlib.RegisteredClaims) string {
+			c.ExpiresAt = nil
+			return signAssertion(t, key, *c)
+		},
+		"missing jti": func(c *jwtlib.RegisteredClaims) string {
+			c.ID = ""
+			return signAssertion(t, key, *c)
+		},
+		"issuer is not the client": func(c *jwtlib.RegisteredClaims) string {
+			c.Issuer = "sa_other"
+			return signAssertion(t, key, *c)
+		},
+		"signed with another key": func(c *jwtlib.RegisteredClaims) string {
+			return signAssertion(t, otherKey, *c)
+		},
+	}
+	for name, build := range rejected {
+		claims := assertionClaims(clientID)
+		_, err := uc.IssueToken(ctx, assertionRequest(build(&claims)))
+		if err == nil {
+			t.Errorf("%s: assertion accepted", name)
+			continue
+		}
+		assertOAuthError(t, err, OAuthErrInvalidClient)
+	}
+
+	// El issuer también es un aud válido
+	claims := assertionClaims(clientID)
+	claims.Audience = jwtlib.ClaimStrings{"https://auth.example.com"}
+	assertion := signAssertion(t, key, claims)
+	response, err := uc.IssueToken(ctx, assertionRequest(assertion))
+	if err != nil {
+		t.Fatalf("valid assertion rejected: %v", err)
+	}
+	if response.Scope != "users:read" || response.RefreshToken != "" {
+		t.Errorf("unexpected token response: %+v", response)
+	}
+
+	// Un jti ya usado no se acepta de nuevo aunque el assertion siga vigente
+	_, err = uc.IssueToken(ctx, assertionRequest(assertion))
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+
+	// client_id, si se envía, debe coincidir con el sub del assertion
+	request := assertionRequest(signAssertion(t, key, assertionClaims(clientID)))
+	request.ClientID = "sa_other"
+	_, err = uc.IssueToken(ctx, request)
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+
+	// Sin assertion una cuenta private_key_jwt no se autentica
+	_, err = uc.IssueToken(ctx, &TokenRequest{GrantType: "client_credentials", ClientCredentials: ClientCredentials{ClientID: clientID, ClientSecret: "secret"}})
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+}
+
+func TestIssueTokenRejectsDisabledServiceAccounts(t *testing.T) {
+	repo := newMemoryServiceAccountRepo()
+	uc := newTestServiceAccountUseCase(t, repo)
+	ctx := context.Background()
+	key, publicKey := newAssertionKey(t)
+
+	secretAccount, err := uc.Create(ctx, &CreateServiceAccountRequest{Name: "jobs", AllowedScopes: []string{"users:read", "sessions:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyAccount, err := uc.Create(ctx, &CreateServiceAccountRequest{Name: "billing", AuthMethod: "private_key_jwt", PublicKey: publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretRequest := &TokenRequest{
+		GrantType:         "client_credentials",
+		Scope:             "users:read",
+		ClientCredentials: ClientCredentials{ClientID: secretAccount.ClientID, ClientSecret: secretAccount.ClientSecret},
+	}
+
+	response, err := uc.IssueToken(ctx, secretRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Scope != "users:read" {
+		t.Errorf("scope = %q, want the requested subset", response.Scope)
+	}
+	stored, _ := repo.GetByClientID(ctx, secretAccount.ClientID)
+	if stored.LastUsedAt == nil {
+		t.Error("last use was not recorded")
+	}
+
+	disabled := false
+	for _, id := range []uuid.UUID{secretAccount.ID, keyAccount.ID} {
+		if _, err := uc.Update(ctx, &UpdateServiceAccountRequest{ID: id.String(), IsActive: &disabled}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = uc.IssueToken(ctx, secretRequest)
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+	_, err = uc.IssueToken(ctx, assertionRequest(signAssertion(t, key, assertionClaims(keyAccount.ClientID))))
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+
+	enabled := true
+	if _, err := uc.Update(ctx, &UpdateServiceAccountRequest{ID: secretAccount.ID.String(), IsActive: &enabled}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.IssueToken(ctx, secretRequest); err != nil {
+		t.Fatalf("re-enabled account rejected: %v", err)
+	}
+}
+
+func TestIssueTokenWithClientSecret(t *testing.T) {
+	uc := newTestServiceAccountUseCase(t, newMemoryServiceAccountRepo())
+	ctx := context.Background()
+
+	account, err := uc.Create(ctx, &CreateServiceAccountRequest{Name: "jobs", AllowedScopes: []string{"users:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(secret, scope string) *TokenRequest {
+		return &TokenRequest{
+			GrantType:         "client_credentials",
+			Scope:             scope,
+			ClientCredentials: ClientCredentials{ClientID: account.ClientID, ClientSecret: secret},
+		}
+	}
+
+	_, err = uc.IssueToken(ctx, request("wrong-secret", ""))
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+	_, err = uc.IssueToken(ctx, request(account.ClientSecret, "users:write"))
+	assertOAuthError(t, err, OAuthErrInvalidScope)
+
+	rotated, err := uc.RotateSecret(ctx, account.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.IssueToken(ctx, request(account.ClientSecret, ""))
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+	if _, err := uc.IssueToken(ctx, request(rotated.ClientSecret, "")); err != nil {
+		t.Fatalf("rotated secret rejected: %v", err)
+	}
+}
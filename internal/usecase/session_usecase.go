@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+)
+
+// SessionUseCase maneja la lógica de negocio para sesiones de usuario
+type SessionUseCase struct {
+	sessionRepo  repositories.SessionRepository
+	tokenRepo    repositories.TokenRepository
+	revocationUC *RevocationUseCase
+}
+
+// NewSessionUseCase crea una nueva instancia de SessionUseCase
+func NewSessionUseCase(sessionRepo repositories.SessionRepository, tokenRepo repositories.TokenRepository, revocationUC *RevocationUseCase) *SessionUseCase {
+	return &SessionUseCase{
+		sessionRepo:  sessionRepo,
+		tokenRepo:    tokenRepo,
+		revocationUC: revocationUC,
+	}
+}
+
+// ListSessionsRequest representa la solicitud para listar sesiones
+type ListSessionsRequest struct {
+	UserID string
+}
+
+// ListSessions lista las sesiones activas de un usuario
+func (uc *SessionUseCase) ListSessions(ctx context.Context, req *ListSessionsRequest) ([]*entities.Session, error) {
+	return uc.sessionRepo.ListActiveByUserID(ctx, req.UserID)
+}
+
+// RevokeSessionRequest representa la solicitud para cerrar una sesión
+type RevokeSessionRequest struct {
+	UserID    string
+	SessionID string
+}
+
+// RevokeSession cierra una sesión y revoca los refresh tokens de su familia
+func (uc *SessionUseCase) RevokeSession(ctx context.Context, req *RevokeSessionRequest) error {
+	session, err := uc.sessionRepo.GetByID(ctx, req.SessionID)
+	if err != nil {
+		return errors.New("session not found")
+	}
+
+	// Una sesión de otro usuario se reporta como inexistente
+	if session.UserID.String() != req.UserID {
+		return errors.New("session not found")
+	}
+
+	if err := uc.tokenRepo.RevokeFamily(ctx, session.FamilyID.String()); err != nil {
+		return err
+	}
+
+	return uc.sessionRepo.Revoke(ctx, session.ID.String())
+}
+
+// RevokeAllSessionsRequest representa la solicitud para cerrar todas las sesiones
+type RevokeAllSessionsRequest struct {
+	UserID string
+}
+
+// RevokeAllSessions cierra todas las sesiones del usuario en todos sus dispositivos
+func (uc *SessionUseCase) RevokeAllSessions(ctx context.Context, req *RevokeAllSessionsRequest) error {
+	if err := uc.tokenRepo.RevokeByUserID(ctx, req.UserID); err != nil {
+		return err
+	}
+
+	if err := uc.sessionRepo.RevokeByUserID(ctx, req.UserID); err != nil {
+		return err
+	}
+
+	// Invalidar también los access tokens emitidos hasta ahora
+	return uc.revocationUC.RevokeAllForUser(ctx, req.UserID)
+}
@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+	"auth-go-microservicio/pkg/keycloak"
+)
+
+// Valores de token_type_hint y de token_type en la respuesta de introspección
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// TokenIntrospectionUseCase implementa la introspección (RFC 7662) y la revocación (RFC 7009) de
+// tokens para los clientes OAuth y las cuentas de servicio. En modo local los access tokens se
+// verifican con jwt.Service y la lista de revocación, y los refresh tokens con el TokenRepository;
+// con Keycloak la consulta se reenvía a Keycloak con las credenciales del cliente.
+type TokenIntrospectionUseCase struct {
+	oauthUC          *OAuthUseCase
+	serviceAccountUC *ServiceAccountUseCase
+	tokenRepo        repositories.TokenRepository
+	sessionRepo      repositories.SessionRepository
+	revocationUC     *RevocationUseCase
+	jwtSvc           jwt.Service
+	keycloakService  keycloak.Service
+	auditLogger      *AuditLogger
+	issuer           string
+	useKeycloak      bool
+}
+
+// NewTokenIntrospectionUseCase crea una nueva instancia de TokenIntrospectionUseCase
+func NewTokenIntrospectionUseCase(
+	oauthUC *OAuthUseCase,
+	serviceAccountUC *ServiceAccountUseCase,
+	tokenRepo repositories.TokenRepository,
+	sessionRepo repositories.SessionRepository,
+	revocationUC *RevocationUseCase,
+	jwtSvc jwt.Service,
+	keycloakService keycloak.Service,
+	auditLogger *AuditLogger,
+	issuer string,
+	useKeycloak bool,
+) *TokenIntrospectionUseCase {
+	return &TokenIntrospectionUseCase{
+		oauthUC:          oauthUC,
+		serviceAccountUC: serviceAccountUC,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		revocationUC:     revocationUC,
+		jwtSvc:           jwtSvc,
+		keycloakService:  keycloakService,
+		auditLogger:      auditLogger,
+		issuer:           strings.TrimRight(issuer, "/"),
+		useKeycloak:      useKeycloak,
+	}
+}
+
+// TokenOperationRequest representa una solicitud a los endpoints de introspección o revocación
+type TokenOperationRequest struct {
+	Token         string
+	TokenTypeHint string // access_token o refresh_token; solo ordena la búsqueda
+	ClientCredentials
+}
+
+// IntrospectionResponse representa la respuesta de introspección (RFC 7662 sección 2.2); un token
+// inactivo solo incluye active
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// oauthCaller representa al cliente autenticado que llama a introspección o revocación
+type oauthCaller struct {
+	clientID       string
+	serviceAccount bool
+	public         bool
+}
+
+// Introspect informa si el token está activo. Solo llaman clientes confidenciales y cuentas de
+// servicio: las cuentas de servicio (servidores de recursos) pueden consultar cualquier token y
+// los clientes OAuth solo los emitidos a ellos. Los errores del protocolo se retornan como *OAuthError.
+func (uc *TokenIntrospectionUseCase) Introspect(ctx context.Context, req *TokenOperationRequest) (*IntrospectionResponse, error) {
+	if req.Token == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "token is required")
+	}
+	if uc.useKeycloak {
+		return uc.introspectWithKeycloak(req)
+	}
+
+	caller, err := uc.authenticate(ctx, req.ClientCredentials)
+	if err != nil {
+		return nil, err
+	}
+	if caller.public {
+		return nil, newOAuthError(OAuthErrUnauthorizedClient, "public clients cannot introspect tokens")
+	}
+
+	response := uc.inspect(ctx, req.Token, req.TokenTypeHint)
+	if !response.Active || (!caller.serviceAccount && response.ClientID != caller.clientID) {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+	return response, nil
+}
+
+// Revoke revoca un access o refresh token emitido al cliente que llama. Los tokens inválidos,
+// ya revocados o de otros clientes se ignoran, como indica RFC 7009 sección 2.2. Revocar un
+// refresh token también cierra su sesión.
+func (uc *TokenIntrospectionUseCase) Revoke(ctx context.Context, req *TokenOperationRequest) error {
+	if req.Token == "" {
+		return newOAuthError(OAuthErrInvalidRequest, "token is required")
+	}
+	if uc.useKeycloak {
+		return uc.revokeWithKeycloak(req)
+	}
+
+	caller, err := uc.authenticate(ctx, req.ClientCredentials)
+	if err != nil {
+		return err
+	}
+
+	if req.TokenTypeHint == TokenTypeHintRefreshToken {
+		if revoked, err := uc.revokeRefreshToken(ctx, caller, req.Token); revoked || err != nil {
+			return err
+		}
+		_, err := uc.revokeAccessToken(ctx, caller, req.Token)
+		return err
+	}
+
+	if revoked, err := uc.revokeAccessToken(ctx, caller, req.Token); revoked || err != nil {
+		return err
+	}
+	_, err = uc.revokeRefreshToken(ctx, caller, req.Token)
+	return err
+}
+
+// authenticate autentica al cliente OAuth o a la cuenta de servicio según sus credenciales
+func (uc *TokenIntrospectionUseCase) authenticate(ctx context.Context, creds ClientCredentials) (*oauthCaller, error) {
+	if creds.ClientAssertion != "" || strings.HasPrefix(creds.ClientID, serviceAccountClientIDPrefix) {
+		account, err := uc.serviceAccountUC.authenticate(ctx, creds)
+		if err != nil {
+			return nil, err
+		}
+		return &oauthCaller{clientID: account.ClientID, serviceAccount: true}, nil
+	}
+
+	client, err := uc.oauthUC.authenticateClient(ctx, creds.ClientID, creds.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &oauthCaller{clientID: client.ClientID, public: client.IsPublic()}, nil
+}
+
+// inspect busca el token como access o refresh token según el hint
+func (uc *TokenIntrospectionUseCase) inspect(ctx context.Context, token, hint string) *IntrospectionResponse {
+	if hint == TokenTypeHintRefreshToken {
+		if response := uc.inspectRefreshToken(ctx, token); response.Active {
+			return response
+		}
+		return uc.inspectAccessToken(ctx, token)
+	}
+
+	if response := uc.inspectAccessToken(ctx, token); response.Active {
+		return response
+	}
+	return uc.inspectRefreshToken(ctx, token)
+}
+
+// inspectAccessToken verifica la firma, la expiración y la lista de revocación del access token
+func (uc *TokenIntrospectionUseCase) inspectAccessToken(ctx context.Context, token string) *IntrospectionResponse {
+	claims, err := uc.jwtSvc.ValidateToken(token)
+	if err != nil {
+		return &IntrospectionResponse{Active: false}
+	}
+	if revoked, err := uc.revocationUC.IsRevoked(ctx, claims); err != nil || revoked {
+		return &IntrospectionResponse{Active: false}
+	}
+
+	response := &IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  claims.Email,
+		TokenType: TokenTypeHintAccessToken,
+		Sub:       claims.Subject,
+		Iss:       uc.issuer,
+		Jti:       claims.ID,
+	}
+	if response.Sub == "" {
+		response.Sub = claims.UserID
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+	return response
+}
+
+// inspectRefreshToken verifica la firma del refresh token y su estado en el TokenRepository
+func (uc *TokenIntrospectionUseCase) inspectRefreshToken(ctx context.Context, token string) *IntrospectionResponse {
+	claims, err := uc.jwtSvc.ValidateRefreshToken(token)
+	if err != nil {
+		return &IntrospectionResponse{Active: false}
+	}
+	stored, err := uc.tokenRepo.GetByToken(ctx, token)
+	if err != nil || !stored.IsValid() {
+		return &IntrospectionResponse{Active: false}
+	}
+
+	response := &IntrospectionResponse{
+		Active:    true,
+		Scope:     stored.Scope,
+		ClientID:  stored.ClientID,
+		TokenType: TokenTypeHintRefreshToken,
+		Exp:       stored.ExpiresAt.Unix(),
+		Sub:       stored.UserID.String(),
+		Iss:       uc.issuer,
+		Jti:       claims.ID,
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+	return response
+}
+
+// revokeAccessToken agrega el jti del access token a la lista de revocación si pertenece al cliente
+func (uc *TokenIntrospectionUseCase) revokeAccessToken(ctx context.Context, caller *oauthCaller, token string) (bool, error) {
+	claims, err := uc.jwtSvc.ValidateToken(token)
+	if err != nil || claims.ClientID != caller.clientID {
+		return false, nil
+	}
+
+	if err := uc.revocationUC.RevokeAccessToken(ctx, claims); err != nil {
+		return false, err
+	}
+
+	uc.auditTokenRevoked(ctx, caller, claims.UserID, TokenTypeHintAccessToken)
+	return true, nil
+}
+
+// revokeRefreshToken revoca el refresh token si pertenece al cliente y cierra su sesión
+func (uc *TokenIntrospectionUseCase) revokeRefreshToken(ctx context.Context, caller *oauthCaller, token string) (bool, error) {
+	if _, err := uc.jwtSvc.ValidateRefreshToken(token); err != nil {
+		return false, nil
+	}
+	stored, err := uc.tokenRepo.GetByToken(ctx, token)
+	if err != nil || stored.ClientID != caller.clientID {
+		return false, nil
+	}
+
+	// Un token ya revocado no es un error: la revocación es idempotente
+	if err := uc.tokenRepo.RevokeToken(ctx, token); err != nil {
+		if errors.Is(err, repositories.ErrTokenNotRevocable) {
+			return true, nil
+		}
+		return false, err
+	}
+	if err := uc.sessionRepo.RevokeByFamilyID(ctx, stored.FamilyID.String()); err != nil {
+		return false, err
+	}
+
+	uc.auditTokenRevoked(ctx, caller, stored.UserID.String(), TokenTypeHintRefreshToken)
+	return true, nil
+}
+
+// auditTokenRevoked registra la revocación de un token por un cliente
+func (uc *TokenIntrospectionUseCase) auditTokenRevoked(ctx context.Context, caller *oauthCaller, userID, tokenType string) {
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionOAuthTokenRevoked,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"client_id": caller.clientID, "token_type": tokenType},
+	})
+}
+
+// introspectWithKeycloak reenvía la introspección a Keycloak con las credenciales del cliente
+func (uc *TokenIntrospectionUseCase) introspectWithKeycloak(req *TokenOperationRequest) (*IntrospectionResponse, error) {
+	claims, err := uc.keycloakService.IntrospectToken(req.Token, req.TokenTypeHint, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, keycloakOAuthError(err)
+	}
+	if !claims.Active {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	// Keycloak informa el tipo en typ y el cliente en azp cuando no incluye client_id
+	tokenType := TokenTypeHintAccessToken
+	if strings.EqualFold(claims.Typ, "Refresh") || strings.EqualFold(claims.Typ, "Offline") {
+		tokenType = TokenTypeHintRefreshToken
+	}
+	clientID := claims.ClientID
+	if clientID == "" {
+		clientID = claims.Azp
+	}
+	username := claims.Username
+	if username == "" {
+		username = claims.PreferredUsername
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  clientID,
+		Username:  username,
+		TokenType: tokenType,
+		Exp:       claims.Exp,
+		Iat:       claims.Iat,
+		Sub:       claims.Sub,
+		Iss:       claims.Iss,
+		Jti:       claims.Jti,
+	}, nil
+}
+
+// revokeWithKeycloak reenvía la revocación a Keycloak con las credenciales del cliente
+func (uc *TokenIntrospectionUseCase) revokeWithKeycloak(req *TokenOperationRequest) error {
+	if err := uc.keycloakService.RevokeToken(req.Token, req.TokenTypeHint, req.ClientID, req.ClientSecret); err != nil {
+		return keycloakOAuthError(err)
+	}
+	return nil
+}
+
+// keycloakOAuthError traduce el rechazo de las credenciales por Keycloak a invalid_client
+func keycloakOAuthError(err error) error {
+	if errors.Is(err, keycloak.ErrClientAuthentication) {
+		return newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+	return err
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// introspectionFixture agrega introspección y revocación sobre el servidor de autorización de prueba
+type introspectionFixture struct {
+	*oauthFixture
+	uc               *TokenIntrospectionUseCase
+	serviceAccountUC *ServiceAccountUseCase
+}
+
+// newIntrospectionFixture crea el caso de uso con el mismo almacenamiento que newOAuthFixture
+func newIntrospectionFixture(t *testing.T) *introspectionFixture {
+	t.Helper()
+	f := &introspectionFixture{oauthFixture: newOAuthFixture(t)}
+	f.serviceAccountUC = newTestServiceAccountUseCase(t, newMemoryServiceAccountRepo())
+	f.uc = NewTokenIntrospectionUseCase(
+		f.oauthFixture.uc,
+		f.serviceAccountUC,
+		&memoryTokenRepo{store: f.store},
+		&memorySessionRepo{store: f.store},
+		NewRevocationUseCase(newMemoryRevocationRepo(), time.Minute),
+		f.oauthFixture.uc.jwtSvc,
+		nil,
+		NewAuditLogger(f.auditRepo),
+		"https://auth.example.com",
+		false,
+	)
+	return f
+}
+
+// issueTokens registra un cliente confidencial y canjea un código por sus tokens
+func (f *introspectionFixture) issueTokens(t *testing.T) (*OAuthClientResponse, *TokenResponse) {
+	t.Helper()
+	client := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID, ScopeProfile})
+	code := f.authorize(t, AuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI, Scope: "openid profile"})
+	tokens, err := f.exchange(client, code, testRedirectURI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, tokens
+}
+
+// introspect consulta el token autenticándose como el cliente
+func (f *introspectionFixture) introspect(t *testing.T, client *OAuthClientResponse, token, hint string) *IntrospectionResponse {
+	t.Helper()
+	response, err := f.uc.Introspect(context.Background(), &TokenOperationRequest{
+		Token:             token,
+		TokenTypeHint:     hint,
+		ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestIntrospectRefreshTokenReflectsRevocation(t *testing.T) {
+	f := newIntrospectionFixture(t)
+	client, tokens := f.issueTokens(t)
+
+	response := f.introspect(t, client, tokens.RefreshToken, TokenTypeHintRefreshToken)
+	if !response.Active || response.TokenType != TokenTypeHintRefreshToken {
+		t.Fatalf("refresh token is not active: %+v", response)
+	}
+	if response.ClientID != client.ClientID || response.Sub != f.user.ID.String() || response.Scope != "openid profile" {
+		t.Errorf("unexpected introspection response: %+v", response)
+	}
+	// Sin hint también se encuentra como refresh token
+	if response := f.introspect(t, client, tokens.RefreshToken, ""); !response.Active {
+		t.Error("refresh token not found without a hint")
+	}
+
+	err := f.uc.Revoke(context.Background(), &TokenOperationRequest{
+		Token:             tokens.RefreshToken,
+		ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response := f.introspect(t, client, tokens.RefreshToken, TokenTypeHintRefreshToken); response.Active || response.ClientID != "" {
+		t.Errorf("revoked refresh token is still active: %+v", response)
+	}
+	familyID := f.store.tokens[tokens.RefreshToken].FamilyID
+	for _, session := range f.store.sessions {
+		if session.FamilyID == familyID && !session.IsRevoked {
+			t.Error("revoking the refresh token did not close its session")
+		}
+	}
+	if !containsAction(f.auditRepo.actions(), entities.AuditActionOAuthTokenRevoked) {
+		t.Error("expected the revocation to be audited")
+	}
+
+	// Revocar de nuevo es idempotente
+	err = f.uc.Revoke(context.Background(), &TokenOperationRequest{
+		Token:             tokens.RefreshToken,
+		TokenTypeHint:     TokenTypeHintRefreshToken,
+		ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+	})
+	if err != nil {
+		t.Errorf("revoking an already revoked token failed: %v", err)
+	}
+}
+
+func TestIntrospectRotatedRefreshTokenIsInactive(t *testing.T) {
+	f := newIntrospectionFixture(t)
+	client, tokens := f.issueTokens(t)
+
+	rotated, err := f.oauthFixture.uc.Token(context.Background(), &TokenRequest{
+		GrantType:         "refresh_token",
+		RefreshToken:      tokens.RefreshToken,
+		ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response := f.introspect(t, client, tokens.RefreshToken, TokenTypeHintRefreshToken); response.Active {
+		t.Error("rotated refresh token is still active")
+	}
+	if response := f.introspect(t, client, rotated.RefreshToken, TokenTypeHintRefreshToken); !response.Active {
+		t.Error("new refresh token is not active")
+	}
+}
+
+func TestIntrospectOnlyShowsTokensToTheirClient(t *testing.T) {
+	f := newIntrospectionFixture(t)
+	client, tokens := f.issueTokens(t)
+	other := f.registerClient(t, entities.OAuthClientConfidential, []string{ScopeOpenID})
+	public := f.registerClient(t, entities.OAuthClientPublic, []string{ScopeOpenID})
+	ctx := context.Background()
+
+	for _, token := range []string{tokens.AccessToken, tokens.RefreshToken} {
+		if response := f.introspect(t, other, token, ""); response.Active {
+			t.Errorf("another client could introspect the token: %+v", response)
+		}
+	}
+
+	_, err := f.uc.Introspect(ctx, &TokenOperationRequest{Token: tokens.AccessToken, ClientCredentials: ClientCredentials{ClientID: public.ClientID}})
+	assertOAuthError(t, err, OAuthErrUnauthorizedClient)
+	_, err = f.uc.Introspect(ctx, &TokenOperationRequest{Token: tokens.AccessToken, ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: "wrong"}})
+	assertOAuthError(t, err, OAuthErrInvalidClient)
+
+	// Las cuentas de servicio, como servidores de recursos, pueden consultar cualquier token
+	account, err := f.serviceAccountUC.Create(ctx, &CreateServiceAccountRequest{Name: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := f.uc.Introspect(ctx, &TokenOperationRequest{
+		Token:             tokens.AccessToken,
+		ClientCredentials: ClientCredentials{ClientID: account.ClientID, ClientSecret: account.ClientSecret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Active || response.TokenType != TokenTypeHintAccessToken || response.ClientID != client.ClientID {
+		t.Errorf("service account introspection: %+v", response)
+	}
+
+	// Revocar un token de otro cliente se ignora
+	if err := f.uc.Revoke(ctx, &TokenOperationRequest{Token: tokens.RefreshToken, ClientCredentials: ClientCredentials{ClientID: other.ClientID, ClientSecret: other.ClientSecret}}); err != nil {
+		t.Fatal(err)
+	}
+	if response := f.introspect(t, client, tokens.RefreshToken, ""); !response.Active {
+		t.Error("another client revoked the refresh token")
+	}
+}
+
+func TestRevokeAccessTokenDeactivatesIt(t *testing.T) {
+	f := newIntrospectionFixture(t)
+	client, tokens := f.issueTokens(t)
+
+	if response := f.introspect(t, client, tokens.AccessToken, ""); !response.Active || response.Scope != "openid profile" {
+		t.Fatalf("access token is not active: %+v", response)
+	}
+
+	err := f.uc.Revoke(context.Background(), &TokenOperationRequest{
+		Token:             tokens.AccessToken,
+		TokenTypeHint:     TokenTypeHintAccessToken,
+		ClientCredentials: ClientCredentials{ClientID: client.ClientID, ClientSecret: client.ClientSecret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response := f.introspect(t, client, tokens.AccessToken, ""); response.Active {
+		t.Error("revoked access token is still active")
+	}
+	// El refresh token no se revoca con el access token
+	if response := f.introspect(t, client, tokens.RefreshToken, ""); !response.Active {
+		t.Error("revoking the access token revoked the refresh token")
+	}
+}
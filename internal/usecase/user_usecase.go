@@ -3,23 +3,41 @@ package usecase
 import (
 	"context"
 	"errors"
+	"log"
 
 	"auth-go-microservicio/internal/domain/entities"
 	"auth-go-microservicio/internal/domain/repositories"
 	"auth-go-microservicio/pkg/password"
+
+	"github.com/google/uuid"
 )
 
 // UserUseCase maneja la lógica de negocio para usuarios
 type UserUseCase struct {
-	userRepo repositories.UserRepository
-	passSvc  password.Service
+	userRepo         repositories.UserRepository
+	revocationUC     *RevocationUseCase
+	passSvc          password.Service
+	passwordPolicyUC *PasswordPolicyUseCase
+	roleUC           *RoleUseCase
+	auditLogger      *AuditLogger
 }
 
 // NewUserUseCase crea una nueva instancia de UserUseCase
-func NewUserUseCase(userRepo repositories.UserRepository, passSvc password.Service) *UserUseCase {
+func NewUserUseCase(
+	userRepo repositories.UserRepository,
+	revocationUC *RevocationUseCase,
+	passSvc password.Service,
+	passwordPolicyUC *PasswordPolicyUseCase,
+	roleUC *RoleUseCase,
+	auditLogger *AuditLogger,
+) *UserUseCase {
 	return &UserUseCase{
-		userRepo: userRepo,
-		passSvc:  passSvc,
+		userRepo:         userRepo,
+		revocationUC:     revocationUC,
+		passSvc:          passSvc,
+		passwordPolicyUC: passwordPolicyUC,
+		roleUC:           roleUC,
+		auditLogger:      auditLogger,
 	}
 }
 
@@ -72,7 +90,7 @@ func (uc *UserUseCase) UpdateProfile(ctx context.Context, req *UpdateProfileRequ
 type ChangePasswordRequest struct {
 	UserID          string `json:"-"`
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
 // ChangePassword cambia la contraseña de un usuario
@@ -84,9 +102,19 @@ func (uc *UserUseCase) ChangePassword(ctx context.Context, req *ChangePasswordRe
 
 	// Verificar contraseña actual
 	if !uc.passSvc.Verify(req.CurrentPassword, user.Password) {
+		uc.auditLogger.Log(ctx, AuditEntry{
+			Action:   entities.AuditActionPasswordChanged,
+			Outcome:  entities.AuditOutcomeFailure,
+			TargetID: req.UserID,
+			Metadata: map[string]interface{}{"reason": "invalid_current_password"},
+		})
 		return errors.New("current password is incorrect")
 	}
 
+	if err := uc.passwordPolicyUC.Validate(ctx, user, req.NewPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
 	// Hash de la nueva contraseña
 	hashedPassword, err := uc.passSvc.Hash(req.NewPassword)
 	if err != nil {
@@ -95,7 +123,20 @@ func (uc *UserUseCase) ChangePassword(ctx context.Context, req *ChangePasswordRe
 
 	// Actualizar contraseña
 	user.Password = hashedPassword
-	return uc.userRepo.Update(ctx, user)
+	user.RecordEvent(entities.EventPasswordChanged, map[string]interface{}{"reason": "change"})
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := uc.passwordPolicyUC.Record(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Error recording password history for user %s: %v", user.ID, err)
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionPasswordChanged,
+		TargetID: req.UserID,
+	})
+
+	// Invalidar los access tokens emitidos con la contraseña anterior
+	return uc.revocationUC.RevokeAllForUser(ctx, user.ID.String())
 }
 
 // DeleteAccountRequest representa la solicitud para eliminar cuenta
@@ -110,13 +151,27 @@ func (uc *UserUseCase) DeleteAccount(ctx context.Context, req *DeleteAccountRequ
 		return errors.New("user not found")
 	}
 
-	return uc.userRepo.Delete(ctx, user.ID.String())
+	user.RecordEvent(entities.EventUserDeleted, map[string]interface{}{
+		"email":  user.Email,
+		"reason": "account_deleted",
+	})
+	if err := uc.userRepo.Delete(ctx, user); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionAccountDeleted,
+		TargetID: req.UserID,
+		Metadata: map[string]interface{}{"email": user.Email},
+	})
+	return nil
 }
 
 // ListUsersRequest representa la solicitud para listar usuarios
 type ListUsersRequest struct {
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	OrganizationID string `json:"organization_id"` // opcional; solo los miembros de la organización
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
 }
 
 // ListUsersResponse representa la respuesta para listar usuarios
@@ -127,12 +182,23 @@ type ListUsersResponse struct {
 
 // ListUsers lista usuarios (solo para administradores)
 func (uc *UserUseCase) ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error) {
-	users, err := uc.userRepo.List(ctx, req.Offset, req.Limit)
+	if req.OrganizationID != "" {
+		if _, err := uuid.Parse(req.OrganizationID); err != nil {
+			return nil, errors.New("invalid organization id")
+		}
+	}
+
+	filter := &repositories.UserFilter{
+		OrganizationID: req.OrganizationID,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+	}
+	users, err := uc.userRepo.List(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := uc.userRepo.Count(ctx)
+	total, err := uc.userRepo.Count(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +216,7 @@ type UpdateUserRequest struct {
 	LastName  string `json:"last_name"`
 	Role      string `json:"role"`
 	IsActive  *bool  `json:"is_active"`
+	Password  string `json:"password"` // opcional; asigna una contraseña nueva validada con la política
 }
 
 // UpdateUser actualiza un usuario (solo para administradores)
@@ -159,6 +226,11 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, req *UpdateUserRequest) (
 		return nil, errors.New("user not found")
 	}
 
+	// Los access tokens llevan el rol y se validan sin consultar la base de datos,
+	// así que una desactivación o cambio de rol debe invalidar los ya emitidos
+	previousRole := user.Role
+	wasActive := user.IsActive
+
 	// Actualizar campos
 	if req.FirstName != "" {
 		user.FirstName = req.FirstName
@@ -167,17 +239,50 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, req *UpdateUserRequest) (
 		user.LastName = req.LastName
 	}
 	if req.Role != "" {
+		if err := uc.roleUC.ValidateRole(ctx, req.Role); err != nil {
+			return nil, err
+		}
 		user.Role = entities.Role(req.Role)
 	}
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
 
+	var hashedPassword string
+	if req.Password != "" {
+		if err := uc.passwordPolicyUC.Validate(ctx, user, req.Password, user.Email, user.FirstName, user.LastName); err != nil {
+			return nil, err
+		}
+		if hashedPassword, err = uc.passSvc.Hash(req.Password); err != nil {
+			return nil, err
+		}
+		user.Password = hashedPassword
+	}
+
+	recordUserUpdateEvents(user, previousRole, wasActive, hashedPassword != "")
+
 	// Guardar cambios
 	if err := uc.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
+	if hashedPassword != "" {
+		if err := uc.passwordPolicyUC.Record(ctx, user.ID, hashedPassword); err != nil {
+			log.Printf("Error recording password history for user %s: %v", user.ID, err)
+		}
+	}
+
+	if user.Role != previousRole {
+		uc.roleUC.InvalidateUser(user.ID.String())
+	}
+	uc.auditUserUpdate(ctx, user, req, previousRole, wasActive, hashedPassword != "")
+
+	if (wasActive && !user.IsActive) || user.Role != previousRole || hashedPassword != "" {
+		if err := uc.revocationUC.RevokeAllForUser(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+
 	return user, nil
 }
 
@@ -188,5 +293,94 @@ type DeleteUserRequest struct {
 
 // DeleteUser elimina un usuario (solo para administradores)
 func (uc *UserUseCase) DeleteUser(ctx context.Context, req *DeleteUserRequest) error {
-	return uc.userRepo.Delete(ctx, req.UserID)
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	user.RecordEvent(entities.EventUserDeleted, map[string]interface{}{
+		"email":  user.Email,
+		"reason": "deleted_by_admin",
+	})
+	if err := uc.userRepo.Delete(ctx, user); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionUserDeleted,
+		TargetID: req.UserID,
+	})
+	return nil
+}
+
+// UnlockUserRequest representa la solicitud para desbloquear un usuario (admin)
+type UnlockUserRequest struct {
+	UserID string `json:"-"`
+}
+
+// UnlockUser reinicia los intentos fallidos y quita el bloqueo temporal de la cuenta
+func (uc *UserUseCase) UnlockUser(ctx context.Context, req *UnlockUserRequest) error {
+	if err := uc.userRepo.ResetFailedLogins(ctx, req.UserID); err != nil {
+		return err
+	}
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionAccountUnlocked,
+		TargetID: req.UserID,
+	})
+	return nil
+}
+
+// auditUserUpdate registra los cambios que un administrador hizo sobre el usuario; la contraseña
+// solo se registra como modificada
+func (uc *UserUseCase) auditUserUpdate(ctx context.Context, user *entities.User, req *UpdateUserRequest, previousRole entities.Role, wasActive, passwordChanged bool) {
+	changes := map[string]interface{}{}
+	if req.FirstName != "" {
+		changes["first_name"] = req.FirstName
+	}
+	if req.LastName != "" {
+		changes["last_name"] = req.LastName
+	}
+	if user.Role != previousRole {
+		changes["role"] = map[string]interface{}{"from": previousRole, "to": user.Role}
+	}
+	if user.IsActive != wasActive {
+		changes["is_active"] = map[string]interface{}{"from": wasActive, "to": user.IsActive}
+	}
+	if passwordChanged {
+		changes["password"] = true
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionUserUpdated,
+		TargetID: user.ID.String(),
+		Metadata: changes,
+	})
+}
+
+// recordUserUpdateEvents registra los eventos de dominio de los cambios que un administrador hizo
+// sobre el usuario; se guardan en el outbox junto con la actualización
+func recordUserUpdateEvents(user *entities.User, previousRole entities.Role, wasActive, passwordChanged bool) {
+	if user.Role != previousRole {
+		user.RecordEvent(entities.EventRoleChanged, map[string]interface{}{
+			"from": previousRole,
+			"to":   user.Role,
+		})
+	}
+	if wasActive && !user.IsActive {
+		user.RecordEvent(entities.EventUserDeactivated, map[string]interface{}{"email": user.Email})
+	}
+	if passwordChanged {
+		user.RecordEvent(entities.EventPasswordChanged, map[string]interface{}{"reason": "admin"})
+	}
+}
+
+// userRegisteredPayload arma el payload del evento de alta de un usuario
+func userRegisteredPayload(user *entities.User, method string) map[string]interface{} {
+	return map[string]interface{}{
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"role":       user.Role,
+		"method":     method,
+	}
 }
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/jwt"
+	"auth-go-microservicio/pkg/mailer"
+)
+
+// VerificationUseCase maneja la verificación de email de las cuentas locales
+type VerificationUseCase struct {
+	userRepo         repositories.UserRepository
+	oneTimeTokenRepo repositories.OneTimeTokenRepository
+	jwtSvc           jwt.Service
+	mailer           mailer.Mailer
+	frontendURL      string
+	tokenExpiration  time.Duration
+}
+
+// NewVerificationUseCase crea una nueva instancia de VerificationUseCase
+func NewVerificationUseCase(
+	userRepo repositories.UserRepository,
+	oneTimeTokenRepo repositories.OneTimeTokenRepository,
+	jwtSvc jwt.Service,
+	mailer mailer.Mailer,
+	frontendURL string,
+	tokenExpiration time.Duration,
+) *VerificationUseCase {
+	return &VerificationUseCase{
+		userRepo:         userRepo,
+		oneTimeTokenRepo: oneTimeTokenRepo,
+		jwtSvc:           jwtSvc,
+		mailer:           mailer,
+		frontendURL:      frontendURL,
+		tokenExpiration:  tokenExpiration,
+	}
+}
+
+// SendVerification genera un token de verificación y lo envía al email del usuario.
+// Los tokens pendientes anteriores quedan invalidados.
+func (uc *VerificationUseCase) SendVerification(ctx context.Context, user *entities.User) error {
+	purpose := entities.TokenPurposeEmailVerification
+
+	token, err := uc.jwtSvc.GenerateActionToken(user.ID.String(), string(purpose), uc.tokenExpiration)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.oneTimeTokenRepo.InvalidateByUserID(ctx, user.ID.String(), purpose); err != nil {
+		return err
+	}
+
+	oneTimeToken := entities.NewOneTimeToken(user.ID, purpose, hashToken(token), time.Now().Add(uc.tokenExpiration))
+	if err := uc.oneTimeTokenRepo.Create(ctx, oneTimeToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", uc.frontendURL, url.QueryEscape(token))
+	return uc.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Verificá tu dirección de email",
+		Body: fmt.Sprintf(
+			"Hola %s,\n\nPara activar tu cuenta verificá tu email ingresando al siguiente enlace:\n\n%s\n\nEl enlace vence en %s. Si no creaste esta cuenta podés ignorar este mensaje.\n",
+			user.FirstName, link, uc.tokenExpiration,
+		),
+	})
+}
+
+// VerifyEmailRequest representa la solicitud de verificación de email
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail marca el email como verificado usando un token de un solo uso
+func (uc *VerificationUseCase) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*entities.User, error) {
+	purpose := entities.TokenPurposeEmailVerification
+
+	// La firma se valida antes de consultar la base de datos
+	claims, err := uc.jwtSvc.ValidateActionToken(req.Token, string(purpose))
+	if err != nil {
+		return nil, errors.New("invalid or expired verification token")
+	}
+
+	oneTimeToken, err := uc.oneTimeTokenRepo.Consume(ctx, hashToken(req.Token), purpose)
+	if err != nil || oneTimeToken.UserID.String() != claims.UserID {
+		return nil, errors.New("invalid or expired verification token")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.EmailVerified {
+		user.MarkEmailVerified()
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// ResendVerificationRequest representa la solicitud de reenvío del email de verificación
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResendVerification reenvía el email de verificación. No informa si la cuenta existe
+// para evitar la enumeración de usuarios.
+func (uc *VerificationUseCase) ResendVerification(ctx context.Context, req *ResendVerificationRequest) error {
+	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || user.EmailVerified || !user.IsActive {
+		return nil
+	}
+
+	return uc.SendVerification(ctx, user)
+}
+
+// hashToken calcula el hash que se almacena en lugar del token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
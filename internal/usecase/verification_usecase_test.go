@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+)
+
+// newTestVerificationUseCase crea un VerificationUseCase con tokens de una hora
+func newTestVerificationUseCase(t *testing.T, store *memoryStore, tokenRepo *memoryOneTimeTokenRepo, mail *capturingMailer, expiration time.Duration) *VerificationUseCase {
+	t.Helper()
+	return NewVerificationUseCase(&memoryUserRepo{store: store}, tokenRepo, newTestJWTService(t), mail, "https://app.example.com", expiration)
+}
+
+// sendVerification envía el email de verificación y retorna el token del enlace
+func sendVerification(t *testing.T, uc *VerificationUseCase, mail *capturingMailer, user *entities.User) string {
+	t.Helper()
+	if err := uc.SendVerification(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	messages := mail.sent()
+	return linkToken(t, messages[len(messages)-1])
+}
+
+func TestSendVerificationEmailsLink(t *testing.T) {
+	store := newMemoryStore()
+	tokenRepo := newMemoryOneTimeTokenRepo()
+	mail := &capturingMailer{}
+	uc := newTestVerificationUseCase(t, store, tokenRepo, mail, time.Hour)
+	user := newTestUser(store)
+
+	token := sendVerification(t, uc, mail, user)
+
+	messages := mail.sent()
+	if len(messages) != 1 || messages[0].To != user.Email {
+		t.Fatalf("expected one message to %s, got %+v", user.Email, messages)
+	}
+	if !strings.Contains(messages[0].Body, "https://app.example.com/verify-email?token=") {
+		t.Errorf("message does not link to the frontend: %q", messages[0].Body)
+	}
+	if _, ok := tokenRepo.tokens[hashToken(token)]; !ok || len(tokenRepo.tokens) != 1 {
+		t.Error("token hash was not stored")
+	}
+	if _, ok := tokenRepo.tokens[token]; ok {
+		t.Error("token was stored in plain text")
+	}
+}
+
+func TestVerifyEmailMarksUserVerified(t *testing.T) {
+	store := newMemoryStore()
+	mail := &capturingMailer{}
+	uc := newTestVerificationUseCase(t, store, newMemoryOneTimeTokenRepo(), mail, time.Hour)
+	user := newTestUser(store)
+	token := sendVerification(t, uc, mail, user)
+
+	verified, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.ID != user.ID {
+		t.Errorf("verified user %s, expected %s", verified.ID, user.ID)
+	}
+	stored := store.users[user.ID]
+	if !stored.EmailVerified || stored.EmailVerifiedAt == nil {
+		t.Error("email was not marked verified")
+	}
+}
+
+func TestVerifyEmailRejectsReusedToken(t *testing.T) {
+	store := newMemoryStore()
+	mail := &capturingMailer{}
+	uc := newTestVerificationUseCase(t, store, newMemoryOneTimeTokenRepo(), mail, time.Hour)
+	token := sendVerification(t, uc, mail, newTestUser(store))
+
+	if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: token}); err == nil {
+		t.Fatal("expected the reused token to be rejected")
+	}
+}
+
+func TestVerifyEmailRejectsExpiredToken(t *testing.T) {
+	t.Run("stored token expired", func(t *testing.T) {
+		store := newMemoryStore()
+		tokenRepo := newMemoryOneTimeTokenRepo()
+		mail := &capturingMailer{}
+		uc := newTestVerificationUseCase(t, store, tokenRepo, mail, time.Hour)
+		user := newTestUser(store)
+		token := sendVerification(t, uc, mail, user)
+		tokenRepo.expireAll()
+
+		if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: token}); err == nil {
+			t.Fatal("expected the expired token to be rejected")
+		}
+		if store.users[user.ID].EmailVerified {
+			t.Error("email was verified with an expired token")
+		}
+	})
+
+	t.Run("signed token expired", func(t *testing.T) {
+		store := newMemoryStore()
+		mail := &capturingMailer{}
+		uc := newTestVerificationUseCase(t, store, newMemoryOneTimeTokenRepo(), mail, -time.Minute)
+		user := newTestUser(store)
+		token := sendVerification(t, uc, mail, user)
+
+		if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: token}); err == nil {
+			t.Fatal("expected the expired token to be rejected")
+		}
+		if store.users[user.ID].EmailVerified {
+			t.Error("email was verified with an expired token")
+		}
+	})
+}
+
+func TestSendVerificationInvalidatesPreviousToken(t *testing.T) {
+	store := newMemoryStore()
+	mail := &capturingMailer{}
+	uc := newTestVerificationUseCase(t, store, newMemoryOneTimeTokenRepo(), mail, time.Hour)
+	user := newTestUser(store)
+	first := sendVerification(t, uc, mail, user)
+	second := sendVerification(t, uc, mail, user)
+
+	if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: first}); err == nil {
+		t.Fatal("expected the previous token to be rejected")
+	}
+	if _, err := uc.VerifyEmail(context.Background(), &VerifyEmailRequest{Token: second}); err != nil {
+		t.Fatalf("latest token was rejected: %v", err)
+	}
+}
+
+func TestResendVerificationDoesNotRevealAccounts(t *testing.T) {
+	store := newMemoryStore()
+	mail := &capturingMailer{}
+	uc := newTestVerificationUseCase(t, store, newMemoryOneTimeTokenRepo(), mail, time.Hour)
+	verified := newTestUser(store)
+	verified.MarkEmailVerified()
+	store.users[verified.ID] = *verified
+
+	for _, email := range []string{"nadie@example.com", verified.Email} {
+		if err := uc.ResendVerification(context.Background(), &ResendVerificationRequest{Email: email}); err != nil {
+			t.Errorf("resend to %s returned %v", email, err)
+		}
+	}
+	if len(mail.sent()) != 0 {
+		t.Errorf("expected no messages, got %d", len(mail.sent()))
+	}
+}
@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnUseCase maneja el registro y la verificación de credenciales WebAuthn
+type WebAuthnUseCase struct {
+	webauthnRepo        repositories.WebAuthnRepository
+	userRepo            repositories.UserRepository
+	relyingParty        *webauthn.RelyingParty
+	auditLogger         *AuditLogger
+	challengeExpiration time.Duration
+}
+
+// NewWebAuthnUseCase crea una nueva instancia de WebAuthnUseCase
+func NewWebAuthnUseCase(
+	webauthnRepo repositories.WebAuthnRepository,
+	userRepo repositories.UserRepository,
+	relyingParty *webauthn.RelyingParty,
+	auditLogger *AuditLogger,
+	challengeExpiration time.Duration,
+) *WebAuthnUseCase {
+	return &WebAuthnUseCase{
+		webauthnRepo:        webauthnRepo,
+		userRepo:            userRepo,
+		relyingParty:        relyingParty,
+		auditLogger:         auditLogger,
+		challengeExpiration: challengeExpiration,
+	}
+}
+
+// BeginRegistrationRequest representa el inicio del registro de una credencial
+type BeginRegistrationRequest struct {
+	UserID string `json:"-"`
+}
+
+// BeginRegistration genera las opciones para navigator.credentials.create()
+func (uc *WebAuthnUseCase) BeginRegistration(ctx context.Context, req *BeginRegistrationRequest) (*webauthn.CreationOptions, error) {
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	credentials, err := uc.webauthnRepo.ListCredentialsByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := uc.createChallenge(ctx, &user.ID, entities.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	webauthnUser := webauthn.User{
+		ID:          user.ID[:],
+		Name:        user.Email,
+		DisplayName: user.FullName(),
+	}
+
+	return uc.relyingParty.BeginRegistration(webauthnUser, challenge, credentialDescriptors(credentials), false), nil
+}
+
+// FinishRegistrationRequest representa la respuesta del navegador al registro
+type FinishRegistrationRequest struct {
+	UserID     string                       `json:"-"`
+	Name       string                       `json:"name" binding:"omitempty,max=255"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+// FinishRegistration verifica la respuesta del autenticador y guarda la credencial
+func (uc *WebAuthnUseCase) FinishRegistration(ctx context.Context, req *FinishRegistrationRequest) (*entities.WebAuthnCredential, error) {
+	challenge, err := uc.consumeChallenge(ctx, req.Credential.Response.ClientDataJSON, entities.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.record.UserID == nil || challenge.record.UserID.String() != req.UserID {
+		return nil, errors.New("invalid or expired webauthn challenge")
+	}
+
+	verified, err := uc.relyingParty.FinishRegistration(challenge.value, &req.Credential, false)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(verified.ID)
+	if _, err := uc.webauthnRepo.GetCredential(ctx, credentialID); err == nil {
+		return nil, errors.New("credential already registered")
+	}
+
+	credential := entities.NewWebAuthnCredential(*challenge.record.UserID, credentialID, verified.PublicKey, verified.SignCount, req.Name)
+	credential.Transports = verified.Transports
+	credential.BackupEligible = verified.BackupEligible
+	credential.BackupState = verified.BackupState
+	if aaguid, err := uuid.FromBytes(verified.AAGUID); err == nil {
+		credential.AAGUID = aaguid
+	}
+
+	if err := uc.webauthnRepo.CreateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebAuthnRegistered,
+		TargetID: req.UserID,
+		Metadata: map[string]interface{}{"credential_id": credential.ID.String()},
+	})
+
+	return credential, nil
+}
+
+// ListCredentials lista las credenciales de un usuario
+func (uc *WebAuthnUseCase) ListCredentials(ctx context.Context, userID string) ([]*entities.WebAuthnCredential, error) {
+	return uc.webauthnRepo.ListCredentialsByUserID(ctx, userID)
+}
+
+// DeleteCredential elimina una credencial del usuario
+func (uc *WebAuthnUseCase) DeleteCredential(ctx context.Context, userID, id string) error {
+	if err := uc.webauthnRepo.DeleteCredential(ctx, userID, id); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebAuthnDeleted,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"credential_id": id},
+	})
+	return nil
+}
+
+// BeginLogin genera las opciones para navigator.credentials.get(). Sin userID se piden
+// credenciales descubribles; como credencial primaria se exige verificación del usuario.
+func (uc *WebAuthnUseCase) BeginLogin(ctx context.Context, userID *uuid.UUID, ceremony entities.WebAuthnCeremony) (*webauthn.RequestOptions, error) {
+	var allow []webauthn.CredentialDescriptor
+	if userID != nil {
+		credentials, err := uc.webauthnRepo.ListCredentialsByUserID(ctx, userID.String())
+		if err != nil {
+			return nil, err
+		}
+		if ceremony == entities.WebAuthnCeremonyMFA && len(credentials) == 0 {
+			return nil, errors.New("no webauthn credentials registered")
+		}
+		allow = credentialDescriptors(credentials)
+	}
+
+	challenge, err := uc.createChallenge(ctx, userID, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.relyingParty.BeginLogin(challenge, allow, ceremony == entities.WebAuthnCeremonyLogin), nil
+}
+
+// FinishLogin verifica la aserción del autenticador y retorna el usuario dueño de la credencial
+func (uc *WebAuthnUseCase) FinishLogin(ctx context.Context, ceremony entities.WebAuthnCeremony, response *webauthn.AssertionResponse) (*entities.User, error) {
+	challenge, err := uc.consumeChallenge(ctx, response.Response.ClientDataJSON, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := uc.webauthnRepo.GetCredential(ctx, response.RawID.String())
+	if err != nil {
+		return nil, errors.New("invalid webauthn credential")
+	}
+
+	// El desafío emitido para un usuario solo puede responderse con sus credenciales
+	if challenge.record.UserID != nil && *challenge.record.UserID != credential.UserID {
+		return nil, errors.New("invalid webauthn credential")
+	}
+	if len(response.Response.UserHandle) > 0 && string(response.Response.UserHandle) != string(credential.UserID[:]) {
+		return nil, errors.New("invalid webauthn credential")
+	}
+
+	assertion, err := uc.relyingParty.FinishLogin(challenge.value, response, credential.PublicKey, credential.SignCount, ceremony == entities.WebAuthnCeremonyLogin)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCountRegression) {
+			uc.auditLogger.Log(ctx, AuditEntry{
+				Action:   entities.AuditActionWebAuthnSignCountDrop,
+				Outcome:  entities.AuditOutcomeFailure,
+				TargetID: credential.UserID.String(),
+				Metadata: map[string]interface{}{"credential_id": credential.ID.String()},
+			})
+		}
+		return nil, errors.New("invalid webauthn assertion")
+	}
+
+	credential.MarkUsed(assertion.SignCount, assertion.BackupState)
+	if err := uc.webauthnRepo.UpdateCredentialUsage(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, credential.UserID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return user, nil
+}
+
+// HasCredentials indica si el usuario registró alguna credencial
+func (uc *WebAuthnUseCase) HasCredentials(ctx context.Context, userID string) (bool, error) {
+	credentials, err := uc.webauthnRepo.ListCredentialsByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
+
+// CleanupChallenges elimina los desafíos expirados
+func (uc *WebAuthnUseCase) CleanupChallenges(ctx context.Context) error {
+	return uc.webauthnRepo.DeleteExpiredChallenges(ctx)
+}
+
+// pendingChallenge es un desafío consumido junto con su valor binario
+type pendingChallenge struct {
+	value  []byte
+	record *entities.WebAuthnChallenge
+}
+
+// createChallenge genera y guarda un desafío para la ceremonia
+func (uc *WebAuthnUseCase) createChallenge(ctx context.Context, userID *uuid.UUID, ceremony entities.WebAuthnCeremony) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	record := entities.NewWebAuthnChallenge(
+		base64.RawURLEncoding.EncodeToString(challenge),
+		userID,
+		ceremony,
+		time.Now().Add(uc.challengeExpiration),
+	)
+	if err := uc.webauthnRepo.CreateChallenge(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge localiza por su valor el desafío al que responde el navegador y lo consume
+func (uc *WebAuthnUseCase) consumeChallenge(ctx context.Context, clientDataJSON []byte, ceremony entities.WebAuthnCeremony) (*pendingChallenge, error) {
+	value, err := webauthn.ChallengeFromClientData(clientDataJSON)
+	if err != nil {
+		return nil, errors.New("invalid or expired webauthn challenge")
+	}
+
+	record, err := uc.webauthnRepo.ConsumeChallenge(ctx, base64.RawURLEncoding.EncodeToString(value), ceremony)
+	if err != nil {
+		return nil, errors.New("invalid or expired webauthn challenge")
+	}
+
+	return &pendingChallenge{value: value, record: record}, nil
+}
+
+// credentialDescriptors convierte las credenciales registradas en descriptores para las opciones
+func credentialDescriptors(credentials []*entities.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		id, err := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+		if err != nil {
+			continue
+		}
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         id,
+			Transports: credential.Transports,
+		})
+	}
+	return descriptors
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/webauthn"
+	"auth-go-microservicio/pkg/webauthn/webauthntest"
+)
+
+const testWebAuthnOrigin = "https://app.example.com"
+
+// newTestWebAuthnUseCase crea un WebAuthnUseCase para el RP example.com
+func newTestWebAuthnUseCase(t *testing.T, store *memoryStore, webauthnRepo *memoryWebAuthnRepo, auditRepo *memoryAuditRepo) *WebAuthnUseCase {
+	t.Helper()
+	relyingParty, err := webauthn.New(webauthn.Config{RPID: "example.com", Origins: []string{testWebAuthnOrigin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWebAuthnUseCase(webauthnRepo, &memoryUserRepo{store: store}, relyingParty, NewAuditLogger(auditRepo), time.Minute)
+}
+
+// registerPasskey completa la ceremonia de registro con el autenticador
+func registerPasskey(t *testing.T, uc *WebAuthnUseCase, authenticator *webauthntest.Authenticator, user *entities.User) *entities.WebAuthnCredential {
+	t.Helper()
+	ctx := context.Background()
+
+	options, err := uc.BeginRegistration(ctx, &BeginRegistrationRequest{UserID: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := authenticator.Register(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := uc.FinishRegistration(ctx, &FinishRegistrationRequest{
+		UserID:     user.ID.String(),
+		Name:       "laptop",
+		Credential: *response,
+	})
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	return credential
+}
+
+// signInWithPasskey ejecuta la ceremonia de login con una credencial descubrible
+func signInWithPasskey(t *testing.T, uc *WebAuthnUseCase, authenticator *webauthntest.Authenticator) *webauthn.AssertionResponse {
+	t.Helper()
+	options, err := uc.BeginLogin(context.Background(), nil, entities.WebAuthnCeremonyLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := authenticator.Login(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestWebAuthnRegistrationStoresCredential(t *testing.T) {
+	store := newMemoryStore()
+	webauthnRepo := newMemoryWebAuthnRepo()
+	auditRepo := &memoryAuditRepo{}
+	uc := newTestWebAuthnUseCase(t, store, webauthnRepo, auditRepo)
+	user := newTestUser(store)
+
+	credential := registerPasskey(t, uc, webauthntest.NewAuthenticator(testWebAuthnOrigin), user)
+
+	stored, err := webauthnRepo.GetCredential(context.Background(), credential.CredentialID)
+	if err != nil {
+		t.Fatal("credential was not stored")
+	}
+	if stored.UserID != user.ID || len(stored.PublicKey) == 0 {
+		t.Errorf("unexpected credential: user_id=%s public_key=%d bytes", stored.UserID, len(stored.PublicKey))
+	}
+	if !stored.BackupEligible || len(stored.Transports) == 0 {
+		t.Errorf("authenticator flags were not stored: backup_eligible=%v transports=%v", stored.BackupEligible, stored.Transports)
+	}
+	if len(webauthnRepo.challenges) != 0 {
+		t.Error("registration challenge was not consumed")
+	}
+	if !containsAction(auditRepo.actions(), entities.AuditActionWebAuthnRegistered) {
+		t.Error("registration was not audited")
+	}
+}
+
+func TestWebAuthnRegistrationRejectsChallengeOfAnotherUser(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), &memoryAuditRepo{})
+	user := newTestUser(store)
+	other := entities.NewUserWithRole("luis@example.com", "hash", "Luis", "Gómez", entities.RoleUser)
+	store.users[other.ID] = *other
+
+	options, err := uc.BeginRegistration(context.Background(), &BeginRegistrationRequest{UserID: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := webauthntest.NewAuthenticator(testWebAuthnOrigin).Register(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uc.FinishRegistration(context.Background(), &FinishRegistrationRequest{
+		UserID:     other.ID.String(),
+		Credential: *response,
+	}); err == nil {
+		t.Fatal("expected the challenge of another user to be rejected")
+	}
+}
+
+func TestWebAuthnRegistrationExcludesRegisteredCredentials(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), &memoryAuditRepo{})
+	user := newTestUser(store)
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	registerPasskey(t, uc, authenticator, user)
+
+	options, err := uc.BeginRegistration(context.Background(), &BeginRegistrationRequest{UserID: user.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options.ExcludeCredentials) != 1 {
+		t.Fatalf("expected the registered credential to be excluded, got %d", len(options.ExcludeCredentials))
+	}
+	if _, err := authenticator.Register(options); err == nil {
+		t.Error("authenticator registered the same credential twice")
+	}
+}
+
+func TestWebAuthnLoginReturnsCredentialOwner(t *testing.T) {
+	store := newMemoryStore()
+	webauthnRepo := newMemoryWebAuthnRepo()
+	uc := newTestWebAuthnUseCase(t, store, webauthnRepo, &memoryAuditRepo{})
+	user := newTestUser(store)
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	credential := registerPasskey(t, uc, authenticator, user)
+
+	for i := 1; i <= 2; i++ {
+		response := signInWithPasskey(t, uc, authenticator)
+		owner, err := uc.FinishLogin(context.Background(), entities.WebAuthnCeremonyLogin, response)
+		if err != nil {
+			t.Fatalf("login %d failed: %v", i, err)
+		}
+		if owner.ID != user.ID {
+			t.Fatalf("login %d returned user %s, expected %s", i, owner.ID, user.ID)
+		}
+	}
+
+	stored, _ := webauthnRepo.GetCredential(context.Background(), credential.CredentialID)
+	if stored.SignCount != 2 || stored.LastUsedAt == nil {
+		t.Errorf("credential usage was not recorded: sign_count=%d last_used_at=%v", stored.SignCount, stored.LastUsedAt)
+	}
+}
+
+func TestWebAuthnLoginRejectsReplayedAssertion(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), &memoryAuditRepo{})
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	registerPasskey(t, uc, authenticator, newTestUser(store))
+
+	response := signInWithPasskey(t, uc, authenticator)
+	if _, err := uc.FinishLogin(context.Background(), entities.WebAuthnCeremonyLogin, response); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.FinishLogin(context.Background(), entities.WebAuthnCeremonyLogin, response); err == nil {
+		t.Fatal("expected the replayed assertion to be rejected")
+	}
+}
+
+func TestWebAuthnLoginRequiresUserVerification(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), &memoryAuditRepo{})
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	registerPasskey(t, uc, authenticator, newTestUser(store))
+
+	authenticator.UserVerification = false
+	response := signInWithPasskey(t, uc, authenticator)
+	if _, err := uc.FinishLogin(context.Background(), entities.WebAuthnCeremonyLogin, response); err == nil {
+		t.Fatal("expected a passkey login without user verification to be rejected")
+	}
+}
+
+func TestWebAuthnLoginRejectsForeignOrigin(t *testing.T) {
+	store := newMemoryStore()
+	uc := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), &memoryAuditRepo{})
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	registerPasskey(t, uc, authenticator, newTestUser(store))
+
+	authenticator.Origin = "https://phishing.example.net"
+	response := signInWithPasskey(t, uc, authenticator)
+	if _, err := uc.FinishLogin(context.Background(), entities.WebAuthnCeremonyLogin, response); err == nil {
+		t.Fatal("expected an assertion from another origin to be rejected")
+	}
+}
+
+func TestFinishWebAuthnLoginRejectsLockedAccount(t *testing.T) {
+	store := newMemoryStore()
+	auditRepo := &memoryAuditRepo{}
+	webauthnUC := newTestWebAuthnUseCase(t, store, newMemoryWebAuthnRepo(), auditRepo)
+	authUC := newTestAuthUseCase(t, store, auditRepo)
+	authUC.webauthnUC = webauthnUC
+
+	user := newTestUser(store)
+	authenticator := webauthntest.NewAuthenticator(testWebAuthnOrigin)
+	registerPasskey(t, webauthnUC, authenticator, user)
+
+	lockedUntil := time.Now().Add(time.Hour)
+	locked := store.users[user.ID]
+	locked.LockedUntil = &lockedUntil
+	store.users[user.ID] = locked
+
+	_, err := authUC.FinishWebAuthnLogin(context.Background(), &FinishWebAuthnLoginRequest{
+		Credential: *signInWithPasskey(t, webauthnUC, authenticator),
+	})
+	if !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("expected the account lock error, got %v", err)
+	}
+	if len(store.tokens) != 0 || len(store.sessions) != 0 {
+		t.Errorf("tokens were issued to a locked account: %d tokens, %d sessions", len(store.tokens), len(store.sessions))
+	}
+
+	// Al vencer el bloqueo la passkey vuelve a servir
+	locked.LockedUntil = nil
+	store.users[user.ID] = locked
+	response, err := authUC.FinishWebAuthnLogin(context.Background(), &FinishWebAuthnLoginRequest{
+		Credential: *signInWithPasskey(t, webauthnUC, authenticator),
+	})
+	if err != nil {
+		t.Fatalf("login after the lock expired failed: %v", err)
+	}
+	if response.AccessToken == "" {
+		t.Error("no access token was issued")
+	}
+}
@@ -0,0 +1,506 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/internal/domain/repositories"
+	"auth-go-microservicio/pkg/encryption"
+	"auth-go-microservicio/pkg/events"
+	"auth-go-microservicio/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// WebhookConfig configura la entrega de webhooks
+type WebhookConfig struct {
+	MaxAttempts   int           // intentos antes de marcar la entrega como fallida
+	RetryBase     time.Duration // espera tras el primer fallo; se duplica en cada intento
+	RetryMaxDelay time.Duration
+	BatchSize     int           // entregas reservadas por ronda
+	Lease         time.Duration // tiempo que una entrega reservada queda oculta a otras réplicas
+	DisableAfter  int           // entregas fallidas consecutivas antes de desactivar la suscripción; 0 para nunca
+}
+
+// WebhookUseCase administra las suscripciones de webhooks y entrega los eventos de dominio.
+// Implementa events.Publisher: el relay del outbox le entrega cada evento y se genera una
+// entrega pendiente por suscripción, que luego envía el worker con reintentos.
+type WebhookUseCase struct {
+	webhookRepo   repositories.WebhookRepository
+	encryptionSvc encryption.Service
+	sender        *webhook.Sender
+	auditLogger   *AuditLogger
+	config        WebhookConfig
+}
+
+// NewWebhookUseCase crea una nueva instancia de WebhookUseCase
+func NewWebhookUseCase(
+	webhookRepo repositories.WebhookRepository,
+	encryptionSvc encryption.Service,
+	sender *webhook.Sender,
+	auditLogger *AuditLogger,
+	config WebhookConfig,
+) *WebhookUseCase {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	return &WebhookUseCase{
+		webhookRepo:   webhookRepo,
+		encryptionSvc: encryptionSvc,
+		sender:        sender,
+		auditLogger:   auditLogger,
+		config:        config,
+	}
+}
+
+// CreateWebhookRequest representa la solicitud de creación de una suscripción
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events"` // vacío para recibir todos los eventos
+	Secret      string   `json:"secret"` // opcional; si está vacío se genera uno
+	CreatedBy   string   `json:"-"`
+}
+
+// WebhookResponse representa una suscripción; Secret solo se incluye al crearla o rotarlo
+type WebhookResponse struct {
+	*entities.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// CreateSubscription guarda la suscripción y retorna su secreto de firma
+func (uc *WebhookUseCase) CreateSubscription(ctx context.Context, req *CreateWebhookRequest) (*WebhookResponse, error) {
+	if err := uc.sender.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := parseEventTypes(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdBy *uuid.UUID
+	if req.CreatedBy != "" {
+		id, err := uuid.Parse(req.CreatedBy)
+		if err != nil {
+			return nil, errors.New("invalid user id")
+		}
+		createdBy = &id
+	}
+
+	secret, encryptedSecret, err := uc.newSecret(req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := entities.NewWebhookSubscription(req.URL, req.Description, encryptedSecret, eventTypes, createdBy)
+	if err := uc.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebhookCreated,
+		TargetID: subscription.ID.String(),
+		Metadata: map[string]interface{}{"url": subscription.URL, "events": subscription.Events},
+	})
+
+	return &WebhookResponse{WebhookSubscription: subscription, Secret: secret}, nil
+}
+
+// ListSubscriptions lista todas las suscripciones
+func (uc *WebhookUseCase) ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	return uc.webhookRepo.ListSubscriptions(ctx)
+}
+
+// GetSubscription obtiene una suscripción
+func (uc *WebhookUseCase) GetSubscription(ctx context.Context, id string) (*entities.WebhookSubscription, error) {
+	return uc.webhookRepo.GetSubscription(ctx, id)
+}
+
+// UpdateWebhookRequest representa la solicitud de actualización de una suscripción; los campos
+// omitidos no se modifican
+type UpdateWebhookRequest struct {
+	ID           string    `json:"-"`
+	URL          string    `json:"url"`
+	Description  *string   `json:"description" binding:"omitempty,max=255"`
+	Events       *[]string `json:"events"`
+	IsActive     *bool     `json:"is_active"`
+	RotateSecret bool      `json:"rotate_secret"` // genera un secreto nuevo y lo retorna
+}
+
+// UpdateSubscription actualiza la suscripción
+func (uc *WebhookUseCase) UpdateSubscription(ctx context.Context, req *UpdateWebhookRequest) (*WebhookResponse, error) {
+	subscription, err := uc.webhookRepo.GetSubscription(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if req.URL != "" {
+		if err := uc.sender.CheckURL(req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = req.URL
+		changes["url"] = req.URL
+	}
+	if req.Description != nil {
+		subscription.Description = *req.Description
+	}
+	if req.Events != nil {
+		if subscription.Events, err = parseEventTypes(*req.Events); err != nil {
+			return nil, err
+		}
+		changes["events"] = subscription.Events
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+		changes["is_active"] = subscription.IsActive
+		// Reactivar una suscripción desactivada por fallos reinicia el contador
+		if subscription.IsActive {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+		}
+	}
+
+	response := &WebhookResponse{WebhookSubscription: subscription}
+	if req.RotateSecret {
+		if response.Secret, subscription.EncryptedSecret, err = uc.newSecret(""); err != nil {
+			return nil, err
+		}
+		changes["secret_rotated"] = true
+	}
+
+	subscription.UpdatedAt = time.Now()
+	if err := uc.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebhookUpdated,
+		TargetID: subscription.ID.String(),
+		Metadata: changes,
+	})
+
+	return response, nil
+}
+
+// DeleteSubscription elimina la suscripción y su historial de entregas
+func (uc *WebhookUseCase) DeleteSubscription(ctx context.Context, id string) error {
+	if err := uc.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebhookDeleted,
+		TargetID: id,
+	})
+	return nil
+}
+
+// ListWebhookDeliveriesRequest representa la solicitud para listar las entregas de una suscripción
+type ListWebhookDeliveriesRequest struct {
+	SubscriptionID string `json:"-"`
+	Status         string `json:"status"` // pending, succeeded o failed; vacío para todas
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+}
+
+// ListWebhookDeliveriesResponse representa una página del historial de entregas
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []*entities.WebhookDelivery `json:"deliveries"`
+	Total      int64                       `json:"total"`
+}
+
+// ListDeliveries lista el historial de entregas de una suscripción, de la más reciente a la más antigua
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, req *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	if _, err := uc.webhookRepo.GetSubscription(ctx, req.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	switch entities.WebhookDeliveryStatus(req.Status) {
+	case "", entities.WebhookDeliveryPending, entities.WebhookDeliverySucceeded, entities.WebhookDeliveryFailed:
+	default:
+		return nil, errors.New("invalid delivery status")
+	}
+
+	filter := &repositories.WebhookDeliveryFilter{
+		SubscriptionID: req.SubscriptionID,
+		Status:         req.Status,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+	}
+	deliveries, err := uc.webhookRepo.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.webhookRepo.CountDeliveries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      total,
+	}, nil
+}
+
+// WebhookDeliveryResponse representa una entrega con sus intentos
+type WebhookDeliveryResponse struct {
+	*entities.WebhookDelivery
+	AttemptHistory []*entities.WebhookAttempt `json:"attempt_history"`
+}
+
+// GetDelivery obtiene una entrega de la suscripción con todos sus intentos
+func (uc *WebhookUseCase) GetDelivery(ctx context.Context, subscriptionID, deliveryID string) (*WebhookDeliveryResponse, error) {
+	delivery, err := uc.subscriptionDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := uc.webhookRepo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookDeliveryResponse{WebhookDelivery: delivery, AttemptHistory: attempts}, nil
+}
+
+// Redeliver vuelve a encolar una entrega, por ejemplo después de corregir el endpoint. Los
+// intentos se reinician; el historial de los anteriores se conserva.
+func (uc *WebhookUseCase) Redeliver(ctx context.Context, subscriptionID, deliveryID string) error {
+	if _, err := uc.subscriptionDelivery(ctx, subscriptionID, deliveryID); err != nil {
+		return err
+	}
+
+	if err := uc.webhookRepo.Redeliver(ctx, deliveryID); err != nil {
+		return err
+	}
+
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebhookRedelivered,
+		TargetID: subscriptionID,
+		Metadata: map[string]interface{}{"delivery_id": deliveryID},
+	})
+	return nil
+}
+
+// subscriptionDelivery obtiene la entrega verificando que pertenezca a la suscripción
+func (uc *WebhookUseCase) subscriptionDelivery(ctx context.Context, subscriptionID, deliveryID string) (*entities.WebhookDelivery, error) {
+	delivery, err := uc.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.SubscriptionID.String() != subscriptionID {
+		return nil, errors.New("delivery not found")
+	}
+	return delivery, nil
+}
+
+// Publish genera una entrega pendiente por cada suscripción activa que recibe el evento. Si el
+// evento se publica más de una vez no se duplican las entregas.
+func (uc *WebhookUseCase) Publish(ctx context.Context, event *events.Event) error {
+	eventID, err := uuid.Parse(event.ID)
+	if err != nil {
+		return errors.New("invalid event id")
+	}
+	eventType := entities.EventType(event.Type)
+
+	subscriptions, err := uc.webhookRepo.ListActiveSubscriptions(ctx, eventType)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]*entities.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, entities.NewWebhookDelivery(subscription.ID, eventID, eventType, payload))
+	}
+
+	return uc.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// Run envía las entregas pendientes cada interval hasta que se cancela el contexto
+func (uc *WebhookUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := uc.DeliverPending(ctx)
+			if err != nil {
+				log.Printf("Error delivering webhooks: %v", err)
+				break
+			}
+			if sent < uc.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverPending reserva un lote de entregas pendientes, las envía y retorna cuántas procesó
+func (uc *WebhookUseCase) DeliverPending(ctx context.Context) (int, error) {
+	deliveries, err := uc.webhookRepo.ClaimDeliveries(ctx, uc.config.BatchSize, time.Now().Add(uc.config.Lease))
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := map[uuid.UUID]*entities.WebhookSubscription{}
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			if subscription, err = uc.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID.String()); err != nil {
+				return 0, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		// Si se desactivó durante el lote, el resto de sus entregas queda pendiente hasta que se reactive
+		if !subscription.IsActive {
+			continue
+		}
+
+		if err := uc.deliver(ctx, subscription, delivery); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver envía un intento de la entrega y guarda su resultado
+func (uc *WebhookUseCase) deliver(ctx context.Context, subscription *entities.WebhookSubscription, delivery *entities.WebhookDelivery) error {
+	now := time.Now()
+	attempt := &entities.WebhookAttempt{
+		ID:          uuid.New(),
+		DeliveryID:  delivery.ID,
+		AttemptedAt: now,
+	}
+
+	secret, err := uc.encryptionSvc.Decrypt(subscription.EncryptedSecret)
+	if err != nil {
+		attempt.Error = "decrypting webhook secret: " + err.Error()
+	} else {
+		response, err := uc.sender.Send(ctx, &webhook.Request{
+			URL:       subscription.URL,
+			Secret:    string(secret),
+			ID:        delivery.ID.String(),
+			Event:     string(delivery.EventType),
+			Body:      delivery.Payload,
+			Timestamp: now,
+		})
+		attempt.StatusCode = response.StatusCode
+		attempt.ResponseBody = response.Body
+		attempt.DurationMs = response.Duration.Milliseconds()
+		switch {
+		case err != nil:
+			attempt.Error = err.Error()
+		case !response.Succeeded():
+			attempt.Error = "endpoint responded with status " + strconv.Itoa(response.StatusCode)
+		}
+	}
+
+	delivery.Attempts++
+	delivery.LastError = attempt.Error
+	switch {
+	case attempt.Error == "":
+		delivery.Status = entities.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= uc.config.MaxAttempts:
+		delivery.Status = entities.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook delivery %s to %s failed after %d attempts: %s", delivery.ID, subscription.URL, delivery.Attempts, attempt.Error)
+	default:
+		next := now.Add(retryDelay(uc.config.RetryBase, uc.config.RetryMaxDelay, delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := uc.webhookRepo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		return err
+	}
+
+	switch delivery.Status {
+	case entities.WebhookDeliverySucceeded:
+		subscription.ConsecutiveFailures = 0
+		return uc.webhookRepo.ResetDeliveryFailures(ctx, subscription.ID.String())
+	case entities.WebhookDeliveryFailed:
+		return uc.recordFailedDelivery(ctx, subscription)
+	}
+	return nil
+}
+
+// recordFailedDelivery cuenta la entrega fallida y desactiva la suscripción si el endpoint acumuló
+// demasiados fallos seguidos, para no seguir enviándole eventos
+func (uc *WebhookUseCase) recordFailedDelivery(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	disabled, err := uc.webhookRepo.RecordDeliveryFailure(ctx, subscription.ID.String(), uc.config.DisableAfter)
+	if err != nil {
+		return err
+	}
+	subscription.ConsecutiveFailures++
+	if !disabled {
+		return nil
+	}
+
+	subscription.IsActive = false
+	log.Printf("Webhook %s to %s disabled after %d consecutive failed deliveries", subscription.ID, subscription.URL, uc.config.DisableAfter)
+	uc.auditLogger.Log(ctx, AuditEntry{
+		Action:   entities.AuditActionWebhookDisabled,
+		TargetID: subscription.ID.String(),
+		Metadata: map[string]interface{}{"url": subscription.URL, "consecutive_failures": uc.config.DisableAfter},
+	})
+	return nil
+}
+
+// newSecret usa el secreto indicado o genera uno, y lo retorna junto con su versión cifrada
+func (uc *WebhookUseCase) newSecret(secret string) (string, string, error) {
+	if secret == "" {
+		var err error
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			return "", "", err
+		}
+	} else if len(secret) < 16 {
+		return "", "", errors.New("webhook secret must be at least 16 characters long")
+	}
+
+	encryptedSecret, err := uc.encryptionSvc.Encrypt([]byte(secret))
+	if err != nil {
+		return "", "", err
+	}
+	return secret, encryptedSecret, nil
+}
+
+// parseEventTypes valida el filtro de eventos de una suscripción
+func parseEventTypes(values []string) ([]entities.EventType, error) {
+	eventTypes := make([]entities.EventType, 0, len(values))
+	seen := map[entities.EventType]bool{}
+	for _, value := range values {
+		eventType := entities.EventType(value)
+		if !entities.IsValidEventType(eventType) {
+			return nil, errors.New("unknown event type: " + value)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/internal/domain/entities"
+	"auth-go-microservicio/pkg/encryption"
+	"auth-go-microservicio/pkg/events"
+	"auth-go-microservicio/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+const testWebhookSecret = "whsec_test-secret-for-signatures"
+
+// webhookReceiver es un endpoint de prueba que responde con los códigos indicados en orden y
+// luego con el último, y guarda cada petición recibida
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*receivedWebhook
+}
+
+// receivedWebhook es una petición recibida por el endpoint de prueba
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, &receivedWebhook{header: req.Header.Clone(), body: body})
+	status := r.statuses[0]
+	if len(r.statuses) > 1 {
+		r.statuses = r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// received retorna las peticiones recibidas
+func (r *webhookReceiver) received() []*receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*receivedWebhook(nil), r.requests...)
+}
+
+// newTestWebhookUseCase crea el caso de uso con una suscripción al servidor de prueba
+func newTestWebhookUseCase(t *testing.T, webhookRepo *memoryWebhookRepo, auditRepo *memoryAuditRepo, config WebhookConfig, receiver *webhookReceiver) (*WebhookUseCase, *entities.WebhookSubscription) {
+	t.Helper()
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	encryptionSvc, err := encryption.NewService("test-encryption-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := NewWebhookUseCase(webhookRepo, encryptionSvc, webhook.NewSender(5*time.Second, true), NewAuditLogger(auditRepo), config)
+
+	created, err := uc.CreateSubscription(context.Background(), &CreateWebhookRequest{URL: server.URL, Secret: testWebhookSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uc, created.WebhookSubscription
+}
+
+// publishTestEvent publica un evento de login para las suscripciones
+func publishTestEvent(t *testing.T, uc *WebhookUseCase) *events.Event {
+	t.Helper()
+	event := &events.Event{
+		ID:          uuid.NewString(),
+		Type:        string(entities.EventUserLoggedIn),
+		AggregateID: uuid.NewString(),
+		OccurredAt:  time.Now(),
+		Payload:     json.RawMessage(`{"method":"password"}`),
+	}
+	if err := uc.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// deliverPending ejecuta una ronda del worker
+func deliverPending(t *testing.T, uc *WebhookUseCase) int {
+	t.Helper()
+	sent, err := uc.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sent
+}
+
+func TestWebhookDeliverySignsPayload(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	receiver := &webhookReceiver{statuses: []int{http.StatusOK}}
+	uc, _ := newTestWebhookUseCase(t, webhookRepo, &memoryAuditRepo{}, WebhookConfig{MaxAttempts: 3, RetryBase: time.Minute, RetryMaxDelay: time.Hour}, receiver)
+	event := publishTestEvent(t, uc)
+
+	if sent := deliverPending(t, uc); sent != 1 {
+		t.Fatalf("expected one delivery, sent %d", sent)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	request := requests[0]
+	delivery := webhookRepo.delivery(t)
+
+	if got := request.header.Get(webhook.HeaderID); got != delivery.ID.String() {
+		t.Errorf("%s = %q, expected the delivery id %s", webhook.HeaderID, got, delivery.ID)
+	}
+	if got := request.header.Get(webhook.HeaderEvent); got != event.Type {
+		t.Errorf("%s = %q, expected %q", webhook.HeaderEvent, got, event.Type)
+	}
+	if err := webhook.Verify(testWebhookSecret, request.header.Get(webhook.HeaderTimestamp), request.header.Get(webhook.HeaderSignature), request.body, time.Minute, time.Now()); err != nil {
+		t.Errorf("signature does not verify with the subscription secret: %v", err)
+	}
+	if err := webhook.Verify("whsec_another-secret-entirely", request.header.Get(webhook.HeaderTimestamp), request.header.Get(webhook.HeaderSignature), request.body, time.Minute, time.Now()); err == nil {
+		t.Error("signature verified with another secret")
+	}
+
+	var received events.Event
+	if err := json.Unmarshal(request.body, &received); err != nil || received.ID != event.ID {
+		t.Errorf("unexpected body %s: %v", request.body, err)
+	}
+
+	if delivery.Status != entities.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil {
+		t.Errorf("delivery was not marked succeeded: status=%s attempts=%d", delivery.Status, delivery.Attempts)
+	}
+	if len(webhookRepo.attempts) != 1 || webhookRepo.attempts[0].StatusCode != http.StatusOK {
+		t.Errorf("attempt was not recorded: %+v", webhookRepo.attempts)
+	}
+}
+
+func TestWebhookDeliveryRetriesWithBackoff(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}}
+	config := WebhookConfig{MaxAttempts: 5, RetryBase: time.Minute, RetryMaxDelay: time.Hour}
+	uc, _ := newTestWebhookUseCase(t, webhookRepo, &memoryAuditRepo{}, config, receiver)
+	publishTestEvent(t, uc)
+
+	for attempt, expectedDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now()
+		deliverPending(t, uc)
+
+		delivery := webhookRepo.delivery(t)
+		if delivery.Status != entities.WebhookDeliveryPending || delivery.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: expected a pending delivery, got status=%s attempts=%d", attempt+1, delivery.Status, delivery.Attempts)
+		}
+		if delivery.LastError == "" {
+			t.Errorf("attempt %d: last error was not recorded", attempt+1)
+		}
+		if delay := delivery.NextAttemptAt.Sub(start); delay < expectedDelay || delay > expectedDelay+5*time.Second {
+			t.Errorf("attempt %d: next attempt in %s, expected %s", attempt+1, delay, expectedDelay)
+		}
+
+		// Antes de vencer la espera no se vuelve a enviar
+		if sent := deliverPending(t, uc); sent != 0 {
+			t.Fatalf("attempt %d: delivery was retried before its backoff expired", attempt+1)
+		}
+		webhookRepo.makeDue()
+	}
+
+	deliverPending(t, uc)
+	delivery := webhookRepo.delivery(t)
+	if delivery.Status != entities.WebhookDeliverySucceeded || delivery.Attempts != 3 {
+		t.Fatalf("expected the third attempt to succeed, got status=%s attempts=%d", delivery.Status, delivery.Attempts)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 3 {
+		t.Fatalf("expected three requests, got %d", len(requests))
+	}
+	for _, request := range requests {
+		if request.header.Get(webhook.HeaderID) != delivery.ID.String() {
+			t.Error("retries did not repeat the delivery id")
+		}
+	}
+	if len(webhookRepo.attempts) != 3 {
+		t.Errorf("expected three recorded attempts, got %d", len(webhookRepo.attempts))
+	}
+}
+
+func TestWebhookDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	receiver := &webhookReceiver{statuses: []int{http.StatusServiceUnavailable}}
+	uc, _ := newTestWebhookUseCase(t, webhookRepo, &memoryAuditRepo{}, WebhookConfig{MaxAttempts: 2, RetryBase: time.Minute, RetryMaxDelay: time.Hour}, receiver)
+	publishTestEvent(t, uc)
+
+	deliverPending(t, uc)
+	webhookRepo.makeDue()
+	deliverPending(t, uc)
+
+	delivery := webhookRepo.delivery(t)
+	if delivery.Status != entities.WebhookDeliveryFailed || delivery.NextAttemptAt != nil {
+		t.Fatalf("expected a failed delivery, got status=%s next_attempt_at=%v", delivery.Status, delivery.NextAttemptAt)
+	}
+	webhookRepo.makeDue()
+	if sent := deliverPending(t, uc); sent != 0 {
+		t.Error("failed delivery was retried")
+	}
+}
+
+func TestWebhookSubscriptionDisabledAfterRepeatedFailures(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	auditRepo := &memoryAuditRepo{}
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	config := WebhookConfig{MaxAttempts: 1, RetryBase: time.Minute, RetryMaxDelay: time.Hour, DisableAfter: 3}
+	uc, subscription := newTestWebhookUseCase(t, webhookRepo, auditRepo, config, receiver)
+
+	// Las entregas fallidas del mismo lote cuentan; la cuarta no se envía
+	for i := 0; i < 4; i++ {
+		publishTestEvent(t, uc)
+	}
+	deliverPending(t, uc)
+
+	stored, _ := webhookRepo.GetSubscription(context.Background(), subscription.ID.String())
+	if stored.IsActive || stored.DisabledAt == nil {
+		t.Fatalf("subscription was not disabled: is_active=%v disabled_at=%v", stored.IsActive, stored.DisabledAt)
+	}
+	if stored.ConsecutiveFailures != 3 {
+		t.Errorf("expected 3 consecutive failures, got %d", stored.ConsecutiveFailures)
+	}
+	if n := len(receiver.received()); n != 3 {
+		t.Errorf("expected 3 requests before disabling, got %d", n)
+	}
+	if !containsAction(auditRepo.actions(), entities.AuditActionWebhookDisabled) {
+		t.Error("disabling was not audited")
+	}
+
+	// Una suscripción desactivada no recibe eventos nuevos ni entregas pendientes
+	publishTestEvent(t, uc)
+	webhookRepo.makeDue()
+	if sent := deliverPending(t, uc); sent != 0 {
+		t.Errorf("disabled subscription received %d deliveries", sent)
+	}
+	if len(webhookRepo.deliveries) != 4 {
+		t.Errorf("expected no deliveries for the new event, got %d in total", len(webhookRepo.deliveries))
+	}
+
+	// Al reactivarla se reinicia el contador y se envía la entrega que quedó pendiente
+	active := true
+	if _, err := uc.UpdateSubscription(context.Background(), &UpdateWebhookRequest{ID: subscription.ID.String(), IsActive: &active}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ = webhookRepo.GetSubscription(context.Background(), subscription.ID.String())
+	if stored.ConsecutiveFailures != 0 || stored.DisabledAt != nil {
+		t.Errorf("reactivation did not reset failures: consecutive_failures=%d disabled_at=%v", stored.ConsecutiveFailures, stored.DisabledAt)
+	}
+	receiver.mu.Lock()
+	receiver.statuses = []int{http.StatusOK}
+	receiver.mu.Unlock()
+	if sent := deliverPending(t, uc); sent != 1 {
+		t.Errorf("expected the pending delivery to be sent after reactivation, sent %d", sent)
+	}
+}
+
+func TestWebhookSuccessfulDeliveryResetsFailures(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK, http.StatusInternalServerError}}
+	config := WebhookConfig{MaxAttempts: 1, RetryBase: time.Minute, RetryMaxDelay: time.Hour, DisableAfter: 3}
+	uc, subscription := newTestWebhookUseCase(t, webhookRepo, &memoryAuditRepo{}, config, receiver)
+
+	for i := 0; i < 4; i++ {
+		publishTestEvent(t, uc)
+		deliverPending(t, uc)
+	}
+
+	stored, _ := webhookRepo.GetSubscription(context.Background(), subscription.ID.String())
+	if !stored.IsActive {
+		t.Fatal("subscription was disabled although a delivery succeeded in between")
+	}
+	if stored.ConsecutiveFailures != 1 {
+		t.Errorf("expected 1 consecutive failure after the success, got %d", stored.ConsecutiveFailures)
+	}
+}
+
+func TestWebhookSubscriptionRejectsPrivateURLs(t *testing.T) {
+	webhookRepo := newMemoryWebhookRepo()
+	uc, subscription := newTestWebhookUseCase(t, webhookRepo, &memoryAuditRepo{}, WebhookConfig{}, &webhookReceiver{})
+
+	encryptionSvc, err := encryption.NewService("test-encryption-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El caso de uso del fixture permite redes privadas para entregar al servidor de prueba
+	uc = NewWebhookUseCase(webhookRepo, encryptionSvc, webhook.NewSender(time.Second, false), NewAuditLogger(&memoryAuditRepo{}), WebhookConfig{})
+
+	for _, target := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest/meta-data/", "http://192.168.0.10/hook"} {
+		if _, err := uc.CreateSubscription(context.Background(), &CreateWebhookRequest{URL: target}); !errors.Is(err, webhook.ErrForbiddenDestination) {
+			t.Errorf("CreateSubscription(%s) = %v, want ErrForbiddenDestination", target, err)
+		}
+		if _, err := uc.UpdateSubscription(context.Background(), &UpdateWebhookRequest{ID: subscription.ID.String(), URL: target}); !errors.Is(err, webhook.ErrForbiddenDestination) {
+			t.Errorf("UpdateSubscription(%s) = %v, want ErrForbiddenDestination", target, err)
+		}
+	}
+
+	if _, err := uc.CreateSubscription(context.Background(), &CreateWebhookRequest{URL: "https://hooks.example.com/auth"}); err != nil {
+		t.Fatalf("public url rejected: %v", err)
+	}
+}
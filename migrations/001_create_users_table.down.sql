@@ -0,0 +1,3 @@
+-- Eliminar tabla de usuarios
+DROP TABLE IF EXISTS users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Eliminar tabla de tokens
+DROP TABLE IF EXISTS tokens;
@@ -1,2 +0,0 @@
--- Created a keycloak table to store user information
-CREATE DATABASE keycloak;
@@ -0,0 +1,4 @@
+-- Quitar familias de refresh tokens
+DROP INDEX IF EXISTS idx_tokens_family_revoked;
+DROP INDEX IF EXISTS idx_tokens_family_id;
+ALTER TABLE tokens DROP COLUMN IF EXISTS family_id;
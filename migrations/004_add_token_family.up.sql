@@ -0,0 +1,11 @@
+-- Agregar familias de refresh tokens para detectar reutilización
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS family_id UUID;
+
+-- Cada token existente inicia su propia familia
+UPDATE tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE tokens ALTER COLUMN family_id SET NOT NULL;
+
+-- Crear índices para revocar familias completas
+CREATE INDEX IF NOT EXISTS idx_tokens_family_id ON tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_tokens_family_revoked ON tokens(family_id, is_revoked);
@@ -0,0 +1,2 @@
+-- Eliminar tabla de sesiones
+DROP TABLE IF EXISTS sessions;
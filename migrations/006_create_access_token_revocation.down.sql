@@ -0,0 +1,3 @@
+-- Eliminar la revocación de access tokens
+DROP TABLE IF EXISTS token_watermarks;
+DROP TABLE IF EXISTS revoked_access_tokens;
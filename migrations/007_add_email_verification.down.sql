@@ -0,0 +1,3 @@
+-- Quitar verificación de email
+DROP TABLE IF EXISTS one_time_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
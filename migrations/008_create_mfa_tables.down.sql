@@ -0,0 +1,3 @@
+-- Eliminar tablas de MFA
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_totp_credentials;
@@ -0,0 +1,3 @@
+-- Eliminar tablas de WebAuthn
+DROP TABLE IF EXISTS webauthn_challenges;
+DROP TABLE IF EXISTS webauthn_credentials;
@@ -0,0 +1,3 @@
+-- Quitar el bloqueo de cuentas
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_attempts;
@@ -0,0 +1,3 @@
+-- Agregar contador de intentos fallidos y bloqueo temporal a los usuarios
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
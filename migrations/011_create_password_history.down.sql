@@ -0,0 +1,2 @@
+-- Eliminar historial de contraseñas
+DROP TABLE IF EXISTS password_history;
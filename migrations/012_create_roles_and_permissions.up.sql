@@ -0,0 +1,62 @@
+-- Crear tabla de roles definidos en la base de datos
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    is_system BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear tabla de permisos de cada rol (<recurso>:<acción>)
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- Crear tabla de roles adicionales de los usuarios (el principal sigue en users.role)
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- Crear índices para mejorar el rendimiento
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+
+-- Roles de sistema
+INSERT INTO roles (name, description, is_system) VALUES
+    ('user', 'Usuario con acceso a su propio perfil', true),
+    ('moderator', 'Consulta usuarios y administra sus sesiones', true),
+    ('admin', 'Administrador con acceso completo', true)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY[
+    'users:read', 'users:write', 'users:delete',
+    'sessions:read', 'sessions:revoke', 'mfa:reset',
+    'keys:read', 'keys:write',
+    'roles:read', 'roles:write', 'roles:assign'
+]) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['users:read', 'sessions:read', 'sessions:revoke']) AS p(permission)
+WHERE r.name = 'moderator'
+ON CONFLICT DO NOTHING;
+
+-- El rol principal pasa a referenciar la tabla de roles en lugar de una lista fija
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(50);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_role') THEN
+        ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+    END IF;
+END $$;
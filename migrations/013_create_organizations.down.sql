@@ -0,0 +1,8 @@
+-- Eliminar tablas de organizaciones
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+
+-- Quitar roles y permisos de las organizaciones
+DELETE FROM role_permissions WHERE permission IN ('orgs:read', 'orgs:write', 'members:read', 'members:write');
+UPDATE users SET role = 'user' WHERE role IN ('org_admin', 'org_member');
+DELETE FROM roles WHERE name IN ('org_admin', 'org_member');
@@ -0,0 +1,52 @@
+-- Crear tabla de organizaciones (tenants)
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear tabla de membresías con el rol del usuario dentro de cada organización
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+-- Crear índices para mejorar el rendimiento
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+
+-- Crear trigger para actualizar updated_at automáticamente
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Roles de sistema para las membresías
+INSERT INTO roles (name, description, is_system) VALUES
+    ('org_admin', 'Administra los miembros de su organización', true),
+    ('org_member', 'Miembro de una organización', true)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['members:read', 'members:write']) AS p(permission)
+WHERE r.name = 'org_admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['members:read']) AS p(permission)
+WHERE r.name = 'org_member'
+ON CONFLICT DO NOTHING;
+
+-- Los administradores de la plataforma gestionan todas las organizaciones
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['orgs:read', 'orgs:write', 'members:read', 'members:write']) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- Eliminar tabla de invitaciones
+DROP TABLE IF EXISTS invitations;
+DELETE FROM role_permissions WHERE permission IN ('invitations:read', 'invitations:write');
@@ -0,0 +1,27 @@
+-- Crear tabla de invitaciones (reemplaza el alta pública de administradores)
+CREATE TABLE IF NOT EXISTS invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE,
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    organization_role VARCHAR(50) REFERENCES roles(name) ON UPDATE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    revoked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear índices para mejorar el rendimiento
+CREATE INDEX IF NOT EXISTS idx_invitations_email ON invitations(email);
+CREATE INDEX IF NOT EXISTS idx_invitations_organization_id ON invitations(organization_id);
+
+-- Permisos para administrar invitaciones
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['invitations:read', 'invitations:write']) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- Eliminar el log de auditoría. ¡CUIDADO! Se pierde todo el historial.
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS reject_audit_event_change();
+DELETE FROM role_permissions WHERE permission = 'audit:read';
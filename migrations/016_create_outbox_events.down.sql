@@ -0,0 +1,2 @@
+-- Eliminar el outbox de eventos de dominio (los eventos pendientes no se publican)
+DROP TABLE IF EXISTS outbox_events;
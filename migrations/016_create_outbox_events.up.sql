@@ -0,0 +1,18 @@
+-- Crear tabla del outbox de eventos de dominio. Los eventos se insertan en la misma transacción
+-- que el cambio que los produce y un proceso en segundo plano los publica (al menos una vez).
+-- aggregate_id no referencia users para conservar los eventos de cuentas eliminadas.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    payload JSON NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMP
+);
+
+-- Índice parcial para reservar los eventos pendientes
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events(published_at);
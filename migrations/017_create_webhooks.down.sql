@@ -0,0 +1,5 @@
+-- Eliminar tablas de webhooks
+DROP TABLE IF EXISTS webhook_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DELETE FROM role_permissions WHERE permission IN ('webhooks:read', 'webhooks:write');
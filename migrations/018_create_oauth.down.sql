@@ -0,0 +1,10 @@
+-- Eliminar el servidor de autorización OAuth; los refresh tokens emitidos a clientes se eliminan
+DELETE FROM tokens WHERE client_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_tokens_client_id;
+ALTER TABLE tokens DROP COLUMN IF EXISTS scope;
+ALTER TABLE tokens DROP COLUMN IF EXISTS client_id;
+
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_clients;
+DELETE FROM role_permissions WHERE permission IN ('clients:read', 'clients:write');
@@ -0,0 +1,62 @@
+-- Crear tabla de clientes OAuth 2.0. Solo se guarda el hash del secreto (vacío en los clientes públicos).
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_id VARCHAR(100) UNIQUE NOT NULL,
+    secret_hash VARCHAR(64),
+    name VARCHAR(255) NOT NULL,
+    client_type VARCHAR(20) NOT NULL CHECK (client_type IN ('public', 'confidential')),
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    allowed_scopes TEXT[] NOT NULL DEFAULT '{}',
+    skip_consent BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear tabla de códigos de autorización de un solo uso. family_id identifica los refresh
+-- tokens emitidos al canjear el código para revocarlos si el código se reutiliza.
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    client_id VARCHAR(100) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    code_challenge VARCHAR(128),
+    code_challenge_method VARCHAR(10),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    family_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear tabla de consentimientos (scopes que cada usuario concedió a cada cliente)
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id VARCHAR(100) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_id)
+);
+
+-- Los refresh tokens emitidos a un cliente guardan el cliente y los scopes concedidos
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS client_id VARCHAR(100) REFERENCES oauth_clients(client_id) ON DELETE CASCADE;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS scope TEXT;
+
+-- Crear índices para mejorar el rendimiento
+CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_consents_client_id ON oauth_consents(client_id);
+CREATE INDEX IF NOT EXISTS idx_tokens_client_id ON tokens(client_id);
+
+-- Crear trigger para actualizar updated_at automáticamente
+CREATE TRIGGER update_oauth_clients_updated_at
+    BEFORE UPDATE ON oauth_clients
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Permisos para administrar clientes OAuth
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['clients:read', 'clients:write']) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- Quitar los datos de OpenID Connect de los códigos de autorización
+ALTER TABLE oauth_authorization_codes DROP COLUMN IF EXISTS auth_methods;
+ALTER TABLE oauth_authorization_codes DROP COLUMN IF EXISTS auth_time;
+ALTER TABLE oauth_authorization_codes DROP COLUMN IF EXISTS nonce;
@@ -0,0 +1,5 @@
+-- Datos de OpenID Connect del código de autorización: nonce de la solicitud y login del usuario
+-- que la concedió, para los claims nonce, auth_time y amr del ID token
+ALTER TABLE oauth_authorization_codes ADD COLUMN IF NOT EXISTS nonce VARCHAR(255);
+ALTER TABLE oauth_authorization_codes ADD COLUMN IF NOT EXISTS auth_time TIMESTAMP;
+ALTER TABLE oauth_authorization_codes ADD COLUMN IF NOT EXISTS auth_methods TEXT[];
@@ -0,0 +1,4 @@
+-- Eliminar las cuentas de servicio
+DROP TABLE IF EXISTS service_account_assertions;
+DROP TABLE IF EXISTS service_accounts;
+DELETE FROM role_permissions WHERE permission IN ('service_accounts:read', 'service_accounts:write');
@@ -0,0 +1,42 @@
+-- Crear tabla de cuentas de servicio (principales de máquina del grant client_credentials).
+-- Solo se guarda el hash del secreto; las cuentas con private_key_jwt guardan su clave pública.
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_id VARCHAR(100) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    auth_method VARCHAR(20) NOT NULL CHECK (auth_method IN ('client_secret', 'private_key_jwt')),
+    secret_hash VARCHAR(64),
+    public_key TEXT,
+    allowed_scopes TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP,
+    secret_rotated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Crear tabla de jti de client assertions ya usados, para rechazar su repetición hasta que expiran
+CREATE TABLE IF NOT EXISTS service_account_assertions (
+    client_id VARCHAR(100) NOT NULL REFERENCES service_accounts(client_id) ON DELETE CASCADE,
+    jti VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (client_id, jti)
+);
+
+-- Crear índices para mejorar el rendimiento
+CREATE INDEX IF NOT EXISTS idx_service_account_assertions_expires_at ON service_account_assertions(expires_at);
+
+-- Crear trigger para actualizar updated_at automáticamente
+CREATE TRIGGER update_service_accounts_updated_at
+    BEFORE UPDATE ON service_accounts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Permisos para administrar cuentas de servicio
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r, unnest(ARRAY['service_accounts:read', 'service_accounts:write']) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
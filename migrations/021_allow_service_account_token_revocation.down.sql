@@ -0,0 +1,3 @@
+-- Volver a exigir el usuario en los access tokens revocados
+DELETE FROM revoked_access_tokens WHERE user_id IS NULL;
+ALTER TABLE revoked_access_tokens ALTER COLUMN user_id SET NOT NULL;
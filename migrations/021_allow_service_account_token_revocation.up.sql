@@ -0,0 +1,2 @@
+-- Los access tokens de cuentas de servicio no tienen usuario; se revocan solo por jti
+ALTER TABLE revoked_access_tokens ALTER COLUMN user_id DROP NOT NULL;
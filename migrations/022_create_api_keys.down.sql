@@ -0,0 +1,2 @@
+-- Eliminar las API keys personales
+DROP TABLE IF EXISTS api_keys;
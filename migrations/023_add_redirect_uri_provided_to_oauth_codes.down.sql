@@ -0,0 +1,2 @@
+-- Quitar el indicador de redirect_uri explícito de los códigos de autorización
+ALTER TABLE oauth_authorization_codes DROP COLUMN IF EXISTS redirect_uri_provided;
@@ -0,0 +1,3 @@
+-- Indica si la solicitud de autorización incluyó redirect_uri; solo entonces el canje del código
+-- debe repetirlo (RFC 6749 sección 4.1.3). Los códigos existentes lo siguen exigiendo.
+ALTER TABLE oauth_authorization_codes ADD COLUMN IF NOT EXISTS redirect_uri_provided BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,3 @@
+-- Quitar el seguimiento de entregas fallidas de las suscripciones
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS consecutive_failures;
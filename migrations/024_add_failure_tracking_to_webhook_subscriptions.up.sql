@@ -0,0 +1,4 @@
+-- Entregas fallidas consecutivas de cada suscripción; al llegar a WEBHOOK_DISABLE_AFTER_FAILURES
+-- la suscripción se desactiva y disabled_at registra cuándo
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
@@ -0,0 +1,2 @@
+-- Quitar el registro del token que reemplazó a cada refresh token rotado
+ALTER TABLE tokens DROP COLUMN IF EXISTS replaced_by;
@@ -0,0 +1,3 @@
+-- Token que reemplazó a cada refresh token rotado. Solo volver a presentar un token rotado es una
+-- reutilización; los revocados por logout o cierre de sesión quedan con replaced_by nulo
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS replaced_by UUID;
@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS contiene las migraciones del esquema embebidas en el binario. Cada versión tiene un script
+// NNN_nombre.up.sql y, opcionalmente, NNN_nombre.down.sql para revertirla.
+//
+//go:embed *.sql
+var FS embed.FS
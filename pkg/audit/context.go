@@ -0,0 +1,31 @@
+package audit
+
+import "context"
+
+// Request contiene los datos de la petición HTTP que acompañan a las entradas de auditoría
+type Request struct {
+	ActorID   string // usuario autenticado; vacío en las rutas públicas
+	IPAddress string
+	UserAgent string
+}
+
+// requestKey es la clave de Request en el contexto
+type requestKey struct{}
+
+// WithRequest guarda los datos de la petición en el contexto
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+// WithActor agrega el usuario autenticado a los datos de la petición del contexto
+func WithActor(ctx context.Context, actorID string) context.Context {
+	request := FromContext(ctx)
+	request.ActorID = actorID
+	return WithRequest(ctx, request)
+}
+
+// FromContext obtiene los datos de la petición; vacíos si el contexto no los tiene
+func FromContext(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey{}).(Request)
+	return request
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaRESTPublisher publica los eventos en un topic de Kafka a través de un REST Proxy
+// (API v2), sin cliente nativo. La clave del registro es el aggregate_id, de modo que los
+// eventos de un mismo usuario van a la misma partición.
+type kafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaRESTPublisher crea un Publisher que produce en topic mediante el REST Proxy en proxyURL
+func NewKafkaRESTPublisher(proxyURL, topic string, timeout time.Duration) Publisher {
+	return &kafkaRESTPublisher{
+		endpoint: strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// kafkaProduceResponse es la respuesta del REST Proxy; cada offset informa el resultado de un registro
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produce el evento y verifica que el broker lo haya aceptado
+func (p *kafkaRESTPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{
+			{"key": event.AggregateID, "value": event},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("kafka rest proxy responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(data, &produced); err != nil {
+		return fmt.Errorf("decoding kafka rest proxy response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected the record (code %d): %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryPublisher guarda los eventos publicados en memoria; útil en tests
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+// NewMemoryPublisher crea un MemoryPublisher vacío
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish guarda una copia del evento, o retorna el error configurado con FailWith
+func (p *MemoryPublisher) Publish(ctx context.Context, event *Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, *event)
+	return nil
+}
+
+// FailWith hace que las siguientes publicaciones fallen con err; nil vuelve a aceptarlas
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events retorna los eventos publicados en orden
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// Reset descarta los eventos publicados
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+}
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsPublisher publica los eventos en NATS con el protocolo de texto del servidor, sin cliente
+// externo. Cada publicación espera el PONG de un PING posterior, de modo que un error indica que
+// el servidor no recibió el mensaje. Para persistirlos el subject debe pertenecer a un stream de JetStream.
+type natsPublisher struct {
+	addr          string
+	user          string
+	pass          string
+	token         string
+	subjectPrefix string
+	timeout       time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSPublisher crea un Publisher que publica en el subject <subjectPrefix>.<tipo de evento>.
+// serverURL tiene la forma nats://[usuario:contraseña@ o token@]host:puerto
+func NewNATSPublisher(serverURL, subjectPrefix string, timeout time.Duration) (Publisher, error) {
+	parsed, err := url.Parse(serverURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid NATS url %q", serverURL)
+	}
+
+	p := &natsPublisher{
+		addr:          parsed.Host,
+		subjectPrefix: strings.TrimSuffix(subjectPrefix, "."),
+		timeout:       timeout,
+	}
+	if parsed.Port() == "" {
+		p.addr = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if parsed.User != nil {
+		if pass, ok := parsed.User.Password(); ok {
+			p.user, p.pass = parsed.User.Username(), pass
+		} else {
+			p.token = parsed.User.Username()
+		}
+	}
+	return p, nil
+}
+
+// Publish publica el evento y espera la confirmación del servidor
+func (p *natsPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	subject := event.Type
+	if p.subjectPrefix != "" {
+		subject = p.subjectPrefix + "." + event.Type
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, subject, body); err != nil {
+		// La conexión queda en un estado desconocido; se abre otra en el siguiente intento
+		p.close()
+		return err
+	}
+	return nil
+}
+
+// publish envía el mensaje sobre la conexión actual, abriéndola si hace falta
+func (p *natsPublisher) publish(ctx context.Context, subject string, body []byte) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	p.setDeadline(ctx)
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(body), body)
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return p.waitPong()
+}
+
+// connect abre la conexión, lee el INFO del servidor y se identifica
+func (p *natsPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+	p.setDeadline(ctx)
+
+	line, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "auth-service",
+		"lang":     "go",
+		"protocol": 1,
+	}
+	if p.user != "" {
+		options["user"] = p.user
+		options["pass"] = p.pass
+	}
+	if p.token != "" {
+		options["auth_token"] = p.token
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		return err
+	}
+	return p.waitPong()
+}
+
+// waitPong lee hasta el PONG, respondiendo los PING del servidor e informando sus errores
+func (p *natsPublisher) waitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK e INFO se ignoran
+	}
+}
+
+// readLine lee una línea del protocolo sin el CRLF final
+func (p *natsPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setDeadline limita la operación al timeout o al vencimiento del contexto, lo que ocurra antes
+func (p *natsPublisher) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(p.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	p.conn.SetDeadline(deadline)
+}
+
+// close cierra la conexión actual
+func (p *natsPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+		p.reader = nil
+	}
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// Event representa un evento de dominio tal como se publica a otros servicios
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Publisher define las operaciones para publicar eventos. La entrega es al menos una vez:
+// un evento puede publicarse más de una vez y los consumidores deben deduplicar por ID.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// logPublisher escribe los eventos en el log de la aplicación
+type logPublisher struct{}
+
+// NewLogPublisher crea un Publisher que solo registra los eventos en el log
+func NewLogPublisher() Publisher {
+	return &logPublisher{}
+}
+
+// Publish registra el evento en el log
+func (p *logPublisher) Publish(ctx context.Context, event *Event) error {
+	log.Printf("📣 event type=%s id=%s aggregate_id=%s payload=%s", event.Type, event.ID, event.AggregateID, event.Payload)
+	return nil
+}
+
+// fanoutPublisher publica cada evento en varios destinos
+type fanoutPublisher struct {
+	publishers []Publisher
+}
+
+// NewFanoutPublisher crea un Publisher que publica en todos los destinos en orden. Si alguno
+// falla el evento se reintenta en todos, por lo que cada destino debe tolerar duplicados.
+func NewFanoutPublisher(publishers ...Publisher) Publisher {
+	return &fanoutPublisher{publishers: publishers}
+}
+
+// Publish publica el evento en cada destino y retorna los errores combinados
+func (p *fanoutPublisher) Publish(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientAssertionType es el client_assertion_type de la autenticación private_key_jwt (RFC 7523)
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// maxAssertionLifetime limita la vigencia de un client assertion; acota cuánto se guarda su jti
+const maxAssertionLifetime = time.Hour
+
+// ClientAssertion representa un client assertion verificado
+type ClientAssertion struct {
+	ClientID  string
+	JTI       string
+	ExpiresAt time.Time
+}
+
+// ParsePublicKeyPEM parsea una clave pública RSA, ECDSA o Ed25519 en formato PEM (PKIX o PKCS#1)
+// y retorna los algoritmos JWS con los que puede verificar
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, []string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("failed to decode public key PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, nil, errors.New("rsa public keys must have at least 2048 bits")
+		}
+		return k, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return k, []string{"ES256"}, nil
+		case elliptic.P384():
+			return k, []string{"ES384"}, nil
+		case elliptic.P521():
+			return k, []string{"ES512"}, nil
+		}
+		return nil, nil, errors.New("unsupported elliptic curve")
+	case ed25519.PublicKey:
+		return k, []string{"EdDSA"}, nil
+	default:
+		return nil, nil, errors.New("unsupported public key type")
+	}
+}
+
+// AssertionSubject retorna el sub de un client assertion sin verificar la firma, para buscar la
+// clave del cliente con la que se verifica después
+func AssertionSubject(assertion string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, claims); err != nil {
+		return "", errors.New("invalid client assertion")
+	}
+	return claims.Subject, nil
+}
+
+// VerifyClientAssertion verifica un client assertion (RFC 7523 sección 3) firmado con la clave
+// privada del cliente: iss y sub deben ser el client_id, aud una de las audiencias aceptadas
+// (el token endpoint o el issuer) y exp no puede superar maxAssertionLifetime. jti es obligatorio
+// para que el llamador rechace su repetición.
+func VerifyClientAssertion(assertion string, publicKeyPEM []byte, clientID string, audiences []string) (*ClientAssertion, error) {
+	publicKey, methods, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(assertion, claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods(methods), jwt.WithExpirationRequired(), jwt.WithIssuer(clientID), jwt.WithSubject(clientID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid client assertion: %w", err)
+	}
+
+	audienceOK := false
+	for _, audience := range audiences {
+		for _, aud := range claims.Audience {
+			if aud == audience {
+				audienceOK = true
+			}
+		}
+	}
+	if !audienceOK {
+		return nil, errors.New("invalid client assertion: audience does not match the token endpoint")
+	}
+	if claims.ID == "" {
+		return nil, errors.New("invalid client assertion: jti is required")
+	}
+	if claims.ExpiresAt.Time.After(time.Now().Add(maxAssertionLifetime)) {
+		return nil, errors.New("invalid client assertion: expiration is too far in the future")
+	}
+
+	return &ClientAssertion{
+		ClientID:  clientID,
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
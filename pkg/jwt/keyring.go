@@ -0,0 +1,391 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFile es el archivo que describe el anillo de claves dentro del directorio
+const manifestFile = "keyring.json"
+
+// KeyRing mantiene una clave activa de firma y claves anteriores que solo sirven para
+// verificar tokens hasta su fecha de retiro (NotAfter). También admite claves
+// preparadas que se publican en el JWKS antes de ser promovidas.
+type KeyRing struct {
+	mu     sync.RWMutex
+	dir    string
+	active *SigningKey
+	keys   map[string]*SigningKey
+	files  map[string]string
+}
+
+// keyRingManifest representa el contenido de keyring.json
+type keyRingManifest struct {
+	Active string             `json:"active"`
+	Keys   []keyManifestEntry `json:"keys"`
+}
+
+// keyManifestEntry describe una clave del manifiesto
+type keyManifestEntry struct {
+	ID       string     `json:"kid"`
+	File     string     `json:"file"`
+	NotAfter *time.Time `json:"not_after,omitempty"`
+}
+
+// NewKeyRing crea un anillo en memoria con una clave activa y claves retiradas opcionales
+func NewKeyRing(active *SigningKey, retired ...*SigningKey) (*KeyRing, error) {
+	if active == nil {
+		return nil, errors.New("active signing key is required")
+	}
+
+	ring := &KeyRing{
+		active: active,
+		keys:   map[string]*SigningKey{active.ID: active},
+		files:  map[string]string{},
+	}
+	for _, key := range retired {
+		if _, exists := ring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	return ring, nil
+}
+
+// LoadKeyRingDir carga el anillo desde un directorio con un keyring.json y los archivos de clave.
+// Los archivos .pem se interpretan como claves privadas y el resto como secretos HMAC.
+func LoadKeyRingDir(dir string) (*KeyRing, error) {
+	ring := &KeyRing{dir: dir}
+	if err := ring.Reload(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// InitKeyRingDir crea un anillo nuevo en un directorio vacío con la clave indicada como activa
+func InitKeyRingDir(dir string, active *SigningKey) (*KeyRing, error) {
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err == nil {
+		return nil, errors.New("key ring already initialized")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{
+		dir:   dir,
+		keys:  map[string]*SigningKey{},
+		files: map[string]string{},
+	}
+	ring.active = active
+	if err := ring.Add(active); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Reload vuelve a leer el directorio del anillo; permite que otras réplicas vean una rotación.
+// Mantiene el lock durante la lectura para no pisar una rotación local en curso.
+func (r *KeyRing) Reload() error {
+	if r.dir == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(r.dir, manifestFile))
+	if err != nil {
+		return fmt.Errorf("reading key ring manifest: %w", err)
+	}
+
+	var manifest keyRingManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("decoding key ring manifest: %w", err)
+	}
+
+	keys := make(map[string]*SigningKey, len(manifest.Keys))
+	files := make(map[string]string, len(manifest.Keys))
+	for _, entry := range manifest.Keys {
+		if _, exists := keys[entry.ID]; exists {
+			return fmt.Errorf("duplicate key id %q", entry.ID)
+		}
+
+		key, err := loadKeyFile(entry.ID, filepath.Join(r.dir, entry.File))
+		if err != nil {
+			return fmt.Errorf("loading key %q: %w", entry.ID, err)
+		}
+		if entry.NotAfter != nil {
+			key.NotAfter = *entry.NotAfter
+		}
+
+		keys[key.ID] = key
+		files[key.ID] = entry.File
+	}
+
+	active, ok := keys[manifest.Active]
+	if !ok {
+		return fmt.Errorf("active key %q not found in key ring", manifest.Active)
+	}
+	if !active.NotAfter.IsZero() {
+		return fmt.Errorf("active key %q cannot have a retirement date", manifest.Active)
+	}
+
+	r.active = active
+	r.keys = keys
+	r.files = files
+
+	return nil
+}
+
+// Active retorna la clave con la que se firman los tokens nuevos
+func (r *KeyRing) Active() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// Lookup busca una clave de verificación por kid, ignorando las ya retiradas
+func (r *KeyRing) Lookup(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	if !ok || key.IsRetired(time.Now()) {
+		return nil, false
+	}
+	return key, true
+}
+
+// Keys retorna las claves vigentes: primero la activa y luego el resto ordenadas por kid
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	keys := []*SigningKey{r.active}
+	for _, key := range r.keys {
+		if key != r.active && !key.IsRetired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys[1:], func(i, j int) bool { return keys[i+1].ID < keys[j+1].ID })
+
+	return keys
+}
+
+// Add incorpora una clave preparada que se publica pero todavía no firma tokens
+func (r *KeyRing) Add(key *SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.add(key); err != nil {
+		return err
+	}
+	if err := r.save(); err != nil {
+		r.remove(key.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Promote convierte la clave indicada en la activa. La clave activa anterior pasa a ser
+// solo de verificación hasta now+retireAfter, que debe cubrir la vida de los refresh tokens.
+func (r *KeyRing) Promote(kid string, retireAfter time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("key %q not found", kid)
+	}
+	if key == r.active {
+		return nil
+	}
+	if key.IsRetired(time.Now()) {
+		return fmt.Errorf("key %q is already retired", kid)
+	}
+
+	undo := r.promote(key, retireAfter)
+	if err := r.save(); err != nil {
+		undo()
+		return err
+	}
+
+	return nil
+}
+
+// Rotate genera una clave nueva con el algoritmo indicado y la promueve inmediatamente.
+// Agregar y promover ocurren bajo el mismo lock; si no se puede guardar el manifiesto el
+// anillo queda como estaba.
+func (r *KeyRing) Rotate(algorithm string, retireAfter time.Duration) (*SigningKey, error) {
+	if algorithm == "" {
+		algorithm = r.Active().Algorithm()
+	}
+
+	// La generación puede ser lenta (RSA); se hace antes de tomar el lock
+	key, err := GenerateSigningKey("", algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.add(key); err != nil {
+		return nil, err
+	}
+	undo := r.promote(key, retireAfter)
+	if err := r.save(); err != nil {
+		undo()
+		r.remove(key.ID)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Prune elimina del anillo las claves cuya fecha de retiro ya pasó. Los archivos se borran
+// solo después de guardar el manifiesto; si falla, las claves vuelven al anillo.
+func (r *KeyRing) Prune() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	retired := make(map[string]*SigningKey)
+	files := make(map[string]string)
+	now := time.Now()
+	for kid, key := range r.keys {
+		if key.IsRetired(now) {
+			retired[kid] = key
+			if file, ok := r.files[kid]; ok {
+				files[kid] = file
+				delete(r.files, kid)
+			}
+			delete(r.keys, kid)
+		}
+	}
+
+	if err := r.save(); err != nil {
+		for kid, key := range retired {
+			r.keys[kid] = key
+		}
+		for kid, file := range files {
+			r.files[kid] = file
+		}
+		return nil, err
+	}
+
+	removed := make([]string, 0, len(retired))
+	for kid := range retired {
+		if file, ok := files[kid]; ok {
+			os.Remove(filepath.Join(r.dir, file))
+		}
+		removed = append(removed, kid)
+	}
+	sort.Strings(removed)
+
+	return removed, nil
+}
+
+// IsPersistent indica si el anillo está respaldado por un directorio
+func (r *KeyRing) IsPersistent() bool {
+	return r.dir != ""
+}
+
+// add escribe el archivo de la clave y la incorpora sin guardar el manifiesto; debe llamarse
+// con el lock tomado
+func (r *KeyRing) add(key *SigningKey) error {
+	if _, exists := r.keys[key.ID]; exists {
+		return fmt.Errorf("duplicate key id %q", key.ID)
+	}
+
+	if r.dir != "" {
+		file := key.ID + ".key"
+		if !key.IsSymmetric() {
+			file = key.ID + ".pem"
+		}
+		data, err := key.EncodePrivateKey()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(r.dir, file), data, 0600); err != nil {
+			return fmt.Errorf("writing key file: %w", err)
+		}
+		r.files[key.ID] = file
+	}
+
+	r.keys[key.ID] = key
+	return nil
+}
+
+// remove deshace add; debe llamarse con el lock tomado
+func (r *KeyRing) remove(kid string) {
+	delete(r.keys, kid)
+	if file, ok := r.files[kid]; ok {
+		os.Remove(filepath.Join(r.dir, file))
+		delete(r.files, kid)
+	}
+}
+
+// promote convierte la clave en la activa sin guardar el manifiesto y retorna una función
+// que restaura el estado anterior; debe llamarse con el lock tomado
+func (r *KeyRing) promote(key *SigningKey, retireAfter time.Duration) func() {
+	previous, previousNotAfter := r.active, key.NotAfter
+
+	previous.NotAfter = time.Now().Add(retireAfter)
+	key.NotAfter = time.Time{}
+	r.active = key
+
+	return func() {
+		previous.NotAfter = time.Time{}
+		key.NotAfter = previousNotAfter
+		r.active = previous
+	}
+}
+
+// save escribe el manifiesto; debe llamarse con el lock tomado
+func (r *KeyRing) save() error {
+	if r.dir == "" {
+		return nil
+	}
+
+	manifest := keyRingManifest{Active: r.active.ID}
+	for kid, key := range r.keys {
+		entry := keyManifestEntry{ID: kid, File: r.files[kid]}
+		if !key.NotAfter.IsZero() {
+			notAfter := key.NotAfter.UTC().Truncate(time.Second)
+			entry.NotAfter = &notAfter
+		}
+		manifest.Keys = append(manifest.Keys, entry)
+	}
+	sort.Slice(manifest.Keys, func(i, j int) bool { return manifest.Keys[i].ID < manifest.Keys[j].ID })
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Escritura atómica para que las otras réplicas nunca lean un manifiesto parcial
+	tmp := filepath.Join(r.dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing key ring manifest: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(r.dir, manifestFile))
+}
+
+// loadKeyFile carga una clave según la extensión del archivo
+func loadKeyFile(id, path string) (*SigningKey, error) {
+	if strings.HasSuffix(path, ".pem") {
+		return LoadPrivateKeyFile(id, path)
+	}
+	return LoadSecretKeyFile(id, path)
+}
@@ -0,0 +1,165 @@
+package jwt
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestKeyRingDir crea un anillo persistente en un directorio temporal
+func newTestKeyRingDir(t *testing.T) (*KeyRing, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ring, err := InitKeyRingDir(dir, NewHMACKey("initial", "initial-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring, dir
+}
+
+// blockSave impide escribir el manifiesto ocupando la ruta del archivo temporal con un directorio
+func blockSave(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, manifestFile+".tmp"), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertActive verifica que la clave activa sea kid y siga sin fecha de retiro
+func assertActive(t *testing.T, ring *KeyRing, kid string) {
+	t.Helper()
+	active := ring.Active()
+	if active.ID != kid {
+		t.Fatalf("expected active key %q, got %q", kid, active.ID)
+	}
+	if !active.NotAfter.IsZero() {
+		t.Errorf("active key %q has a retirement date", kid)
+	}
+}
+
+func TestPromoteRestoresActiveKeyWhenSaveFails(t *testing.T) {
+	ring, dir := newTestKeyRingDir(t)
+	if err := ring.Add(NewHMACKey("next", "next-secret")); err != nil {
+		t.Fatal(err)
+	}
+	blockSave(t, dir)
+
+	if err := ring.Promote("next", time.Hour); err == nil {
+		t.Fatal("expected the save error")
+	}
+
+	assertActive(t, ring, "initial")
+	if _, ok := ring.Lookup("next"); !ok {
+		t.Error("prepared key was lost after the failed promotion")
+	}
+}
+
+func TestRotateRestoresKeyRingWhenSaveFails(t *testing.T) {
+	ring, dir := newTestKeyRingDir(t)
+	blockSave(t, dir)
+
+	if _, err := ring.Rotate("HS256", time.Hour); err == nil {
+		t.Fatal("expected the save error")
+	}
+
+	assertActive(t, ring, "initial")
+	if keys := ring.Keys(); len(keys) != 1 {
+		t.Errorf("rotated key was kept after the failed rotation: %d keys", len(keys))
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("rotated key file was kept after the failed rotation: %v", files)
+	}
+}
+
+func TestAddRemovesKeyWhenSaveFails(t *testing.T) {
+	ring, dir := newTestKeyRingDir(t)
+	blockSave(t, dir)
+
+	if err := ring.Add(NewHMACKey("next", "next-secret")); err == nil {
+		t.Fatal("expected the save error")
+	}
+
+	if _, ok := ring.Lookup("next"); ok {
+		t.Error("key was kept after the failed add")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "next.key")); !os.IsNotExist(err) {
+		t.Error("key file was kept after the failed add")
+	}
+}
+
+func TestPruneKeepsKeysWhenSaveFails(t *testing.T) {
+	ring, dir := newTestKeyRingDir(t)
+	if _, err := ring.Rotate("HS256", -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	blockSave(t, dir)
+
+	if _, err := ring.Prune(); err == nil {
+		t.Fatal("expected the save error")
+	}
+
+	// Las claves retiradas ya no se publican, pero siguen en el anillo para volver a intentarlo
+	if _, ok := ring.keys["initial"]; !ok {
+		t.Error("retired key was removed after the failed prune")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "initial.key")); err != nil {
+		t.Errorf("retired key file was deleted after the failed prune: %v", err)
+	}
+
+	// El manifiesto todavía referencia la clave, por lo que otra réplica puede cargarlo
+	if _, err := LoadKeyRingDir(dir); err != nil {
+		t.Fatalf("key ring cannot be loaded after the failed prune: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, manifestFile+".tmp")); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := ring.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != "initial" {
+		t.Errorf("expected the retired key to be pruned, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "initial.key")); !os.IsNotExist(err) {
+		t.Error("retired key file was kept after the prune")
+	}
+}
+
+func TestRotateMatchesManifestWithConcurrentReloads(t *testing.T) {
+	ring, _ := newTestKeyRingDir(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := ring.Rotate("HS256", time.Hour); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := ring.Reload(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// La clave activa en memoria es la que quedó en el manifiesto
+	active := ring.Active().ID
+	if err := ring.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	assertActive(t, ring, active)
+	if keys := ring.Keys(); len(keys) != 6 {
+		t.Errorf("expected every rotated key in the key ring, got %d keys", len(keys))
+	}
+}
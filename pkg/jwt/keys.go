@@ -0,0 +1,200 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey representa una clave de firma identificada por su kid
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}      // []byte para HMAC, crypto.Signer para claves asimétricas
+	PublicKey  crypto.PublicKey // nil para HMAC
+	NotAfter   time.Time        // cero si la clave no está retirada
+}
+
+// NewHMACKey crea una clave simétrica HS256 a partir de un secreto compartido
+func NewHMACKey(id, secret string) *SigningKey {
+	if id == "" {
+		id = "default"
+	}
+	return &SigningKey{
+		ID:         id,
+		Method:     jwt.SigningMethodHS256,
+		PrivateKey: []byte(secret),
+	}
+}
+
+// LoadSecretKeyFile carga un secreto HMAC desde un archivo de texto
+func LoadSecretKeyFile(id, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret key: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return nil, errors.New("secret key file is empty")
+	}
+	return NewHMACKey(id, secret), nil
+}
+
+// LoadPrivateKeyFile carga una clave privada RSA, ECDSA o Ed25519 desde un archivo PEM
+func LoadPrivateKeyFile(id, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	return ParsePrivateKeyPEM(id, data)
+}
+
+// ParsePrivateKeyPEM parsea una clave privada en formato PEM (PKCS#8, PKCS#1 o SEC1).
+// Si id está vacío se usa el thumbprint RFC 7638 de la clave pública como kid.
+func ParsePrivateKeyPEM(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	key := &SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PrivateKey = k
+		key.PublicKey = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		key.PrivateKey = k
+		key.PublicKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PrivateKey = k
+		key.PublicKey = k.Public()
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+
+	if key.ID == "" {
+		jwk, err := key.JWK()
+		if err != nil {
+			return nil, err
+		}
+		key.ID = jwk.Thumbprint()
+	}
+
+	return key, nil
+}
+
+// GenerateSigningKey genera una nueva clave para el algoritmo indicado (HS256, RS256, ES256, ES384, ES512 o EdDSA)
+func GenerateSigningKey(id, algorithm string) (*SigningKey, error) {
+	var private interface{}
+	var err error
+
+	switch algorithm {
+	case "HS256":
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if id == "" {
+			// Un secreto no tiene thumbprint publicable; se deriva un kid aleatorio
+			id = "hs256-" + hex.EncodeToString(secret[:4])
+		}
+		return NewHMACKey(id, hex.EncodeToString(secret)), nil
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		private, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKeyPEM(id, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// EncodePrivateKey serializa la clave: PEM PKCS#8 para claves asimétricas y el secreto en texto para HMAC
+func (k *SigningKey) EncodePrivateKey() ([]byte, error) {
+	if k.IsSymmetric() {
+		return append([]byte(nil), k.PrivateKey.([]byte)...), nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// IsRetired indica si la clave ya no sirve para verificar tokens
+func (k *SigningKey) IsRetired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && now.After(k.NotAfter)
+}
+
+// IsSymmetric indica si la clave es un secreto compartido (HMAC)
+func (k *SigningKey) IsSymmetric() bool {
+	return k.PublicKey == nil
+}
+
+// Algorithm retorna el nombre del algoritmo JWS de la clave
+func (k *SigningKey) Algorithm() string {
+	return k.Method.Alg()
+}
+
+// verificationKey retorna la clave usada para verificar firmas
+func (k *SigningKey) verificationKey() interface{} {
+	if k.IsSymmetric() {
+		return k.PrivateKey
+	}
+	return k.PublicKey
+}
@@ -5,70 +5,253 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Usos de token; evitan que un token emitido para un propósito se acepte en otro
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
 )
 
 // Claims representa los claims del JWT
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`             // rol principal
+	OrgID    string `json:"org_id,omitempty"` // organización activa
+	TokenUse string `json:"token_use,omitempty"`
+
+	// Cliente OAuth al que se emitió el token y scopes concedidos; vacíos en los tokens del login propio
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
+	// Roles efectivos y, si se embeben, sus permisos; sin permisos se resuelven en cada petición
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+
+	// Momento y métodos del login que originó el token (RFC 8176); ausentes en tokens anteriores
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Authentication retorna el login que originó el token
+func (c *Claims) Authentication() Authentication {
+	return newAuthentication(c.AuthTime, c.AMR)
+}
+
+// IsServiceAccount indica si el token se emitió a una cuenta de servicio, sin usuario
+func (c *Claims) IsServiceAccount() bool {
+	return c.UserID == "" && c.ClientID != ""
+}
+
 // RefreshClaims representa los claims del refresh token
 type RefreshClaims struct {
-	UserID string `json:"user_id"`
+	UserID   string `json:"user_id"`
+	OrgID    string `json:"org_id,omitempty"` // organización activa que hereda el próximo access token
+	TokenUse string `json:"token_use,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
+	// Login que originó la familia; se conserva al rotar para los access e ID tokens siguientes
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Authentication retorna el login que originó el token
+func (c *RefreshClaims) Authentication() Authentication {
+	return newAuthentication(c.AuthTime, c.AMR)
+}
+
+// IdentityClaims representa los claims estándar del usuario de OpenID Connect; se incluyen
+// según los scopes concedidos y los vacíos se omiten
+type IdentityClaims struct {
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+}
+
+// IDTokenClaims representa los claims de un ID token de OpenID Connect
+type IDTokenClaims struct {
+	Nonce    string           `json:"nonce,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	IdentityClaims
+	jwt.RegisteredClaims
+}
+
+// Authentication describe el login de un usuario: cuándo se autenticó y con qué métodos
+// (valores amr de RFC 8176, por ejemplo pwd, otp, mfa o hwk)
+type Authentication struct {
+	Time    time.Time
+	Methods []string
+}
+
+// newAuthentication arma un Authentication a partir de los claims auth_time y amr
+func newAuthentication(authTime *jwt.NumericDate, amr []string) Authentication {
+	auth := Authentication{Methods: amr}
+	if authTime != nil {
+		auth.Time = authTime.Time
+	}
+	return auth
+}
+
+// apply copia el login a los claims auth_time y amr; un login sin fecha no se incluye
+func (a Authentication) apply(authTime **jwt.NumericDate, amr *[]string) {
+	if a.Time.IsZero() {
+		return
+	}
+	*authTime = jwt.NewNumericDate(a.Time)
+	*amr = a.Methods
+}
+
+// ActionClaims representa los claims de un token de un solo propósito (verificación de email, etc.)
+type ActionClaims struct {
+	UserID   string `json:"user_id"`
+	TokenUse string `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
 // Service define las operaciones del servicio JWT
 type Service interface {
-	GenerateToken(userID, email, role string) (string, error)
-	GenerateRefreshToken(userID string) (string, error)
+	GenerateToken(userID, email, role, orgID string, roles, permissions []string, auth Authentication) (string, error)
+	GenerateRefreshToken(userID, orgID string, auth Authentication) (string, error)
+	GenerateClientToken(userID, email, orgID, clientID, scope string, permissions []string, auth Authentication) (string, error)
+	GenerateClientRefreshToken(userID, orgID, clientID, scope string, auth Authentication) (string, error)
+	GenerateIDToken(issuer, userID, clientID, nonce string, identity IdentityClaims, auth Authentication) (string, error)
+	GenerateServiceToken(clientID, scope string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	ValidateRefreshToken(tokenString string) (*RefreshClaims, error)
+	GenerateActionToken(userID, purpose string, expiration time.Duration) (string, error)
+	ValidateActionToken(tokenString, purpose string) (*ActionClaims, error)
+	JWKS() *JWKS
+	SigningAlgorithm() string
 }
 
 // service implementa el servicio JWT
 type service struct {
-	secretKey         string
+	keyRing           *KeyRing
 	tokenExpiration   time.Duration
 	refreshExpiration time.Duration
 }
 
 // NewService crea una nueva instancia del servicio JWT
-func NewService(secretKey string, tokenExpiration, refreshExpiration time.Duration) Service {
+func NewService(keyRing *KeyRing, tokenExpiration, refreshExpiration time.Duration) Service {
 	return &service{
-		secretKey:         secretKey,
+		keyRing:           keyRing,
 		tokenExpiration:   tokenExpiration,
 		refreshExpiration: refreshExpiration,
 	}
 }
 
-// GenerateToken genera un token JWT de acceso
-func (s *service) GenerateToken(userID, email, role string) (string, error) {
+// GenerateToken genera un token JWT de acceso; orgID vacío si no hay organización activa
+func (s *service) GenerateToken(userID, email, role, orgID string, roles, permissions []string, auth Authentication) (string, error) {
+	return s.sign(s.accessClaims(userID, email, role, orgID, roles, permissions, auth))
+}
+
+// GenerateRefreshToken genera un token JWT de refresh
+func (s *service) GenerateRefreshToken(userID, orgID string, auth Authentication) (string, error) {
+	return s.sign(s.refreshClaims(userID, orgID, auth))
+}
+
+// GenerateClientToken genera el access token de un usuario para un cliente OAuth; el cliente
+// es la audiencia del token. No lleva los roles del usuario: el cliente solo actúa con los
+// permisos que le conceden sus scopes.
+func (s *service) GenerateClientToken(userID, email, orgID, clientID, scope string, permissions []string, auth Authentication) (string, error) {
+	claims := s.accessClaims(userID, email, "", orgID, nil, permissions, auth)
+	claims.ClientID = clientID
+	claims.Scope = scope
+	claims.Audience = jwt.ClaimStrings{clientID}
+
+	return s.sign(claims)
+}
+
+// GenerateClientRefreshToken genera el refresh token de un usuario para un cliente OAuth
+func (s *service) GenerateClientRefreshToken(userID, orgID, clientID, scope string, auth Authentication) (string, error) {
+	claims := s.refreshClaims(userID, orgID, auth)
+	claims.ClientID = clientID
+	claims.Scope = scope
+	claims.Audience = jwt.ClaimStrings{clientID}
+
+	return s.sign(claims)
+}
+
+// GenerateServiceToken genera el access token de una cuenta de servicio (grant client_credentials).
+// No tiene usuario: sub es el client_id y los permisos son los scopes concedidos.
+func (s *service) GenerateServiceToken(clientID, scope string) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		TokenUse: TokenUseAccess,
+		ClientID: clientID,
+		Scope:    scope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "auth-service",
+			Subject:   clientID,
+		},
+	}
+
+	return s.sign(claims)
+}
+
+// GenerateIDToken genera el ID token de OpenID Connect de un usuario para un cliente, con la
+// vigencia de los access tokens. issuer es la URL pública del servicio publicada en el discovery.
+func (s *service) GenerateIDToken(issuer, userID, clientID, nonce string, identity IdentityClaims, auth Authentication) (string, error) {
+	claims := &IDTokenClaims{
+		Nonce:          nonce,
+		IdentityClaims: identity,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    issuer,
 			Subject:   userID,
+			Audience:  jwt.ClaimStrings{clientID},
 		},
 	}
+	auth.apply(&claims.AuthTime, &claims.AMR)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	return s.sign(claims)
 }
 
-// GenerateRefreshToken genera un token JWT de refresh
-func (s *service) GenerateRefreshToken(userID string) (string, error) {
+// accessClaims arma los claims de un access token
+func (s *service) accessClaims(userID, email, role, orgID string, roles, permissions []string, auth Authentication) *Claims {
+	claims := &Claims{
+		UserID:      userID,
+		Email:       email,
+		Role:        role,
+		OrgID:       orgID,
+		TokenUse:    TokenUseAccess,
+		Roles:       roles,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // jti usado por la lista de revocación
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+			Subject:   userID,
+		},
+	}
+	auth.apply(&claims.AuthTime, &claims.AMR)
+	return claims
+}
+
+// refreshClaims arma los claims de un refresh token
+func (s *service) refreshClaims(userID, orgID string, auth Authentication) *RefreshClaims {
 	claims := &RefreshClaims{
-		UserID: userID,
+		UserID:   userID,
+		OrgID:    orgID,
+		TokenUse: TokenUseRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // evita colisiones al rotar dentro del mismo segundo
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -76,25 +259,22 @@ func (s *service) GenerateRefreshToken(userID string) (string, error) {
 			Subject:   userID,
 		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	auth.apply(&claims.AuthTime, &claims.AMR)
+	return claims
 }
 
 // ValidateToken valida un token JWT de acceso
 func (s *service) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(s.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	// Los tokens sin token_use son anteriores a su incorporación; un access token identifica a un
+	// usuario o a una cuenta de servicio, lo que también descarta los ID tokens
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && (claims.TokenUse == "" || claims.TokenUse == TokenUseAccess) &&
+		(claims.UserID != "" || claims.ClientID != "") {
 		return claims, nil
 	}
 
@@ -103,20 +283,105 @@ func (s *service) ValidateToken(tokenString string) (*Claims, error) {
 
 // ValidateRefreshToken valida un token JWT de refresh
 func (s *service) ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(s.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, s.keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid && (claims.TokenUse == "" || claims.TokenUse == TokenUseRefresh) {
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid refresh token")
 }
+
+// GenerateActionToken genera un token firmado para un propósito específico
+func (s *service) GenerateActionToken(userID, purpose string, expiration time.Duration) (string, error) {
+	if purpose == "" || purpose == TokenUseAccess || purpose == TokenUseRefresh {
+		return "", errors.New("invalid token purpose")
+	}
+
+	claims := &ActionClaims{
+		UserID:   userID,
+		TokenUse: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+			Subject:   userID,
+		},
+	}
+
+	return s.sign(claims)
+}
+
+// ValidateActionToken valida un token de propósito específico
+func (s *service) ValidateActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, s.keyFunc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*ActionClaims); ok && token.Valid && claims.TokenUse == purpose {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// JWKS retorna el conjunto de claves públicas de verificación
+func (s *service) JWKS() *JWKS {
+	set := &JWKS{Keys: []JWK{}}
+	for _, key := range s.keyRing.Keys() {
+		if jwk, err := key.JWK(); err == nil {
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return set
+}
+
+// SigningAlgorithm retorna el algoritmo de la clave activa, con el que se firman los tokens nuevos
+func (s *service) SigningAlgorithm() string {
+	return s.keyRing.Active().Algorithm()
+}
+
+// sign firma los claims con la clave activa y agrega el header kid
+func (s *service) sign(claims jwt.Claims) (string, error) {
+	key := s.keyRing.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// keyFunc selecciona la clave de verificación según el kid del token
+func (s *service) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		// Tokens emitidos antes de incorporar kid: se prueban todas las claves vigentes del mismo algoritmo
+		set := jwt.VerificationKeySet{}
+		for _, key := range s.keyRing.Keys() {
+			if key.Algorithm() == token.Method.Alg() {
+				set.Keys = append(set.Keys, key.verificationKey())
+			}
+		}
+		if len(set.Keys) == 0 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return set, nil
+	}
+
+	key, ok := s.keyRing.Lookup(kid)
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if token.Method.Alg() != key.Algorithm() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.verificationKey(), nil
+}
@@ -19,6 +19,7 @@ type Service interface {
 	ValidateToken(tokenString string) (*KeycloakClaims, error)
 	GetUserInfo(tokenString string) (*UserInfo, error)
 	GetUserByID(userID string) (*UserInfo, error)
+	GetUserByEmail(email string) (*UserInfo, error)
 	Login(username, password string) (string, error)
 	CreateUser(user *CreateUserRequest) error
 	UpdateUser(userID string, user *UpdateUserRequest) error
@@ -27,8 +28,14 @@ type Service interface {
 	GetUserGroups(userID string) ([]*Group, error)
 	AddUserToGroup(userID, groupID string) error
 	RemoveUserFromGroup(userID, groupID string) error
+	ExecuteActionsEmail(userID string, actions []string, lifespan time.Duration) error
+	IntrospectToken(token, tokenTypeHint, clientID, clientSecret string) (*KeycloakClaims, error)
+	RevokeToken(token, tokenTypeHint, clientID, clientSecret string) error
 }
 
+// ErrClientAuthentication indica que Keycloak rechazó las credenciales del cliente
+var ErrClientAuthentication = errors.New("client authentication failed")
+
 // service implementa el servicio de Keycloak
 type service struct {
 	baseURL      string
@@ -193,6 +200,83 @@ func (s *service) ValidateToken(tokenString string) (*KeycloakClaims, error) {
 	return &claims, nil
 }
 
+// IntrospectToken consulta a Keycloak si un access o refresh token está activo (RFC 7662), con las
+// credenciales del cliente que pregunta. Un token inactivo retorna claims con Active en false.
+func (s *service) IntrospectToken(token, tokenTypeHint, clientID, clientSecret string) (*KeycloakClaims, error) {
+	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token/introspect", s.baseURL, s.realm)
+
+	resp, err := s.postClientForm(url, token, tokenTypeHint, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrClientAuthentication
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error introspecting token: %d", resp.StatusCode)
+	}
+
+	var claims KeycloakClaims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// RevokeToken revoca en Keycloak un access o refresh token del cliente (RFC 7009)
+func (s *service) RevokeToken(token, tokenTypeHint, clientID, clientSecret string) error {
+	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/revoke", s.baseURL, s.realm)
+
+	resp, err := s.postClientForm(url, token, tokenTypeHint, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrClientAuthentication
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errorResponse) == nil &&
+			(errorResponse.Error == "invalid_client" || errorResponse.Error == "unauthorized_client") {
+			return ErrClientAuthentication
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error revoking token: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// postClientForm envía un token con las credenciales del cliente a un endpoint de Keycloak
+func (s *service) postClientForm(url, token, tokenTypeHint, clientID, clientSecret string) (*http.Response, error) {
+	data := url2.Values{}
+	data.Set("token", token)
+	if tokenTypeHint != "" {
+		data.Set("token_type_hint", tokenTypeHint)
+	}
+	data.Set("client_id", clientID)
+	if clientSecret != "" {
+		data.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return s.httpClient.Do(req)
+}
+
 // GetUserInfo obtiene información del usuario desde el token
 func (s *service) GetUserInfo(tokenString string) (*UserInfo, error) {
 	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/userinfo", s.baseURL, s.realm)
@@ -256,6 +340,47 @@ func (s *service) GetUserByID(userID string) (*UserInfo, error) {
 	return &userInfo, nil
 }
 
+// GetUserByEmail obtiene un usuario por su email exacto
+func (s *service) GetUserByEmail(email string) (*UserInfo, error) {
+	accessToken, err := s.getAdminToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url2.Values{}
+	query.Set("email", email)
+	query.Set("exact", "true")
+	url := fmt.Sprintf("%s/admin/realms/%s/users?%s", s.baseURL, s.realm, query.Encode())
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error getting user: %d", resp.StatusCode)
+	}
+
+	var users []*UserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, errors.New("user not found")
+	}
+
+	return users[0], nil
+}
+
 // CreateUser crea un nuevo usuario en Keycloak
 func (s *service) CreateUser(user *CreateUserRequest) error {
 	accessToken, err := s.getAdminToken()
@@ -481,6 +606,43 @@ func (s *service) RemoveUserFromGroup(userID, groupID string) error {
 	return nil
 }
 
+// ExecuteActionsEmail envía al usuario el email de Keycloak con las acciones requeridas
+// (por ejemplo UPDATE_PASSWORD); el enlace vence después de lifespan
+func (s *service) ExecuteActionsEmail(userID string, actions []string, lifespan time.Duration) error {
+	accessToken, err := s.getAdminToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/admin/realms/%s/users/%s/execute-actions-email?lifespan=%d",
+		s.baseURL, s.realm, userID, int(lifespan.Seconds()))
+
+	actionsData, err := json.Marshal(actions)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", url, strings.NewReader(string(actionsData)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("error executing actions email: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // getPublicKey obtiene la clave pública de Keycloak
 func (s *service) getPublicKey() (interface{}, error) {
 	if s.publicKey != nil && time.Now().Before(s.publicKeyExpiry) {
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"auth-go-microservicio/pkg/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContext guarda la IP y el user agent de la petición en su contexto para que los use
+// cases los registren en el log de auditoría. Authenticate agrega el usuario autenticado.
+func AuditContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := audit.WithRequest(c.Request.Context(), audit.Request{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
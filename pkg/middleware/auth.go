@@ -1,34 +1,85 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"auth-go-microservicio/pkg/audit"
 	"auth-go-microservicio/pkg/jwt"
 	"auth-go-microservicio/pkg/keycloak"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RevocationChecker verifica si un access token local fue revocado antes de expirar
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *jwt.Claims) (bool, error)
+}
+
+// PermissionResolver resuelve los permisos efectivos cuando el access token no los incluye
+type PermissionResolver interface {
+	UserPermissions(ctx context.Context, userID string) ([]string, error)
+	RolePermissions(ctx context.Context, roles []string) ([]string, error)
+}
+
+// OrganizationResolver resuelve la membresía del usuario en una organización y sus permisos en ella
+type OrganizationResolver interface {
+	MemberPermissions(ctx context.Context, organizationID, userID string) ([]string, bool, error)
+}
+
+// APIKeyAuthenticator valida las API keys personales y retorna los claims del usuario, con el ID
+// de la clave y, si la clave tiene scopes, los permisos a los que se limita
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, error)
+}
+
 // AuthMiddleware middleware para autenticación
 type AuthMiddleware struct {
-	jwtService      jwt.Service
-	keycloakService keycloak.Service
-	useKeycloak     bool
+	jwtService           jwt.Service
+	revocationChecker    RevocationChecker
+	permissionResolver   PermissionResolver
+	organizationResolver OrganizationResolver
+	apiKeyAuthenticator  APIKeyAuthenticator
+	keycloakService      keycloak.Service
+	useKeycloak          bool
 }
 
 // NewAuthMiddleware crea una nueva instancia del middleware de autenticación
-func NewAuthMiddleware(jwtService jwt.Service, keycloakService keycloak.Service, useKeycloak bool) *AuthMiddleware {
+func NewAuthMiddleware(
+	jwtService jwt.Service,
+	revocationChecker RevocationChecker,
+	permissionResolver PermissionResolver,
+	organizationResolver OrganizationResolver,
+	apiKeyAuthenticator APIKeyAuthenticator,
+	keycloakService keycloak.Service,
+	useKeycloak bool,
+) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService:      jwtService,
-		keycloakService: keycloakService,
-		useKeycloak:     useKeycloak,
+		jwtService:           jwtService,
+		revocationChecker:    revocationChecker,
+		permissionResolver:   permissionResolver,
+		organizationResolver: organizationResolver,
+		apiKeyAuthenticator:  apiKeyAuthenticator,
+		keycloakService:      keycloakService,
+		useKeycloak:          useKeycloak,
 	}
 }
 
-// Authenticate middleware para verificar autenticación
+// Authenticate middleware para verificar autenticación. Acepta access tokens y API keys personales
+// ("Authorization: ApiKey <key>" o el header X-API-Key).
 func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			if !m.authenticateAPIKey(c, key) {
+				c.Abort()
+				return
+			}
+			c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), c.GetString("user_id")))
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
@@ -92,6 +143,7 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 				}
 			}
 			c.Set("role", role)
+			c.Set("roles", claims.RealmAccess.Roles)
 
 		} else {
 			// Usar autenticación JWT local
@@ -102,17 +154,130 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 				return
 			}
 
-			// Agregar claims al contexto
-			c.Set("user_id", claims.UserID)
-			c.Set("email", claims.Email)
-			c.Set("role", claims.Role)
+			// Verificar la lista de revocación (logout, cambio de contraseña, desactivación)
+			if m.revocationChecker != nil {
+				revoked, err := m.revocationChecker.IsRevoked(c.Request.Context(), claims)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking token revocation"})
+					c.Abort()
+					return
+				}
+				if revoked {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+					c.Abort()
+					return
+				}
+			}
+
+			c.Set("token_claims", claims)
+			if claims.ClientID != "" {
+				c.Set("client_id", claims.ClientID)
+				c.Set("scope", claims.Scope)
+			}
+
+			if claims.IsServiceAccount() {
+				// Las cuentas de servicio no tienen usuario: sus scopes son sus permisos
+				c.Set("principal_type", "service_account")
+				c.Set("permissions", strings.Fields(claims.Scope))
+			} else {
+				// Agregar claims al contexto
+				c.Set("principal_type", "user")
+				c.Set("user_id", claims.UserID)
+				c.Set("email", claims.Email)
+				c.Set("org_id", claims.OrgID)
+				// Los tokens emitidos a clientes OAuth no actúan con los roles del usuario
+				if claims.ClientID == "" {
+					c.Set("role", claims.Role)
+					c.Set("roles", claims.Roles)
+				}
+				if len(claims.Permissions) > 0 {
+					c.Set("permissions", delegatedPermissions(c, claims.Permissions))
+				}
+			}
+		}
+
+		// Identificar al actor de las operaciones auditadas de esta petición
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), c.GetString("user_id")))
+
+		c.Next()
+	}
+}
+
+// authenticateAPIKey valida la API key y agrega al contexto los mismos datos del usuario que un
+// access token, además de api_key_id
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, key string) bool {
+	if m.apiKeyAuthenticator == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "api keys are not supported"})
+		return false
+	}
+
+	claims, err := m.apiKeyAuthenticator.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		return false
+	}
+
+	c.Set("principal_type", "user")
+	c.Set("api_key_id", claims.ID)
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("roles", claims.Roles)
+	if claims.Permissions != nil {
+		c.Set("permissions", claims.Permissions)
+		c.Set("scope", claims.Scope)
+	}
+	return true
+}
+
+// apiKeyFromRequest obtiene la API key del header Authorization con el esquema ApiKey o del header X-API-Key
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if scheme, key, found := strings.Cut(c.GetHeader("Authorization"), " "); found && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+// RequireFirstParty middleware que solo acepta la sesión propia del usuario: rechaza las API keys
+// y los access tokens emitidos a clientes OAuth, en nombre del usuario o a cuentas de servicio.
+// Protege la administración de la cuenta y el consentimiento OAuth, donde los scopes de una
+// credencial no limitan lo que se puede hacer.
+func (m *AuthMiddleware) RequireFirstParty() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("api_key_id") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api keys cannot access this resource"})
+			c.Abort()
+			return
+		}
+		if c.GetString("client_id") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "tokens issued to oauth clients cannot access this resource"})
+			c.Abort()
+			return
 		}
 
 		c.Next()
 	}
 }
 
-// RequireRole middleware para verificar roles específicos
+// RejectDelegated middleware que rechaza los access tokens emitidos a clientes OAuth en nombre del
+// usuario. Protege las rutas de administración; las API keys y las cuentas de servicio las usan
+// limitadas por sus scopes en RequirePermission.
+func (m *AuthMiddleware) RejectDelegated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isDelegated(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "tokens issued to oauth clients cannot access this resource"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole middleware para verificar roles específicos; acepta el rol principal o cualquiera de los asignados
 func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
@@ -129,10 +294,12 @@ func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		// Verificar si el rol del usuario está en la lista de roles permitidos
+		userRoles := append([]string{roleStr}, c.GetStringSlice("roles")...)
+
+		// Verificar si alguno de los roles del usuario está en la lista de roles permitidos
 		hasRole := false
 		for _, role := range roles {
-			if roleStr == role {
+			if containsString(userRoles, role) {
 				hasRole = true
 				break
 			}
@@ -148,6 +315,151 @@ func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission middleware para verificar que el usuario tenga todos los permisos indicados.
+// Usa los permisos del access token o, si no los incluye, los resuelve con el PermissionResolver.
+func (m *AuthMiddleware) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, err := m.userPermissions(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error resolving permissions"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !containsString(granted, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions", "required": permission})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOrgMember middleware para verificar que el usuario pertenezca a la organización del
+// parámetro :org_id. Guarda en el contexto los permisos del rol de su membresía; los usuarios
+// con el permiso global orgs:write acceden a cualquier organización con sus permisos globales.
+func (m *AuthMiddleware) RequireOrgMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := c.Param("org_id")
+
+		// Las cuentas de servicio no son miembros de ninguna organización
+		var permissions []string
+		isMember := false
+		if m.organizationResolver != nil && c.GetString("user_id") != "" {
+			var err error
+			permissions, isMember, err = m.organizationResolver.MemberPermissions(c.Request.Context(), organizationID, c.GetString("user_id"))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error resolving organization membership"})
+				c.Abort()
+				return
+			}
+			permissions = delegatedPermissions(c, permissions)
+		}
+
+		if !isMember {
+			granted, err := m.userPermissions(c)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error resolving permissions"})
+				c.Abort()
+				return
+			}
+			if !containsString(granted, "orgs:write") {
+				c.JSON(http.StatusForbidden, gin.H{"error": "not a member of the organization"})
+				c.Abort()
+				return
+			}
+			permissions = granted
+		}
+
+		c.Set("org_permissions", permissions)
+		c.Next()
+	}
+}
+
+// RequireOrgPermission middleware para verificar que el usuario tenga todos los permisos indicados
+// dentro de la organización. Debe usarse después de RequireOrgMember.
+func (m *AuthMiddleware) RequireOrgPermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, exists := c.Get("org_permissions")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a member of the organization"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !containsString(granted.([]string), permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions", "required": permission})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// userPermissions obtiene los permisos del usuario autenticado y los guarda en el contexto
+func (m *AuthMiddleware) userPermissions(c *gin.Context) ([]string, error) {
+	if permissions, exists := c.Get("permissions"); exists {
+		return permissions.([]string), nil
+	}
+	if m.permissionResolver == nil {
+		return nil, nil
+	}
+
+	var permissions []string
+	var err error
+	if m.useKeycloak {
+		permissions, err = m.permissionResolver.RolePermissions(c.Request.Context(), c.GetStringSlice("roles"))
+	} else {
+		permissions, err = m.permissionResolver.UserPermissions(c.Request.Context(), c.GetString("user_id"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	permissions = delegatedPermissions(c, permissions)
+	c.Set("permissions", permissions)
+	return permissions, nil
+}
+
+// isDelegated indica si la petición se autenticó con un access token emitido a un cliente OAuth
+// en nombre del usuario
+func isDelegated(c *gin.Context) bool {
+	return c.GetString("client_id") != "" && c.GetString("principal_type") == "user"
+}
+
+// delegatedPermissions limita los permisos de un token emitido a un cliente OAuth a los que se le
+// concedieron como scopes; con los demás tokens retorna los permisos sin cambios
+func delegatedPermissions(c *gin.Context, permissions []string) []string {
+	if !isDelegated(c) {
+		return permissions
+	}
+
+	scopes := strings.Fields(c.GetString("scope"))
+	scoped := []string{}
+	for _, permission := range permissions {
+		if containsString(scopes, permission) {
+			scoped = append(scoped, permission)
+		}
+	}
+	return scoped
+}
+
+// containsString indica si el valor está en la lista
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // RequireAdmin middleware para verificar que el usuario sea administrador
 func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return m.RequireRole("admin")
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth-go-microservicio/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+// fakeAPIKeys autentica las claves registradas
+type fakeAPIKeys map[string]*jwt.Claims
+
+func (k fakeAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, error) {
+	claims, ok := k[key]
+	if !ok {
+		return nil, errors.New("invalid api key")
+	}
+	return claims, nil
+}
+
+// fakePermissions resuelve los mismos permisos para cualquier usuario
+type fakePermissions []string
+
+func (p fakePermissions) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	return p, nil
+}
+
+func (p fakePermissions) RolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	return p, nil
+}
+
+// adminPermissions son los permisos del usuario de las pruebas
+var adminPermissions = fakePermissions{"users:read", "users:delete"}
+
+// newTestRouter arma las rutas de usuario y de administración como routes.SetupRoutes
+func newTestRouter(t *testing.T, keys fakeAPIKeys) (*gin.Engine, jwt.Service) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	ring, err := jwt.NewKeyRing(jwt.NewHMACKey("test", "test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtSvc := jwt.NewService(ring, 15*time.Minute, 24*time.Hour)
+	m := NewAuthMiddleware(jwtSvc, nil, adminPermissions, nil, keys, nil, false)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	users := router.Group("/users", m.Authenticate(), m.RequireFirstParty())
+	users.DELETE("/profile", ok)
+	admin := router.Group("/admin", m.Authenticate(), m.RejectDelegated())
+	admin.GET("/users", m.RequirePermission("users:read"), ok)
+	admin.DELETE("/users/:id", m.RequirePermission("users:delete"), ok)
+
+	return router, jwtSvc
+}
+
+// do ejecuta la petición con el header Authorization indicado y retorna el código de respuesta
+func do(router *gin.Engine, method, path, authorization string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", authorization)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// scopedKey es una API key limitada a users:read
+var scopedKey = &jwt.Claims{
+	UserID:           "550e8400-e29b-41d4-a716-446655440000",
+	Role:             "admin",
+	Scope:            "users:read",
+	Permissions:      []string{"users:read"},
+	RegisteredClaims: jwtlib.RegisteredClaims{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"},
+}
+
+func TestAPIKeyIsLimitedToItsScopes(t *testing.T) {
+	router, _ := newTestRouter(t, fakeAPIKeys{"ak_read": scopedKey})
+
+	if code := do(router, http.MethodGet, "/admin/users", "ApiKey ak_read"); code != http.StatusNoContent {
+		t.Errorf("scoped permission was rejected: %d", code)
+	}
+	if code := do(router, http.MethodDelete, "/admin/users/1", "ApiKey ak_read"); code != http.StatusForbidden {
+		t.Errorf("permission outside the key scopes was allowed: %d", code)
+	}
+}
+
+func TestAPIKeyCannotManageTheAccount(t *testing.T) {
+	router, jwtSvc := newTestRouter(t, fakeAPIKeys{"ak_read": scopedKey})
+
+	if code := do(router, http.MethodDelete, "/users/profile", "ApiKey ak_read"); code != http.StatusForbidden {
+		t.Errorf("api key managed the account: %d", code)
+	}
+
+	// La sesión propia del usuario sí puede
+	token, err := jwtSvc.GenerateToken(scopedKey.UserID, "ana@example.com", "admin", "", nil, nil, jwt.Authentication{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := do(router, http.MethodDelete, "/users/profile", "Bearer "+token); code != http.StatusNoContent {
+		t.Errorf("user session was rejected: %d", code)
+	}
+}
+
+func TestDelegatedTokenIsRejectedOnAccountAndAdminRoutes(t *testing.T) {
+	router, jwtSvc := newTestRouter(t, nil)
+
+	token, err := jwtSvc.GenerateClientToken(scopedKey.UserID, "ana@example.com", "", "client", "users:read", nil, jwt.Authentication{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/users/profile", "/admin/users/1"} {
+		if code := do(router, http.MethodDelete, path, "Bearer "+token); code != http.StatusForbidden {
+			t.Errorf("%s: token issued to an oauth client was allowed: %d", path, code)
+		}
+	}
+}
+
+func TestUnknownAPIKeyIsRejected(t *testing.T) {
+	router, _ := newTestRouter(t, fakeAPIKeys{"ak_read": scopedKey})
+
+	for _, header := range []string{"ApiKey ak_unknown", "ApiKey "} {
+		if code := do(router, http.MethodGet, "/admin/users", header); code != http.StatusUnauthorized {
+			t.Errorf("%q: expected 401, got %d", header, code)
+		}
+	}
+}
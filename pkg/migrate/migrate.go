@@ -0,0 +1,427 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockID identifica el advisory lock que serializa las migraciones entre réplicas
+const lockID int64 = 7_349_120_551
+
+// fileNamePattern reconoce NNN_nombre.up.sql y NNN_nombre.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	// ErrChecksumMismatch indica que una migración aplicada fue modificada después de aplicarse
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	// ErrNoDownScript indica que una migración no se puede revertir
+	ErrNoDownScript = errors.New("migration has no down script")
+	// ErrUnknownVersion indica que la versión no corresponde a ninguna migración
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// Migration representa una versión del esquema
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // vacío si la migración no se puede revertir
+	Checksum string // SHA-256 del script up
+}
+
+// Status representa el estado de una versión en la base de datos
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Modified  bool       `json:"modified"` // el script cambió después de aplicarse
+	Missing   bool       `json:"missing"`  // aplicada pero sin script en el binario
+}
+
+// appliedMigration representa una fila de schema_migrations
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator aplica y revierte migraciones registrando las versiones aplicadas en
+// schema_migrations. Cada migración corre en su propia transacción y un advisory lock de
+// Postgres impide que dos réplicas migren a la vez.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+// New crea un Migrator con las migraciones de la raíz de fsys
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load lee las migraciones de la raíz de fsys ordenadas por versión
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has scripts with different names", version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			migration.Checksum = checksum(migration.Up)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up script", migration.Version)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up aplica todas las migraciones pendientes y retorna cuántas aplicó. Nunca revierte: las
+// versiones aplicadas que este binario no conoce (por ejemplo, tras volver a una versión
+// anterior del servicio) se conservan.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.migrate(ctx, m.latest(), false)
+}
+
+// Down revierte las últimas steps migraciones aplicadas y retorna cuántas revirtió. Como Up,
+// no revierte nada si alguna migración aplicada fue modificada.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for i := len(applied) - 1; i >= 0 && count < steps; i-- {
+			if err := m.revert(ctx, conn, applied[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To aplica o revierte migraciones hasta que version sea la última aplicada; 0 revierte todas.
+// Retorna cuántas migraciones aplicó o revirtió.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.migrate(ctx, version, true)
+}
+
+// migrate aplica las pendientes hasta version y, si revert es true, revierte las posteriores
+func (m *Migrator) migrate(ctx context.Context, version int64, revert bool) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		// Primero se revierten, de la más nueva a la más antigua, las posteriores a version
+		for i := len(applied) - 1; i >= 0 && revert; i-- {
+			if applied[i].Version <= version {
+				continue
+			}
+			if err := m.revert(ctx, conn, applied[i]); err != nil {
+				return err
+			}
+			count++
+		}
+
+		// Después se aplican en orden las pendientes hasta version, incluidas las de versiones
+		// intermedias que se agregaron tarde
+		isApplied := make(map[int64]bool, len(applied))
+		for _, a := range applied {
+			isApplied[a.Version] = true
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version || isApplied[migration.Version] {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Baseline registra como aplicadas, sin ejecutarlas, las migraciones hasta version. Sirve para
+// adoptar bases creadas antes de que el servicio aplicara sus propias migraciones.
+func (m *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	if m.find(version) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	var count int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			result, err := conn.ExecContext(ctx, `
+				INSERT INTO schema_migrations (version, name, checksum)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (version) DO NOTHING
+			`, migration.Version, migration.Name, migration.Checksum)
+			if err != nil {
+				return err
+			}
+			if rows, _ := result.RowsAffected(); rows > 0 {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status retorna el estado de cada migración conocida o aplicada, ordenado por versión
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	var statuses []*Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		byVersion := make(map[int64]*Status)
+		for _, migration := range m.migrations {
+			status := &Status{Version: migration.Version, Name: migration.Name}
+			byVersion[migration.Version] = status
+			statuses = append(statuses, status)
+		}
+		for _, a := range applied {
+			appliedAt := a.AppliedAt
+			status, ok := byVersion[a.Version]
+			if !ok {
+				status = &Status{Version: a.Version, Name: a.Name, Missing: true}
+				statuses = append(statuses, status)
+			} else {
+				status.Modified = m.find(a.Version).Checksum != a.Checksum
+			}
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// withLock ejecuta fn en una conexión dedicada que mantiene el advisory lock de migraciones
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Bloquea hasta que la réplica que está migrando termine
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// applied obtiene las migraciones aplicadas ordenadas por versión
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]*appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version, name, checksum, applied_at
+		FROM schema_migrations
+		ORDER BY version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []*appliedMigration
+	for rows.Next() {
+		a := &appliedMigration{}
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// verify comprueba que las migraciones aplicadas no cambiaron desde que se aplicaron
+func (m *Migrator) verify(applied []*appliedMigration) error {
+	for _, a := range applied {
+		migration := m.find(a.Version)
+		if migration != nil && migration.Checksum != a.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, a.Version, a.Name)
+		}
+	}
+	return nil
+}
+
+// apply ejecuta el script up y registra la versión en la misma transacción
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	err := inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, name, checksum)
+			VALUES ($1, $2, $3)
+		`, migration.Version, migration.Name, migration.Checksum)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// revert ejecuta el script down y elimina el registro de la versión en la misma transacción
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, applied *appliedMigration) error {
+	migration := m.find(applied.Version)
+	if migration == nil || strings.TrimSpace(migration.Down) == "" {
+		return fmt.Errorf("%w: %d_%s", ErrNoDownScript, applied.Version, applied.Name)
+	}
+
+	err := inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	log.Printf("Reverted migration %d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// find obtiene la migración de una versión o nil si no existe
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// latest retorna la versión más reciente o 0 si no hay migraciones
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// inTx ejecuta fn en una transacción sobre la conexión que mantiene el lock
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checksum calcula el SHA-256 del script ignorando la diferencia entre finales de línea
+func checksum(script string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(script, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeDriver es un driver de database/sql que registra las sentencias ejecutadas y responde
+// a la consulta de schema_migrations con las versiones de applied, sin base de datos
+type fakeDriver struct {
+	mu      sync.Mutex
+	execs   []string
+	applied [][]driver.Value // version, name, checksum, applied_at
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+func (d *fakeDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+func (d *fakeDriver) Driver() driver.Driver { return d }
+
+// executed indica si se ejecutó alguna sentencia que contiene el fragmento
+func (d *fakeDriver) executed(fragment string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, query := range d.execs {
+		if strings.Contains(query, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.execs = append(c.driver.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	return &fakeRows{rows: c.driver.applied}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"version", "name", "checksum", "applied_at"}
+}
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// testMigrations contiene dos migraciones reversibles
+var testMigrations = fstest.MapFS{
+	"001_create_users.up.sql":      {Data: []byte("CREATE TABLE users ();")},
+	"001_create_users.down.sql":    {Data: []byte("DROP TABLE users;")},
+	"002_create_sessions.up.sql":   {Data: []byte("CREATE TABLE sessions ();")},
+	"002_create_sessions.down.sql": {Data: []byte("DROP TABLE sessions;")},
+}
+
+// newTestMigrator crea un Migrator sobre el driver de prueba con las versiones indicadas aplicadas
+func newTestMigrator(t *testing.T, applied ...[]driver.Value) (*Migrator, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{applied: applied}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, d
+}
+
+// appliedRow representa una fila de schema_migrations de la migración con el checksum indicado
+func appliedRow(version int64, name, checksum string) []driver.Value {
+	return []driver.Value{version, name, checksum, time.Now()}
+}
+
+func TestDownRevertsLatestMigration(t *testing.T) {
+	m, d := newTestMigrator(t,
+		appliedRow(1, "create_users", checksum("CREATE TABLE users ();")),
+		appliedRow(2, "create_sessions", checksum("CREATE TABLE sessions ();")),
+	)
+
+	count, err := m.Down(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 || !d.executed("DROP TABLE sessions") {
+		t.Errorf("latest migration was not reverted: %d reverted", count)
+	}
+	if d.executed("DROP TABLE users") {
+		t.Error("more migrations than requested were reverted")
+	}
+}
+
+func TestDownRejectsModifiedMigrations(t *testing.T) {
+	m, d := newTestMigrator(t,
+		appliedRow(1, "create_users", checksum("CREATE TABLE users (id UUID);")),
+		appliedRow(2, "create_sessions", checksum("CREATE TABLE sessions ();")),
+	)
+
+	count, err := m.Down(context.Background(), 1)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+
+	if count != 0 || d.executed("DROP TABLE") {
+		t.Error("a migration was reverted despite the checksum mismatch")
+	}
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2KeyLength es el largo en bytes del hash argon2id
+const argon2KeyLength = 32
+
+// argon2idHasher genera hashes argon2id en formato PHC ($argon2id$v=19$m=...,t=...,p=...$sal$hash)
+type argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// newArgon2idHasher crea un hasher argon2id con los parámetros indicados
+func newArgon2idHasher(memory, iterations uint32, parallelism uint8) *argon2idHasher {
+	defaults := DefaultConfig()
+	if memory == 0 {
+		memory = defaults.Argon2Memory
+	}
+	if iterations == 0 {
+		iterations = defaults.Argon2Iterations
+	}
+	if parallelism == 0 {
+		parallelism = defaults.Argon2Parallelism
+	}
+	return &argon2idHasher{memory: memory, iterations: iterations, parallelism: parallelism}
+}
+
+func (h *argon2idHasher) hash(password string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, argon2KeyLength)
+	return encodePHC(&phcHash{
+		id:      AlgorithmArgon2id,
+		version: argon2.Version,
+		params: map[string]string{
+			"m": strconv.FormatUint(uint64(h.memory), 10),
+			"t": strconv.FormatUint(uint64(h.iterations), 10),
+			"p": strconv.FormatUint(uint64(h.parallelism), 10),
+		},
+		salt: salt,
+		hash: key,
+	}, []string{"m", "t", "p"}), nil
+}
+
+func (h *argon2idHasher) verify(password, hash string) bool {
+	parsed, memory, iterations, parallelism, err := parseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt, iterations, memory, parallelism, uint32(len(parsed.hash)))
+	return subtle.ConstantTimeCompare(key, parsed.hash) == 1
+}
+
+func (h *argon2idHasher) needsRehash(hash string) bool {
+	parsed, memory, iterations, parallelism, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return memory != h.memory || iterations != h.iterations || parallelism != h.parallelism ||
+		len(parsed.hash) != argon2KeyLength
+}
+
+// parseArgon2id parsea un hash argon2id y sus parámetros
+func parseArgon2id(hash string) (*phcHash, uint32, uint32, uint8, error) {
+	parsed, err := decodePHC(hash)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if parsed.id != AlgorithmArgon2id || parsed.version != argon2.Version {
+		return nil, 0, 0, 0, errors.New("invalid argon2id hash")
+	}
+
+	memory, err := parsed.intParam("m")
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	iterations, err := parsed.intParam("t")
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	parallelism, err := parsed.intParam("p")
+	if err != nil || parallelism > 255 {
+		return nil, 0, 0, 0, errors.New("invalid argon2id parameters")
+	}
+
+	return parsed, uint32(memory), uint32(iterations), uint8(parallelism), nil
+}
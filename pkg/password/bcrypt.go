@@ -0,0 +1,36 @@
+package password
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptHasher genera hashes bcrypt en formato modular crypt ($2a$<cost>$...)
+type bcryptHasher struct {
+	cost int
+}
+
+// newBcryptHasher crea un hasher bcrypt con el costo indicado
+func newBcryptHasher(cost int) *bcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = DefaultConfig().BcryptCost
+	}
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (h *bcryptHasher) verify(password, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+func (h *bcryptHasher) needsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
@@ -0,0 +1,64 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachChecker verifica si una contraseña aparece en filtraciones conocidas
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// rangeDirChecker consulta una copia local de Pwned Passwords por rangos (k-anonimato):
+// el directorio contiene un archivo <PREFIJO>.txt por cada prefijo de 5 caracteres del
+// SHA-1, con líneas SUFIJO:CANTIDAD como las que retorna la API de rangos.
+type rangeDirChecker struct {
+	dir string
+}
+
+// NewRangeDirChecker crea un BreachChecker sobre un directorio de rangos de Pwned Passwords
+func NewRangeDirChecker(dir string) (BreachChecker, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening breached passwords directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, errors.New("breached passwords path is not a directory")
+	}
+	return &rangeDirChecker{dir: dir}, nil
+}
+
+// IsBreached busca el sufijo del SHA-1 de la contraseña en el archivo de su prefijo.
+// Un prefijo sin archivo se considera sin filtraciones.
+func (c *rangeDirChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	file, err := os.Open(filepath.Join(c.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -0,0 +1,101 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SHA-1 de "password" dividido como en la API de rangos de Pwned Passwords
+const (
+	passwordSHA1Prefix = "5BAA6"
+	passwordSHA1Suffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+)
+
+// newRangeDir crea un directorio de rangos con los archivos indicados por prefijo
+func newRangeDir(t *testing.T, ranges map[string]string) BreachChecker {
+	t.Helper()
+	dir := t.TempDir()
+	for prefix, content := range ranges {
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checker, err := NewRangeDirChecker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checker
+}
+
+func TestRangeDirCheckerLooksUpTheSuffixInItsPrefixFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "listed", content: "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + passwordSHA1Suffix + ":9659365\r\n", want: true},
+		{name: "lowercase suffix", content: "1e4c9b93f3f0682250b6cf8331b7ee68fd8:3\n", want: true},
+		{name: "padding entry", content: passwordSHA1Suffix + ":0\n", want: false},
+		{name: "other suffixes", content: "0018A45C4D1DEF81644B54AB7F969B88D65:1\n", want: false},
+		{name: "empty file", content: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := newRangeDir(t, map[string]string{passwordSHA1Prefix: tt.content})
+			breached, err := checker.IsBreached("password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if breached != tt.want {
+				t.Errorf("expected breached=%t, got %t", tt.want, breached)
+			}
+		})
+	}
+}
+
+func TestRangeDirCheckerOnlyReadsThePasswordPrefix(t *testing.T) {
+	// El sufijo de "password" listado bajo otro prefijo no cuenta
+	checker := newRangeDir(t, map[string]string{"00000": passwordSHA1Suffix + ":10\n"})
+
+	breached, err := checker.IsBreached("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breached {
+		t.Error("suffix matched in a different prefix file")
+	}
+}
+
+func TestRangeDirCheckerReportsReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	// Un directorio en lugar del archivo del prefijo hace fallar la lectura
+	if err := os.Mkdir(filepath.Join(dir, passwordSHA1Prefix+".txt"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewRangeDirChecker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := checker.IsBreached("password"); err == nil {
+		t.Error("expected the read error")
+	}
+}
+
+func TestNewRangeDirCheckerRequiresADirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ranges.txt")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"missing": filepath.Join(t.TempDir(), "missing"),
+		"file":    file,
+	} {
+		if _, err := NewRangeDirChecker(path); err == nil {
+			t.Errorf("%s: path was accepted", name)
+		}
+	}
+}
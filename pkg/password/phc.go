@@ -0,0 +1,105 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// saltLength es el largo en bytes de la sal de argon2id y scrypt
+const saltLength = 16
+
+// phcHash representa un hash en formato PHC: $<id>[$v=<version>]$<param>=<valor>,...$<sal>$<hash>
+type phcHash struct {
+	id      string
+	version int
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+// encodePHC serializa el hash; los parámetros se escriben en el orden de keys
+func encodePHC(h *phcHash, keys []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	b.WriteString(h.id)
+	if h.version != 0 {
+		b.WriteString("$v=")
+		b.WriteString(strconv.Itoa(h.version))
+	}
+	b.WriteString("$")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(h.params[key])
+	}
+	b.WriteString("$")
+	b.WriteString(base64.RawStdEncoding.EncodeToString(h.salt))
+	b.WriteString("$")
+	b.WriteString(base64.RawStdEncoding.EncodeToString(h.hash))
+	return b.String()
+}
+
+// decodePHC parsea un hash en formato PHC
+func decodePHC(encoded string) (*phcHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 && len(parts) != 6 || parts[0] != "" {
+		return nil, errors.New("invalid phc hash")
+	}
+
+	h := &phcHash{id: parts[1], params: make(map[string]string)}
+	rest := parts[2:]
+	if len(parts) == 6 {
+		version, ok := strings.CutPrefix(parts[2], "v=")
+		if !ok {
+			return nil, errors.New("invalid phc version")
+		}
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, errors.New("invalid phc version")
+		}
+		h.version = v
+		rest = parts[3:]
+	}
+
+	for _, param := range strings.Split(rest[0], ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, errors.New("invalid phc parameter")
+		}
+		h.params[key] = value
+	}
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(rest[1]); err != nil {
+		return nil, errors.New("invalid phc salt")
+	}
+	if h.hash, err = base64.RawStdEncoding.DecodeString(rest[2]); err != nil || len(h.hash) == 0 {
+		return nil, errors.New("invalid phc hash")
+	}
+
+	return h, nil
+}
+
+// intParam lee un parámetro entero del hash
+func (h *phcHash) intParam(key string) (int, error) {
+	value, err := strconv.Atoi(h.params[key])
+	if err != nil || value <= 0 {
+		return 0, errors.New("invalid phc parameter " + key)
+	}
+	return value, nil
+}
+
+// newSalt genera una sal aleatoria
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPHCRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    *phcHash
+		keys    []string
+		encoded string
+	}{
+		{
+			name: "with version",
+			hash: &phcHash{
+				id:      "argon2id",
+				version: 19,
+				params:  map[string]string{"m": "65536", "t": "3", "p": "4"},
+				salt:    []byte("somesalt"),
+				hash:    []byte("somehash"),
+			},
+			keys:    []string{"m", "t", "p"},
+			encoded: "$argon2id$v=19$m=65536,t=3,p=4$c29tZXNhbHQ$c29tZWhhc2g",
+		},
+		{
+			name: "without version",
+			hash: &phcHash{
+				id:     "scrypt",
+				params: map[string]string{"ln": "17", "r": "8", "p": "1"},
+				salt:   []byte("somesalt"),
+				hash:   []byte("somehash"),
+			},
+			keys:    []string{"ln", "r", "p"},
+			encoded: "$scrypt$ln=17,r=8,p=1$c29tZXNhbHQ$c29tZWhhc2g",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encodePHC(tt.hash, tt.keys)
+			if encoded != tt.encoded {
+				t.Fatalf("expected %q, got %q", tt.encoded, encoded)
+			}
+
+			decoded, err := decodePHC(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.id != tt.hash.id || decoded.version != tt.hash.version {
+				t.Errorf("expected %s v%d, got %s v%d", tt.hash.id, tt.hash.version, decoded.id, decoded.version)
+			}
+			for _, key := range tt.keys {
+				if decoded.params[key] != tt.hash.params[key] {
+					t.Errorf("parameter %s: expected %q, got %q", key, tt.hash.params[key], decoded.params[key])
+				}
+			}
+			if !bytes.Equal(decoded.salt, tt.hash.salt) || !bytes.Equal(decoded.hash, tt.hash.hash) {
+				t.Error("salt or hash changed in the round trip")
+			}
+		})
+	}
+}
+
+func TestDecodePHCRejectsMalformedHashes(t *testing.T) {
+	tests := map[string]string{
+		"empty":               "",
+		"no leading dollar":   "argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"too few fields":      "$argon2id$m=64,t=1,p=1$c2FsdA",
+		"too many fields":     "$argon2id$v=19$m=64$t=1,p=1$c2FsdA$aGFzaA",
+		"bad version prefix":  "$argon2id$x=19$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"non numeric version": "$argon2id$v=nineteen$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"parameter no value":  "$argon2id$v=19$m=64,t,p=1$c2FsdA$aGFzaA",
+		"bad salt":            "$argon2id$v=19$m=64,t=1,p=1$c2F*dA$aGFzaA",
+		"bad hash":            "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGF*aA",
+		"empty hash":          "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+		"padded base64":       "$argon2id$v=19$m=64,t=1,p=1$c2FsdA==$aGFzaA",
+	}
+
+	for name, encoded := range tests {
+		if _, err := decodePHC(encoded); err == nil {
+			t.Errorf("%s: %q was decoded", name, encoded)
+		}
+	}
+}
+
+func TestIntParamRejectsInvalidValues(t *testing.T) {
+	h := &phcHash{params: map[string]string{"ok": "3", "zero": "0", "negative": "-1", "text": "x"}}
+
+	if value, err := h.intParam("ok"); err != nil || value != 3 {
+		t.Errorf("expected 3, got %d (%v)", value, err)
+	}
+	for _, key := range []string{"zero", "negative", "text", "missing"} {
+		if _, err := h.intParam(key); err == nil {
+			t.Errorf("parameter %s was accepted", key)
+		}
+	}
+}
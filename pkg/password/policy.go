@@ -0,0 +1,202 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos de las reglas de la política de contraseñas
+const (
+	ViolationTooShort          = "password_too_short"
+	ViolationTooLong           = "password_too_long"
+	ViolationMissingUpper      = "password_missing_uppercase"
+	ViolationMissingLower      = "password_missing_lowercase"
+	ViolationMissingDigit      = "password_missing_digit"
+	ViolationMissingSymbol     = "password_missing_symbol"
+	ViolationPersonalInfo      = "password_contains_personal_info"
+	ViolationCommon            = "password_too_common"
+	ViolationBreached          = "password_breached"
+	ViolationReused            = "password_reused"
+	ViolationBreachUnavailable = "password_breach_check_unavailable"
+)
+
+// PolicyConfig define las reglas que debe cumplir una contraseña nueva
+type PolicyConfig struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// DisallowPersonalInfo rechaza contraseñas que contienen el email o el nombre del usuario
+	DisallowPersonalInfo bool
+
+	// DictionaryFile es una lista de contraseñas prohibidas, una por línea, que se suma a la lista incorporada
+	DictionaryFile string
+}
+
+// Violation representa una regla incumplida
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PolicyError agrupa las reglas incumplidas por una contraseña
+type PolicyError struct {
+	Violations []Violation
+}
+
+// Error implementa la interfaz error
+func (e *PolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		codes[i] = violation.Code
+	}
+	return "password does not meet the policy: " + strings.Join(codes, ", ")
+}
+
+// Policy valida contraseñas nuevas contra las reglas configuradas y, si hay un BreachChecker,
+// contra una base de contraseñas filtradas
+type Policy struct {
+	cfg        PolicyConfig
+	dictionary map[string]struct{}
+	breached   BreachChecker
+}
+
+// NewPolicy crea una política; breached puede ser nil para omitir la verificación de filtraciones
+func NewPolicy(cfg PolicyConfig, breached BreachChecker) (*Policy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = 8
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = 128
+	}
+
+	dictionary := make(map[string]struct{}, len(commonPasswords))
+	for _, word := range commonPasswords {
+		dictionary[word] = struct{}{}
+	}
+
+	if cfg.DictionaryFile != "" {
+		file, err := os.Open(cfg.DictionaryFile)
+		if err != nil {
+			return nil, fmt.Errorf("opening password dictionary: %w", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if word := strings.ToLower(strings.TrimSpace(scanner.Text())); word != "" {
+				dictionary[word] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading password dictionary: %w", err)
+		}
+	}
+
+	return &Policy{cfg: cfg, dictionary: dictionary, breached: breached}, nil
+}
+
+// Validate verifica la contraseña contra todas las reglas. personalInfo son datos del usuario
+// (email, nombre, apellido) que la contraseña no debe contener. Retorna un *PolicyError con
+// todas las reglas incumplidas.
+func (p *Policy) Validate(password string, personalInfo ...string) error {
+	var violations []Violation
+	add := func(code, message string) {
+		violations = append(violations, Violation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		add(ViolationTooShort, fmt.Sprintf("password must be at least %d characters long", p.cfg.MinLength))
+	}
+	if length > p.cfg.MaxLength {
+		add(ViolationTooLong, fmt.Sprintf("password must be at most %d characters long", p.cfg.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		add(ViolationMissingUpper, "password must contain an uppercase letter")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		add(ViolationMissingLower, "password must contain a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "password must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "password must contain a symbol")
+	}
+
+	lower := strings.ToLower(password)
+	if p.cfg.DisallowPersonalInfo && containsPersonalInfo(lower, personalInfo) {
+		add(ViolationPersonalInfo, "password must not contain your email or name")
+	}
+
+	if _, ok := p.dictionary[lower]; ok {
+		add(ViolationCommon, "password is too common")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.IsBreached(password)
+		switch {
+		case err != nil:
+			add(ViolationBreachUnavailable, "password could not be checked against known breaches, try again later")
+		case breached:
+			add(ViolationBreached, "password has appeared in a data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo indica si la contraseña contiene alguno de los datos del usuario.
+// Del email se usa la parte local; se ignoran los fragmentos de menos de 3 caracteres.
+func containsPersonalInfo(lowerPassword string, personalInfo []string) bool {
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.IndexByte(info, '@'); at >= 0 {
+			info = info[:at]
+		}
+		for _, part := range strings.FieldsFunc(info, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if utf8.RuneCountInString(part) >= 3 && strings.Contains(lowerPassword, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commonPasswords son contraseñas prohibidas aunque no se configure un diccionario
+var commonPasswords = []string{
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd",
+	"12345678", "123456789", "1234567890", "87654321", "11111111",
+	"00000000", "qwerty123", "qwertyuiop", "1q2w3e4r", "1qaz2wsx",
+	"abc12345", "abcd1234", "iloveyou", "sunshine", "princess",
+	"football", "baseball", "welcome1", "letmein1", "trustno1",
+	"superman", "starwars", "dragon123", "monkey123", "admin123",
+	"administrator", "changeme", "contraseña", "contrasena", "qwerty12",
+}
@@ -0,0 +1,189 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubBreachChecker responde siempre lo mismo
+type stubBreachChecker struct {
+	breached bool
+	err      error
+}
+
+func (c stubBreachChecker) IsBreached(password string) (bool, error) {
+	return c.breached, c.err
+}
+
+// violationCodes retorna los códigos de las reglas incumplidas, o nil si la contraseña es válida
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a *PolicyError, got %v", err)
+	}
+	codes := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		codes[i] = violation.Code
+	}
+	return codes
+}
+
+func TestPolicyViolationCodes(t *testing.T) {
+	strict := PolicyConfig{
+		MinLength:     10,
+		MaxLength:     20,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	tests := []struct {
+		name     string
+		cfg      PolicyConfig
+		breached BreachChecker
+		password string
+		want     []string
+	}{
+		{name: "valid", cfg: strict, password: "Tr1cky-Horse"},
+		{name: "too short", cfg: strict, password: "Tr1c-Hor", want: []string{ViolationTooShort}},
+		{name: "too long", cfg: strict, password: "Tr1cky-Horse-Battery-Staple", want: []string{ViolationTooLong}},
+		{name: "length counts runes", cfg: PolicyConfig{MinLength: 4}, password: "ñandú"},
+		{name: "missing upper", cfg: strict, password: "tr1cky-horse", want: []string{ViolationMissingUpper}},
+		{name: "missing lower", cfg: strict, password: "TR1CKY-HORSE", want: []string{ViolationMissingLower}},
+		{name: "missing digit", cfg: strict, password: "Tricky-Horse", want: []string{ViolationMissingDigit}},
+		{name: "missing symbol", cfg: strict, password: "Tr1ckyHorse1", want: []string{ViolationMissingSymbol}},
+		{name: "space is a symbol", cfg: strict, password: "Tr1cky Horse"},
+		{
+			name:     "every class missing",
+			cfg:      strict,
+			password: "          ",
+			want:     []string{ViolationMissingUpper, ViolationMissingLower, ViolationMissingDigit},
+		},
+		{name: "classes not required", cfg: PolicyConfig{}, password: "trickyhorse"},
+		{name: "common", cfg: PolicyConfig{}, password: "Password123", want: []string{ViolationCommon}},
+		{
+			name:     "short and common",
+			cfg:      PolicyConfig{MinLength: 12},
+			password: "sunshine",
+			want:     []string{ViolationTooShort, ViolationCommon},
+		},
+		{name: "breached", cfg: PolicyConfig{}, breached: stubBreachChecker{breached: true}, password: "trickyhorse", want: []string{ViolationBreached}},
+		{name: "not breached", cfg: PolicyConfig{}, breached: stubBreachChecker{}, password: "trickyhorse"},
+		{
+			name:     "breach check unavailable",
+			cfg:      PolicyConfig{},
+			breached: stubBreachChecker{err: errors.New("disk failure")},
+			password: "trickyhorse",
+			want:     []string{ViolationBreachUnavailable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.cfg, tt.breached)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := violationCodes(t, policy.Validate(tt.password)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicyDefaultsLengthLimits(t *testing.T) {
+	policy, err := NewPolicy(PolicyConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := violationCodes(t, policy.Validate("seven77")); !reflect.DeepEqual(got, []string{ViolationTooShort}) {
+		t.Errorf("expected the 8 character minimum, got %v", got)
+	}
+	if got := violationCodes(t, policy.Validate(strings.Repeat("x", 129))); !reflect.DeepEqual(got, []string{ViolationTooLong}) {
+		t.Errorf("expected the 128 character maximum, got %v", got)
+	}
+}
+
+func TestPolicyRejectsPersonalInfo(t *testing.T) {
+	policy, err := NewPolicy(PolicyConfig{DisallowPersonalInfo: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	personalInfo := []string{"ana.perez@example.com", "Ana", "Pérez-Gil"}
+
+	tests := map[string]bool{
+		"perez-secret-2024": true,  // parte local del email
+		"my-PEREZ-password": true,  // sin distinguir mayúsculas
+		"viva-perez!":       true,  // fragmento del email separado por el punto
+		"gil-and-friends":   true,  // fragmento del apellido compuesto
+		"pérez-rocks":       true,  // apellido con tilde
+		"example-domain":    false, // el dominio del email no cuenta
+		"banana-split":      true,  // el nombre tiene 3 caracteres, el mínimo que se compara
+		"trickyhorse":       false,
+	}
+
+	for password, rejected := range tests {
+		got := violationCodes(t, policy.Validate(password, personalInfo...))
+		if rejected != reflect.DeepEqual(got, []string{ViolationPersonalInfo}) {
+			t.Errorf("%q: expected rejected=%t, got %v", password, rejected, got)
+		}
+	}
+
+	// Los fragmentos de menos de 3 caracteres se ignoran
+	if err := policy.Validate("al-bo-trickyhorse", "al@example.com", "Al", "Bo"); err != nil {
+		t.Errorf("short personal info was matched: %v", err)
+	}
+
+	// Sin DisallowPersonalInfo los datos del usuario se ignoran
+	lenient, err := NewPolicy(PolicyConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lenient.Validate("perez-secret-2024", personalInfo...); err != nil {
+		t.Errorf("personal info was checked while disabled: %v", err)
+	}
+}
+
+func TestPolicyDictionaryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.txt")
+	if err := os.WriteFile(path, []byte("Acme2024\n\n  corporate-default  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := NewPolicy(PolicyConfig{DictionaryFile: path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range []string{"acme2024", "ACME2024", "Corporate-Default", "password123"} {
+		if got := violationCodes(t, policy.Validate(password)); !reflect.DeepEqual(got, []string{ViolationCommon}) {
+			t.Errorf("%q: expected %s, got %v", password, ViolationCommon, got)
+		}
+	}
+	if err := policy.Validate("acme2024!"); err != nil {
+		t.Errorf("only exact dictionary words should be rejected: %v", err)
+	}
+
+	if _, err := NewPolicy(PolicyConfig{DictionaryFile: filepath.Join(t.TempDir(), "missing.txt")}, nil); err == nil {
+		t.Error("missing dictionary file was accepted")
+	}
+}
+
+func TestPolicyErrorListsCodes(t *testing.T) {
+	err := &PolicyError{Violations: []Violation{{Code: ViolationTooShort}, {Code: ViolationCommon}}}
+
+	want := "password does not meet the policy: password_too_short, password_too_common"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scryptKeyLength es el largo en bytes del hash scrypt
+const scryptKeyLength = 32
+
+// scryptHasher genera hashes scrypt en formato PHC ($scrypt$ln=...,r=...,p=...$sal$hash)
+type scryptHasher struct {
+	logN int
+	r    int
+	p    int
+}
+
+// newScryptHasher crea un hasher scrypt con los parámetros indicados
+func newScryptHasher(logN, r, p int) *scryptHasher {
+	defaults := DefaultConfig()
+	if logN <= 1 || logN > 30 {
+		logN = defaults.ScryptLogN
+	}
+	if r <= 0 {
+		r = defaults.ScryptR
+	}
+	if p <= 0 {
+		p = defaults.ScryptP
+	}
+	return &scryptHasher{logN: logN, r: r, p: p}
+}
+
+func (h *scryptHasher) hash(password string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.logN, h.r, h.p, scryptKeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePHC(&phcHash{
+		id: AlgorithmScrypt,
+		params: map[string]string{
+			"ln": strconv.Itoa(h.logN),
+			"r":  strconv.Itoa(h.r),
+			"p":  strconv.Itoa(h.p),
+		},
+		salt: salt,
+		hash: key,
+	}, []string{"ln", "r", "p"}), nil
+}
+
+func (h *scryptHasher) verify(password, hash string) bool {
+	parsed, logN, r, p, err := parseScrypt(hash)
+	if err != nil {
+		return false
+	}
+
+	key, err := scrypt.Key([]byte(password), parsed.salt, 1<<logN, r, p, len(parsed.hash))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, parsed.hash) == 1
+}
+
+func (h *scryptHasher) needsRehash(hash string) bool {
+	parsed, logN, r, p, err := parseScrypt(hash)
+	if err != nil {
+		return true
+	}
+	return logN != h.logN || r != h.r || p != h.p || len(parsed.hash) != scryptKeyLength
+}
+
+// parseScrypt parsea un hash scrypt y sus parámetros
+func parseScrypt(hash string) (*phcHash, int, int, int, error) {
+	parsed, err := decodePHC(hash)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if parsed.id != AlgorithmScrypt {
+		return nil, 0, 0, 0, errors.New("invalid scrypt hash")
+	}
+
+	logN, err := parsed.intParam("ln")
+	if err != nil || logN > 30 {
+		return nil, 0, 0, 0, errors.New("invalid scrypt parameters")
+	}
+	r, err := parsed.intParam("r")
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	p, err := parsed.intParam("p")
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	return parsed, logN, r, p, nil
+}
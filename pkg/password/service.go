@@ -1,39 +1,123 @@
 package password
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// Algoritmos de hash soportados
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmScrypt   = "scrypt"
 )
 
 // Service define las operaciones del servicio de contraseñas
 type Service interface {
 	Hash(password string) (string, error)
 	Verify(password, hash string) bool
+	// NeedsRehash indica si el hash no usa el algoritmo o los parámetros actuales
+	NeedsRehash(hash string) bool
+}
+
+// Config define el algoritmo con el que se generan los hashes nuevos y sus parámetros.
+// Los hashes existentes se verifican con el algoritmo codificado en ellos.
+type Config struct {
+	Algorithm string // bcrypt, argon2id o scrypt
+
+	BcryptCost int
+
+	Argon2Memory      uint32 // en KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	ScryptLogN int // N = 2^ScryptLogN
+	ScryptR    int
+	ScryptP    int
+}
+
+// DefaultConfig retorna los parámetros recomendados por OWASP para cada algoritmo
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:         AlgorithmArgon2id,
+		BcryptCost:        12,
+		Argon2Memory:      19 * 1024,
+		Argon2Iterations:  2,
+		Argon2Parallelism: 1,
+		ScryptLogN:        17,
+		ScryptR:           8,
+		ScryptP:           1,
+	}
+}
+
+// hasher implementa un algoritmo concreto
+type hasher interface {
+	hash(password string) (string, error)
+	verify(password, hash string) bool
+	needsRehash(hash string) bool
 }
 
 // service implementa el servicio de contraseñas
 type service struct {
-	cost int
+	current string
+	hashers map[string]hasher
 }
 
-// NewService crea una nueva instancia del servicio de contraseñas
-func NewService(cost int) Service {
-	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
-		cost = bcrypt.DefaultCost
+// NewService crea una nueva instancia del servicio de contraseñas. Los parámetros en cero
+// o fuera de rango se reemplazan por los de DefaultConfig.
+func NewService(cfg Config) Service {
+	defaults := DefaultConfig()
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = defaults.Algorithm
 	}
-	return &service{cost: cost}
+
+	s := &service{
+		current: cfg.Algorithm,
+		hashers: map[string]hasher{
+			AlgorithmBcrypt:   newBcryptHasher(cfg.BcryptCost),
+			AlgorithmArgon2id: newArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism),
+			AlgorithmScrypt:   newScryptHasher(cfg.ScryptLogN, cfg.ScryptR, cfg.ScryptP),
+		},
+	}
+	if _, ok := s.hashers[s.current]; !ok {
+		s.current = defaults.Algorithm
+	}
+
+	return s
 }
 
-// Hash genera un hash de la contraseña
+// Hash genera un hash de la contraseña con el algoritmo actual
 func (s *service) Hash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
-	if err != nil {
-		return "", err
-	}
-	return string(bytes), nil
+	return s.hashers[s.current].hash(password)
 }
 
-// Verify verifica si la contraseña coincide con el hash
+// Verify verifica si la contraseña coincide con el hash, cualquiera sea su algoritmo
 func (s *service) Verify(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	h, ok := s.hashers[algorithmOf(hash)]
+	if !ok {
+		return false
+	}
+	return h.verify(password, hash)
+}
+
+// NeedsRehash indica si el hash debe regenerarse con el algoritmo y los parámetros actuales
+func (s *service) NeedsRehash(hash string) bool {
+	algorithm := algorithmOf(hash)
+	if algorithm != s.current {
+		return true
+	}
+	return s.hashers[algorithm].needsRehash(hash)
+}
+
+// algorithmOf identifica el algoritmo a partir del prefijo del hash
+func algorithmOf(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return AlgorithmScrypt
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
 }
@@ -0,0 +1,150 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+// Hashes de "correct horse" guardados con parámetros de bajo costo, como los que quedan en la base
+// de datos de una configuración anterior
+const (
+	legacyBcryptHash   = "$2a$04$KSdLvTtuXfHeymGW.2/zlO8/ri3d04RQFv45hLQSwzdkYicD5T2V6"
+	legacyArgon2idHash = "$argon2id$v=19$m=64,t=1,p=1$6lvidnjYyqGG3oT7Ns54SQ$PL3zewDDVwI1vDKfzOA4R1p8NAeFFtjl5qRDrF0822c"
+	legacyScryptHash   = "$scrypt$ln=4,r=8,p=1$Ut6v0t6bJxcb3ZzXJoTXiQ$g8VscpU9RCDCqI53CeIdKqLHNxoVKKhM69QPKWu5AY0"
+)
+
+// testConfig retorna parámetros de bajo costo para el algoritmo indicado
+func testConfig(algorithm string) Config {
+	return Config{
+		Algorithm:         algorithm,
+		BcryptCost:        4,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		ScryptLogN:        4,
+		ScryptR:           8,
+		ScryptP:           1,
+	}
+}
+
+func TestHashProducesTheConfiguredFormat(t *testing.T) {
+	tests := map[string]string{
+		AlgorithmBcrypt:   "$2a$04$",
+		AlgorithmArgon2id: "$argon2id$v=19$m=64,t=1,p=1$",
+		AlgorithmScrypt:   "$scrypt$ln=4,r=8,p=1$",
+	}
+
+	for algorithm, prefix := range tests {
+		t.Run(algorithm, func(t *testing.T) {
+			svc := NewService(testConfig(algorithm))
+			hash, err := svc.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, prefix) {
+				t.Errorf("expected a hash starting with %q, got %q", prefix, hash)
+			}
+			if !svc.Verify("correct horse", hash) || svc.Verify("wrong horse", hash) {
+				t.Error("hash does not verify only the original password")
+			}
+			if svc.NeedsRehash(hash) {
+				t.Error("fresh hash needs a rehash")
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsHashesFromAnyAlgorithm(t *testing.T) {
+	hashes := map[string]string{
+		"bcrypt $2a$": legacyBcryptHash,
+		"bcrypt $2y$": "$2y$" + strings.TrimPrefix(legacyBcryptHash, "$2a$"),
+		"argon2id":    legacyArgon2idHash,
+		"scrypt":      legacyScryptHash,
+	}
+
+	// El algoritmo configurado solo decide cómo se generan los hashes nuevos
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id, AlgorithmScrypt} {
+		svc := NewService(testConfig(algorithm))
+		for name, hash := range hashes {
+			if !svc.Verify("correct horse", hash) {
+				t.Errorf("%s service: %s hash was not verified", algorithm, name)
+			}
+			if svc.Verify("wrong horse", hash) {
+				t.Errorf("%s service: %s hash verified a wrong password", algorithm, name)
+			}
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		hash   string
+		want   bool
+	}{
+		{name: "current bcrypt", config: func(c *Config) { c.Algorithm = AlgorithmBcrypt }, hash: legacyBcryptHash, want: false},
+		{name: "bcrypt cost raised", config: func(c *Config) { c.Algorithm = AlgorithmBcrypt; c.BcryptCost = 5 }, hash: legacyBcryptHash, want: true},
+		{name: "bcrypt to argon2id", config: func(c *Config) {}, hash: legacyBcryptHash, want: true},
+		{name: "current argon2id", config: func(c *Config) {}, hash: legacyArgon2idHash, want: false},
+		{name: "argon2id memory raised", config: func(c *Config) { c.Argon2Memory = 128 }, hash: legacyArgon2idHash, want: true},
+		{name: "argon2id iterations raised", config: func(c *Config) { c.Argon2Iterations = 2 }, hash: legacyArgon2idHash, want: true},
+		{name: "argon2id parallelism raised", config: func(c *Config) { c.Argon2Parallelism = 2 }, hash: legacyArgon2idHash, want: true},
+		{name: "current scrypt", config: func(c *Config) { c.Algorithm = AlgorithmScrypt }, hash: legacyScryptHash, want: false},
+		{name: "scrypt cost raised", config: func(c *Config) { c.Algorithm = AlgorithmScrypt; c.ScryptLogN = 5 }, hash: legacyScryptHash, want: true},
+		{name: "scrypt block size raised", config: func(c *Config) { c.Algorithm = AlgorithmScrypt; c.ScryptR = 16 }, hash: legacyScryptHash, want: true},
+		{name: "scrypt to argon2id", config: func(c *Config) {}, hash: legacyScryptHash, want: true},
+		{name: "unknown format", config: func(c *Config) {}, hash: "plaintext", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(AlgorithmArgon2id)
+			tt.config(&cfg)
+			if got := NewService(cfg).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("expected NeedsRehash %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsMalformedHashes(t *testing.T) {
+	hashes := map[string]string{
+		"empty":                  "",
+		"plaintext":              "correct horse",
+		"unknown algorithm":      "$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA",
+		"truncated bcrypt":       legacyBcryptHash[:20],
+		"argon2id wrong version": strings.Replace(legacyArgon2idHash, "v=19", "v=16", 1),
+		"argon2id no memory":     strings.Replace(legacyArgon2idHash, "m=64,", "", 1),
+		"argon2id zero time":     strings.Replace(legacyArgon2idHash, "t=1", "t=0", 1),
+		"argon2id parallelism":   strings.Replace(legacyArgon2idHash, "p=1", "p=256", 1),
+		"argon2id bad salt":      strings.Replace(legacyArgon2idHash, "$6lvi", "$*lvi", 1),
+		"argon2id empty hash":    legacyArgon2idHash[:strings.LastIndex(legacyArgon2idHash, "$")+1],
+		"argon2id as scrypt":     strings.Replace(legacyArgon2idHash, "$argon2id$v=19", "$scrypt", 1),
+		"scrypt huge cost":       strings.Replace(legacyScryptHash, "ln=4", "ln=31", 1),
+		"scrypt no block size":   strings.Replace(legacyScryptHash, "r=8,", "", 1),
+		"scrypt text parameter":  strings.Replace(legacyScryptHash, "p=1", "p=x", 1),
+	}
+
+	svc := NewService(testConfig(AlgorithmArgon2id))
+	for name, hash := range hashes {
+		if svc.Verify("correct horse", hash) {
+			t.Errorf("%s: malformed hash %q was verified", name, hash)
+		}
+		if !svc.NeedsRehash(hash) {
+			t.Errorf("%s: malformed hash %q does not need a rehash", name, hash)
+		}
+	}
+}
+
+func TestNewServiceReplacesInvalidConfiguration(t *testing.T) {
+	svc := NewService(Config{Algorithm: "md5", BcryptCost: 99})
+
+	hash, err := svc.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("unknown algorithm did not fall back to the argon2id defaults: %q", hash)
+	}
+}
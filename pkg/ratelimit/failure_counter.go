@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureCounter cuenta fallos por clave (por ejemplo, una IP) en una ventana fija y bloquea
+// la clave al alcanzar el límite hasta que la ventana termina. El estado es local al proceso.
+type FailureCounter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*failureWindow
+	lastSweep time.Time
+}
+
+// failureWindow representa los fallos de una clave en la ventana actual
+type failureWindow struct {
+	failures int
+	resetAt  time.Time
+}
+
+// NewFailureCounter crea un contador; con limit <= 0 nunca bloquea
+func NewFailureCounter(limit int, window time.Duration) *FailureCounter {
+	return &FailureCounter{
+		limit:     limit,
+		window:    window,
+		entries:   make(map[string]*failureWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Blocked indica si la clave alcanzó el límite y cuánto falta para que se libere
+func (c *FailureCounter) Blocked(key string) (bool, time.Duration) {
+	if c.limit <= 0 || key == "" {
+		return false, 0
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.resetAt) {
+		return false, 0
+	}
+	if entry.failures < c.limit {
+		return false, 0
+	}
+	return true, entry.resetAt.Sub(now)
+}
+
+// Fail registra un fallo para la clave
+func (c *FailureCounter) Fail(key string) {
+	if c.limit <= 0 || key == "" {
+		return
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.resetAt) {
+		entry = &failureWindow{resetAt: now.Add(c.window)}
+		c.entries[key] = entry
+	}
+	entry.failures++
+}
+
+// Reset elimina los fallos registrados para la clave
+func (c *FailureCounter) Reset(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// sweep elimina las ventanas vencidas como mucho una vez por ventana
+func (c *FailureCounter) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.window {
+		return
+	}
+	for key, entry := range c.entries {
+		if !now.Before(entry.resetAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.lastSweep = now
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret es el secreto SHA1 de los vectores de prueba de RFC 6238 ("12345678901234567890")
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	// Vectores del apéndice B de RFC 6238 para SHA1; el RFC usa 8 dígitos y aquí se usan los 6 últimos
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	code, err := Code(" "+strings.ToLower(rfcSecret)+" ", Step(time.Unix(59, 0)))
+	if err != nil || code != "287082" {
+		t.Fatalf("Code with lowercase secret = %s, %v", code, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("expected an invalid secret to be rejected")
+	}
+}
+
+func TestValidateAcceptsSkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	for offset := int64(-1); offset <= 1; offset++ {
+		code, _ := Code(rfcSecret, current+offset)
+		step, err := Validate(rfcSecret, code, now, 1)
+		if err != nil {
+			t.Fatalf("code at offset %d rejected: %v", offset, err)
+		}
+		if step != current+offset {
+			t.Errorf("Validate at offset %d returned step %d, want %d", offset, step, current+offset)
+		}
+	}
+
+	for _, offset := range []int64{-2, 2} {
+		code, _ := Code(rfcSecret, current+offset)
+		if _, err := Validate(rfcSecret, code, now, 1); err == nil {
+			t.Errorf("code at offset %d accepted outside the skew window", offset)
+		}
+	}
+
+	code, _ := Code(rfcSecret, current+1)
+	if _, err := Validate(rfcSecret, code, now, 0); err == nil {
+		t.Error("code from the next step accepted without skew")
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, err := Validate(rfcSecret, code, now, 1); err == nil {
+			t.Errorf("Validate(%q) accepted a malformed code", code)
+		}
+	}
+	if _, err := Validate(rfcSecret, " 287082 ", now, 0); err != nil {
+		t.Errorf("Validate with surrounding spaces: %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Auth Service", "ana@example.com", rfcSecret)
+	for _, part := range []string{"otpauth://totp/Auth%20Service:ana@example.com?", "secret=" + rfcSecret, "issuer=Auth+Service", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("URI %s does not contain %s", uri, part)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("expected a 160-bit secret (32 base32 characters), got %d", len(secret))
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("generated secret cannot compute codes: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Flags de los datos del autenticador (WebAuthn §6.1)
+const (
+	FlagUserPresent        byte = 0x01
+	FlagUserVerified       byte = 0x04
+	FlagBackupEligible     byte = 0x08
+	FlagBackupState        byte = 0x10
+	FlagAttestedCredential byte = 0x40
+	FlagExtensionData      byte = 0x80
+)
+
+// Base64URL representa datos binarios serializados en base64url sin relleno, como en la API JSON de WebAuthn
+type Base64URL []byte
+
+// MarshalJSON codifica el valor en base64url sin relleno
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON acepta base64url con o sin relleno
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return errors.New("invalid base64url value")
+	}
+	*b = decoded
+	return nil
+}
+
+// String retorna el valor codificado en base64url sin relleno
+func (b Base64URL) String() string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CollectedClientData representa el clientDataJSON que firma el autenticador
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// AuthenticatorData representa los datos del autenticador ya parseados
+type AuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte // clave COSE en CBOR, solo en el registro
+}
+
+// HasFlag indica si el flag está presente
+func (d *AuthenticatorData) HasFlag(flag byte) bool {
+	return d.Flags&flag != 0
+}
+
+// ParseAuthenticatorData parsea los datos binarios del autenticador
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data too short")
+	}
+
+	authData := &AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[37:]
+	if authData.HasFlag(FlagAttestedCredential) {
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data too short")
+		}
+		authData.AAGUID = rest[:16]
+		credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if credentialIDLength > 1023 || len(rest) < credentialIDLength {
+			return nil, errors.New("invalid credential id length")
+		}
+		authData.CredentialID = rest[:credentialIDLength]
+		rest = rest[credentialIDLength:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, errors.New("invalid credential public key")
+		}
+		authData.PublicKey = rest[:n]
+		rest = rest[n:]
+	}
+
+	if authData.HasFlag(FlagExtensionData) {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, errors.New("invalid extension data")
+		}
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected trailing authenticator data")
+	}
+
+	return authData, nil
+}
+
+// parseClientData valida el tipo, el desafío y el origen del clientDataJSON
+func (rp *RelyingParty) parseClientData(data []byte, ceremonyType string, challenge []byte) error {
+	var clientData CollectedClientData
+	if err := json.Unmarshal(data, &clientData); err != nil {
+		return errors.New("invalid client data")
+	}
+
+	if clientData.Type != ceremonyType {
+		return errors.New("unexpected client data type")
+	}
+
+	if clientData.Challenge != base64.RawURLEncoding.EncodeToString(challenge) {
+		return errors.New("challenge mismatch")
+	}
+
+	if !rp.isAllowedOrigin(clientData.Origin) {
+		return errors.New("origin not allowed")
+	}
+
+	return nil
+}
+
+// ChallengeFromClientData extrae el desafío del clientDataJSON para localizar la ceremonia pendiente.
+// El valor todavía no está verificado; la verificación la hacen FinishRegistration y FinishLogin.
+func ChallengeFromClientData(data []byte) ([]byte, error) {
+	var clientData CollectedClientData
+	if err := json.Unmarshal(data, &clientData); err != nil {
+		return nil, errors.New("invalid client data")
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	if err != nil || len(challenge) == 0 {
+		return nil, errors.New("invalid challenge")
+	}
+
+	return challenge, nil
+}
+
+// verifyAuthenticatorData valida el hash del RP ID y la presencia y verificación del usuario
+func (rp *RelyingParty) verifyAuthenticatorData(authData *AuthenticatorData, requireUserVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.config.RPID))
+	if string(authData.RPIDHash) != string(rpIDHash[:]) {
+		return errors.New("rp id hash mismatch")
+	}
+
+	if !authData.HasFlag(FlagUserPresent) {
+		return errors.New("user not present")
+	}
+
+	if requireUserVerification && !authData.HasFlag(FlagUserVerified) {
+		return errors.New("user verification required")
+	}
+
+	return nil
+}
+
+// verifyAttestationStatement verifica la declaración de atestación. Se aceptan "none" y "packed"
+// (autoatestación o con certificado); con conveyance "none" los navegadores entregan "none".
+// No se validan cadenas de certificados contra metadatos de fabricantes.
+func verifyAttestationStatement(format string, statement map[interface{}]interface{}, authData []byte, clientDataHash []byte, credentialKey *PublicKey) error {
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return errors.New("invalid none attestation statement")
+		}
+		return nil
+
+	case "packed":
+		alg, _ := statement["alg"].(int64)
+		signature, _ := statement["sig"].([]byte)
+		if len(signature) == 0 {
+			return errors.New("invalid packed attestation statement")
+		}
+
+		signed := append(append([]byte(nil), authData...), clientDataHash...)
+
+		chain, hasCertificates := statement["x5c"].([]interface{})
+		if !hasCertificates {
+			// Autoatestación: firmada con la propia clave de la credencial
+			if alg != credentialKey.Algorithm {
+				return errors.New("attestation algorithm mismatch")
+			}
+			return credentialKey.Verify(signed, signature)
+		}
+
+		if len(chain) == 0 {
+			return errors.New("invalid packed attestation statement")
+		}
+		der, _ := chain[0].([]byte)
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return errors.New("invalid attestation certificate")
+		}
+		attestationKey := &PublicKey{Algorithm: alg, Key: certificate.PublicKey}
+		return attestationKey.Verify(signed, signature)
+
+	default:
+		return errors.New("unsupported attestation format")
+	}
+}
@@ -0,0 +1,331 @@
+// Package webauthn implementa el lado del relying party de WebAuthn Level 2 para registrar
+// credenciales de clave pública (passkeys y llaves de seguridad) y verificar aserciones.
+// Las opciones y respuestas usan la serialización JSON de WebAuthn Level 3
+// (PublicKeyCredential.parseCreationOptionsFromJSON y PublicKeyCredential.toJSON).
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// challengeSize es el tamaño de los desafíos en bytes (mínimo recomendado: 16)
+const challengeSize = 32
+
+// Config configuración del relying party
+type Config struct {
+	RPID    string        // dominio efectivo, por ejemplo "example.com"
+	RPName  string        // nombre visible del servicio
+	Origins []string      // orígenes permitidos, por ejemplo "https://app.example.com"
+	Timeout time.Duration // tiempo que el navegador espera al autenticador
+}
+
+// RelyingParty ejecuta las ceremonias de registro y autenticación
+type RelyingParty struct {
+	config Config
+}
+
+// New crea una nueva instancia de RelyingParty
+func New(config Config) (*RelyingParty, error) {
+	if config.RPID == "" {
+		return nil, errors.New("webauthn rp id is required")
+	}
+	if len(config.Origins) == 0 {
+		return nil, errors.New("webauthn origins are required")
+	}
+	for _, origin := range config.Origins {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, errors.New("invalid webauthn origin " + origin)
+		}
+	}
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Minute
+	}
+	return &RelyingParty{config: config}, nil
+}
+
+// User representa la cuenta para la que se registra una credencial
+type User struct {
+	ID          []byte // identificador opaco; no debe contener datos personales
+	Name        string
+	DisplayName string
+}
+
+// RelyingPartyEntity identifica al relying party ante el autenticador
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifica al usuario ante el autenticador
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter indica un algoritmo de clave aceptado
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor identifica una credencial existente
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection expresa los requisitos sobre el autenticador
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions son las opciones para navigator.credentials.create()
+type CreationOptions struct {
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Base64URL              `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions son las opciones para navigator.credentials.get()
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse es la credencial retornada por navigator.credentials.create() serializada con toJSON()
+type AttestationResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AttestationObject Base64URL `json:"attestationObject"`
+		Transports        []string  `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse es la credencial retornada por navigator.credentials.get() serializada con toJSON()
+type AssertionResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AuthenticatorData Base64URL `json:"authenticatorData"`
+		Signature         Base64URL `json:"signature"`
+		UserHandle        Base64URL `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential representa una credencial registrada y verificada
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // clave COSE en CBOR
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	UserVerified   bool
+	BackupEligible bool
+	BackupState    bool
+}
+
+// Assertion representa el resultado de una aserción verificada
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackupState  bool
+}
+
+// NewChallenge genera un desafío aleatorio
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// BeginRegistration construye las opciones de registro para el desafío dado.
+// exclude contiene las credenciales ya registradas del usuario para no duplicarlas.
+func (rp *RelyingParty) BeginRegistration(user User, challenge []byte, exclude []CredentialDescriptor, requireUserVerification bool) *CreationOptions {
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return &CreationOptions{
+		RP:        RelyingPartyEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User:      UserEntity{ID: user.ID, Name: user.Name, DisplayName: user.DisplayName},
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.config.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: userVerification(requireUserVerification),
+		},
+		Attestation: "none",
+	}
+}
+
+// FinishRegistration verifica la respuesta de registro (WebAuthn §7.1) y retorna la credencial
+func (rp *RelyingParty) FinishRegistration(challenge []byte, response *AttestationResponse, requireUserVerification bool) (*Credential, error) {
+	if response.Type != "public-key" {
+		return nil, errors.New("invalid credential type")
+	}
+
+	if err := rp.parseClientData(response.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	value, n, err := decodeCBOR(response.Response.AttestationObject)
+	if err != nil || n != len(response.Response.AttestationObject) {
+		return nil, errors.New("invalid attestation object")
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	if !authData.HasFlag(FlagAttestedCredential) {
+		return nil, errors.New("missing attested credential data")
+	}
+
+	if len(response.RawID) > 0 && string(response.RawID) != string(authData.CredentialID) {
+		return nil, errors.New("credential id mismatch")
+	}
+
+	credentialKey, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	if err := verifyAttestationStatement(format, statement, rawAuthData, clientDataHash[:], credentialKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             authData.CredentialID,
+		PublicKey:      authData.PublicKey,
+		SignCount:      authData.SignCount,
+		AAGUID:         authData.AAGUID,
+		Transports:     response.Response.Transports,
+		UserVerified:   authData.HasFlag(FlagUserVerified),
+		BackupEligible: authData.HasFlag(FlagBackupEligible),
+		BackupState:    authData.HasFlag(FlagBackupState),
+	}, nil
+}
+
+// BeginLogin construye las opciones de autenticación para el desafío dado.
+// Con allow vacío el autenticador ofrece las credenciales descubribles (passkeys) del RP.
+func (rp *RelyingParty) BeginLogin(challenge []byte, allow []CredentialDescriptor, requireUserVerification bool) *RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.config.Timeout.Milliseconds(),
+		RPID:             rp.config.RPID,
+		AllowCredentials: allow,
+		UserVerification: userVerification(requireUserVerification),
+	}
+}
+
+// FinishLogin verifica una aserción (WebAuthn §7.2) con la clave pública almacenada de la credencial.
+// storedSignCount permite detectar autenticadores clonados.
+func (rp *RelyingParty) FinishLogin(challenge []byte, response *AssertionResponse, publicKey []byte, storedSignCount uint32, requireUserVerification bool) (*Assertion, error) {
+	if response.Type != "public-key" {
+		return nil, errors.New("invalid credential type")
+	}
+
+	if err := rp.parseClientData(response.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	credentialKey, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), response.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := credentialKey.Verify(signed, response.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	// Un contador que no avanza indica una posible clonación (los autenticadores sin contador envían siempre 0)
+	if (authData.SignCount != 0 || storedSignCount != 0) && authData.SignCount <= storedSignCount {
+		return nil, ErrSignCountRegression
+	}
+
+	return &Assertion{
+		SignCount:    authData.SignCount,
+		UserVerified: authData.HasFlag(FlagUserVerified),
+		BackupState:  authData.HasFlag(FlagBackupState),
+	}, nil
+}
+
+// ErrSignCountRegression indica que el contador de firmas no avanzó
+var ErrSignCountRegression = errors.New("sign count did not increase")
+
+// isAllowedOrigin verifica que el origen esté entre los configurados
+func (rp *RelyingParty) isAllowedOrigin(origin string) bool {
+	for _, allowed := range rp.config.Origins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// userVerification traduce el requisito de verificación al valor de las opciones
+func userVerification(required bool) string {
+	if required {
+		return "required"
+	}
+	return "preferred"
+}
@@ -0,0 +1,200 @@
+// Package webauthntest provee un autenticador WebAuthn por software para pruebas.
+// Genera credenciales ES256 y respuestas con el mismo formato JSON que envía el navegador.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"auth-go-microservicio/pkg/webauthn"
+)
+
+// Authenticator es un autenticador de plataforma simulado con credenciales descubribles
+type Authenticator struct {
+	Origin string
+
+	// UserVerification indica si el autenticador verifica al usuario (biometría o PIN)
+	UserVerification bool
+
+	mu          sync.Mutex
+	credentials []*credential
+}
+
+// credential es una credencial guardada en el autenticador
+type credential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+// NewAuthenticator crea un autenticador que firma para el origen indicado
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerification: true}
+}
+
+// Register ejecuta navigator.credentials.create() con las opciones del relying party
+func (a *Authenticator) Register(options *webauthn.CreationOptions) (*webauthn.AttestationResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(options.RP.ID, excluded.ID) != nil {
+			return nil, errors.New("credential already registered")
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	cred := &credential{
+		id:         id,
+		rpID:       options.RP.ID,
+		userHandle: append([]byte(nil), options.User.ID...),
+		key:        key,
+	}
+
+	publicKey := encodeCBOR(map[int64]interface{}{
+		1:  int64(2), // kty: EC2
+		3:  webauthn.AlgES256,
+		-1: int64(1), // crv: P-256
+		-2: padCoordinate(key.X.Bytes()),
+		-3: padCoordinate(key.Y.Bytes()),
+	})
+
+	attested := make([]byte, 16, 16+2+len(id)+len(publicKey)) // AAGUID en cero
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(id)))
+	attested = append(attested, id...)
+	attested = append(attested, publicKey...)
+
+	authData := a.authenticatorData(cred, webauthn.FlagAttestedCredential)
+	authData = append(authData, attested...)
+
+	clientData, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	a.credentials = append(a.credentials, cred)
+
+	response := &webauthn.AttestationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(id),
+		RawID: id,
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = clientData
+	response.Response.AttestationObject = encodeCBOR(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	response.Response.Transports = []string{"internal"}
+
+	return response, nil
+}
+
+// Login ejecuta navigator.credentials.get() con las opciones del relying party
+func (a *Authenticator) Login(options *webauthn.RequestOptions) (*webauthn.AssertionResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var cred *credential
+	if len(options.AllowCredentials) == 0 {
+		for _, candidate := range a.credentials {
+			if candidate.rpID == options.RPID {
+				cred = candidate
+				break
+			}
+		}
+	} else {
+		for _, allowed := range options.AllowCredentials {
+			if cred = a.find(options.RPID, allowed.ID); cred != nil {
+				break
+			}
+		}
+	}
+	if cred == nil {
+		return nil, errors.New("no credential available")
+	}
+
+	cred.signCount++
+	authData := a.authenticatorData(cred, 0)
+
+	clientData, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	response := &webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(cred.id),
+		RawID: cred.id,
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = clientData
+	response.Response.AuthenticatorData = authData
+	response.Response.Signature = signature
+	response.Response.UserHandle = cred.userHandle
+
+	return response, nil
+}
+
+// find busca una credencial por RP e identificador
+func (a *Authenticator) find(rpID string, id []byte) *credential {
+	for _, cred := range a.credentials {
+		if cred.rpID == rpID && string(cred.id) == string(id) {
+			return cred
+		}
+	}
+	return nil
+}
+
+// authenticatorData construye la parte fija de los datos del autenticador
+func (a *Authenticator) authenticatorData(cred *credential, extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(cred.rpID))
+
+	flags := webauthn.FlagUserPresent | webauthn.FlagBackupEligible | webauthn.FlagBackupState | extraFlags
+	if a.UserVerification {
+		flags |= webauthn.FlagUserVerified
+	}
+
+	data := append([]byte(nil), rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, cred.signCount)
+}
+
+// clientData construye el clientDataJSON que arma el navegador
+func (a *Authenticator) clientData(ceremonyType string, challenge []byte) ([]byte, error) {
+	return json.Marshal(webauthn.CollectedClientData{
+		Type:      ceremonyType,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.Origin,
+	})
+}
+
+// padCoordinate completa una coordenada de P-256 a 32 bytes
+func padCoordinate(b []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
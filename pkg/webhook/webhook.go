@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers de cada entrega. La firma es HMAC-SHA256 con el secreto de la suscripción sobre
+// "<timestamp>.<cuerpo>", de modo que el receptor puede rechazar entregas reenviadas fuera de tolerancia.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// maxResponseBody es la cantidad de bytes de la respuesta que se conservan en el historial
+const maxResponseBody = 4 << 10
+
+// ErrForbiddenDestination indica que la URL apunta a una dirección de loopback, privada o link-local
+var ErrForbiddenDestination = errors.New("webhook url must point to a public address")
+
+// sharedAddressSpace es el rango 100.64.0.0/10 (CGNAT), que net.IP no considera privado
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Request representa una entrega a enviar
+type Request struct {
+	URL       string
+	Secret    string
+	ID        string // identificador de la entrega; se repite en los reintentos
+	Event     string
+	Body      []byte
+	Timestamp time.Time
+}
+
+// Response representa el resultado de un intento de entrega
+type Response struct {
+	StatusCode int // 0 si no hubo respuesta
+	Body       string
+	Duration   time.Duration
+}
+
+// Succeeded indica si el endpoint aceptó la entrega (respuesta 2xx)
+func (r *Response) Succeeded() bool {
+	return r.StatusCode >= 200 && r.StatusCode <= 299
+}
+
+// Sender envía entregas firmadas por HTTP
+type Sender struct {
+	client               *http.Client
+	allowPrivateNetworks bool
+}
+
+// NewSender crea un Sender con el timeout indicado por intento. Salvo que allowPrivateNetworks
+// sea true, las conexiones a direcciones de loopback, privadas o link-local se rechazan: la
+// verificación se hace sobre la IP ya resuelta al conectar, por lo que un DNS que cambie entre
+// el alta de la suscripción y la entrega no permite alcanzar la red interna.
+func NewSender(timeout time.Duration, allowPrivateNetworks bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrForbiddenDestination
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Con un proxy la conexión verificada sería la del proxy y no la del destino
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// Una redirección podría reenviar la entrega firmada a otro destino
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowPrivateNetworks: allowPrivateNetworks,
+	}
+}
+
+// CheckURL verifica que la URL sea absoluta, use HTTP o HTTPS y no apunte a localhost ni a una
+// IP literal no pública. Los nombres de host se verifican al conectar, ya que su resolución
+// puede cambiar.
+func (s *Sender) CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("webhook url must be an absolute http or https url")
+	}
+	if s.allowPrivateNetworks {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenDestination
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return ErrForbiddenDestination
+	}
+	return nil
+}
+
+// IsPublicIP indica si la IP puede recibir entregas: no es de loopback, privada, link-local,
+// CGNAT, multicast ni no especificada
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Send firma y envía la entrega. Retorna error solo si no se obtuvo respuesta; una respuesta
+// fuera de 2xx se informa en Response.
+func (s *Sender) Send(ctx context.Context, req *Request) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return &Response{}, err
+	}
+
+	timestamp := strconv.FormatInt(req.Timestamp.Unix(), 10)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "auth-service-webhooks/1.0")
+	httpReq.Header.Set(HeaderID, req.ID)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderTimestamp, timestamp)
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	start := time.Now()
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return &Response{Duration: time.Since(start)}, err
+	}
+	defer httpResp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseBody))
+	return &Response{
+		StatusCode: httpResp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}, nil
+}
+
+// Sign calcula el valor del header de firma
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify valida la firma y la antigüedad del timestamp de una entrega recibida; la usan los
+// receptores y los tests
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	sent := time.Unix(seconds, 0)
+	if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+		return errors.New("webhook timestamp outside tolerance")
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
+
+// GenerateSecret genera un secreto aleatorio de 32 bytes para firmar las entregas
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
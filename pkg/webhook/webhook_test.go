@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	sender := NewSender(time.Second, false)
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{"https://hooks.example.com/auth", nil},
+		{"http://93.184.216.34:8080/hook", nil},
+		{"http://localhost:8080/hook", ErrForbiddenDestination},
+		{"http://api.localhost/hook", ErrForbiddenDestination},
+		{"http://127.0.0.1/hook", ErrForbiddenDestination},
+		{"http://[::1]/hook", ErrForbiddenDestination},
+		{"http://169.254.169.254/latest/meta-data/", ErrForbiddenDestination},
+		{"http://10.0.0.5/hook", ErrForbiddenDestination},
+	}
+	for _, tt := range tests {
+		if err := sender.CheckURL(tt.url); !errors.Is(err, tt.wantErr) {
+			t.Errorf("CheckURL(%s) = %v, want %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	for _, invalid := range []string{"ftp://hooks.example.com", "/relative", "https://"} {
+		if err := sender.CheckURL(invalid); err == nil || errors.Is(err, ErrForbiddenDestination) {
+			t.Errorf("CheckURL(%s) = %v, want invalid url error", invalid, err)
+		}
+	}
+
+	if err := NewSender(time.Second, true).CheckURL("http://127.0.0.1/hook"); err != nil {
+		t.Errorf("CheckURL with private networks allowed: %v", err)
+	}
+}
+
+func TestSendRejectsPrivateDestinationAtDial(t *testing.T) {
+	received := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+
+	req := &Request{URL: server.URL, Secret: "secret", ID: "1", Event: "user.login", Body: []byte(`{}`), Timestamp: time.Now()}
+
+	resp, err := NewSender(time.Second, false).Send(context.Background(), req)
+	if !errors.Is(err, ErrForbiddenDestination) {
+		t.Fatalf("Send to loopback = %v, want ErrForbiddenDestination", err)
+	}
+	if resp.StatusCode != 0 || received {
+		t.Fatal("delivery to a loopback address reached the server")
+	}
+
+	resp, err = NewSender(time.Second, true).Send(context.Background(), req)
+	if err != nil || !resp.Succeeded() || !received {
+		t.Fatalf("Send with private networks allowed = %+v, %v", resp, err)
+	}
+}